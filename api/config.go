@@ -2,9 +2,12 @@ package api
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/logging"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
 )
 
 // server modes
@@ -14,16 +17,40 @@ const (
 	ModeTest       = "test"
 	DefaultPort    = "2503"
 	DefaultRPCPort = "2504"
+	// DefaultContentSecurityPolicy only allows the webapp to load resources from
+	// this server & the webapp script host, keeping untrusted dataset metadata
+	// rendered by the webapp from pulling in active content
+	DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self' http://localhost:4000; style-src 'self' 'unsafe-inline'; img-src 'self' data:; object-src 'none'; frame-ancestors 'none'"
+	// DefaultIPFSCacheControl caches raw IPFS content for a year. content is
+	// addressed by hash, so a path always returns the same bytes
+	DefaultIPFSCacheControl = "public, max-age=31536000, immutable"
+	// DefaultMaxDataRows is the default cap on rows returned by a single data request
+	DefaultMaxDataRows = 100000
+	// DefaultQueryTimeout is the default time limit for running a query
+	DefaultQueryTimeout = time.Minute
 )
 
 // DefaultConfig returns the default configuration details
 func DefaultConfig() *Config {
 	return &Config{
-		Logger:  logging.DefaultLogger,
-		Mode:    "develop",
-		Port:    DefaultPort,
-		RPCPort: DefaultRPCPort,
-		Online:  true,
+		Logger:            logging.DefaultLogger,
+		Mode:              "develop",
+		Port:              DefaultPort,
+		RPCPort:           DefaultRPCPort,
+		Online:            true,
+		DatasetCacheSize:  repo.DefaultDatasetCacheSize,
+		MaxDataRows:       DefaultMaxDataRows,
+		QueryTimeout:      DefaultQueryTimeout,
+		SnapshotLimit:     core.DefaultSnapshotLimit,
+		DefaultVisibility: string(repo.VisibilityPublic),
+
+		ContentSecurityPolicy: DefaultContentSecurityPolicy,
+		FrameOptions:          "DENY",
+		WebappCacheControl:    "no-cache",
+		IPFSCacheControl:      DefaultIPFSCacheControl,
+
+		HealthCheckInterval: p2p.DefaultHealthCheckInterval,
+		MinConnectedPeers:   p2p.DefaultMinConnectedPeers,
 	}
 }
 
@@ -67,6 +94,49 @@ type Config struct {
 	Online bool
 	// list of addresses to bootsrap qri peers on
 	BoostrapAddrs []string
+	// DatasetCacheSize is the max number of datasets the repo keeps in
+	// memory, zero disables dataset caching
+	DatasetCacheSize int
+	// SnapshotBeforeMutation snapshots the namestore before datasets are
+	// deleted, renamed, aliased or squashed, so destructive operations can be
+	// rolled back. content released by a delete or squash stays pinned until
+	// the snapshot taken before it is dropped
+	SnapshotBeforeMutation bool
+	// SnapshotLimit is the number of namestore snapshots to keep
+	SnapshotLimit int
+	// MaxDataRows is the most rows a single data request can return, even
+	// when requesting all rows. zero or less removes the limit
+	MaxDataRows int
+	// QueryTimeout is the default time limit for a query's response, requests
+	// can set their own with a timeout param. queries that time out stop at
+	// their next data read. zero lets queries run until done
+	QueryTimeout time.Duration
+	// DefaultVisibility is the visibility of datasets that haven't been given
+	// one, either "public" or "private"
+	DefaultVisibility string
+	// PublicDatasetsOnly hides private datasets from dataset, history,
+	// activity & query API endpoints, refuses requests that change datasets,
+	// and doesn't serve raw /ipfs/ content. enable for nodes that serve
+	// datasets to users other than the owner
+	PublicDatasetsOnly bool
+	// ContentSecurityPolicy is the Content-Security-Policy header sent with the
+	// webapp. empty string omits the header
+	ContentSecurityPolicy string
+	// FrameOptions is the X-Frame-Options header sent with the webapp,
+	// empty string omits the header
+	FrameOptions string
+	// WebappCacheControl is the Cache-Control header sent with the webapp,
+	// empty string omits the header
+	WebappCacheControl string
+	// IPFSCacheControl is the Cache-Control header sent with raw /ipfs/ content,
+	// empty string omits the header
+	IPFSCacheControl string
+	// HealthCheckInterval is the time between checks of p2p connectivity,
+	// zero disables connectivity monitoring
+	HealthCheckInterval time.Duration
+	// MinConnectedPeers is the number of connected peers below which the
+	// node will try to reconnect to the network
+	MinConnectedPeers int
 	// PostP2POnlineHook is a chance to call a function after starting P2P services
 	PostP2POnlineHook func(*p2p.QriNode)
 }
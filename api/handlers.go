@@ -1,24 +1,124 @@
 package api
 
 import (
-	"github.com/datatogether/api/apiutil"
-	"github.com/ipfs/go-datastore"
+	"bufio"
+	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/datatogether/api/apiutil"
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/p2p"
 )
 
-// HandleIPFSPath responds to IPFS Hash requests with raw data
+// HandleIPFSPath responds to IPFS Hash requests with raw data. requests with
+// a Range header get a partial response. raw content isn't tied to a dataset
+// name, so it's visibility can't be checked & public-only nodes don't serve it
 func (s *Server) HandleIPFSPath(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.PublicDatasetsOnly {
+		apiutil.WriteErrResponse(w, http.StatusNotFound, fmt.Errorf("raw content isn't served by this node"))
+		return
+	}
 	file, err := s.qriNode.Repo.Store().Get(datastore.NewKey(r.URL.Path))
 	if err != nil {
 		apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	io.Copy(w, file)
+	// raw content can be anything, peek at the first bytes to pick a content type
+	// that can't be interpreted as active content by a browser
+	rdr := bufio.NewReader(file)
+	head, _ := rdr.Peek(512)
+
+	w.Header().Set("Content-Type", safeContentType(head))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{
+		"filename": filepath.Base(r.URL.Path),
+	}))
+	if s.cfg.IPFSCacheControl != "" {
+		w.Header().Set("Cache-Control", s.cfg.IPFSCacheControl)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	if r.Header.Get("Range") == "" {
+		io.Copy(w, rdr)
+		return
+	}
+
+	// ranges need to seek. store files that can't are buffered in memory
+	rs, ok := file.(io.ReadSeeker)
+	if ok {
+		_, err = rs.Seek(0, io.SeekStart)
+	}
+	if !ok || err != nil {
+		data, err := ioutil.ReadAll(rdr)
+		if err != nil {
+			apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		rs = bytes.NewReader(data)
+	}
+	// content is addressed by path, so the path is a strong etag for If-Range
+	w.Header().Set("ETag", fmt.Sprintf("%q", r.URL.Path))
+	http.ServeContent(w, r, "", time.Time{}, rs)
+}
+
+// safeContentType detects the content type of data, downgrading any type
+// a browser could execute or render as a document to plain text
+func safeContentType(data []byte) string {
+	ct := http.DetectContentType(data)
+	for _, active := range []string{"html", "xml", "javascript", "svg"} {
+		if strings.Contains(ct, active) {
+			return "text/plain; charset=utf-8"
+		}
+	}
+	return ct
 }
 
 // WebappHandler renders the home page
-func WebappHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) WebappHandler(w http.ResponseWriter, r *http.Request) {
+	headers := map[string]string{
+		"Content-Security-Policy": s.cfg.ContentSecurityPolicy,
+		"X-Frame-Options":         s.cfg.FrameOptions,
+		"Cache-Control":           s.cfg.WebappCacheControl,
+	}
+	for key, val := range headers {
+		if val != "" {
+			w.Header().Set(key, val)
+		}
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
 	renderTemplate(w, "webapp")
 }
+
+// Status is a verbose report on the state of the server
+type Status struct {
+	// Online is true when the node is connected to the p2p network
+	Online bool `json:"online"`
+	// P2P is the result of the last connectivity check, nil when
+	// connectivity isn't being monitored
+	P2P *p2p.Health `json:"p2p"`
+}
+
+// StatusHandler responds to health checks. Adding the "verbose=true"
+// param includes details of the node's p2p connectivity
+func (s *Server) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	if verbose, err := apiutil.ReqParamBool("verbose", r); err != nil || !verbose {
+		apiutil.HealthCheckHandler(w, r)
+		return
+	}
+
+	apiutil.WriteResponse(w, Status{
+		Online: s.qriNode.Online,
+		P2P:    s.qriNode.Health(),
+	})
+}
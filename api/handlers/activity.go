@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/logging"
+	"github.com/qri-io/qri/repo"
+)
+
+// ActivityHandlers wraps an ActivityRequests with http.HandlerFuncs
+type ActivityHandlers struct {
+	core.ActivityRequests
+	log logging.Logger
+}
+
+// NewActivityHandlers allocates an ActivityHandlers pointer
+func NewActivityHandlers(log logging.Logger, r repo.Repo) *ActivityHandlers {
+	req := core.NewActivityRequests(r, nil)
+	h := ActivityHandlers{*req, log}
+	return &h
+}
+
+// NewPublicActivityHandlers allocates an ActivityHandlers pointer that only
+// serves activity of public datasets
+func NewPublicActivityHandlers(log logging.Logger, r repo.Repo) *ActivityHandlers {
+	req := core.NewPublicActivityRequests(r)
+	h := ActivityHandlers{*req, log}
+	return &h
+}
+
+// ActivityHandler is the endpoint for the node's activity log
+func (h *ActivityHandlers) ActivityHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.activityHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// activityHandler lists activity, filtered by a comma-separated "types"
+// param and RFC3339 "start" & "end" times
+func (h *ActivityHandlers) activityHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.ActivityParams{
+		ListParams: core.ListParamsFromRequest(r),
+	}
+	if types := r.FormValue("types"); types != "" {
+		p.Types = strings.Split(types, ",")
+	}
+	for key, t := range map[string]*time.Time{"start": &p.Start, "end": &p.End} {
+		if str := r.FormValue(key); str != "" {
+			parsed, err := time.Parse(time.RFC3339, str)
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid %s time '%s'. times must be RFC3339 formatted", key, str))
+				return
+			}
+			*t = parsed
+		}
+	}
+
+	res := []*core.Activity{}
+	if err := h.List(p, &res); err != nil {
+		h.log.Infof("error listing activity: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	if err := util.WritePageResponse(w, res, r, p.Page()); err != nil {
+		h.log.Infof("error list activity response: %s", err.Error())
+	}
+}
@@ -3,14 +3,20 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	util "github.com/datatogether/api/apiutil"
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/cafs/memfs"
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsutil"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/logging"
 	"github.com/qri-io/qri/repo"
@@ -30,6 +36,14 @@ func NewDatasetHandlers(log logging.Logger, r repo.Repo) *DatasetHandlers {
 	return &h
 }
 
+// NewPublicDatasetHandlers allocates a DatasetHandlers pointer that only
+// serves public datasets
+func NewPublicDatasetHandlers(log logging.Logger, r repo.Repo) *DatasetHandlers {
+	req := core.NewPublicDatasetRequests(r)
+	h := DatasetHandlers{*req, log, r}
+	return &h
+}
+
 // DatasetsHandler is a dataset list endpoint
 func (h *DatasetHandlers) DatasetsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -53,6 +67,8 @@ func (h *DatasetHandlers) DatasetHandler(w http.ResponseWriter, r *http.Request)
 		util.EmptyOkHandler(w, r)
 	case "GET":
 		h.getDatasetHandler(w, r)
+	case "HEAD":
+		h.datasetExistsHandler(w, r)
 	case "PUT":
 		h.updateDatasetHandler(w, r)
 	case "DELETE":
@@ -74,6 +90,30 @@ func (h *DatasetHandlers) InitDatasetHandler(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// InitDatasetsHandler is an endpoint for creating many datasets at once
+func (h *DatasetHandlers) InitDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.initDatasetsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// ValidateDatasetHandler is the endpoint for checking a dataset for errors
+func (h *DatasetHandlers) ValidateDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.validateDatasetHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // StructuredDataHandler is the data endpoint for a dataset
 func (h *DatasetHandlers) StructuredDataHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -86,6 +126,19 @@ func (h *DatasetHandlers) StructuredDataHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+// DataBatchHandler is the endpoint for getting the data of many datasets in
+// a single multipart response
+func (h *DatasetHandlers) DataBatchHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.dataBatchHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // AddDatasetHandler is the endpoint for adding an existing dataset to this repo
 func (h *DatasetHandlers) AddDatasetHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -98,6 +151,57 @@ func (h *DatasetHandlers) AddDatasetHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// AddDatasetsHandler is the endpoint for adding many existing datasets to
+// this repo at once
+func (h *DatasetHandlers) AddDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.addDatasetsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// PinHandler is the endpoint for keeping a dataset in the store without
+// naming it
+func (h *DatasetHandlers) PinHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.pinHandler(w, r, "/pin/", h.Pin)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// UnpinHandler is the endpoint for releasing a pinned dataset
+func (h *DatasetHandlers) UnpinHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.pinHandler(w, r, "/unpin/", h.Unpin)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// RefreshHandler is the endpoint for re-fetching a dataset from it's
+// download url
+func (h *DatasetHandlers) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.refreshHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // RenameDatasetHandler is the endpoint for renaming datasets
 func (h *DatasetHandlers) RenameDatasetHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -108,190 +212,1191 @@ func (h *DatasetHandlers) RenameDatasetHandler(w http.ResponseWriter, r *http.Re
 	}
 }
 
-// ZipDatasetHandler is the endpoint for getting a zip archive of a dataset
-func (h *DatasetHandlers) ZipDatasetHandler(w http.ResponseWriter, r *http.Request) {
-	res := &repo.DatasetRef{}
-	args := &core.GetDatasetParams{
-		Path: datastore.NewKey(r.URL.Path[len("/download/"):]),
-		Hash: r.FormValue("hash"),
+// BulkRenameDatasetsHandler is the endpoint for renaming many datasets at once
+func (h *DatasetHandlers) BulkRenameDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		h.bulkRenameDatasetsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// AliasDatasetHandler is the endpoint for adding aliases to datasets
+func (h *DatasetHandlers) AliasDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST", "PUT":
+		h.aliasDatasetHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// VisibilityHandler is the endpoint for setting the visibility of a dataset
+func (h *DatasetHandlers) VisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST", "PUT":
+		h.visibilityHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
 	}
-	err := h.Get(args, res)
+}
+
+// ZipDatasetHandler is the endpoint for getting a zip archive of a dataset.
+// requesting format=csv downloads dataset data as a csv file instead, which
+// can be transcoded with the charset param
+func (h *DatasetHandlers) ZipDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	path, err := pathFromRequest(r, "/download/")
 	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	switch r.FormValue("format") {
+	case "csv":
+		h.writeRawData(w, r, path, dataset.CSVDataFormat, true, 0)
+		return
+	case "", "zip":
+		if r.FormValue("charset") != "" {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("charset is only supported for csv data"))
+			return
+		}
+	default:
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid download format '%s'", r.FormValue("format")))
+		return
+	}
+
+	// archives are written by Export, which joins partitioned data
+	res := &core.ExportResult{}
+	if err := h.Export(&core.ExportParams{Path: path}, res); err != nil {
 		h.log.Infof("error getting dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("filename=\"%s.zip\"", "dataset"))
-	dsutil.WriteZipArchive(h.repo.Store(), res.Dataset, w)
+	w.Write(res.Zip)
 }
 
-func (h *DatasetHandlers) listDatasetsHandler(w http.ResponseWriter, r *http.Request) {
-	args := core.ListParamsFromRequest(r)
-	args.OrderBy = "created"
-	res := []*repo.DatasetRef{}
-	if err := h.List(&args, &res); err != nil {
-		h.log.Infof("error listing datasets: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+// ExportHandler is the endpoint for downloading a zip archive of a dataset,
+// named after the dataset. history=true adds previous versions to the archive
+func (h *DatasetHandlers) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.exportHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *DatasetHandlers) exportHandler(w http.ResponseWriter, r *http.Request) {
+	rf, err := refFromRequest(r, "/export/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
-	if err := util.WritePageResponse(w, res, r, args.Page()); err != nil {
-		h.log.Infof("error list datasests response: %s", err.Error())
+	p := &core.ExportParams{}
+	if rf.IsPath() {
+		p.Path = datastore.NewKey(rf.Path)
+	} else {
+		p.Name = rf.String()
+	}
+	if history, err := util.ReqParamBool("history", r); err == nil {
+		p.History = history
+	}
+
+	res := &core.ExportResult{}
+	if err := h.Export(p, res); err != nil {
+		h.log.Infof("error exporting dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", res.Filename))
+	w.Write(res.Zip)
 }
 
-func (h *DatasetHandlers) getDatasetHandler(w http.ResponseWriter, r *http.Request) {
-	res := &repo.DatasetRef{}
-	args := &core.GetDatasetParams{
-		Path: datastore.NewKey(r.URL.Path[len("/datasets/"):]),
-		Hash: r.FormValue("hash"),
+func (h *DatasetHandlers) refreshHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.RefreshParams{
+		Name:    strings.TrimPrefix(r.URL.Path, "/refresh/"),
+		Context: r.Context(),
 	}
-	err := h.Get(args, res)
-	if err != nil {
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+	if p.Name == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("name is required to refresh a dataset"))
+		return
+	}
+	if force, err := util.ReqParamBool("force", r); err == nil {
+		p.Force = force
+	}
+
+	res := &repo.DatasetRef{}
+	if err := h.Refresh(p, res); err != nil {
+		h.log.Infof("error refreshing dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WriteResponse(w, res)
 }
 
-func (h *DatasetHandlers) initDatasetHandler(w http.ResponseWriter, r *http.Request) {
-	p := &core.InitDatasetParams{}
-	switch r.Header.Get("Content-Type") {
-	case "application/json":
-		json.NewDecoder(r.Body).Decode(p)
+// SnapshotsHandler is the endpoint for listing namestore snapshots
+func (h *DatasetHandlers) SnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.listSnapshotsHandler(w, r)
 	default:
-		var f cafs.File
-		infile, header, err := r.FormFile("file")
-		if err != nil && err != http.ErrMissingFile {
-			util.WriteErrResponse(w, http.StatusBadRequest, err)
-			return
-		}
+		util.NotFoundHandler(w, r)
+	}
+}
 
-		f = memfs.NewMemfileReader(header.Filename, infile)
-		p = &core.InitDatasetParams{
-			URL:          r.FormValue("url"),
-			Name:         r.FormValue("name"),
-			DataFilename: header.Filename,
-			Data:         f,
-		}
+// RestoreSnapshotHandler is the endpoint for rolling the namestore back to a snapshot
+func (h *DatasetHandlers) RestoreSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.restoreSnapshotHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
 	}
+}
 
-	res := &repo.DatasetRef{}
-	if err := h.InitDataset(p, res); err != nil {
-		h.log.Infof("error initializing dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
-		return
+// ExportCKANHandler is the endpoint for exporting dataset metadata as a CKAN package
+func (h *DatasetHandlers) ExportCKANHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.exportCKANHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
 	}
-	util.WriteResponse(w, res.Dataset)
 }
 
-func (h *DatasetHandlers) updateDatasetHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Header.Get("Content-Type") {
-	case "application/json":
-		h.updateMetadataHandler(w, r)
-		// default:
-		//  h.initDatasetFileHandler(w, r)
+// SchemaHandler is the endpoint for getting a dataset's structure as JSON Schema
+func (h *DatasetHandlers) SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.schemaHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
 	}
 }
 
-func (h *DatasetHandlers) updateMetadataHandler(w http.ResponseWriter, r *http.Request) {
-	p := &core.UpdateParams{}
-	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
-		util.WriteErrResponse(w, http.StatusBadRequest, err)
-		return
+// StatsHandler is the endpoint for getting summary statistics of a dataset's columns
+func (h *DatasetHandlers) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.statsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
 	}
-	res := &repo.DatasetRef{}
-	if err := h.Update(p, res); err != nil {
-		h.log.Infof("error updating dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+}
+
+// CountHandler is the endpoint for counting the rows of a dataset's data
+func (h *DatasetHandlers) CountHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.countHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *DatasetHandlers) listDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	// datasets are listed in namespace order unless orderBy is given, sorting
+	// loads every dataset in the namespace
+	args := core.ListParamsFromRequest(r)
+	res := &core.DatasetList{}
+	if err := h.ListPage(&args, res); err != nil {
+		h.log.Infof("error listing datasets: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
-	util.WriteResponse(w, res)
+	if err := util.WritePageResponse(w, res, r, args.Page()); err != nil {
+		h.log.Infof("error list datasests response: %s", err.Error())
+	}
 }
 
-func (h *DatasetHandlers) deleteDatasetHandler(w http.ResponseWriter, r *http.Request) {
-	p := &core.DeleteParams{
+// getParamsFromRequest reads the dataset a /datasets/ request refers to.
+// datasets can be requested by name alone, eg: /datasets/?name=movies
+func getParamsFromRequest(r *http.Request) (*core.GetDatasetParams, error) {
+	args := &core.GetDatasetParams{
 		Name: r.FormValue("name"),
-		Path: datastore.NewKey(r.URL.Path[len("/datasets"):]),
+		Hash: r.FormValue("hash"),
 	}
+	if strings.TrimPrefix(r.URL.Path, "/datasets/") != "" || args.Name == "" {
+		rf, err := refFromRequest(r, "/datasets/")
+		if err != nil {
+			return nil, err
+		}
+		if rf.IsPath() {
+			args.Path = datastore.NewKey(rf.Path)
+		} else {
+			args.Name = rf.String()
+		}
+	}
+	return args, nil
+}
 
-	ref := &repo.DatasetRef{}
-	if err := h.Get(&core.GetDatasetParams{Name: p.Name, Path: p.Path}, ref); err != nil {
+// datasetExistsHandler responds 200 if a dataset exists, 404 if it doesn't
+func (h *DatasetHandlers) datasetExistsHandler(w http.ResponseWriter, r *http.Request) {
+	args, err := getParamsFromRequest(r)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
-
-	res := false
-	if err := h.Delete(p, &res); err != nil {
-		h.log.Infof("error deleting dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+	exists := false
+	if err := h.Exists(args, &exists); err != nil {
+		h.log.Infof("error checking dataset exists: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
-
-	util.WriteResponse(w, ref.Dataset)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
 }
 
-func (h *DatasetHandlers) getStructuredDataHandler(w http.ResponseWriter, r *http.Request) {
-	listParams := core.ListParamsFromRequest(r)
-	all, err := util.ReqParamBool("all", r)
-	if err != nil {
-		all = false
+func (h *DatasetHandlers) getDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.URL.Path, "/columns/") {
+		h.getColumnHandler(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/distinct") {
+		h.getDistinctHandler(w, r)
+		return
 	}
 
-	objectRows, err := util.ReqParamBool("object_rows", r)
+	res := &repo.DatasetRef{}
+	args, err := getParamsFromRequest(r)
 	if err != nil {
-		objectRows = true
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
 	}
-
+	format := acceptedFormat(r)
+	w.Header().Add("Vary", "Accept")
+	if format == "zip" {
+		if err := h.Get(args, res); err != nil {
+			util.WriteErrResponse(w, errStatus(err), err)
+			return
+		}
+		http.Redirect(w, r, "/export"+res.Path.String(), http.StatusSeeOther)
+		return
+	}
+
+	err = h.Get(args, res)
+	if err != nil {
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	// dataset responses include the name, which can change for a path, so
+	// they aren't immutable
+	if notModified(w, r, datasetETag(res.Path, r, format, res.Name)) {
+		return
+	}
+	if format != "json" {
+		if err := writeDatasetRef(w, res, format); err != nil {
+			h.log.Infof("error writing dataset: %s", err.Error())
+			util.WriteErrResponse(w, errStatus(err), err)
+		}
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// getColumnHandler responds to requests of the form /datasets/[path]/columns/[name]
+func (h *DatasetHandlers) getColumnHandler(w http.ResponseWriter, r *http.Request) {
+	str := strings.TrimPrefix(r.URL.Path, "/datasets/")
+	i := strings.LastIndex(str, "/columns/")
+	path, err := parsePath(str[:i])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	p := &core.ColumnParams{
+		Path: path,
+		Name: str[i+len("/columns/"):],
+	}
+	if max := r.FormValue("max"); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid max '%s'", max))
+			return
+		}
+		p.MaxValues = n
+	}
+
+	res := &core.Column{}
+	if err := h.Column(p, res); err != nil {
+		h.log.Infof("error getting column: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) getDistinctHandler(w http.ResponseWriter, r *http.Request) {
+	path, err := parsePath(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/datasets/"), "/distinct"))
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	p := &core.DistinctParams{
+		Path:   path,
+		Column: r.FormValue("column"),
+	}
+	if max := r.FormValue("max"); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid max '%s'", max))
+			return
+		}
+		p.MaxValues = n
+	}
+
+	res := &core.DistinctResponse{}
+	if err := h.DistinctValues(p, res); err != nil {
+		h.log.Infof("error getting distinct values: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) initDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.InitDatasetParams{}
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		var f cafs.File
+		infile, header, err := r.FormFile("file")
+		if err != nil && err != http.ErrMissingFile {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ckan, err := util.ReqParamBool("ckan", r)
+		if err != nil {
+			ckan = false
+		}
+
+		sampleSize := 0
+		if size := r.FormValue("sample_size"); size != "" {
+			if sampleSize, err = strconv.Atoi(size); err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid sample_size '%s'", size))
+				return
+			}
+		}
+
+		p = &core.InitDatasetParams{
+			URL:                r.FormValue("url"),
+			Name:               r.FormValue("name"),
+			CKAN:               ckan,
+			SampleStrategy:     r.FormValue("sample"),
+			SampleSize:         sampleSize,
+			AccrualPeriodicity: r.FormValue("accrual_periodicity"),
+			SheetName:          r.FormValue("sheet_name"),
+		}
+		if dataPath := r.FormValue("data_path"); dataPath != "" {
+			p.DataPath = datastore.NewKey(dataPath)
+		}
+		if header != nil {
+			f = memfs.NewMemfileReader(header.Filename, infile)
+			p.DataFilename = header.Filename
+			p.Data = f
+		}
+	}
+
+	p.Context = r.Context()
+
+	res := &repo.DatasetRef{}
+	if err := h.InitDataset(p, res); err != nil {
+		h.log.Infof("error initializing dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res.Dataset)
+}
+
+// initDatasetsHandler accepts either a json array of init params, or a
+// multipart form with one "file" part per dataset. names are taken from
+// filenames
+func (h *DatasetHandlers) initDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.InitDatasetsParams{}
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&p.Params); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		for _, header := range r.MultipartForm.File["file"] {
+			infile, err := header.Open()
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			defer infile.Close()
+			p.Params = append(p.Params, &core.InitDatasetParams{
+				DataFilename: header.Filename,
+				Data:         memfs.NewMemfileReader(header.Filename, infile),
+			})
+		}
+	}
+
+	res := []*core.InitDatasetResult{}
+	if err := h.InitDatasets(p, &res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) validateDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.ValidateDatasetParams{}
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		infile, header, err := r.FormFile("file")
+		if err != nil && err != http.ErrMissingFile {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		p = &core.ValidateDatasetParams{
+			Name: r.FormValue("name"),
+			URL:  r.FormValue("url"),
+		}
+		if path := r.FormValue("path"); path != "" {
+			if p.Path, err = parsePath(path); err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if header != nil {
+			p.DataFilename = header.Filename
+			p.Data = memfs.NewMemfileReader(header.Filename, infile)
+		}
+	}
+
+	p.Context = r.Context()
+
+	res := []core.ValidationError{}
+	if err := h.Validate(p, &res); err != nil {
+		h.log.Infof("error validating dataset: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) updateDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	switch ct := r.Header.Get("Content-Type"); {
+	case ct == "application/json":
+		h.updateMetadataHandler(w, r)
+	case strings.HasPrefix(ct, "multipart/form-data"):
+		h.updateDataHandler(w, r)
+	default:
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported content type '%s'", ct))
+	}
+}
+
+// updateDataHandler updates a dataset with an uploaded data file, and
+// optionally a metadata json file, as a new version of the dataset the
+// request's url path refers to
+func (h *DatasetHandlers) updateDataHandler(w http.ResponseWriter, r *http.Request) {
+	infile, header, err := r.FormFile("file")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error reading data file: %s", err.Error()))
+		return
+	}
+
+	rf, err := refFromRequest(r, "/datasets/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	args := &core.GetDatasetParams{}
+	if rf.IsPath() {
+		args.Path = datastore.NewKey(rf.Path)
+	} else {
+		args.Name = rf.String()
+	}
+	prev := &repo.DatasetRef{}
+	if err := h.Get(args, prev); err != nil {
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	force, err := util.ReqParamBool("force", r)
+	if err != nil {
+		force = false
+	}
+	p := &core.UpdateParams{
+		Changes:      &dataset.Dataset{},
+		DataFilename: header.Filename,
+		Data:         memfs.NewMemfileReader(header.Filename, infile),
+		Force:        force,
+	}
+	mdfile, _, err := r.FormFile("metadata")
+	if err != nil && err != http.ErrMissingFile {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if mdfile != nil {
+		if err := json.NewDecoder(mdfile).Decode(p.Changes); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error parsing metadata json: %s", err.Error()))
+			return
+		}
+	}
+	p.Changes.Previous = prev.Path
+
+	res := &repo.DatasetRef{}
+	if err := h.Update(p, res); err != nil {
+		h.log.Infof("error updating dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) updateMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.UpdateParams{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	res := &repo.DatasetRef{}
+	if err := h.Update(p, res); err != nil {
+		h.log.Infof("error updating dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) deleteDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.DeleteParams{
+		Name: r.FormValue("name"),
+	}
+	if rf, err := refFromRequest(r, "/datasets/"); err == nil {
+		// deleting removes a local name & it's history, versions & peer
+		// datasets can't be deleted on their own
+		if rf.Version != 0 || rf.Peer != "" {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("can't delete '%s'. datasets are deleted by local name or path", rf.String()))
+			return
+		}
+		if rf.IsPath() {
+			p.Path = datastore.NewKey(rf.Path)
+		} else {
+			p.Name = rf.Name
+		}
+	} else if p.Name == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := h.Get(&core.GetDatasetParams{Name: p.Name, Path: p.Path}, ref); err != nil {
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	if all, err := util.ReqParamBool("all", r); err == nil {
+		p.All = all
+	}
+	if purge, err := util.ReqParamBool("purge", r); err == nil {
+		p.Purge = purge
+	}
+
+	res := false
+	if err := h.Delete(p, &res); err != nil {
+		h.log.Infof("error deleting dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, ref.Dataset)
+}
+
+func (h *DatasetHandlers) getStructuredDataHandler(w http.ResponseWriter, r *http.Request) {
+	listParams := core.ListParamsFromRequest(r)
+	all, err := util.ReqParamBool("all", r)
+	if err != nil {
+		all = false
+	}
+
+	objectRows, err := util.ReqParamBool("object_rows", r)
+	if err != nil {
+		objectRows = true
+	}
+
+	maxBytes := 0
+	if max := r.FormValue("maxBytes"); max != "" {
+		if maxBytes, err = strconv.Atoi(max); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid maxBytes '%s'", max))
+			return
+		}
+	}
+
+	path, err := pathFromRequest(r, "/data/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// ndjson is json data written a row per line
+	formatStr := r.FormValue("format")
+	jsonLines := strings.ToLower(formatStr) == "ndjson"
+	if jsonLines {
+		formatStr = ""
+	}
+	format, err := parseDataFormat(formatStr)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, param := range []string{"charset", "delimiter", "header"} {
+		if format != dataset.CSVDataFormat && r.FormValue(param) != "" {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("%s is only supported for csv data", param))
+			return
+		}
+	}
+	if isCompletePath(path) {
+		w = &immutableWriter{ResponseWriter: w}
+		if r.Header.Get("If-None-Match") != "" {
+			exists := false
+			if err := h.Exists(&core.GetDatasetParams{Path: path}, &exists); err != nil {
+				util.WriteErrResponse(w, errStatus(err), err)
+				return
+			}
+			if !exists {
+				util.WriteErrResponse(w, http.StatusNotFound, repo.ErrNotFound)
+				return
+			}
+		}
+		// the row cap changes what a request returns, so it's part of the tag
+		if notModified(w, r, datasetETag(path, r, strconv.Itoa(core.MaxDataRows()))) {
+			return
+		}
+	}
+	if format != dataset.JSONDataFormat {
+		h.writeRawData(w, r, path, format, all, maxBytes)
+		return
+	}
+
+	filters := []*core.Filter{}
+	for _, str := range r.URL.Query()["filter"] {
+		f, err := core.ParseFilter(str)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		filters = append(filters, f)
+	}
+
 	p := &core.StructuredDataParams{
 		Format: dataset.JSONDataFormat,
 		FormatConfig: &dataset.JSONOptions{
 			ArrayEntries: !objectRows,
 		},
-		Path:   datastore.NewKey(r.URL.Path[len("/data"):]),
-		Limit:  listParams.Limit,
-		Offset: listParams.Offset,
-		All:    all,
+		Path:     path,
+		Limit:    listParams.Limit,
+		Offset:   listParams.Offset,
+		All:      all,
+		Filters:  filters,
+		MaxBytes: maxBytes,
+		Fields:   parseFields(r.FormValue("fields")),
+		Where:    r.FormValue("where"),
+	}
+	if jsonLines {
+		h.writeJSONLines(w, p)
+		return
 	}
 	data := &core.StructuredData{}
 	if err := h.StructuredData(p, data); err != nil {
+		if _, ok := err.(*core.FilterError); ok {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
 		h.log.Infof("error reading structured data: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
 	util.WriteResponse(w, data)
 }
 
+// writeJSONLines responds with newline-delimited json, writing rows as
+// they're read. truncated data sets the Qri-Truncated & Qri-Next-Offset
+// trailers, which are only known once all rows are written
+func (h *DatasetHandlers) writeJSONLines(w http.ResponseWriter, p *core.StructuredDataParams) {
+	w.Header().Set("Content-Type", core.NDJSONContentType)
+	w.Header().Set("Trailer", "Qri-Truncated, Qri-Next-Offset")
+
+	sw := &streamWriter{ResponseWriter: w}
+	p.JSONLines = true
+	p.Writer = sw
+	data := &core.StructuredData{}
+	if err := h.StructuredData(p, data); err != nil {
+		h.log.Infof("error reading structured data: %s", err.Error())
+		// once rows are written the response status can't change
+		if sw.started {
+			return
+		}
+		w.Header().Del("Trailer")
+		if _, ok := err.(*core.FilterError); ok {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if data.Truncated {
+		w.Header().Set("Qri-Truncated", "true")
+		w.Header().Set("Qri-Next-Offset", strconv.Itoa(data.NextOffset))
+	}
+}
+
+// streamWriter is a response writer that records if anything has been
+// written to it, flushing through to the response
+type streamWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.started = true
+	return sw.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher
+func (sw *streamWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// parseFields splits the comma-separated fields request param into field
+// names. empty names are dropped
+func parseFields(str string) (fields []string) {
+	for _, name := range strings.Split(str, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// parseDataFormat parses the format request param. json is the default,
+// csv & xlsx data is written raw instead of in a response envelope
+func parseDataFormat(str string) (dataset.DataFormat, error) {
+	switch strings.ToLower(str) {
+	case "":
+		return dataset.JSONDataFormat, nil
+	case "xlsx":
+		return dataset.XLSDataFormat, nil
+	}
+	if df, err := dataset.ParseDataFormatString(str); err == nil {
+		switch df {
+		case dataset.JSONDataFormat, dataset.CSVDataFormat, dataset.XLSDataFormat:
+			return df, nil
+		}
+	}
+	return dataset.UnknownDataFormat, fmt.Errorf("invalid data format '%s'", str)
+}
+
+// writeRawData responds with dataset data as csv or xlsx, named for the
+// dataset. csv is transcoded to the charset request param if one is provided,
+// the delimiter & header params set the field separator & header row.
+// truncated data sets the Qri-Truncated & Qri-Next-Offset headers
+func (h *DatasetHandlers) writeRawData(w http.ResponseWriter, r *http.Request, path datastore.Key, format dataset.DataFormat, all bool, maxBytes int) {
+	var (
+		charset      string
+		delimiter    rune
+		formatConfig dataset.FormatConfig
+	)
+	if format == dataset.CSVDataFormat {
+		charset = "utf-8"
+		if r.FormValue("charset") != "" {
+			name, _, err := core.LookupCharset(r.FormValue("charset"))
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			charset = name
+		}
+		if str := r.FormValue("delimiter"); str != "" {
+			d, err := parseDelimiter(str)
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			delimiter = d
+		}
+		if r.FormValue("header") != "" {
+			header, err := util.ReqParamBool("header", r)
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid header param: %s", err.Error()))
+				return
+			}
+			formatConfig = &dataset.CSVOptions{HeaderRow: header}
+		}
+	}
+
+	listParams := core.ListParamsFromRequest(r)
+	p := &core.StructuredDataParams{
+		Format:       format,
+		FormatConfig: formatConfig,
+		Path:         path,
+		Limit:        listParams.Limit,
+		Offset:       listParams.Offset,
+		All:          all,
+		Charset:      charset,
+		Delimiter:    delimiter,
+		MaxBytes:     maxBytes,
+		Fields:       parseFields(r.FormValue("fields")),
+	}
+	data := &core.StructuredData{}
+	if err := h.StructuredData(p, data); err != nil {
+		h.log.Infof("error reading structured data: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	body, ok := data.Data.([]byte)
+	if !ok {
+		err := fmt.Errorf("unexpected data type %T", data.Data)
+		h.log.Infof("error reading structured data: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	filename := data.Name
+	if filename == "" {
+		filename = "data"
+	}
+	if format == dataset.XLSDataFormat {
+		w.Header().Set("Content-Type", core.XLSXContentType)
+		filename += ".xlsx"
+	} else {
+		w.Header().Set("Content-Type", mime.FormatMediaType("text/csv", map[string]string{"charset": charset}))
+		filename += ".csv"
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	if data.Truncated {
+		w.Header().Set("Qri-Truncated", "true")
+		w.Header().Set("Qri-Next-Offset", strconv.Itoa(data.NextOffset))
+	}
+	w.Write(body)
+}
+
+// parseDelimiter parses the delimiter request param, a single character.
+// "tab" is accepted for tab-separated output
+func parseDelimiter(str string) (rune, error) {
+	if strings.ToLower(str) == "tab" {
+		return '\t', nil
+	}
+	d, size := utf8.DecodeRuneInString(str)
+	if d == utf8.RuneError || size != len(str) {
+		return 0, fmt.Errorf("delimiter must be a single character")
+	}
+	return d, nil
+}
+
+// DataBatchRequest is one dataset in a batch data request
+type DataBatchRequest struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	All    bool   `json:"all"`
+}
+
+// dataBatchHandler responds with a multipart/mixed body, one part per
+// requested dataset. Each part is written as soon as it's data is read,
+// errors reading a dataset are written as an error part
+func (h *DatasetHandlers) dataBatchHandler(w http.ResponseWriter, r *http.Request) {
+	reqs := []*DataBatchRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(reqs) == 0 {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("at least one dataset is required"))
+		return
+	}
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for _, req := range reqs {
+		if err := h.writeDataPart(mw, req); err != nil {
+			h.log.Infof("error writing batch data: %s", err.Error())
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	mw.Close()
+}
+
+// writeDataPart writes the data for a single batch request as a part. a
+// returned error means the response itself can't be written to
+func (h *DatasetHandlers) writeDataPart(mw *multipart.Writer, req *DataBatchRequest) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Location", req.Path)
+
+	path, err := parsePath(req.Path)
+	p := &core.StructuredDataParams{
+		Path:   path,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+		All:    req.All,
+	}
+	data := &core.StructuredData{}
+
+	switch req.Format {
+	case "csv":
+		p.Format = dataset.CSVDataFormat
+		header.Set("Content-Type", "text/csv; charset=utf-8")
+	case "", "json":
+		p.Format = dataset.JSONDataFormat
+		header.Set("Content-Type", "application/json")
+	default:
+		err = fmt.Errorf("invalid data format '%s'", req.Format)
+	}
+	if err == nil {
+		err = h.StructuredData(p, data)
+	}
+
+	var body []byte
+	if err == nil {
+		switch d := data.Data.(type) {
+		case json.RawMessage:
+			body = d
+		case []byte:
+			body = d
+		default:
+			err = fmt.Errorf("unexpected data type %T", data.Data)
+		}
+	}
+	if err != nil {
+		header.Set("Content-Type", "application/json")
+		header.Set("Qri-Error", "true")
+		body, _ = json.Marshal(map[string]string{"path": req.Path, "error": err.Error()})
+	}
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body)
+	return err
+}
+
+func (h *DatasetHandlers) listSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	p := core.ListParamsFromRequest(r)
+	res := []*repo.Snapshot{}
+	if err := h.ListSnapshots(&p, &res); err != nil {
+		h.log.Infof("error listing snapshots: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) restoreSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("id")
+	res := &repo.Snapshot{}
+	if err := h.RestoreSnapshot(&id, res); err != nil {
+		h.log.Infof("error restoring snapshot: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) exportCKANHandler(w http.ResponseWriter, r *http.Request) {
+	path, err := pathFromRequest(r, "/export/ckan/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	args := &core.GetDatasetParams{
+		Path: path,
+	}
+	res := &core.CKANPackage{}
+	if err := h.ExportCKAN(args, res); err != nil {
+		h.log.Infof("error exporting ckan package: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) schemaHandler(w http.ResponseWriter, r *http.Request) {
+	rf, err := refFromRequest(r, "/schema/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	args := &core.GetDatasetParams{}
+	if rf.IsPath() {
+		args.Path = datastore.NewKey(rf.Path)
+	} else {
+		args.Name = rf.String()
+	}
+	res := &core.StructureSchema{}
+	if err := h.Schema(args, res); err != nil {
+		h.log.Infof("error getting dataset schema: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) statsHandler(w http.ResponseWriter, r *http.Request) {
+	rf, err := refFromRequest(r, "/stats/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	args := &core.StatsParams{}
+	if rf.IsPath() {
+		args.Path = datastore.NewKey(rf.Path)
+	} else {
+		args.Name = rf.String()
+	}
+	res := &core.DatasetStats{}
+	if err := h.Stats(args, res); err != nil {
+		h.log.Infof("error getting dataset stats: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) countHandler(w http.ResponseWriter, r *http.Request) {
+	rf, err := refFromRequest(r, "/count/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	args := &core.GetDatasetParams{}
+	if rf.IsPath() {
+		args.Path = datastore.NewKey(rf.Path)
+	} else {
+		args.Name = rf.String()
+	}
+	rows := 0
+	if err := h.CountRows(args, &rows); err != nil {
+		h.log.Infof("error counting dataset rows: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+	util.WriteResponse(w, rows)
+}
+
 func (h *DatasetHandlers) addDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	path, err := pathFromRequest(r, "/add/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
 	p := &core.AddParams{}
 	if r.Header.Get("Content-Type") == "application/json" {
 		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
 			util.WriteErrResponse(w, http.StatusBadRequest, err)
 			return
 		}
-		// TODO - clean this up
-		p.Hash = r.URL.Path[len("/add/"):]
+		p.Hash = path.String()
 		if p.Name == "" && r.FormValue("name") != "" {
 			p.Name = r.FormValue("name")
 		}
 	} else {
 		p = &core.AddParams{
 			Name: r.URL.Query().Get("name"),
-			Hash: r.URL.Path[len("/add/"):],
+			Hash: path.String(),
+		}
+	}
+	if t := r.FormValue("timeout"); t != "" {
+		if p.Timeout, err = time.ParseDuration(t); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid timeout: %s", err.Error()))
+			return
 		}
 	}
+	p.Context = r.Context()
 
 	res := &repo.DatasetRef{}
 	if err := h.AddDataset(p, res); err != nil {
 		h.log.Infof("error adding dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) addDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	p := []core.AddParams{}
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
+	res := []*core.AddDatasetResult{}
+	if err := h.AddDatasets(&p, &res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
 	util.WriteResponse(w, res)
 }
 
+func (h *DatasetHandlers) pinHandler(w http.ResponseWriter, r *http.Request, prefix string, pin func(*core.PinParams, *bool) error) {
+	path, err := pathFromRequest(r, prefix)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ok := false
+	if err := pin(&core.PinParams{Path: path}, &ok); err != nil {
+		h.log.Infof("error changing pin of %s: %s", path.String(), err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, ok)
+}
+
 func (h DatasetHandlers) renameDatasetHandler(w http.ResponseWriter, r *http.Request) {
 	p := &core.RenameParams{}
 	if r.Header.Get("Content-Type") == "application/json" {
@@ -304,14 +1409,82 @@ func (h DatasetHandlers) renameDatasetHandler(w http.ResponseWriter, r *http.Req
 			Current: r.URL.Query().Get("current"),
 			New:     r.URL.Query().Get("new"),
 		}
+		if currentPath := r.URL.Query().Get("currentPath"); currentPath != "" {
+			p.CurrentPath = datastore.NewKey(currentPath)
+		}
 	}
 
 	res := &repo.DatasetRef{}
 	if err := h.Rename(p, res); err != nil {
 		h.log.Infof("error renaming dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+func (h DatasetHandlers) bulkRenameDatasetsHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.BulkRenameParams{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
 		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
+	res := []*repo.DatasetRef{}
+	if err := h.BulkRename(p, &res); err != nil {
+		h.log.Infof("error renaming datasets: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+func (h DatasetHandlers) aliasDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.AliasParams{}
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		p = &core.AliasParams{
+			Name:  r.URL.Query().Get("name"),
+			Alias: r.URL.Query().Get("alias"),
+		}
+	}
+
+	res := &repo.DatasetRef{}
+	if err := h.AddAlias(p, res); err != nil {
+		h.log.Infof("error aliasing dataset: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+func (h DatasetHandlers) visibilityHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.VisibilityParams{}
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		p = &core.VisibilityParams{
+			Name:       r.URL.Query().Get("name"),
+			Visibility: r.URL.Query().Get("visibility"),
+		}
+	}
+
+	res := &repo.DatasetRef{}
+	if err := h.SetVisibility(p, res); err != nil {
+		h.log.Infof("error setting dataset visibility: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
 	util.WriteResponse(w, res)
 }
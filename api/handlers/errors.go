@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+)
+
+// errStatus gives the http status code for an error returned by core
+// requests, so handlers respond to the same errors with the same codes.
+// unknown causes are internal server errors, which clients may retry
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repo.ErrNameTaken), errors.Is(err, core.ErrDataExists):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, core.ErrFetchTimeout), errors.Is(err, core.ErrQueryTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, repo.ErrNameRequired), errors.Is(err, core.ErrInvalidParams),
+		errors.Is(err, core.ErrInvalidFormat), errors.Is(err, core.ErrNoChanges):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
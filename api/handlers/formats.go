@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/qri/repo"
+	"gopkg.in/yaml.v2"
+)
+
+// datasetMediaTypes maps the media types a dataset can be requested as to
+// response formats
+var datasetMediaTypes = map[string]string{
+	"application/json":   "json",
+	"text/csv":           "csv",
+	"application/x-yaml": "yaml",
+	"application/zip":    "zip",
+}
+
+// acceptedFormat picks a response format from a request's Accept header,
+// preferring media types with higher quality values, then the first listed.
+// requests that don't accept a supported media type get json
+func acceptedFormat(r *http.Request) string {
+	format, best := "json", 0.0
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		f, ok := datasetMediaTypes[mt]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q > best {
+			format, best = f, q
+		}
+	}
+	return format
+}
+
+// writeDatasetRef writes a dataset reference in a metadata format, csv or
+// yaml. json responses use util.WriteResponse
+func writeDatasetRef(w http.ResponseWriter, ref *repo.DatasetRef, format string) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		data, err = datasetRefCSV(ref)
+	case "yaml":
+		w.Header().Set("Content-Type", "application/x-yaml")
+		data, err = datasetRefYAML(ref)
+	default:
+		return fmt.Errorf("invalid metadata format '%s'", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// datasetRefYAML encodes a reference as yaml. the reference is encoded
+// through it's json form, so fields are named & ordered like json responses
+func datasetRefYAML(ref *repo.DatasetRef) ([]byte, error) {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+	// json is valid yaml, a MapSlice keeps the order of fields
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+	return yaml.Marshal(doc)
+}
+
+// datasetRefCSV encodes a reference as two columns of field names & values.
+// name & path come first, then the dataset's fields by name. values that
+// aren't strings are written as json
+func datasetRefCSV(ref *repo.DatasetRef) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if ref.Dataset != nil {
+		data, err := json.Marshal(ref.Dataset)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("error encoding dataset: %s", err.Error())
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	cw := csv.NewWriter(buf)
+	cw.Write([]string{"field", "value"})
+	cw.Write([]string{"name", ref.Name})
+	cw.Write([]string{"path", ref.Path.String()})
+	for _, name := range names {
+		val := string(fields[name])
+		var str string
+		if err := json.Unmarshal(fields[name], &str); err == nil {
+			val = str
+		}
+		cw.Write([]string{name, val})
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	util "github.com/datatogether/api/apiutil"
-	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/logging"
 	"github.com/qri-io/qri/repo"
@@ -23,6 +22,14 @@ func NewHistoryHandlers(log logging.Logger, r repo.Repo) *HistoryHandlers {
 	return &h
 }
 
+// NewPublicHistoryHandlers allocates a HistoryHandlers pointer that only
+// serves the history of public datasets
+func NewPublicHistoryHandlers(log logging.Logger, r repo.Repo) *HistoryHandlers {
+	req := core.NewPublicHistoryRequests(r)
+	h := HistoryHandlers{*req, log}
+	return &h
+}
+
 // LogHandler is the endpoint for dataset logs
 func (h *HistoryHandlers) LogHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -36,17 +43,52 @@ func (h *HistoryHandlers) LogHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *HistoryHandlers) logHandler(w http.ResponseWriter, r *http.Request) {
+	path, err := pathFromRequest(r, "/history/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
 	params := &core.LogParams{
 		ListParams: core.ListParamsFromRequest(r),
-		Path:       datastore.NewKey(r.URL.Path[len("/history/"):]),
+		Path:       path,
 	}
 
-	res := []*repo.DatasetRef{}
-	if err := h.Log(params, &res); err != nil {
-		h.log.Infof("")
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+	res := &core.LogPage{}
+	if err := h.LogPage(params, res); err != nil {
+		h.log.Infof("error getting log for '%s': %s", path.String(), err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
 	util.WritePageResponse(w, res, r, params.Page())
 }
+
+// DescendantsHandler is the endpoint for the versions that came after a
+// dataset
+func (h *HistoryHandlers) DescendantsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.descendantsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *HistoryHandlers) descendantsHandler(w http.ResponseWriter, r *http.Request) {
+	path, err := pathFromRequest(r, "/descendants/")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := []*repo.DatasetRef{}
+	if err := h.Descendants(&core.LogParams{Path: path}, &res); err != nil {
+		h.log.Infof("error getting descendants of '%s': %s", path.String(), err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
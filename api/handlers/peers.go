@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	util "github.com/datatogether/api/apiutil"
@@ -92,7 +93,7 @@ func (h *PeerHandlers) listPeersHandler(w http.ResponseWriter, r *http.Request)
 	res := []*profile.Profile{}
 	if err := h.List(&args, &res); err != nil {
 		h.log.Infof("list peers: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WritePageResponse(w, res, r, args.Page())
@@ -105,7 +106,7 @@ func (h *PeerHandlers) listConnectionsHandler(w http.ResponseWriter, r *http.Req
 	peers := []string{}
 	if err := h.ConnectedPeers(&listParams.Limit, &peers); err != nil {
 		h.log.Infof("error showing connected peers: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
@@ -123,7 +124,7 @@ func (h *PeerHandlers) connectToPeerHandler(w http.ResponseWriter, r *http.Reque
 	res := &profile.Profile{}
 	if err := h.ConnectToPeer(&pid, res); err != nil {
 		h.log.Infof("error connecting to peer: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
@@ -137,9 +138,12 @@ func (h *PeerHandlers) getPeerHandler(w http.ResponseWriter, r *http.Request) {
 		Username: r.FormValue("username"),
 	}
 	err := h.Get(args, res)
-	if err != nil {
+	if errors.Is(err, repo.ErrNotFound) {
+		util.WriteErrResponse(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
 		h.log.Infof("error getting peer profile: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WriteResponse(w, res)
@@ -148,15 +152,18 @@ func (h *PeerHandlers) getPeerHandler(w http.ResponseWriter, r *http.Request) {
 func (h *PeerHandlers) peerNamespaceHandler(w http.ResponseWriter, r *http.Request) {
 	listParams := core.ListParamsFromRequest(r)
 	args := &core.NamespaceParams{
-		PeerID: r.URL.Path[len("/peernamespace/"):],
-		Limit:  listParams.Limit,
-		Offset: listParams.Offset,
+		PeerID:  r.URL.Path[len("/peernamespace/"):],
+		Limit:   listParams.Limit,
+		Offset:  listParams.Offset,
+		Context: r.Context(),
 	}
-	res := []*repo.DatasetRef{}
-	if err := h.GetNamespace(args, &res); err != nil {
+	res := &core.DatasetList{}
+	if err := h.GetNamespace(args, res); err != nil {
 		h.log.Infof("error getting peer namespace: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
-	util.WriteResponse(w, res)
+	if err := util.WritePageResponse(w, res, r, listParams.Page()); err != nil {
+		h.log.Infof("error writing peer namespace response: %s", err.Error())
+	}
 }
@@ -42,7 +42,7 @@ func (h *ProfileHandlers) getProfileHandler(w http.ResponseWriter, r *http.Reque
 	res := &core.Profile{}
 	if err := h.GetProfile(&args, res); err != nil {
 		h.log.Infof("error getting profile: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
@@ -57,7 +57,7 @@ func (h *ProfileHandlers) saveProfileHandler(w http.ResponseWriter, r *http.Requ
 	}
 	res := &core.Profile{}
 	if err := h.SaveProfile(p, res); err != nil {
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WriteResponse(w, res)
@@ -96,7 +96,7 @@ func (h *ProfileHandlers) setProfilePhotoHandler(w http.ResponseWriter, r *http.
 	res := &core.Profile{}
 	if err := h.SetProfilePhoto(p, res); err != nil {
 		h.log.Infof("error initializing dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WriteResponse(w, res)
@@ -134,7 +134,7 @@ func (h *ProfileHandlers) setPosterHandler(w http.ResponseWriter, r *http.Reques
 	res := &core.Profile{}
 	if err := h.SetPosterPhoto(p, res); err != nil {
 		h.log.Infof("error initializing dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WriteResponse(w, res)
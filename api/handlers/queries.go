@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"time"
 
 	util "github.com/datatogether/api/apiutil"
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/logging"
@@ -23,6 +27,13 @@ func NewQueryHandlers(log logging.Logger, r repo.Repo) *QueryHandlers {
 	return &QueryHandlers{*req, log}
 }
 
+// NewPublicQueryHandlers allocates a QueryHandlers pointer that can only
+// query public datasets
+func NewPublicQueryHandlers(log logging.Logger, r repo.Repo) *QueryHandlers {
+	req := core.NewPublicQueryRequests(r)
+	return &QueryHandlers{*req, log}
+}
+
 // ListHandler is the endpoint for listing this repo's log of queries
 func (h *QueryHandlers) ListHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -67,7 +78,7 @@ func (h *QueryHandlers) listQueriesHandler(w http.ResponseWriter, r *http.Reques
 	err := h.List(&args, &res)
 	if err != nil {
 		h.log.Infof("error listing datasets: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 	util.WritePageResponse(w, res, r, args.Page())
@@ -85,12 +96,41 @@ func (h *QueryHandlers) RunHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// runRequest is the body of a run request. Inputs maps table names in the
+// query to dataset names or paths. for backwards compatibility a bare dataset
+// is also accepted as a request body
+type runRequest struct {
+	Dataset *dataset.Dataset  `json:"dataset"`
+	Inputs  map[string]string `json:"inputs"`
+}
+
+// decodeRunRequest reads a run request from a request body
+func decodeRunRequest(r *http.Request) (*runRequest, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &runRequest{}
+	if err := json.Unmarshal(data, body); err != nil {
+		return nil, err
+	}
+	if body.Dataset == nil {
+		body.Dataset = &dataset.Dataset{}
+		if err := json.Unmarshal(data, body.Dataset); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
 func (h *QueryHandlers) runHandler(w http.ResponseWriter, r *http.Request) {
-	ds := &dataset.Dataset{}
-	if err := json.NewDecoder(r.Body).Decode(ds); err != nil {
+	body, err := decodeRunRequest(r)
+	if err != nil {
 		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	ds := body.Dataset
 
 	format := r.FormValue("format")
 	if format == "" {
@@ -102,16 +142,60 @@ func (h *QueryHandlers) runHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var timeout time.Duration
+	if t := r.FormValue("timeout"); t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid timeout: %s", err.Error()))
+			return
+		}
+	}
+
 	p := &core.RunParams{
 		SaveName: r.FormValue("name"),
 		Dataset:  ds,
+		Inputs:   body.Inputs,
+		Timeout:  timeout,
+		Context:  r.Context(),
 	}
 	p.Format = df
 
 	res := &repo.DatasetRef{}
 	if err := h.Run(p, res); err != nil {
 		h.log.Infof("error running query: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+// ValidateHandler is the endpoint for checking a query without running it
+func (h *QueryHandlers) ValidateHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.validateHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *QueryHandlers) validateHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := decodeRunRequest(r)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	p := &core.QueryValidateParams{
+		Dataset: body.Dataset,
+		Inputs:  body.Inputs,
+	}
+	res := &core.QueryValidation{}
+	if err := h.Validate(p, res); err != nil {
+		h.log.Infof("error validating query: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -129,14 +213,31 @@ func (h *QueryHandlers) DatasetQueriesHandler(w http.ResponseWriter, r *http.Req
 }
 
 func (h *QueryHandlers) datasetQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	args := core.ListParamsFromRequest(r)
 	p := &core.DatasetQueriesParams{
-		Path: r.URL.Path[len("/queries"):],
+		Name:   r.FormValue("name"),
+		Limit:  args.Limit,
+		Offset: args.Offset,
+	}
+	if p.Name == "" {
+		// /queries/[path] lists queries that reference a dataset,
+		// /queries/[name] lists queries saved under a name
+		rf, err := refFromRequest(r, "/queries/")
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		if rf.IsPath() {
+			p.Path = datastore.NewKey(rf.Path).String()
+		} else {
+			p.Name = rf.Name
+		}
 	}
 
 	res := []*repo.DatasetRef{}
 	if err := h.DatasetQueries(p, &res); err != nil {
 		h.log.Info("error listing dataset queries: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/ref"
+)
+
+// refFromRequest parses the dataset reference that follows a route prefix
+// in a request's URL path, eg: /datasets/[ref]
+func refFromRequest(r *http.Request, prefix string) (ref.Ref, error) {
+	return ref.ParseRef(strings.TrimPrefix(r.URL.Path, prefix))
+}
+
+// pathFromRequest parses the dataset path that follows a route prefix in a
+// request's URL path. references that aren't paths are an error
+func pathFromRequest(r *http.Request, prefix string) (datastore.Key, error) {
+	return parsePath(strings.TrimPrefix(r.URL.Path, prefix))
+}
+
+// parsePath parses a string as a dataset path
+func parsePath(str string) (datastore.Key, error) {
+	rf, err := ref.ParseRef(str)
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+	return refPath(rf)
+}
+
+// refPath gives the path of a reference, erroring if the reference isn't a path
+func refPath(rf ref.Ref) (datastore.Key, error) {
+	if !rf.IsPath() {
+		return datastore.NewKey(""), fmt.Errorf("'%s' is not a dataset path", rf.String())
+	}
+	return datastore.NewKey(rf.Path), nil
+}
+
+// isCompletePath reports if path is a complete dataset path, not a hash
+// prefix. complete paths always refer to the same dataset
+func isCompletePath(path datastore.Key) bool {
+	return strings.HasSuffix(path.String(), "/"+dsfs.PackageFileDataset.String())
+}
+
+// immutableCacheControl is sent with data responses for dataset paths. paths
+// are content-addressed, so a path always refers to the same data
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// datasetETag builds an ETag for a response about the dataset at path. the
+// response body can depend on more than the path, so the request's query &
+// any representation details, like the format or name the body is written
+// with, are part of the tag
+func datasetETag(path datastore.Key, r *http.Request, representation ...string) string {
+	parts := append([]string{r.URL.Query().Encode()}, representation...)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return fmt.Sprintf("%q", path.String()+"-"+hex.EncodeToString(sum[:8]))
+}
+
+// notModified sets a response's ETag, reporting if the request's
+// If-None-Match header matches it. matching requests get a 304 Not Modified
+// response & shouldn't be written to further. callers must check the
+// dataset is visible first, so tags can't confirm private datasets exist
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// immutableWriter is a response writer for data requests of dataset paths. it
+// sets immutableCacheControl on responses that aren't errors, so errors can
+// be retried
+type immutableWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *immutableWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status < http.StatusBadRequest {
+			w.Header().Set("Cache-Control", immutableCacheControl)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *immutableWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher
+func (w *immutableWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
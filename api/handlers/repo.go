@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/logging"
+	"github.com/qri-io/qri/repo"
+)
+
+// RepoHandlers wraps a requests struct to interface with http.HandlerFunc
+type RepoHandlers struct {
+	core.RepoRequests
+	log logging.Logger
+}
+
+// NewRepoHandlers allocates a RepoHandlers pointer
+func NewRepoHandlers(log logging.Logger, r repo.Repo) *RepoHandlers {
+	req := core.NewRepoRequests(r, nil)
+	h := RepoHandlers{*req, log}
+	return &h
+}
+
+// SizeHandler is the endpoint for the number of bytes the repo occupies
+func (h *RepoHandlers) SizeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.sizeHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *RepoHandlers) sizeHandler(w http.ResponseWriter, r *http.Request) {
+	args := true
+	var size int64
+	if err := h.Size(&args, &size); err != nil {
+		h.log.Infof("error getting repo size: %s", err.Error())
+		util.WriteErrResponse(w, errStatus(err), err)
+		return
+	}
+
+	util.WriteResponse(w, size)
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	util "github.com/datatogether/api/apiutil"
 	"github.com/qri-io/qri/core"
@@ -40,6 +42,15 @@ func (h *SearchHandlers) searchHandler(w http.ResponseWriter, r *http.Request) {
 		Q:      r.FormValue("q"),
 		Limit:  p.Limit(),
 		Offset: p.Offset(),
+		Fields: r.Form["fields"],
+	}
+	if str := r.FormValue("min_score"); str != "" {
+		min, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid min_score '%s'", str))
+			return
+		}
+		sp.MinScore = min
 	}
 
 	if r.Header.Get("Content-Type") == "application/json" {
@@ -52,7 +63,7 @@ func (h *SearchHandlers) searchHandler(w http.ResponseWriter, r *http.Request) {
 	res := make([]*repo.DatasetRef, p.Limit())
 	if err := h.Search(sp, &res); err != nil {
 		h.log.Infof("search error: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		util.WriteErrResponse(w, errStatus(err), err)
 		return
 	}
 
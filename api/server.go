@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"net/rpc"
 
-	"github.com/datatogether/api/apiutil"
 	"github.com/qri-io/qri/api/handlers"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/logging"
@@ -40,10 +39,31 @@ func New(r repo.Repo, options ...func(*Config)) (s *Server, err error) {
 		log: cfg.Logger,
 	}
 
+	visibility, err := repo.ParseVisibility(cfg.DefaultVisibility)
+	if err != nil {
+		return nil, fmt.Errorf("server configuration error: %s", err.Error())
+	}
+	if err := repo.SetDefaultVisibility(r, visibility); err != nil {
+		return nil, fmt.Errorf("server configuration error: %s", err.Error())
+	}
+
+	if c, ok := r.(repo.DatasetCacher); ok {
+		c.DatasetCache().SetSize(cfg.DatasetCacheSize)
+	}
+	core.SetMaxDataRows(cfg.MaxDataRows)
+	core.SetQueryTimeout(cfg.QueryTimeout)
+	if cfg.SnapshotBeforeMutation {
+		core.SetSnapshotLimit(cfg.SnapshotLimit)
+	} else {
+		core.SetSnapshotLimit(0)
+	}
+
 	// allocate a new node
 	s.qriNode, err = p2p.NewQriNode(r, func(ncfg *p2p.NodeCfg) {
 		ncfg.Logger = s.log
 		ncfg.Online = s.cfg.Online
+		ncfg.HealthCheckInterval = s.cfg.HealthCheckInterval
+		ncfg.MinConnectedPeers = s.cfg.MinConnectedPeers
 		if cfg.BoostrapAddrs != nil {
 			ncfg.QriBootstrapAddrs = cfg.BoostrapAddrs
 		}
@@ -118,8 +138,8 @@ func (s *Server) ServeRPC() {
 func NewServerRoutes(s *Server) *http.ServeMux {
 	m := http.NewServeMux()
 
-	m.HandleFunc("/", WebappHandler)
-	m.Handle("/status", s.middleware(apiutil.HealthCheckHandler))
+	m.HandleFunc("/", s.WebappHandler)
+	m.Handle("/status", s.middleware(s.StatusHandler))
 	m.Handle("/ipfs/", s.middleware(s.HandleIPFSPath))
 
 	proh := handlers.NewProfileHandlers(s.log, s.qriNode.Repo)
@@ -138,21 +158,58 @@ func NewServerRoutes(s *Server) *http.ServeMux {
 	m.Handle("/peernamespace/", s.middleware(ph.PeerNamespaceHandler))
 
 	dsh := handlers.NewDatasetHandlers(s.log, s.qriNode.Repo)
+	if s.cfg.PublicDatasetsOnly {
+		dsh = handlers.NewPublicDatasetHandlers(s.log, s.qriNode.Repo)
+	}
 	m.Handle("/datasets", s.middleware(dsh.DatasetsHandler))
 	m.Handle("/datasets/", s.middleware(dsh.DatasetHandler))
+	m.Handle("/add", s.middleware(dsh.AddDatasetsHandler))
 	m.Handle("/add/", s.middleware(dsh.AddDatasetHandler))
+	m.Handle("/pin/", s.middleware(dsh.PinHandler))
+	m.Handle("/unpin/", s.middleware(dsh.UnpinHandler))
+	m.Handle("/refresh/", s.middleware(dsh.RefreshHandler))
 	m.Handle("/init/", s.middleware(dsh.InitDatasetHandler))
+	m.Handle("/init/batch", s.middleware(dsh.InitDatasetsHandler))
+	m.Handle("/validate", s.middleware(dsh.ValidateDatasetHandler))
 	m.Handle("/rename", s.middleware(dsh.RenameDatasetHandler))
+	m.Handle("/datasets/rename/batch", s.middleware(dsh.BulkRenameDatasetsHandler))
+	m.Handle("/alias", s.middleware(dsh.AliasDatasetHandler))
+	m.Handle("/visibility", s.middleware(dsh.VisibilityHandler))
 	m.Handle("/data/ipfs/", s.middleware(dsh.StructuredDataHandler))
+	m.Handle("/data/batch", s.middleware(dsh.DataBatchHandler))
 	m.Handle("/download/", s.middleware(dsh.ZipDatasetHandler))
+	m.Handle("/export/", s.middleware(dsh.ExportHandler))
+	m.Handle("/export/ckan/", s.middleware(dsh.ExportCKANHandler))
+	m.Handle("/schema/", s.middleware(dsh.SchemaHandler))
+	m.Handle("/stats/", s.middleware(dsh.StatsHandler))
+	m.Handle("/count/", s.middleware(dsh.CountHandler))
+	m.Handle("/snapshots", s.middleware(dsh.SnapshotsHandler))
+	m.Handle("/snapshots/restore", s.middleware(dsh.RestoreSnapshotHandler))
 
 	hh := handlers.NewHistoryHandlers(s.log, s.qriNode.Repo)
+	if s.cfg.PublicDatasetsOnly {
+		hh = handlers.NewPublicHistoryHandlers(s.log, s.qriNode.Repo)
+	}
 	m.Handle("/history/", s.middleware(hh.LogHandler))
+	m.Handle("/descendants/", s.middleware(hh.DescendantsHandler))
+
+	ah := handlers.NewActivityHandlers(s.log, s.qriNode.Repo)
+	if s.cfg.PublicDatasetsOnly {
+		ah = handlers.NewPublicActivityHandlers(s.log, s.qriNode.Repo)
+	}
+	m.Handle("/activity", s.middleware(ah.ActivityHandler))
+
+	rh := handlers.NewRepoHandlers(s.log, s.qriNode.Repo)
+	m.Handle("/repo/size", s.middleware(rh.SizeHandler))
 
 	qh := handlers.NewQueryHandlers(s.log, s.qriNode.Repo)
+	if s.cfg.PublicDatasetsOnly {
+		qh = handlers.NewPublicQueryHandlers(s.log, s.qriNode.Repo)
+	}
 	m.Handle("/queries", s.middleware(qh.ListHandler))
 	m.Handle("/queries/", s.middleware(qh.DatasetQueriesHandler))
 	m.Handle("/run", s.middleware(qh.RunHandler))
+	m.Handle("/run/validate", s.middleware(qh.ValidateHandler))
 
 	return m
 }
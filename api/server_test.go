@@ -1,12 +1,24 @@
 package api
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/api/handlers"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/test"
 )
 
@@ -18,6 +30,24 @@ func TestServerRoutes(t *testing.T) {
 	}{
 		// {"GET", "/", nil, 200},
 		{"GET", "/status", nil, 200},
+		{"GET", "/status?verbose=true", nil, 200},
+		{"GET", "/activity", nil, 200},
+		{"POST", "/validate", nil, 400},
+		{"GET", "/schema/", nil, 400},
+		{"GET", "/stats/", nil, 400},
+		{"GET", "/count/", nil, 400},
+		{"GET", "/repo/size", nil, 200},
+		{"POST", "/add", []byte("[]"), 400},
+		{"POST", "/pin/", nil, 400},
+		{"POST", "/refresh/", nil, 400},
+		{"GET", "/export/not_a_dataset", nil, 500},
+		{"GET", "/datasets/not_a_dataset", nil, 404},
+		{"DELETE", "/datasets/not_a_dataset", nil, 404},
+		{"POST", "/rename?new=films", nil, 400},
+		{"POST", "/rename?current=not_a_dataset&new=films", nil, 404},
+		{"POST", "/rename?current=movies&new=cities", nil, 409},
+		{"POST", "/alias?name=movies&alias=cities", nil, 409},
+		{"POST", "/visibility?name=movies&visibility=secret", nil, 400},
 		// {"GET", "/datasets", nil, 200},
 		// {"GET", "/ipfs", nil, 200},
 		// {"GET", "/datasets", nil, 200},
@@ -85,3 +115,624 @@ func TestServerRoutes(t *testing.T) {
 		}
 	}
 }
+
+func TestSecurityHeaders(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+		opt.FrameOptions = "SAMEORIGIN"
+		opt.WebappCacheControl = ""
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	path, err := r.Store().Put(memfs.NewMemfileBytes("evil.html", []byte(`<html><script>alert("hi")</script></html>`)), false)
+	if err != nil {
+		t.Errorf("error putting file in store: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		handler  http.HandlerFunc
+		endpoint string
+		headers  map[string]string
+	}{
+		{s.WebappHandler, "/", map[string]string{
+			"Content-Security-Policy": DefaultContentSecurityPolicy,
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "SAMEORIGIN",
+			"Cache-Control":           "",
+		}},
+		{s.HandleIPFSPath, path.String(), map[string]string{
+			"Content-Type":           "text/plain; charset=utf-8",
+			"Content-Disposition":    "inline; filename=" + filepath.Base(path.String()),
+			"X-Content-Type-Options": "nosniff",
+			"Cache-Control":          DefaultIPFSCacheControl,
+		}},
+	}
+
+	for i, c := range cases {
+		w := httptest.NewRecorder()
+		c.handler(w, httptest.NewRequest("GET", c.endpoint, nil))
+
+		for key, val := range c.headers {
+			if got := w.Header().Get(key); got != val {
+				t.Errorf("case %d: %s header mismatch. expected: '%s', got: '%s'", i, key, val, got)
+			}
+		}
+	}
+}
+
+func TestDatasetExists(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	server := httptest.NewServer(NewServerRoutes(s))
+	defer server.Close()
+
+	cases := []struct {
+		endpoint string
+		status   int
+	}{
+		{"/datasets/movies", http.StatusOK},
+		{"/datasets/?name=movies", http.StatusOK},
+		{"/datasets/movies@99", http.StatusNotFound},
+		{"/datasets/not_a_dataset", http.StatusNotFound},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest("HEAD", server.URL+c.endpoint, nil)
+		if err != nil {
+			t.Errorf("case %d error creating request: %s", i, err.Error())
+			continue
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("case %d error performing request: %s", i, err.Error())
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode != c.status {
+			t.Errorf("case %d: HEAD %s status mismatch. expected: %d, got: %d", i, c.endpoint, c.status, res.StatusCode)
+		}
+	}
+}
+
+func TestDeleteDatasetRefs(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := r.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	server := httptest.NewServer(NewServerRoutes(s))
+	defer server.Close()
+
+	// versions & peer datasets can't be deleted, and mustn't delete the local
+	// head of the name instead
+	for i, endpoint := range []string{"/datasets/movies@1", "/datasets/QmPeer/movies"} {
+		req, err := http.NewRequest("DELETE", server.URL+endpoint, nil)
+		if err != nil {
+			t.Errorf("case %d error creating request: %s", i, err.Error())
+			continue
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("case %d error performing request: %s", i, err.Error())
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("case %d: DELETE %s status mismatch. expected: %d, got: %d", i, endpoint, http.StatusBadRequest, res.StatusCode)
+		}
+	}
+
+	if path, err := r.GetPath("movies"); err != nil || !path.Equal(moviesPath) {
+		t.Errorf("expected movies to be unchanged, got: %s, %v", path, err)
+	}
+}
+
+func TestExportVersion(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := r.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	// movies has one version, add another
+	if err := core.NewDatasetRequests(r, nil).Update(&core.UpdateParams{Changes: &dataset.Dataset{Title: "movies v2", Previous: path}}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error updating dataset: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	server := httptest.NewServer(NewServerRoutes(s))
+	defer server.Close()
+
+	cases := []struct {
+		endpoint string
+		title    string
+	}{
+		{"/export/movies", "movies v2"},
+		{"/export/movies@2", "movies v2"},
+		{"/export/movies@1", "example movie data"},
+	}
+
+	for i, c := range cases {
+		res, err := http.Get(server.URL + c.endpoint)
+		if err != nil {
+			t.Errorf("case %d error performing request: %s", i, err.Error())
+			continue
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Errorf("case %d error reading response: %s", i, err.Error())
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("case %d: GET %s status mismatch. expected: %d, got: %d", i, c.endpoint, http.StatusOK, res.StatusCode)
+			continue
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Errorf("case %d error reading zip: %s", i, err.Error())
+			continue
+		}
+		ds := &dataset.Dataset{}
+		for _, f := range zr.File {
+			if f.Name != "dataset.json" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				t.Errorf("case %d error opening dataset.json: %s", i, err.Error())
+				break
+			}
+			err = json.NewDecoder(rc).Decode(ds)
+			rc.Close()
+			if err != nil {
+				t.Errorf("case %d error decoding dataset.json: %s", i, err.Error())
+			}
+		}
+		if ds.Title != c.title {
+			t.Errorf("case %d: GET %s title mismatch. expected: '%s', got: '%s'", i, c.endpoint, c.title, ds.Title)
+		}
+	}
+}
+
+func TestDatasetETag(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := r.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	// the test store's paths aren't ipfs paths, so data requests skip the
+	// /data/ipfs/ route. /public/data/ requests are served as a public-only
+	// node would serve them
+	routes := NewServerRoutes(s)
+	dsh := handlers.NewDatasetHandlers(s.log, r)
+	pub := handlers.NewPublicDatasetHandlers(s.log, r)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/public/data/") {
+			req.URL.Path = strings.TrimPrefix(req.URL.Path, "/public")
+			pub.StructuredDataHandler(w, req)
+			return
+		}
+		if strings.HasPrefix(req.URL.Path, "/data/") {
+			dsh.StructuredDataHandler(w, req)
+			return
+		}
+		routes.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+
+	get := func(endpoint, ifNoneMatch string) (*http.Response, error) {
+		req, err := http.NewRequest("GET", server.URL+endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		res.Body.Close()
+		return res, nil
+	}
+
+	// tags are read from a first, unconditional request of each endpoint
+	dsEndpoint, dataEndpoint := "/datasets"+path.String(), "/data"+path.String()
+	etags := map[string]string{}
+	for _, endpoint := range []string{"/datasets/movies", dsEndpoint, dataEndpoint, dataEndpoint + "?limit=1"} {
+		res, err := get(endpoint, "")
+		if err != nil {
+			t.Errorf("GET %s error performing request: %s", endpoint, err.Error())
+			return
+		}
+		if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == "" {
+			t.Errorf("GET %s expected a 200 response with an etag, got: %d, '%s'", endpoint, res.StatusCode, res.Header.Get("ETag"))
+			return
+		}
+		etags[endpoint] = res.Header.Get("ETag")
+	}
+	if etags[dataEndpoint] == etags[dataEndpoint+"?limit=1"] {
+		t.Errorf("expected data requests with different params to have different etags")
+	}
+
+	immutable := "public, max-age=31536000, immutable"
+	cases := []struct {
+		endpoint, ifNoneMatch string
+		status                int
+		cacheControl          string
+	}{
+		{"/datasets/movies", etags["/datasets/movies"], http.StatusNotModified, ""},
+		{dsEndpoint, `"/map/QmOther/dataset.json", ` + etags[dsEndpoint], http.StatusNotModified, ""},
+		{dsEndpoint, "W/" + etags[dsEndpoint], http.StatusNotModified, ""},
+		{dsEndpoint, `"/map/QmOther/dataset.json"`, http.StatusOK, ""},
+		{dataEndpoint, "", http.StatusOK, immutable},
+		{dataEndpoint, etags[dataEndpoint], http.StatusNotModified, immutable},
+		{dataEndpoint + "?limit=1", etags[dataEndpoint], http.StatusOK, immutable},
+		{dataEndpoint + "?format=csv", "*", http.StatusNotModified, immutable},
+	}
+
+	for i, c := range cases {
+		res, err := get(c.endpoint, c.ifNoneMatch)
+		if err != nil {
+			t.Errorf("case %d error performing request: %s", i, err.Error())
+			continue
+		}
+		if res.StatusCode != c.status {
+			t.Errorf("case %d: GET %s status mismatch. expected: %d, got: %d", i, c.endpoint, c.status, res.StatusCode)
+		}
+		if got := res.Header.Get("Cache-Control"); got != c.cacheControl {
+			t.Errorf("case %d: GET %s cache control mismatch. expected: '%s', got: '%s'", i, c.endpoint, c.cacheControl, got)
+		}
+	}
+
+	// dataset responses include the name, so renaming changes their tags
+	owner := core.NewDatasetRequests(r, nil)
+	if err := owner.Rename(&core.RenameParams{Current: "movies", New: "films"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error renaming dataset: %s", err.Error())
+		return
+	}
+	if res, err := get(dsEndpoint, etags[dsEndpoint]); err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("expected renamed dataset to not match it's old etag, got: %v, %v", res, err)
+	}
+
+	// tags can't confirm private datasets exist
+	if err := owner.SetVisibility(&core.VisibilityParams{Name: "films", Visibility: "private"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+	if res, err := get("/public"+dataEndpoint, etags[dataEndpoint]); err != nil || res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected private dataset to not be found, got: %v, %v", res, err)
+	}
+}
+
+func TestDatasetAccept(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := r.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	server := httptest.NewServer(NewServerRoutes(s))
+	defer server.Close()
+	// check redirects instead of following them
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	cases := []struct {
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"", http.StatusOK, "application/json", `"path":"` + path.String() + `"`},
+		{"text/html", http.StatusOK, "application/json", `"path":"` + path.String() + `"`},
+		{"application/json", http.StatusOK, "application/json", `"path":"` + path.String() + `"`},
+		{"text/csv", http.StatusOK, "text/csv; charset=utf-8", "field,value\nname,movies\npath," + path.String() + "\n"},
+		{"application/x-yaml", http.StatusOK, "application/x-yaml", "path: " + path.String() + "\n"},
+		{"application/json;q=0.5, application/x-yaml", http.StatusOK, "application/x-yaml", "name: movies\n"},
+		{"application/zip", http.StatusSeeOther, "", ""},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest("GET", server.URL+"/datasets/movies", nil)
+		if err != nil {
+			t.Errorf("case %d error creating request: %s", i, err.Error())
+			continue
+		}
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Errorf("case %d error performing request: %s", i, err.Error())
+			continue
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Errorf("case %d error reading body: %s", i, err.Error())
+			continue
+		}
+
+		if res.StatusCode != c.status {
+			t.Errorf("case %d: Accept '%s' status mismatch. expected: %d, got: %d", i, c.accept, c.status, res.StatusCode)
+			continue
+		}
+		if c.status == http.StatusSeeOther {
+			if loc := res.Header.Get("Location"); loc != "/export"+path.String() {
+				t.Errorf("case %d: Accept '%s' location mismatch. expected: %s, got: %s", i, c.accept, "/export"+path.String(), loc)
+			}
+			continue
+		}
+		if got := res.Header.Get("Content-Type"); !strings.HasPrefix(got, c.contentType) {
+			t.Errorf("case %d: Accept '%s' content type mismatch. expected: %s, got: %s", i, c.accept, c.contentType, got)
+		}
+		if !strings.Contains(string(body), c.body) {
+			t.Errorf("case %d: Accept '%s' expected body to contain: %s\ngot: %s", i, c.accept, c.body, string(body))
+		}
+	}
+}
+
+func TestHandleIPFSPathRange(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	path, err := r.Store().Put(memfs.NewMemfileBytes("data.csv", []byte("a,b\n1,2\n3,4\n")), false)
+	if err != nil {
+		t.Errorf("error putting file in store: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		rng    string
+		status int
+		body   string
+	}{
+		{"", http.StatusOK, "a,b\n1,2\n3,4\n"},
+		{"bytes=0-3", http.StatusPartialContent, "a,b\n"},
+		{"bytes=8-", http.StatusPartialContent, "3,4\n"},
+		{"bytes=-4", http.StatusPartialContent, "3,4\n"},
+		{"bytes=100-200", http.StatusRequestedRangeNotSatisfiable, ""},
+	}
+
+	for i, c := range cases {
+		req := httptest.NewRequest("GET", path.String(), nil)
+		if c.rng != "" {
+			req.Header.Set("Range", c.rng)
+		}
+		w := httptest.NewRecorder()
+		s.HandleIPFSPath(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("case %d status mismatch. expected: %d, got: %d", i, c.status, w.Code)
+			continue
+		}
+		if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+			t.Errorf("case %d Accept-Ranges mismatch. expected: bytes, got: '%s'", i, got)
+		}
+		if c.body != "" && w.Body.String() != c.body {
+			t.Errorf("case %d body mismatch. expected: %q, got: %q", i, c.body, w.Body.String())
+		}
+	}
+}
+
+func TestHandleIPFSPathPublicOnly(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+		opt.PublicDatasetsOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	path, err := r.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	w := httptest.NewRecorder()
+	s.HandleIPFSPath(w, httptest.NewRequest("GET", path.String(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected public-only node to not serve raw content. expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDataBatch(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	s, err := New(r, func(opt *Config) {
+		opt.Online = false
+		opt.MemOnly = true
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	moviesPath, err := r.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	citiesPath, err := r.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+
+	body, err := json.Marshal([]map[string]interface{}{
+		{"path": moviesPath.String(), "format": "json", "limit": 2},
+		{"path": "/map/missing"},
+		{"path": citiesPath.String(), "format": "csv", "all": true},
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	server := httptest.NewServer(NewServerRoutes(s))
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/data/batch", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("error performing request: %s", err.Error())
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status code mismatch. expected: %d, got: %d", http.StatusOK, res.StatusCode)
+		return
+	}
+	mediatype, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		t.Errorf("error parsing content type: %s", err.Error())
+		return
+	}
+	if mediatype != "multipart/mixed" {
+		t.Errorf("content type mismatch. expected: multipart/mixed, got: %s", mediatype)
+		return
+	}
+
+	cases := []struct {
+		path, contentType string
+		err               bool
+	}{
+		{moviesPath.String(), "application/json", false},
+		{"/map/missing", "application/json", true},
+		{citiesPath.String(), "text/csv; charset=utf-8", false},
+	}
+
+	mr := multipart.NewReader(res.Body, params["boundary"])
+	for i, c := range cases {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Errorf("case %d error reading part: %s", i, err.Error())
+			return
+		}
+		if got := part.Header.Get("Content-Location"); got != c.path {
+			t.Errorf("case %d path mismatch. expected: '%s', got: '%s'", i, c.path, got)
+		}
+		if got := part.Header.Get("Content-Type"); got != c.contentType {
+			t.Errorf("case %d content type mismatch. expected: '%s', got: '%s'", i, c.contentType, got)
+		}
+		if got := part.Header.Get("Qri-Error") == "true"; got != c.err {
+			t.Errorf("case %d error part mismatch. expected: %t, got: %t", i, c.err, got)
+		}
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Errorf("case %d error reading part body: %s", i, err.Error())
+			continue
+		}
+		if len(data) == 0 {
+			t.Errorf("case %d expected part body to have data", i)
+		}
+	}
+
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("expected exactly %d parts", len(cases))
+	}
+}
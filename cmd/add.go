@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/ref"
 	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +20,11 @@ var (
 	addDsName         string
 	addDsURL          string
 	addDsPassive      bool
+	addDsCKAN         bool
+	addDsSample       string
+	addDsSampleSize   int
+	addDsSheet        string
+	addDsTimeout      time.Duration
 )
 
 var datasetAddCmd = &cobra.Command{
@@ -37,10 +44,11 @@ var datasetAddCmd = &cobra.Command{
 			req, err := datasetRequests(false)
 			ExitIfErr(err)
 
-			root := strings.TrimSuffix(args[0], "/"+dsfs.PackageFileDataset.String())
+			root := ref.RootPath(args[0])
 			p := &core.AddParams{
-				Name: addDsName,
-				Hash: root,
+				Name:    addDsName,
+				Hash:    root,
+				Timeout: addDsTimeout,
 			}
 			res := &repo.DatasetRef{}
 			err = req.AddDataset(p, res)
@@ -55,18 +63,18 @@ var datasetAddCmd = &cobra.Command{
 
 func initDataset() {
 	var (
-		dataFile, metaFile *os.File
-		err                error
+		metaFile *os.File
+		err      error
 	)
 
 	if addDsFilepath == "" && addDsURL == "" {
 		ErrExit(fmt.Errorf("please provide either a file or a url argument"))
+	} else if addDsCKAN && addDsURL == "" {
+		ErrExit(fmt.Errorf("please provide the url of a ckan package to import"))
 	} else if addDsName == "" {
 		ErrExit(fmt.Errorf("please provide a --name"))
 	}
 
-	dataFile, err = loadFileIfPath(addDsFilepath)
-	ExitIfErr(err)
 	metaFile, err = loadFileIfPath(addDsMetaFilepath)
 	ExitIfErr(err)
 
@@ -74,16 +82,20 @@ func initDataset() {
 	ExitIfErr(err)
 
 	p := &core.InitDatasetParams{
-		Name:         addDsName,
-		URL:          addDsURL,
-		DataFilename: filepath.Base(addDsFilepath),
+		Name:           addDsName,
+		URL:            addDsURL,
+		CKAN:           addDsCKAN,
+		SampleStrategy: addDsSample,
+		SampleSize:     addDsSampleSize,
+		SheetName:      addDsSheet,
+	}
+	if addDsFilepath != "" {
+		p.DataFilepath, err = filepath.Abs(addDsFilepath)
+		ExitIfErr(err)
 	}
 
 	// this is because passing nil to interfaces is bad
 	// see: https://golang.org/doc/faq#nil_error
-	if dataFile != nil {
-		p.Data = dataFile
-	}
 	if metaFile != nil {
 		p.Metadata = metaFile
 	}
@@ -101,5 +113,10 @@ func init() {
 	datasetAddCmd.Flags().StringVarP(&addDsFilepath, "file", "f", "", "data file to initialize from")
 	datasetAddCmd.Flags().StringVarP(&addDsMetaFilepath, "meta", "m", "", "dataset metadata file")
 	datasetAddCmd.Flags().BoolVarP(&addDsPassive, "passive", "p", false, "disable interactive init")
+	datasetAddCmd.Flags().BoolVarP(&addDsCKAN, "ckan", "", false, "treat url as a CKAN package, importing it's metadata & first resource")
+	datasetAddCmd.Flags().StringVarP(&addDsSample, "sample", "", "", "rows to infer field types from: head, random or full")
+	datasetAddCmd.Flags().IntVarP(&addDsSampleSize, "sample-size", "", 0, "number of rows to sample for head & random sampling")
+	datasetAddCmd.Flags().StringVarP(&addDsSheet, "sheet", "", "", "worksheet to import from an xlsx workbook")
+	datasetAddCmd.Flags().DurationVarP(&addDsTimeout, "timeout", "", 0, "give up fetching a dataset hash after this long, eg: 30s")
 	RootCmd.AddCommand(datasetAddCmd)
 }
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/ipfs/go-datastore"
@@ -57,11 +59,24 @@ var exportCmd = &cobra.Command{
 			return
 		}
 
+		if cmd.Flag("ckan").Value.String() == "true" {
+			pkg := &core.CKANPackage{}
+			err = req.ExportCKAN(p, pkg)
+			ExitIfErr(err)
+
+			data, err := json.MarshalIndent(pkg, "", "  ")
+			ExitIfErr(err)
+
+			err = ioutil.WriteFile(fmt.Sprintf("%s.json", path), data, os.ModePerm)
+			ExitIfErr(err)
+			return
+		}
+
 		if cmd.Flag("zip").Value.String() == "true" {
 			dst, err := os.Create(fmt.Sprintf("%s.zip", path))
 			ExitIfErr(err)
 
-			err = dsutil.WriteZipArchive(r.Store(), ds, dst)
+			err = req.Export(&core.ExportParams{Path: res.Path, Writer: dst}, &core.ExportResult{})
 			ExitIfErr(err)
 			err = dst.Close()
 			ExitIfErr(err)
@@ -78,6 +93,7 @@ func init() {
 	exportCmd.Flags().StringP("output", "o", "dataset", "path to write to")
 	exportCmd.Flags().BoolP("data-only", "d", false, "write data only (no package)")
 	exportCmd.Flags().BoolP("zip", "z", false, "compress export as zip archive")
+	exportCmd.Flags().BoolP("ckan", "", false, "export dataset metadata as a CKAN package")
 	// TODO - get format conversion up & running
 	// exportCmd.Flags().StringP("format", "f", "csv", "set output format [csv,json]")
 }
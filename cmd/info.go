@@ -6,8 +6,8 @@ import (
 
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/ref"
 	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
 )
@@ -38,16 +38,16 @@ var infoCmd = &cobra.Command{
 		ExitIfErr(err)
 
 		for i, arg := range args {
-			rt, ref := dsfs.RefType(arg)
+			rf, err := ref.ParseRef(arg)
+			ExitIfErr(err)
 			p := &core.GetDatasetParams{}
-			switch rt {
-			case "path":
-				p.Path = datastore.NewKey(ref)
-			case "name":
-				p.Name = ref
+			if rf.IsPath() {
+				p.Path = datastore.NewKey(rf.Path)
+			} else {
+				p.Name = rf.String()
 			}
 			res := &repo.DatasetRef{}
-			err := req.Get(p, res)
+			err = req.Get(p, res)
 			ExitIfErr(err)
 			if outformat == "" {
 				printDatasetRefInfo(i, res)
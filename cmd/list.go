@@ -51,6 +51,6 @@ var datasetListCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(datasetListCmd)
 	datasetListCmd.Flags().StringP("format", "f", "", "set output format [json]")
-	datasetListCmd.Flags().IntVarP(&dsListLimit, "limit", "l", 25, "limit results, default 25")
+	datasetListCmd.Flags().IntVarP(&dsListLimit, "limit", "l", 25, "limit results, 0 lists all datasets, default 25")
 	datasetListCmd.Flags().IntVarP(&dsListOffset, "offset", "o", 0, "offset results, default 0")
 }
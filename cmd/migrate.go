@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/qri-io/qri/core"
+	"github.com/spf13/cobra"
+)
+
+var migrateDryRun bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "upgrade datasets that use a deprecated format",
+	Long: `Migrate scans all named datasets for ones written in a format used by
+older versions of qri, and rewrites them to the current format.
+Each migrated dataset is saved as a new version that references the old
+version as it's previous version, so history is preserved.
+Use --dry-run to list datasets that need migration without changing anything.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.MigrateParams{DryRun: migrateDryRun}
+		res := []*core.Migration{}
+		err = req.Migrate(p, &res)
+		ExitIfErr(err)
+
+		if len(res) == 0 {
+			printSuccess("all datasets are up to date")
+			return
+		}
+		for _, m := range res {
+			if migrateDryRun {
+				printInfo("%s: %s needs migration: %s", m.Name, m.Path, strings.Join(m.Changes, ", "))
+			} else {
+				printSuccess("migrated %s: %s -> %s (%s)", m.Name, m.Path, m.NewPath, strings.Join(m.Changes, ", "))
+			}
+		}
+	},
+}
+
+func init() {
+	migrateCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "", false, "list datasets that need migration without changing them")
+	RootCmd.AddCommand(migrateCmd)
+}
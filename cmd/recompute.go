@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recomputeAll    bool
+	recomputeDryRun bool
+)
+
+var recomputeCmd = &cobra.Command{
+	Use:   "recompute [name]",
+	Short: "fix the declared length of datasets",
+	Long: `Recompute reads a dataset's data to measure it's length, saving a
+corrected version of the dataset if the length it declares is missing or
+wrong. Use --all to recompute every dataset in your namespace, and --dry-run
+with --all to list datasets that need correcting without changing them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		if recomputeAll {
+			res := []*repo.DatasetRef{}
+			err = req.RecomputeAll(&recomputeDryRun, &res)
+			ExitIfErr(err)
+
+			if len(res) == 0 {
+				printSuccess("all dataset lengths are accurate")
+				return
+			}
+			for _, ref := range res {
+				if recomputeDryRun {
+					printInfo("%s: %s needs recomputing", ref.Name, ref.Path)
+				} else {
+					printSuccess("recomputed %s: %s", ref.Name, ref.Path)
+				}
+			}
+			return
+		}
+
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please provide the name of a dataset to recompute, or use --all"))
+		}
+		res := &repo.DatasetRef{}
+		err = req.Recompute(&core.RecomputeParams{Name: args[0]}, res)
+		ExitIfErr(err)
+		printSuccess("%s: %s has a length of %d bytes", args[0], res.Path, res.Dataset.Length)
+	},
+}
+
+func init() {
+	recomputeCmd.Flags().BoolVarP(&recomputeAll, "all", "a", false, "recompute all datasets")
+	recomputeCmd.Flags().BoolVarP(&recomputeDryRun, "dry-run", "", false, "with --all, list datasets that need recomputing without changing them")
+	RootCmd.AddCommand(recomputeCmd)
+}
@@ -4,11 +4,16 @@ import (
 	"fmt"
 
 	"github.com/ipfs/go-datastore"
-	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/ref"
 	"github.com/spf13/cobra"
 )
 
+var (
+	removeAll   bool
+	removePurge bool
+)
+
 var datasetRemoveCmd = &cobra.Command{
 	Use:     "remove",
 	Aliases: []string{"rm"},
@@ -23,22 +28,26 @@ var datasetRemoveCmd = &cobra.Command{
 		ExitIfErr(err)
 
 		for _, arg := range args {
-			rt, ref := dsfs.RefType(arg)
+			rf, err := ref.ParseRef(arg)
+			ExitIfErr(err)
 			p := &core.DeleteParams{}
-			switch rt {
-			case "path":
-				p.Path = datastore.NewKey(ref)
-			case "name":
-				p.Name = ref
+			if rf.IsPath() {
+				p.Path = datastore.NewKey(rf.Path)
+			} else {
+				p.Name = rf.Name
 			}
-			res := false
-			err := req.Delete(p, &res)
+			p.All = removeAll
+			p.Purge = removePurge
+			res := &core.DeleteResult{}
+			err = req.DeleteWithResult(p, res)
 			ExitIfErr(err)
-			printSuccess("removed dataset %s", ref)
+			printSuccess("removed dataset %s, unpinned %d versions", rf.String(), res.Unpinned)
 		}
 	},
 }
 
 func init() {
+	datasetRemoveCmd.Flags().BoolVarP(&removeAll, "all", "a", false, "unpin every version of the dataset's history, not just the latest")
+	datasetRemoveCmd.Flags().BoolVarP(&removePurge, "purge", "p", false, "also remove logged queries saved under the dataset's name & cached copies of the dataset")
 	RootCmd.AddCommand(datasetRemoveCmd)
 }
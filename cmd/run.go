@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/qri-io/dataset"
@@ -14,7 +15,8 @@ import (
 )
 
 var (
-	runCmdName string
+	runCmdName   string
+	runCmdInputs []string
 )
 
 // runCmd represents the run command
@@ -36,11 +38,21 @@ var runCmd = &cobra.Command{
 			ErrExit(fmt.Errorf("invalid data format: %s", cmd.Flag("format").Value.String()))
 		}
 
+		inputs := map[string]string{}
+		for _, in := range runCmdInputs {
+			kv := strings.SplitN(in, "=", 2)
+			if len(kv) != 2 {
+				ErrExit(fmt.Errorf("invalid input '%s'. inputs should be of the form table_name=dataset_name", in))
+			}
+			inputs[kv[0]] = kv[1]
+		}
+
 		p := &core.RunParams{
 			ExecOpt: sql.ExecOpt{
 				Format: format,
 			},
 			SaveName: runCmdName,
+			Inputs:   inputs,
 			Dataset: &dataset.Dataset{
 				Timestamp: time.Now().In(time.UTC),
 				Transform: &dataset.Transform{
@@ -76,4 +88,5 @@ func init() {
 	runCmd.Flags().StringP("output", "o", "", "file to write to")
 	runCmd.Flags().StringP("format", "f", "", "set output format [csv,json]")
 	runCmd.Flags().StringVarP(&runCmdName, "name", "n", "", "save output to name")
+	runCmd.Flags().StringSliceVarP(&runCmdInputs, "input", "i", nil, "map a table name in the query to a dataset name or path, eg: -i jobs=jobs_by_automation")
 }
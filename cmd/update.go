@@ -21,6 +21,7 @@ var (
 	updateName       string
 	updatePassive    bool
 	updateRescursive bool
+	updateForce      bool
 )
 
 // updateCmd represents the update command
@@ -61,7 +62,14 @@ var updateCmd = &cobra.Command{
 		author, err := r.Profile()
 		ExitIfErr(err)
 
-		update := &core.UpdateParams{}
+		update := &core.UpdateParams{Changes: &dataset.Dataset{}, Force: updateForce}
+		if datapath != "" {
+			dataFile, err := os.Open(datapath)
+			ExitIfErr(err)
+			defer dataFile.Close()
+			update.Data = dataFile
+			update.DataFilename = filepath.Base(datapath)
+		}
 
 		metaFile, err = loadFileIfPath(updateMetaFile)
 		ExitIfErr(err)
@@ -93,5 +101,6 @@ func init() {
 	updateCmd.Flags().StringVarP(&updateTitle, "title", "t", "", "title of commit message for update")
 	updateCmd.Flags().StringVarP(&updateMessage, "message", "m", "", "commit message for update")
 	updateCmd.Flags().StringVarP(&updateName, "name", "n", "", "name to give dataset")
+	updateCmd.Flags().BoolVarP(&updateForce, "force", "", false, "update data even if it has a different number of columns")
 	RootCmd.AddCommand(updateCmd)
 }
@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/ipfs/go-datastore"
-	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsfs"
+	"github.com/olekukonko/tablewriter"
 	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/ref"
 	"github.com/spf13/cobra"
 )
 
@@ -33,32 +34,23 @@ in the dataset's fields.`,
 			ExitIfErr(err)
 
 			for _, arg := range args {
-				rt, ref := dsfs.RefType(arg)
+				rf, err := ref.ParseRef(arg)
+				ExitIfErr(err)
 				p := &core.ValidateDatasetParams{}
-				switch rt {
-				case "path":
-					p.Path = datastore.NewKey(ref)
-				case "name":
-					p.Name = ref
+				if rf.IsPath() {
+					p.Path = datastore.NewKey(rf.Path)
+				} else {
+					p.Name = rf.Name
 				}
 
-				res := &dataset.Dataset{}
-				err := req.Validate(p, res)
+				res := []core.ValidationError{}
+				err = req.Validate(p, &res)
 				ExitIfErr(err)
-
-				// validation, data, count, err := ds.ValidateData(Cache())
-				// ExitIfErr(err)
-				// if count > 0 {
-				// 	PrintResults(validation, data, dataset.CsvDataFormat)
-				// } else {
-				// 	PrintSuccess("✔ All good!")
-				// }
-
-				printSuccess("✔ All good!")
+				printValidation(res)
 			}
 
 		} else {
-			initDataset()
+			validateDataset()
 		}
 	},
 }
@@ -98,19 +90,31 @@ func validateDataset() {
 		p.Metadata = metaFile
 	}
 
-	ref := &dataset.Dataset{}
-	err = req.Validate(p, ref)
+	res := []core.ValidationError{}
+	err = req.Validate(p, &res)
 	ExitIfErr(err)
+	printValidation(res)
+}
 
-	// validation, data, count, err := ds.ValidateData(Cache())
-	// ExitIfErr(err)
-	// if count > 0 {
-	// 	PrintResults(validation, data, dataset.CsvDataFormat)
-	// } else {
-	// 	PrintSuccess("✔ All good!")
-	// }
+// printValidation prints a table of validation errors, if there are any
+func printValidation(errs []core.ValidationError) {
+	if len(errs) == 0 {
+		printSuccess("✔ All good!")
+		return
+	}
 
-	printSuccess("✔ All good!")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetHeader([]string{"row", "column", "message"})
+	for _, e := range errs {
+		row := strconv.Itoa(e.Row)
+		if e.Row < 0 {
+			row = ""
+		}
+		table.Append([]string{row, e.Column, e.Message})
+	}
+	table.Render()
 }
 
 func init() {
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"fmt"
+	"net/rpc"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+// activity types
+const (
+	ActivityDatasetCreated   = "dataset_created"
+	ActivityDatasetUpdated   = "dataset_updated"
+	ActivityDatasetDeleted   = string(repo.ETDatasetDeleted)
+	ActivityQuery            = "query"
+	ActivityPeerConnected    = string(repo.ETPeerConnected)
+	ActivityPeerDisconnected = string(repo.ETPeerDisconnected)
+)
+
+// activityTypes is the set of valid activity types
+var activityTypes = map[string]bool{
+	ActivityDatasetCreated:   true,
+	ActivityDatasetUpdated:   true,
+	ActivityDatasetDeleted:   true,
+	ActivityQuery:            true,
+	ActivityPeerConnected:    true,
+	ActivityPeerDisconnected: true,
+}
+
+// ActivityRequests encapsulates business logic for the log of what a node
+// has been doing
+type ActivityRequests struct {
+	repo repo.Repo
+	cli  *rpc.Client
+	// publicOnly hides private datasets, see NewPublicActivityRequests
+	publicOnly bool
+}
+
+// CoreRequestsName implements the Requets interface
+func (ActivityRequests) CoreRequestsName() string { return "activity" }
+
+// NewActivityRequests creates an ActivityRequests pointer from either a repo
+// or an rpc.Client
+func NewActivityRequests(r repo.Repo, cli *rpc.Client) *ActivityRequests {
+	if r != nil && cli != nil {
+		panic(fmt.Errorf("both repo and client supplied to NewActivityRequests"))
+	}
+	return &ActivityRequests{
+		repo: r,
+		cli:  cli,
+	}
+}
+
+// ActivityParams defines parameters for the List method
+type ActivityParams struct {
+	ListParams
+	// Types restricts activity to the listed types, empty includes all types
+	Types []string
+	// Start & End restrict activity to a range of time, [Start, End). zero
+	// times leave the range open
+	Start, End time.Time
+}
+
+// Activity is an entry in a node's activity log
+type Activity struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Name & Path identify the dataset of dataset activity & queries
+	Name string        `json:"name,omitempty"`
+	Path datastore.Key `json:"path,omitempty"`
+	// Query is the statement of a query that was run
+	Query string `json:"query,omitempty"`
+	// PeerID identifies the peer of peer activity
+	PeerID string `json:"peerID,omitempty"`
+}
+
+// List gives a timeline of node activity, newest first, merging dataset
+// histories, the query log, and the repo's event log if it keeps one
+func (r *ActivityRequests) List(p *ActivityParams, res *[]*Activity) error {
+	if r.cli != nil {
+		return r.cli.Call("ActivityRequests.List", p, res)
+	}
+
+	types := map[string]bool{}
+	for _, t := range p.Types {
+		if !activityTypes[t] {
+			return fmt.Errorf("invalid activity type '%s'", t)
+		}
+		types[t] = true
+	}
+	if r.publicOnly && types[ActivityQuery] {
+		return errQueryLogsOwnerOnly
+	}
+	include := func(a *Activity) bool {
+		if len(types) > 0 && !types[a.Type] {
+			return false
+		}
+		if !(p.Start.IsZero() || !a.Time.Before(p.Start)) || !(p.End.IsZero() || a.Time.Before(p.End)) {
+			return false
+		}
+		return r.visible(a)
+	}
+
+	activity := []*Activity{}
+	add := func(a *Activity) bool {
+		if include(a) {
+			activity = append(activity, a)
+			return true
+		}
+		return false
+	}
+
+	// no source can contribute more than a page's worth of entries past the
+	// offset, so histories don't need to be walked further than that
+	max := 0
+	if p.Limit > 0 {
+		max = p.Offset + p.Limit
+	}
+	if err := r.datasetActivity(p.Start, max, add); err != nil {
+		return err
+	}
+	// logged queries can read private datasets, they're only listed for the
+	// repo owner
+	if !r.publicOnly {
+		if err := r.queryActivity(add); err != nil {
+			return err
+		}
+	}
+	if el, ok := r.repo.(repo.Events); ok {
+		events, err := el.ListEvents()
+		if err != nil {
+			return fmt.Errorf("error listing events: %s", err.Error())
+		}
+		for _, e := range events {
+			add(&Activity{Type: string(e.Type), Time: e.Time, Name: e.Name, Path: e.Path, PeerID: e.PeerID})
+		}
+	}
+
+	sort.SliceStable(activity, func(i, j int) bool { return activity[i].Time.After(activity[j].Time) })
+
+	if p.Offset >= len(activity) {
+		activity = []*Activity{}
+	} else if p.Offset > 0 {
+		activity = activity[p.Offset:]
+	}
+	if p.Limit > 0 && p.Limit < len(activity) {
+		activity = activity[:p.Limit]
+	}
+
+	*res = activity
+	return nil
+}
+
+// visible checks an entry doesn't refer to a private dataset when r only
+// exposes public datasets. names that no longer exist, like those of deleted
+// datasets, can't be checked & are left out
+func (r *ActivityRequests) visible(a *Activity) bool {
+	if !r.publicOnly {
+		return true
+	}
+	if a.Name != "" {
+		if v, err := repo.NameVisibility(r.repo, a.Name); err != nil || v == repo.VisibilityPrivate {
+			return false
+		}
+	}
+	if a.Path.String() != "" && a.Path.String() != "/" {
+		if err := checkPathVisible(r.repo, a.Path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// datasetActivity adds an entry for each version of each named dataset.
+// histories are walked back only as far as start, and stop once max entries
+// from a history have been added. a max of zero walks whole histories
+func (r *ActivityRequests) datasetActivity(start time.Time, max int, add func(*Activity) bool) error {
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.repo.Namespace(count, 0)
+	if err != nil {
+		return fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+
+	// aliases & shared histories point to the same versions, only add each once
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		path := ref.Path
+		added := 0
+		for path.String() != "" && !seen[path.String()] && (max <= 0 || added < max) {
+			seen[path.String()] = true
+			ds, err := loadDataset(r.repo, path)
+			if err != nil {
+				return fmt.Errorf("error loading dataset '%s': %s", path.String(), err.Error())
+			}
+
+			a := &Activity{Type: ActivityDatasetUpdated, Time: ds.Timestamp, Name: ref.Name, Path: path}
+			if ds.Previous.String() == "" || ds.Previous.String() == "/" {
+				a.Type = ActivityDatasetCreated
+			}
+			if add(a) {
+				added++
+			}
+
+			if a.Type == ActivityDatasetCreated || !start.IsZero() && ds.Timestamp.Before(start) {
+				break
+			}
+			path = previousPath(ds)
+		}
+	}
+	return nil
+}
+
+// queryActivity adds an entry for each query in the query log
+func (r *ActivityRequests) queryActivity(add func(*Activity) bool) error {
+	for offset := 0; ; offset += DefaultPageSize {
+		items, err := r.repo.ListQueryLogs(DefaultPageSize, offset)
+		if err != nil {
+			return fmt.Errorf("error listing query logs: %s", err.Error())
+		}
+		for _, item := range items {
+			add(&Activity{Type: ActivityQuery, Time: item.Time, Name: item.Name, Path: item.DatasetPath, Query: item.Query})
+		}
+		if len(items) < DefaultPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestActivityRequestsList(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	now := time.Now()
+	if err := mr.LogQuery(&repo.QueryLogItem{Query: "select * from movies", Name: "movies", Key: datastore.NewKey("/q"), Time: now}); err != nil {
+		t.Errorf("error logging query: %s", err.Error())
+		return
+	}
+	if err := repo.LogEvent(mr, &repo.Event{Type: repo.ETPeerConnected, PeerID: "peer", Time: now.Add(time.Second)}); err != nil {
+		t.Errorf("error logging event: %s", err.Error())
+		return
+	}
+
+	count, err := mr.NameCount()
+	if err != nil {
+		t.Errorf("error getting name count: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p      *ActivityParams
+		res    int
+		latest string
+		err    string
+	}{
+		{&ActivityParams{}, count + 2, ActivityPeerConnected, ""},
+		{&ActivityParams{Types: []string{ActivityQuery}}, 1, ActivityQuery, ""},
+		{&ActivityParams{Types: []string{ActivityDatasetCreated}}, count, ActivityDatasetCreated, ""},
+		{&ActivityParams{Types: []string{"x"}}, 0, "", "invalid activity type 'x'"},
+		{&ActivityParams{Start: now}, 2, ActivityPeerConnected, ""},
+		{&ActivityParams{Start: now, End: now.Add(time.Second)}, 1, ActivityQuery, ""},
+		{&ActivityParams{ListParams: ListParams{Limit: 1, Offset: 1}}, 1, ActivityQuery, ""},
+	}
+
+	req := NewActivityRequests(mr, nil)
+	for i, c := range cases {
+		got := []*Activity{}
+		err := req.List(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != c.res {
+			t.Errorf("case %d result count mismatch: expected: %d, got: %d", i, c.res, len(got))
+			continue
+		}
+		if c.latest != "" && got[0].Type != c.latest {
+			t.Errorf("case %d latest type mismatch: expected: %s, got: %s", i, c.latest, got[0].Type)
+		}
+	}
+}
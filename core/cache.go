@@ -0,0 +1,48 @@
+package core
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// loadDataset loads the dataset at path from r's store, going through the
+// repo's dataset cache if it keeps one
+func loadDataset(r repo.Repo, path datastore.Key) (*dataset.Dataset, error) {
+	if c, ok := r.(repo.DatasetCacher); ok {
+		return c.DatasetCache().LoadDataset(r.Store(), path)
+	}
+	return dsfs.LoadDataset(r.Store(), path)
+}
+
+// statsKey is the cache key of stats computed from data. keys are prefixed so
+// they can't collide with the dataset cached at a path
+func statsKey(data string) string {
+	return "stats:" + data
+}
+
+// cachedStats gives a copy of the stats of data from the repo's dataset cache
+func cachedStats(r repo.Repo, data string) (*DatasetStats, bool) {
+	c, ok := r.(repo.DatasetCacher)
+	if !ok || data == "" {
+		return nil, false
+	}
+	v, ok := c.DatasetCache().Get(statsKey(data))
+	if !ok {
+		return nil, false
+	}
+	s, ok := v.(*DatasetStats)
+	if !ok {
+		return nil, false
+	}
+	return copyStats(s), true
+}
+
+// cacheStats keeps a copy of the stats of data in the repo's dataset cache, if
+// the repo keeps one
+func cacheStats(r repo.Repo, data string, s *DatasetStats) {
+	if c, ok := r.(repo.DatasetCacher); ok && data != "" {
+		c.DatasetCache().Put(statsKey(data), copyStats(s))
+	}
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// LookupCharset finds a character encoding by it's name or label, returning
+// the canonical name for the encoding, as used in a Content-Type header
+func LookupCharset(charset string) (name string, enc encoding.Encoding, err error) {
+	enc, err = htmlindex.Get(strings.TrimSpace(charset))
+	if err != nil {
+		return "", nil, fmt.Errorf("unsupported charset '%s'", charset)
+	}
+	if name, err = htmlindex.Name(enc); err != nil {
+		return "", nil, fmt.Errorf("unsupported charset '%s'", charset)
+	}
+	return name, enc, nil
+}
+
+// EncodeCharset transcodes utf-8 encoded data to charset
+func EncodeCharset(charset string, data []byte) ([]byte, error) {
+	name, enc, err := LookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	if name == "utf-8" {
+		return data, nil
+	}
+	encoded, err := enc.NewEncoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding data as %s: %s", name, err.Error())
+	}
+	return encoded, nil
+}
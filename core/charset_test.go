@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestEncodeCharset(t *testing.T) {
+	cases := []struct {
+		charset string
+		in      string
+		out     []byte
+		err     string
+	}{
+		{"not_a_charset", "", nil, "unsupported charset 'not_a_charset'"},
+		{"utf-8", "Déjà Vu", []byte("Déjà Vu"), ""},
+		{"windows-1252", "Déjà Vu", []byte("D\xe9j\xe0 Vu"), ""},
+		{"Windows-1252", "WALL·E", []byte("WALL\xb7E"), ""},
+		{"cp1252", "€5", []byte("\x805"), ""},
+		{"latin1", "Brüno", []byte("Br\xfcno"), ""},
+	}
+
+	for i, c := range cases {
+		got, err := EncodeCharset(c.charset, []byte(c.in))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if !bytes.Equal(got, c.out) {
+			t.Errorf("case %d output mismatch. expected: %q, got: %q", i, c.out, got)
+		}
+	}
+}
+
+func TestStructuredDataCharset(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p        *StructuredDataParams
+		contains [][]byte
+		err      string
+	}{
+		{&StructuredDataParams{Format: dataset.JSONDataFormat, Path: moviesPath, Limit: 10, Charset: "windows-1252"}, nil, "charset is only supported for csv data"},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: moviesPath, Limit: 10, Charset: "not_a_charset"}, nil, "unsupported charset 'not_a_charset'"},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: moviesPath, Limit: 500}, [][]byte{[]byte("WALL·E"), []byte("Déjà Vu")}, ""},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: moviesPath, Limit: 500, Charset: "windows-1252"}, [][]byte{[]byte("WALL\xb7E"), []byte("D\xe9j\xe0 Vu")}, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		data, ok := got.Data.([]byte)
+		if !ok {
+			t.Errorf("case %d expected csv data to be a byte slice", i)
+			continue
+		}
+		for _, b := range c.contains {
+			if !bytes.Contains(data, b) {
+				t.Errorf("case %d expected data to contain %q", i, b)
+			}
+		}
+	}
+}
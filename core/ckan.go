@@ -0,0 +1,256 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+)
+
+// CKANPackage is a subset of the CKAN "package" (dataset) schema, enough to
+// publish qri metadata to, and read metadata from, CKAN-based data portals.
+// see: http://docs.ckan.org/en/latest/api/index.html#ckan.logic.action.create.package_create
+type CKANPackage struct {
+	ID               string          `json:"id,omitempty"`
+	Name             string          `json:"name"`
+	Title            string          `json:"title,omitempty"`
+	Notes            string          `json:"notes,omitempty"`
+	URL              string          `json:"url,omitempty"`
+	Version          string          `json:"version,omitempty"`
+	LicenseID        string          `json:"license_id,omitempty"`
+	LicenseURL       string          `json:"license_url,omitempty"`
+	Author           string          `json:"author,omitempty"`
+	AuthorEmail      string          `json:"author_email,omitempty"`
+	MetadataModified string          `json:"metadata_modified,omitempty"`
+	Tags             []*CKANTag      `json:"tags,omitempty"`
+	Resources        []*CKANResource `json:"resources,omitempty"`
+	Extras           []*CKANExtra    `json:"extras,omitempty"`
+}
+
+// CKANTag is a single CKAN package tag
+type CKANTag struct {
+	Name string `json:"name"`
+}
+
+// CKANResource is a data file attached to a CKAN package
+type CKANResource struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+	Format      string `json:"format,omitempty"`
+}
+
+// CKANExtra is a free-form key-value pair attached to a CKAN package
+type CKANExtra struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ckanExtraPrefix namespaces extras written by qri. Any dataset field that
+// doesn't have a CKAN counterpart (accrualPeriodicity, language, readme, etc.)
+// is written as a json-encoded extra with this key prefix, and restored from
+// extras with this prefix on import. Extras without the prefix are ignored.
+const ckanExtraPrefix = "qri:"
+
+// ckanNameRegex matches characters not allowed in CKAN package names, which
+// must be lowercase alphanumeric plus "-" and "_"
+var ckanNameRegex = regexp.MustCompile(`[^a-z0-9_\-]`)
+
+// DatasetToCKAN maps a dataset's metadata to a CKAN package
+func DatasetToCKAN(name string, ds *dataset.Dataset) (*CKANPackage, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("dataset is required")
+	}
+
+	pkg := &CKANPackage{
+		ID:      ds.Identifier,
+		Name:    ckanNameRegex.ReplaceAllString(strings.ToLower(name), "_"),
+		Title:   ds.Title,
+		Notes:   ds.Description,
+		URL:     ds.HomeURL,
+		Version: string(ds.Version),
+	}
+
+	if ds.License != nil {
+		pkg.LicenseID = ds.License.Type
+		pkg.LicenseURL = ds.License.URL
+	}
+	if ds.Commit != nil && ds.Commit.Author != nil {
+		pkg.Author = ds.Commit.Author.Fullname
+		pkg.AuthorEmail = ds.Commit.Author.Email
+	}
+	if !ds.Timestamp.IsZero() {
+		pkg.MetadataModified = ds.Timestamp.In(time.UTC).Format(time.RFC3339)
+	}
+	for _, kw := range ds.Keywords {
+		pkg.Tags = append(pkg.Tags, &CKANTag{Name: kw})
+	}
+
+	resURL := ds.DownloadURL
+	if resURL == "" {
+		resURL = ds.AccessURL
+	}
+	if resURL != "" {
+		res := &CKANResource{
+			Name: ds.Title,
+			URL:  resURL,
+		}
+		if ds.Structure != nil && ds.Structure.Format != dataset.UnknownDataFormat {
+			res.Format = strings.ToUpper(ds.Structure.Format.String())
+		}
+		pkg.Resources = append(pkg.Resources, res)
+	}
+
+	extras := map[string]interface{}{
+		"accessURL":          ds.AccessURL,
+		"accrualPeriodicity": ds.AccrualPeriodicity,
+		"downloadURL":        ds.DownloadURL,
+		"readme":             ds.Readme,
+	}
+	if len(ds.Language) > 0 {
+		extras["language"] = ds.Language
+	}
+	if len(ds.Citations) > 0 {
+		extras["citations"] = ds.Citations
+	}
+	for _, key := range []string{"accessURL", "accrualPeriodicity", "citations", "downloadURL", "language", "readme"} {
+		val, ok := extras[key]
+		if !ok || val == "" {
+			continue
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding ckan extra '%s': %s", key, err.Error())
+		}
+		pkg.Extras = append(pkg.Extras, &CKANExtra{Key: ckanExtraPrefix + key, Value: string(data)})
+	}
+
+	return pkg, nil
+}
+
+// CKANToDataset maps a CKAN package to dataset metadata. the first resource
+// with a url is used as the dataset's download url
+func CKANToDataset(pkg *CKANPackage) (*dataset.Dataset, error) {
+	if pkg == nil {
+		return nil, fmt.Errorf("ckan package is required")
+	}
+
+	ds := &dataset.Dataset{
+		Identifier:  pkg.ID,
+		Title:       pkg.Title,
+		Description: pkg.Notes,
+		HomeURL:     pkg.URL,
+		Version:     dataset.VersionNumber(pkg.Version),
+	}
+
+	if pkg.LicenseID != "" || pkg.LicenseURL != "" {
+		ds.License = &dataset.License{
+			Type: pkg.LicenseID,
+			URL:  pkg.LicenseURL,
+		}
+	}
+	if pkg.MetadataModified != "" {
+		// CKAN omits the timezone from timestamps, accept both forms
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05.999999"} {
+			if t, err := time.Parse(layout, pkg.MetadataModified); err == nil {
+				ds.Timestamp = t.In(time.UTC)
+				break
+			}
+		}
+	}
+	for _, tag := range pkg.Tags {
+		ds.Keywords = append(ds.Keywords, tag.Name)
+	}
+	if res := pkg.DataResource(); res != nil {
+		ds.DownloadURL = res.URL
+	}
+
+	for _, extra := range pkg.Extras {
+		if !strings.HasPrefix(extra.Key, ckanExtraPrefix) {
+			continue
+		}
+
+		var err error
+		val := []byte(extra.Value)
+		switch strings.TrimPrefix(extra.Key, ckanExtraPrefix) {
+		case "accessURL":
+			err = json.Unmarshal(val, &ds.AccessURL)
+		case "accrualPeriodicity":
+			err = json.Unmarshal(val, &ds.AccrualPeriodicity)
+		case "citations":
+			err = json.Unmarshal(val, &ds.Citations)
+		case "downloadURL":
+			err = json.Unmarshal(val, &ds.DownloadURL)
+		case "language":
+			err = json.Unmarshal(val, &ds.Language)
+		case "readme":
+			err = json.Unmarshal(val, &ds.Readme)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding ckan extra '%s': %s", extra.Key, err.Error())
+		}
+	}
+
+	return ds, nil
+}
+
+// DataResource returns the first package resource that has a url, nil if
+// no such resource exists
+func (pkg *CKANPackage) DataResource() *CKANResource {
+	for _, res := range pkg.Resources {
+		if res.URL != "" {
+			return res
+		}
+	}
+	return nil
+}
+
+// ReadCKANPackage decodes a CKAN package from a reader. both bare package
+// json and CKAN action API responses (eg: package_show) are accepted
+func ReadCKANPackage(r io.Reader) (*CKANPackage, error) {
+	data := struct {
+		CKANPackage
+		Success *bool        `json:"success"`
+		Result  *CKANPackage `json:"result"`
+	}{}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("error parsing ckan package json: %s", err.Error())
+	}
+	if data.Success != nil {
+		if !*data.Success || data.Result == nil {
+			return nil, fmt.Errorf("ckan api request was unsuccessful")
+		}
+		return data.Result, nil
+	}
+	return &data.CKANPackage, nil
+}
+
+// ExportCKAN gets a dataset's metadata as a CKAN package
+func (r *DatasetRequests) ExportCKAN(p *GetDatasetParams, res *CKANPackage) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ExportCKAN", p, res)
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(p, ref); err != nil {
+		return err
+	}
+
+	name := ref.Name
+	if name == "" {
+		name = ref.Dataset.Title
+	}
+
+	pkg, err := DatasetToCKAN(name, ref.Dataset)
+	if err != nil {
+		return fmt.Errorf("error mapping dataset to ckan package: %s", err.Error())
+	}
+
+	*res = *pkg
+	return nil
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestCKANRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		ds   *dataset.Dataset
+		err  string
+	}{
+		{"", nil, "dataset is required"},
+		{"empty", &dataset.Dataset{}, ""},
+		{"movies", &dataset.Dataset{
+			Identifier:         "f9a2a5c8-bb5e-4e5b-9e4f-5e6f38c4fe10",
+			Title:              "example movie data",
+			Description:        "the top movies of all time",
+			HomeURL:            "https://example.com/movies",
+			DownloadURL:        "https://example.com/movies.csv",
+			AccessURL:          "https://example.com/movies/api",
+			AccrualPeriodicity: "R/P1W",
+			Readme:             "# movies",
+			Version:            "1.0.0",
+			Keywords:           []string{"movies", "film"},
+			Language:           []string{"en"},
+			License:            &dataset.License{Type: "cc-by", URL: "http://www.opendefinition.org/licenses/cc-by"},
+			Citations:          []*dataset.Citation{{Name: "IMDB", URL: "http://imdb.com"}},
+			Timestamp:          time.Date(2017, 1, 1, 1, 0, 0, 0, time.UTC),
+		}, ""},
+	}
+
+	for i, c := range cases {
+		pkg, err := DatasetToCKAN(c.name, c.ds)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		// round trip through json to mimic publishing to a portal
+		data, err := json.Marshal(pkg)
+		if err != nil {
+			t.Errorf("case %d error encoding package: %s", i, err.Error())
+			continue
+		}
+		pkg, err = ReadCKANPackage(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("case %d error reading package: %s", i, err.Error())
+			continue
+		}
+
+		got, err := CKANToDataset(pkg)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if err := compareCKANMetadata(c.ds, got); err != nil {
+			t.Errorf("case %d metadata mismatch: %s", i, err.Error())
+		}
+	}
+}
+
+func TestDatasetToCKAN(t *testing.T) {
+	ds := &dataset.Dataset{
+		Title:       "Movies",
+		DownloadURL: "https://example.com/movies.csv",
+		Structure:   &dataset.Structure{Format: dataset.CSVDataFormat},
+		Commit: &dataset.CommitMsg{
+			Author: &dataset.User{Fullname: "Rico", Email: "rico@example.com"},
+		},
+	}
+
+	pkg, err := DatasetToCKAN("Top Movies!", ds)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+
+	if pkg.Name != "top_movies_" {
+		t.Errorf("name mismatch. expected: %s, got: %s", "top_movies_", pkg.Name)
+	}
+	if pkg.Author != "Rico" || pkg.AuthorEmail != "rico@example.com" {
+		t.Errorf("author mismatch. got: %s <%s>", pkg.Author, pkg.AuthorEmail)
+	}
+	if len(pkg.Resources) != 1 {
+		t.Errorf("expected 1 resource, got: %d", len(pkg.Resources))
+		return
+	}
+	if pkg.Resources[0].Format != "CSV" {
+		t.Errorf("resource format mismatch. expected: %s, got: %s", "CSV", pkg.Resources[0].Format)
+	}
+	if pkg.Resources[0].URL != ds.DownloadURL {
+		t.Errorf("resource url mismatch. expected: %s, got: %s", ds.DownloadURL, pkg.Resources[0].URL)
+	}
+}
+
+func TestReadCKANPackage(t *testing.T) {
+	cases := []struct {
+		data string
+		name string
+		err  string
+	}{
+		{`{`, "", "error parsing ckan package json: unexpected EOF"},
+		{`{"name":"movies"}`, "movies", ""},
+		{`{"success":true,"result":{"name":"movies"}}`, "movies", ""},
+		{`{"success":false,"error":{"message":"Not found"}}`, "", "ckan api request was unsuccessful"},
+		// ckan timestamps don't include a timezone
+		{`{"name":"movies","metadata_modified":"2017-10-30T18:48:31.785047","extras":[{"key":"region","value":"EU"}]}`, "movies", ""},
+	}
+
+	for i, c := range cases {
+		got, err := ReadCKANPackage(strings.NewReader(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Name != c.name {
+			t.Errorf("case %d name mismatch. expected: %s, got: %s", i, c.name, got.Name)
+		}
+		if _, err := CKANToDataset(got); err != nil {
+			t.Errorf("case %d error mapping to dataset: %s", i, err.Error())
+		}
+	}
+}
+
+func TestDatasetRequestsExportCKAN(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	got := &CKANPackage{}
+	if err := req.ExportCKAN(&GetDatasetParams{Path: path}, got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if got.Name != "movies" {
+		t.Errorf("name mismatch. expected: %s, got: %s", "movies", got.Name)
+	}
+}
+
+func TestDatasetRequestsInitCKAN(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/3/action/package_show":
+			fmt.Fprintf(w, `{"success":true,"result":{"name":"ckan_jobs","title":"Jobs","notes":"jobs by automation","tags":[{"name":"jobs"}],"resources":[{"url":"%s/jobs","format":"CSV"}]}}`, server.URL)
+		case "/empty":
+			w.Write([]byte(`{"name":"empty","resources":[]}`))
+		case "/jobs":
+			w.Write([]byte("title,probability\nChoreographers,0.004\nDentists,0.0044\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		p   *InitDatasetParams
+		err string
+	}{
+		{&InitDatasetParams{CKAN: true}, "a url is required to import a ckan package"},
+		{&InitDatasetParams{CKAN: true, URL: server.URL + "/empty"}, "ckan package has no resources with a url"},
+		{&InitDatasetParams{CKAN: true, Name: "ckan_jobs", URL: server.URL + "/api/3/action/package_show"}, ""},
+	}
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.InitDataset(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Dataset.Description != "jobs by automation" {
+			t.Errorf("case %d description mismatch. expected: %s, got: %s", i, "jobs by automation", got.Dataset.Description)
+		}
+		if got.Dataset.DownloadURL != server.URL+"/jobs" {
+			t.Errorf("case %d download url mismatch. expected: %s, got: %s", i, server.URL+"/jobs", got.Dataset.DownloadURL)
+		}
+	}
+}
+
+// compareCKANMetadata checks the fields that survive a trip through CKAN
+func compareCKANMetadata(a, b *dataset.Dataset) error {
+	if a.Identifier != b.Identifier {
+		return fmt.Errorf("identifier: %s != %s", a.Identifier, b.Identifier)
+	}
+	if a.Title != b.Title {
+		return fmt.Errorf("title: %s != %s", a.Title, b.Title)
+	}
+	if a.Description != b.Description {
+		return fmt.Errorf("description: %s != %s", a.Description, b.Description)
+	}
+	if a.HomeURL != b.HomeURL {
+		return fmt.Errorf("homeURL: %s != %s", a.HomeURL, b.HomeURL)
+	}
+	if a.DownloadURL != b.DownloadURL {
+		return fmt.Errorf("downloadURL: %s != %s", a.DownloadURL, b.DownloadURL)
+	}
+	if a.AccessURL != b.AccessURL {
+		return fmt.Errorf("accessURL: %s != %s", a.AccessURL, b.AccessURL)
+	}
+	if a.AccrualPeriodicity != b.AccrualPeriodicity {
+		return fmt.Errorf("accrualPeriodicity: %s != %s", a.AccrualPeriodicity, b.AccrualPeriodicity)
+	}
+	if a.Readme != b.Readme {
+		return fmt.Errorf("readme: %s != %s", a.Readme, b.Readme)
+	}
+	if a.Version != b.Version {
+		return fmt.Errorf("version: %s != %s", a.Version, b.Version)
+	}
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return fmt.Errorf("timestamp: %s != %s", a.Timestamp, b.Timestamp)
+	}
+	if !reflect.DeepEqual(a.Keywords, b.Keywords) {
+		return fmt.Errorf("keywords: %v != %v", a.Keywords, b.Keywords)
+	}
+	if !reflect.DeepEqual(a.Language, b.Language) {
+		return fmt.Errorf("language: %v != %v", a.Language, b.Language)
+	}
+	if !reflect.DeepEqual(a.License, b.License) {
+		return fmt.Errorf("license: %v != %v", a.License, b.License)
+	}
+	if !reflect.DeepEqual(a.Citations, b.Citations) {
+		return fmt.Errorf("citations: %v != %v", a.Citations, b.Citations)
+	}
+	return nil
+}
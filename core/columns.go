@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+)
+
+// DefaultMaxColumnValues is the default cap on distinct values returned for a column
+const DefaultMaxColumnValues = 100
+
+// ColumnParams defines parameters for getting a column of a dataset
+type ColumnParams struct {
+	Path datastore.Key
+	Name string
+	// MaxValues caps the number of distinct values returned, defaults to
+	// DefaultMaxColumnValues
+	MaxValues int
+}
+
+// Column is the definition of a dataset field, with a summary of it's values
+type Column struct {
+	Field *dataset.Field `json:"field"`
+	// Values is the sorted set of distinct values in the column. Values is
+	// only set if the column has no more than the max number of distinct values
+	Values []string `json:"values,omitempty"`
+	// Truncated is true when the column has too many distinct values to list
+	Truncated bool `json:"truncated,omitempty"`
+	// Cardinality is the number of distinct values in the column. When
+	// Truncated is true, Cardinality is an estimate
+	Cardinality int `json:"cardinality"`
+}
+
+// Column gets the definition of a single field of a dataset along with the
+// distinct values of the field, reading data in a single pass
+func (r *DatasetRequests) Column(p *ColumnParams, res *Column) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Column", p, res)
+	}
+
+	max := p.MaxValues
+	if max <= 0 {
+		max = DefaultMaxColumnValues
+	}
+
+	ds, index, err := r.loadColumn(p.Path, p.Name)
+	if err != nil {
+		return err
+	}
+	store := r.repo.Store()
+
+	values := map[string]bool{}
+	est := newCardinalityEstimator(cardinalityEstimatorSize)
+	if err := eachDataRow(store, ds, func(i int, row [][]byte, err error) error {
+		if err != nil {
+			return err
+		}
+		if index >= len(row) {
+			return nil
+		}
+		val := string(row[index])
+		est.add(val)
+		// stop collecting values once there are too many to return
+		if values != nil && !values[val] {
+			if len(values) == max {
+				values = nil
+			} else {
+				values[val] = true
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error reading data: %s", err.Error())
+	}
+
+	col := Column{Field: ds.Structure.Schema.Fields[index]}
+	if values == nil {
+		col.Truncated = true
+		col.Cardinality = est.estimate()
+	} else {
+		col.Cardinality = len(values)
+		col.Values = make([]string, 0, len(values))
+		for val := range values {
+			col.Values = append(col.Values, val)
+		}
+		sort.Strings(col.Values)
+	}
+
+	*res = col
+	return nil
+}
+
+// loadColumn loads the dataset at path, giving the index of the named field
+func (r *DatasetRequests) loadColumn(path datastore.Key, name string) (*dataset.Dataset, int, error) {
+	if name == "" {
+		return nil, -1, fmt.Errorf("column name is required")
+	}
+
+	path, err := resolvePath(r.repo, path)
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := r.checkVisible(path); err != nil {
+		return nil, -1, fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+
+	ds, err := dsfs.LoadDataset(r.repo.Store(), path)
+	if err != nil {
+		return nil, -1, fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	if ds.Structure == nil || ds.Structure.Schema == nil {
+		return nil, -1, fmt.Errorf("dataset has no schema")
+	}
+
+	for i, f := range ds.Structure.Schema.Fields {
+		if f.Name == name {
+			return ds, i, nil
+		}
+	}
+	return nil, -1, fmt.Errorf("column '%s' not found", name)
+}
+
+// cardinalityEstimatorSize is the number of hashes kept when estimating
+// cardinality, giving an error of roughly 1/sqrt(size)
+const cardinalityEstimatorSize = 1024
+
+// cardinalityEstimator estimates the number of distinct values in a stream
+// in constant memory by keeping the k smallest hashes of values seen
+// ("k minimum values"). distinct hashes are uniformly spread across the hash
+// space, so the k-th smallest hash tells us how densely values fill it
+type cardinalityEstimator struct {
+	k      int
+	hashes []uint64
+}
+
+func newCardinalityEstimator(k int) *cardinalityEstimator {
+	return &cardinalityEstimator{k: k}
+}
+
+func (e *cardinalityEstimator) add(val string) {
+	h := fnv.New64a()
+	h.Write([]byte(val))
+	// fnv doesn't spread similar values evenly enough across the hash space,
+	// mix bits with the murmur3 finalizer
+	sum := h.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	sum *= 0xc4ceb9fe1a85ec53
+	sum ^= sum >> 33
+
+	if len(e.hashes) == e.k && sum >= e.hashes[e.k-1] {
+		return
+	}
+	i := sort.Search(len(e.hashes), func(i int) bool { return e.hashes[i] >= sum })
+	if i < len(e.hashes) && e.hashes[i] == sum {
+		return
+	}
+	e.hashes = append(e.hashes, 0)
+	copy(e.hashes[i+1:], e.hashes[i:])
+	e.hashes[i] = sum
+	if len(e.hashes) > e.k {
+		e.hashes = e.hashes[:e.k]
+	}
+}
+
+func (e *cardinalityEstimator) estimate() int {
+	if len(e.hashes) < e.k {
+		// fewer than k distinct hashes means we've seen every value
+		return len(e.hashes)
+	}
+	kth := float64(e.hashes[e.k-1]) / math.MaxUint64
+	return int(float64(e.k-1) / kth)
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsColumn(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p           *ColumnParams
+		values      []string
+		truncated   bool
+		cardinality int
+		err         string
+	}{
+		{&ColumnParams{Path: citiesPath}, nil, false, 0, "column name is required"},
+		{&ColumnParams{Path: citiesPath, Name: "not_a_column"}, nil, false, 0, "column 'not_a_column' not found"},
+		{&ColumnParams{Path: citiesPath, Name: "in_usa"}, []string{"false", "true"}, false, 2, ""},
+		{&ColumnParams{Path: citiesPath, Name: "avg_age"}, []string{"44.4", "50.65", "55.5", "65.25"}, false, 4, ""},
+		{&ColumnParams{Path: citiesPath, Name: "avg_age", MaxValues: 3}, nil, true, 4, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &Column{}
+		err := req.Column(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Field == nil || got.Field.Name != c.p.Name {
+			t.Errorf("case %d expected field definition for '%s', got: %v", i, c.p.Name, got.Field)
+		}
+		if fmt.Sprintf("%v", got.Values) != fmt.Sprintf("%v", c.values) {
+			t.Errorf("case %d values mismatch. expected: %v, got: %v", i, c.values, got.Values)
+		}
+		if got.Truncated != c.truncated {
+			t.Errorf("case %d truncated mismatch. expected: %t, got: %t", i, c.truncated, got.Truncated)
+		}
+		if got.Cardinality != c.cardinality {
+			t.Errorf("case %d cardinality mismatch. expected: %d, got: %d", i, c.cardinality, got.Cardinality)
+		}
+	}
+
+	// high-cardinality columns only report an estimate
+	got := &Column{}
+	if err := req.Column(&ColumnParams{Path: moviesPath, Name: "movie_title", MaxValues: 10}, got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if !got.Truncated || got.Values != nil {
+		t.Errorf("expected high-cardinality column to be truncated without values")
+	}
+	if got.Cardinality < 4000 || got.Cardinality > 6000 {
+		t.Errorf("expected cardinality estimate near 5000, got: %d", got.Cardinality)
+	}
+}
+
+func TestCardinalityEstimator(t *testing.T) {
+	cases := []struct {
+		distinct, repeat int
+	}{
+		{0, 1},
+		{10, 3},
+		{1000, 2},
+		{100000, 1},
+		{50000, 4},
+	}
+
+	for i, c := range cases {
+		est := newCardinalityEstimator(cardinalityEstimatorSize)
+		for r := 0; r < c.repeat; r++ {
+			for j := 0; j < c.distinct; j++ {
+				est.add(fmt.Sprintf("value_%d", j))
+			}
+		}
+		got := est.estimate()
+		// estimates should be within ~3 standard errors
+		margin := float64(c.distinct) * 0.1
+		if diff := float64(got - c.distinct); diff > margin || diff < -margin {
+			t.Errorf("case %d estimate out of range. expected ~%d, got: %d", i, c.distinct, got)
+		}
+	}
+}
@@ -22,11 +22,13 @@ type Requests interface {
 func Receivers(node *p2p.QriNode) []Requests {
 	r := node.Repo
 	return []Requests{
+		NewActivityRequests(r, nil),
 		NewDatasetRequests(r, nil),
 		NewHistoryRequests(r, nil),
 		NewPeerRequests(node, nil),
 		NewProfileRequests(r, nil),
 		NewQueryRequests(r, nil),
+		NewRepoRequests(r, nil),
 		NewSearchRequests(r, nil),
 	}
 }
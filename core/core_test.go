@@ -0,0 +1,21 @@
+package core
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+)
+
+// newTestRPCClient serves rcvr over an in-memory connection, returning a
+// client connected to it. the json codec is used because datastore keys
+// only know how to encode themselves as json
+func newTestRPCClient(t *testing.T, rcvr Requests) *rpc.Client {
+	srv := rpc.NewServer()
+	if err := srv.Register(rcvr); err != nil {
+		t.Fatalf("error registering %s requests: %s", rcvr.CoreRequestsName(), err.Error())
+	}
+	sconn, cconn := net.Pipe()
+	go srv.ServeCodec(jsonrpc.NewServerCodec(sconn))
+	return rpc.NewClientWithCodec(jsonrpc.NewClientCodec(cconn))
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+// CountRows gives the number of rows in a dataset's data. rows are counted
+// as they're read without holding data in memory. repos that record row
+// counts keep them by data hash, so later calls for the same data don't read
+// it again. rows counted by Stats are used when available
+func (r *DatasetRequests) CountRows(p *GetDatasetParams, res *int) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.CountRows", p, res)
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(p, ref); err != nil {
+		return err
+	}
+	ds := ref.Dataset
+	if ds.Structure == nil {
+		return fmt.Errorf("dataset has no structure")
+	}
+
+	key := ds.Data
+	rc, recorded := r.repo.(repo.RowCounts)
+	if recorded && key != "" {
+		n, err := rc.RowCount(datastore.NewKey(key))
+		if err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("error getting row count: %s", err.Error())
+		}
+		if err == nil {
+			*res = n
+			return nil
+		}
+	}
+	if s, ok := cachedStats(r.repo, key); ok {
+		*res = s.Rows
+		return recordRowCount(rc, key, s.Rows)
+	}
+
+	rows := 0
+	if err := eachDataRow(r.repo.Store(), ds, func(i int, row [][]byte, err error) error {
+		if err != nil {
+			return err
+		}
+		rows++
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error reading data: %s", err.Error())
+	}
+
+	*res = rows
+	return recordRowCount(rc, key, rows)
+}
+
+// recordRowCount records the row count of the data at key if the repo
+// keeps row counts
+func recordRowCount(rc repo.RowCounts, key string, rows int) error {
+	if rc == nil || key == "" {
+		return nil
+	}
+	if err := rc.SetRowCount(datastore.NewKey(key), rows); err != nil {
+		return fmt.Errorf("error recording row count: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsCountRows(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	datakey, err := mr.Store().Put(memfs.NewMemfileBytes("data.csv", []byte("name\na\nb\nc\n")), false)
+	if err != nil {
+		t.Errorf("error putting data: %s", err.Error())
+		return
+	}
+	ds := &dataset.Dataset{
+		Title: "count",
+		Data:  datakey.String(),
+		Structure: &dataset.Structure{
+			Format:       dataset.CSVDataFormat,
+			FormatConfig: &dataset.CSVOptions{HeaderRow: true},
+			Schema: &dataset.Schema{
+				Fields: []*dataset.Field{{Name: "name", Type: datatypes.String}},
+			},
+		},
+	}
+	dspath, err := dsfs.SaveDataset(mr.Store(), ds, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	if err := mr.PutName("count", dspath); err != nil {
+		t.Errorf("error putting name: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p    *GetDatasetParams
+		rows int
+		err  string
+	}{
+		{&GetDatasetParams{Name: "not_a_dataset"}, 0, "error getting dataset path: repo: not found"},
+		{&GetDatasetParams{Name: "count"}, 3, ""},
+		// recorded by data hash
+		{&GetDatasetParams{Path: dspath}, 3, ""},
+	}
+
+	for i, c := range cases {
+		got := 0
+		err := req.CountRows(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got != c.rows {
+			t.Errorf("case %d rows mismatch. expected: %d, got: %d", i, c.rows, got)
+		}
+	}
+
+	if n, err := mr.(repo.RowCounts).RowCount(datakey); err != nil || n != 3 {
+		t.Errorf("expected a recorded count of 3 rows, got: %d, %v", n, err)
+	}
+}
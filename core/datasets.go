@@ -2,25 +2,32 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/rpc"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
-	ipfs "github.com/qri-io/cafs/ipfs"
 	"github.com/qri-io/cafs/memfs"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
 	"github.com/qri-io/dataset/detect"
 	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsgraph"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/validate"
+	"github.com/qri-io/qri/ref"
 	"github.com/qri-io/qri/repo"
 )
 
@@ -29,6 +36,8 @@ import (
 type DatasetRequests struct {
 	repo repo.Repo
 	cli  *rpc.Client
+	// publicOnly hides private datasets, see NewPublicDatasetRequests
+	publicOnly bool
 }
 
 // CoreRequestsName implements the Requets interface
@@ -53,73 +62,357 @@ func (r *DatasetRequests) List(p *ListParams, res *[]*repo.DatasetRef) error {
 		return r.cli.Call("DatasetRequests.List", p, res)
 	}
 
-	store := r.repo.Store()
-	// ensure valid limit value
-	if p.Limit <= 0 {
-		p.Limit = 25
+	replies, err := r.list(p)
+	if err != nil {
+		return err
+	}
+	*res = replies
+	return nil
+}
+
+// DatasetList is a page of datasets, with the total number of datasets
+// available to page through
+type DatasetList struct {
+	Datasets []*repo.DatasetRef `json:"datasets"`
+	Total    int                `json:"total"`
+	HasMore  bool               `json:"hasMore"`
+}
+
+// ListPage returns a page of this repo's datasets, like List, along with the
+// total number of datasets & whether pages remain past this one
+func (r *DatasetRequests) ListPage(p *ListParams, res *DatasetList) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ListPage", p, res)
 	}
-	// ensure valid offset value
-	if p.Offset < 0 {
-		p.Offset = 0
+
+	replies, err := r.list(p)
+	if err != nil {
+		return err
 	}
-	replies, err := r.repo.Namespace(p.Limit, p.Offset)
+	total, err := r.count(p)
 	if err != nil {
-		return fmt.Errorf("error getting namespace: %s", err.Error())
+		return err
 	}
 
-	for i, ref := range replies {
-		if i >= p.Limit {
-			break
+	*res = DatasetList{
+		Datasets: replies,
+		Total:    total,
+		HasMore:  p.Offset+len(replies) < total,
+	}
+	return nil
+}
+
+// count gives the total number of datasets List can return
+func (r *DatasetRequests) count(p *ListParams) (int, error) {
+	if p.DueForUpdate {
+		due, err := r.filtered(p)
+		return len(due), err
+	}
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return 0, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	if !r.publicOnly {
+		return count, nil
+	}
+	public, err := r.namespace(count, 0)
+	if err != nil {
+		return 0, err
+	}
+	return len(public), nil
+}
+
+// datasetOrders are the orderings List supports, as less functions. an
+// order prefixed with "-" is descending. ties are broken by name
+var datasetOrders = map[string]func(a, b *repo.DatasetRef) bool{
+	"name":      datasetNameLess,
+	"created":   datasetTimestampLess,
+	"timestamp": datasetTimestampLess,
+	"length":    datasetLengthLess,
+}
+
+// datasetOrder gives the less function for an ordering, reversed for
+// descending orders. unknown orderings give nil
+func datasetOrder(orderBy string) func(a, b *repo.DatasetRef) bool {
+	if strings.HasPrefix(orderBy, "-") {
+		if less := datasetOrders[orderBy[1:]]; less != nil {
+			return func(a, b *repo.DatasetRef) bool { return less(b, a) }
+		}
+		return nil
+	}
+	return datasetOrders[orderBy]
+}
+
+func datasetNameLess(a, b *repo.DatasetRef) bool {
+	return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+}
+
+func datasetTimestampLess(a, b *repo.DatasetRef) bool {
+	if !a.Dataset.Timestamp.Equal(b.Dataset.Timestamp) {
+		return a.Dataset.Timestamp.Before(b.Dataset.Timestamp)
+	}
+	return datasetNameLess(a, b)
+}
+
+func datasetLengthLess(a, b *repo.DatasetRef) bool {
+	if a.Dataset.Length != b.Dataset.Length {
+		return a.Dataset.Length < b.Dataset.Length
+	}
+	return datasetNameLess(a, b)
+}
+
+// list loads a page of datasets. offsets past the end give an empty page,
+// a limit of zero lists all datasets. unknown orderings list datasets in
+// namespace order
+func (r *DatasetRequests) list(p *ListParams) ([]*repo.DatasetRef, error) {
+	p.EnsureDefaults()
+
+	less := datasetOrder(p.OrderBy)
+	if less == nil && p.Limit > 0 && !p.DueForUpdate {
+		replies, err := r.namespace(p.Limit, p.Offset)
+		if err != nil {
+			return nil, err
+		}
+		if len(replies) > p.Limit {
+			replies = replies[:p.Limit]
+		}
+		return replies, r.loadDatasets(replies)
+	}
+
+	// ordered, filtered & unlimited lists load all datasets before paging
+	replies, err := r.filtered(p)
+	if err != nil {
+		return nil, err
+	}
+	if less != nil {
+		sort.SliceStable(replies, func(i, j int) bool { return less(replies[i], replies[j]) })
+	}
+
+	if p.Offset >= len(replies) {
+		return []*repo.DatasetRef{}, nil
+	}
+	replies = replies[p.Offset:]
+	if p.Limit > 0 && len(replies) > p.Limit {
+		replies = replies[:p.Limit]
+	}
+	return replies, nil
+}
+
+// filtered loads all datasets, leaving out datasets that aren't due for an
+// update if p.DueForUpdate is set
+func (r *DatasetRequests) filtered(p *ListParams) ([]*repo.DatasetRef, error) {
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.namespace(count, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadDatasets(refs); err != nil {
+		return nil, err
+	}
+	if !p.DueForUpdate {
+		return refs, nil
+	}
+	at := p.Before
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return dueForUpdate(r.repo, refs, at), nil
+}
+
+// namespace reads refs from the repo's namespace, leaving out private
+// datasets if requests are public only. private datasets are left out before
+// paging, so public pages are only short at the end of the namespace
+func (r *DatasetRequests) namespace(limit, offset int) ([]*repo.DatasetRef, error) {
+	if !r.publicOnly {
+		replies, err := r.repo.Namespace(limit, offset)
+		if err != nil {
+			return nil, fmt.Errorf("error getting namespace: %s", err.Error())
+		}
+		return replies, nil
+	}
+
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	replies, err := r.repo.Namespace(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace: %s", err.Error())
+	}
+
+	public := make([]*repo.DatasetRef, 0, len(replies))
+	for _, ref := range replies {
+		v, err := repo.NameVisibility(r.repo, ref.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error checking dataset visibility: %s", err.Error())
+		}
+		if v != repo.VisibilityPrivate {
+			public = append(public, ref)
 		}
+	}
+
+	if offset >= len(public) {
+		return []*repo.DatasetRef{}, nil
+	}
+	public = public[offset:]
+	if limit > 0 && len(public) > limit {
+		public = public[:limit]
+	}
+	return public, nil
+}
 
-		ds, err := dsfs.LoadDataset(store, ref.Path)
+// loadDatasets sets the Dataset of each ref
+func (r *DatasetRequests) loadDatasets(refs []*repo.DatasetRef) error {
+	for _, ref := range refs {
+		ds, err := loadDataset(r.repo, ref.Path)
 		if err != nil {
 			// try one extra time...
 			// TODO - remove this horrible hack
-			ds, err = dsfs.LoadDataset(store, ref.Path)
+			ds, err = loadDataset(r.repo, ref.Path)
 			if err != nil {
 				return fmt.Errorf("error loading path: %s, err: %s", ref.Path.String(), err.Error())
 			}
 		}
-		replies[i].Dataset = ds
+		ref.Dataset = ds
 	}
-
-	*res = replies
 	return nil
 }
 
 // GetDatasetParams defines parameters for DatasetRequests.Get
 type GetDatasetParams struct {
+	// Path of the dataset to get. takes precedence over Name
 	Path datastore.Key
+	// Name gets a dataset by reference when Path is empty, eg: movies or movies@2
 	Name string
 	Hash string
 }
 
-// Get a dataset
+// Get a dataset by path or name. the returned ref's Name is the name the
+// repo stores for the dataset's path, if any
 func (r *DatasetRequests) Get(p *GetDatasetParams, res *repo.DatasetRef) error {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Get", p, res)
 	}
+	if p.Path.String() == "" && p.Name == "" {
+		return withKind(ErrInvalidParams, fmt.Errorf("either a path or a name is required to get a dataset"))
+	}
 
-	store := r.repo.Store()
-	ds, err := dsfs.LoadDataset(store, p.Path)
+	path, err := resolvePath(r.repo, p.Path)
+	if err != nil {
+		return err
+	}
+	if path.String() == "" && p.Name != "" {
+		// names can be any reference, including versioned names: name@N
+		rf, err := ref.ParseRef(p.Name)
+		if err != nil {
+			return withKind(ErrInvalidParams, err)
+		}
+		if path, err = resolveRef(r.repo, rf); err != nil {
+			return fmt.Errorf("error getting dataset path: %w", err)
+		}
+	}
+	if err := r.checkVisible(path); err != nil {
+		return fmt.Errorf("error loading dataset: %w", err)
+	}
+
+	ds, err := loadDataset(r.repo, path)
 	if err != nil {
-		return fmt.Errorf("error loading dataset: %s", err.Error())
+		return fmt.Errorf("error loading dataset: %w", err)
 	}
 
 	name := p.Name
-	if p.Path.String() != "" {
-		name, _ = r.repo.GetName(p.Path)
+	if path.String() != "" {
+		name, _ = r.repo.GetName(path)
 	}
 
 	*res = repo.DatasetRef{
 		Name:    name,
-		Path:    p.Path,
+		Path:    path,
 		Dataset: ds,
 	}
 	return nil
 }
 
+// Exists checks if the repo has a dataset for a path or name, without
+// loading the dataset. names with a version, eg: movies@2, are checked by
+// walking the dataset's history
+func (r *DatasetRequests) Exists(p *GetDatasetParams, exists *bool) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Exists", p, exists)
+	}
+	if p.Path.String() == "" && p.Name == "" {
+		return fmt.Errorf("either a path or a name is required to check if a dataset exists")
+	}
+
+	var (
+		path datastore.Key
+		err  error
+	)
+	if p.Path.String() != "" {
+		if path, err = resolvePath(r.repo, p.Path); err != nil {
+			return err
+		}
+		has, err := repo.HasPath(r.repo, path)
+		if err != nil && !strings.Contains(err.Error(), repo.ErrRepoEmpty.Error()) {
+			return err
+		}
+		if !has {
+			*exists = false
+			return nil
+		}
+	} else {
+		rf, err := ref.ParseRef(p.Name)
+		if err != nil {
+			return err
+		}
+		if path, err = resolveRef(r.repo, rf); errors.Is(err, repo.ErrNotFound) {
+			*exists = false
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := r.checkVisible(path); errors.Is(err, repo.ErrNotFound) {
+		*exists = false
+		return nil
+	} else if err != nil {
+		return err
+	}
+	*exists = true
+	return nil
+}
+
+// resolvePath expands a hash prefix to the full path of a dataset the repo
+// knows about. paths that aren't prefixes are returned unchanged. see
+// repo.MinHashPrefixLength for the shortest prefix that will be resolved
+func resolvePath(r repo.Repo, path datastore.Key) (datastore.Key, error) {
+	if !repo.IsHashPrefix(path.String()) {
+		return path, nil
+	}
+	full, err := repo.ResolveHashPrefix(r, path.String())
+	if err != nil {
+		return path, fmt.Errorf("error resolving hash prefix '%s': %w", path.String(), err)
+	}
+	return full, nil
+}
+
+// urlFilename gives the last element of a url's path, ignoring any query
+// string or fragment so extensions like .csv.gz are detected
+func urlFilename(rawurl string) string {
+	if u, err := url.Parse(rawurl); err == nil && u.Path != "" {
+		return path.Base(u.Path)
+	}
+	return filepath.Base(rawurl)
+}
+
+// DefaultAccrualPeriodicity is the accrual periodicity of datasets downloaded
+// from a url when none is given: weekly
+const DefaultAccrualPeriodicity = "R/P1W"
+
 // InitDatasetParams encapsulates arguments to InitDataset
 type InitDatasetParams struct {
 	Name             string    // variable name for referring to this dataset. required.
@@ -128,65 +421,202 @@ type InitDatasetParams struct {
 	Data             io.Reader // reader of structured data. either Url or Data is required
 	MetadataFilename string    // filename of metadata file. optional.
 	Metadata         io.Reader // reader of json-formatted metadata
-	// TODO - add support for adding via path/hash
-	// DataPath         datastore.Key // path to structured data
+	CKAN             bool      // treat URL as a CKAN package, importing metadata & data from it's first resource
+	SampleStrategy   string    // rows to infer field types from: "head", "random" or "full". optional.
+	SampleSize       int       // number of rows sampled by the head & random strategies. optional.
+	// DataPath is the path of data that's already in the store. optional, can't be combined with URL or Data
+	DataPath datastore.Key
+	// DataFilepath is the local filesystem path of a data file. optional, can't be combined with URL, Data or DataPath
+	DataFilepath string
+	// ReuseExisting returns the dataset of data that's already in the repo
+	// instead of erroring, naming it if it doesn't have a name
+	ReuseExisting bool
+	// AccrualPeriodicity is how often the dataset's source is expected to
+	// change, as an ISO8601 repeating interval, eg: R/P1D. optional. datasets
+	// downloaded from a URL default to DefaultAccrualPeriodicity
+	AccrualPeriodicity string
+	// SheetName is the worksheet to read from excel (.xlsx) data. optional
+	// for workbooks with a single worksheet
+	SheetName string
+	// Context cancels downloading from URL when done. Context isn't sent over
+	// RPC
+	Context context.Context `json:"-"`
 }
 
 // InitDataset creates a new qri dataset from a source of data
 func (r *DatasetRequests) InitDataset(p *InitDatasetParams, res *repo.DatasetRef) error {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.InitDataset", p, res)
+		args := *p
+		args.Context = nil
+		return r.cli.Call("DatasetRequests.InitDataset", &args, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
 	}
 
 	var (
 		rdr      io.Reader
 		store    = r.repo.Store()
 		filename = p.DataFilename
+		ckands   *dataset.Dataset
+		dataURL  = p.URL
+		datakey  datastore.Key
 	)
 
-	if p.URL != "" {
-		res, err := http.Get(p.URL)
+	sources := 0
+	for _, set := range []bool{p.URL != "", p.Data != nil, p.DataPath.String() != "", p.DataFilepath != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return withKind(ErrInvalidParams, fmt.Errorf("only one of a url, a file, a data path, or a file path can be used to create a dataset"))
+	}
+	if p.AccrualPeriodicity != "" {
+		if _, err := parseRepeatingInterval(p.AccrualPeriodicity); err != nil {
+			return withKind(ErrInvalidParams, fmt.Errorf("invalid accrual periodicity '%s': %s", p.AccrualPeriodicity, err.Error()))
+		}
+	}
+
+	if p.CKAN {
+		if p.URL == "" {
+			return withKind(ErrInvalidParams, fmt.Errorf("a url is required to import a ckan package"))
+		}
+		res, err := httpGet(p.Context, p.URL)
+		if err != nil {
+			return fmt.Errorf("error fetching ckan package: %s", err.Error())
+		}
+		defer res.Body.Close()
+
+		pkg, err := ReadCKANPackage(res.Body)
+		if err != nil {
+			return err
+		}
+		resource := pkg.DataResource()
+		if resource == nil {
+			return fmt.Errorf("ckan package has no resources with a url")
+		}
+		if ckands, err = CKANToDataset(pkg); err != nil {
+			return err
+		}
+
+		dataURL = resource.URL
+		filename = urlFilename(resource.URL)
+		if filepath.Ext(filename) == "" && resource.Format != "" {
+			filename = filename + "." + strings.ToLower(resource.Format)
+		}
+	}
+
+	if dataURL != "" {
+		res, err := httpGet(p.Context, dataURL)
 		if err != nil {
 			return fmt.Errorf("error fetching url: %s", err.Error())
 		}
-		filename = filepath.Base(p.URL)
+		if !p.CKAN {
+			filename = urlFilename(dataURL)
+		}
 		defer res.Body.Close()
 		rdr = res.Body
 	} else if p.Data != nil {
 		rdr = p.Data
+	} else if p.DataPath.String() != "" {
+		f, err := store.Get(p.DataPath)
+		if err != nil {
+			return fmt.Errorf("error getting data path: %s", err.Error())
+		}
+		defer f.Close()
+		if filename == "" {
+			filename = filepath.Base(p.DataPath.String())
+		}
+		datakey = p.DataPath
+		rdr = f
+	} else if p.DataFilepath != "" {
+		f, err := os.Open(p.DataFilepath)
+		if err != nil {
+			return fmt.Errorf("error opening file: %s", err.Error())
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("error reading file info: %s", err.Error())
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("'%s' is a directory, not a data file", p.DataFilepath)
+		}
+		filename = fi.Name()
+		rdr = f
 	} else {
-		return fmt.Errorf("either a file or a url is required to create a dataset")
+		return withKind(ErrInvalidParams, fmt.Errorf("either a file or a url is required to create a dataset"))
 	}
 
 	if p.Name != "" {
 		if err := validate.ValidName(p.Name); err != nil {
-			return fmt.Errorf("invalid name: %s", err.Error())
+			return withKind(ErrInvalidParams, fmt.Errorf("invalid name: %s", err.Error()))
+		}
+	}
+
+	// data that's already stored is kept as-is, anything else may be gzipped
+	xlsx := false
+	if datakey.String() == "" {
+		var err error
+		if rdr, filename, err = gunzipData(rdr, filename); err != nil {
+			return withKind(ErrInvalidFormat, err)
+		}
+		// newline-delimited json is stored as a json array
+		if isJSONLines(filename) {
+			rdr = jsonLinesToArray(rdr)
+			filename = jsonArrayFilename(filename)
+		}
+		// excel workbooks are stored as csv data of a single worksheet
+		if xlsx = isXLSX(filename); xlsx {
+			if rdr, err = xlsxToCSV(rdr, p.SheetName); err != nil {
+				return withKind(ErrInvalidFormat, err)
+			}
+			filename = xlsxCSVFilename(filename)
 		}
 	}
+	if p.SheetName != "" && !xlsx {
+		return withKind(ErrInvalidParams, fmt.Errorf("a sheet name can only be used with xlsx data"))
+	}
 
-	// TODO - need a better strategy for huge files
-	data, err := ioutil.ReadAll(rdr)
+	// large files are spooled to disk, only a prefix is held in memory for
+	// detection & sampling
+	data, err := spoolData(rdr)
 	if err != nil {
 		return fmt.Errorf("error reading file: %s", err.Error())
 	}
+	defer data.Close()
+
 	// Ensure that dataset is well-formed
-	format, err := detect.ExtensionDataFormat(filename)
+	format, detectFilename, err := detectDataFormat(filename, data.prefix)
 	if err != nil {
-		return fmt.Errorf("error detecting format extension: %s", err.Error())
+		return withKind(ErrInvalidFormat, err)
 	}
-	if err = validate.DataFormat(format, bytes.NewReader(data)); err != nil {
-		return fmt.Errorf("invalid data format: %s", err.Error())
+	if err = validateDataFormat(format, data); err != nil {
+		return withKind(ErrInvalidFormat, err)
 	}
-	st, err := detect.FromReader(filename, bytes.NewReader(data))
+	st, err := detect.FromReader(detectFilename, bytes.NewReader(data.prefix))
 	if err != nil {
-		return fmt.Errorf("error determining dataset schema: %s", err.Error())
+		return withKind(ErrInvalidFormat, fmt.Errorf("error determining dataset schema: %s", err.Error()))
+	}
+	if st.Format == dataset.JSONDataFormat {
+		if fields, ok := jsonObjectFields(data.prefix); ok {
+			st.Schema = &dataset.Schema{Fields: fields}
+		}
+	}
+	if p.SampleStrategy != "" {
+		rdr, err := data.Reader()
+		if err != nil {
+			return err
+		}
+		if err := refineSchema(st, rdr, data.size, p.SampleStrategy, p.SampleSize); err != nil {
+			return withKind(ErrInvalidFormat, fmt.Errorf("error sampling data: %s", err.Error()))
+		}
 	}
 	// Ensure that dataset contains valid field names
 	if err = validate.Structure(st); err != nil {
-		return fmt.Errorf("invalid structure: %s", err.Error())
-	}
-	if err := validate.DataFormat(st.Format, bytes.NewReader(data)); err != nil {
-		return fmt.Errorf("invalid data format: %s", err.Error())
+		return withKind(ErrInvalidFormat, fmt.Errorf("invalid structure: %s", err.Error()))
 	}
 
 	// TODO - check for errors in dataset and warn user if errors exist
@@ -194,9 +624,20 @@ func (r *DatasetRequests) InitDataset(p *InitDatasetParams, res *repo.DatasetRef
 	// 	return fmt.Errorf("data is invalid")
 	// }
 
-	datakey, err := store.Put(memfs.NewMemfileBytes("data."+st.Format.String(), data), false)
-	if err != nil {
-		return fmt.Errorf("error putting data file in store: %s", err.Error())
+	// data that's already stored doesn't need to be put again
+	if datakey.String() == "" {
+		dr, err := data.Reader()
+		if err != nil {
+			return err
+		}
+		if datakey, err = store.Put(memfs.NewMemfileReader("data."+st.Format.String(), dr), false); err != nil {
+			return fmt.Errorf("error putting data file in store: %s", err.Error())
+		}
+	}
+
+	name := p.Name
+	if name == "" && filename != "" {
+		name = detect.Camelize(filename)
 	}
 
 	dataexists, err := repo.HasPath(r.repo, datakey)
@@ -204,40 +645,52 @@ func (r *DatasetRequests) InitDataset(p *InitDatasetParams, res *repo.DatasetRef
 		return fmt.Errorf("error checking repo for already-existing data: %s", err.Error())
 	}
 	if dataexists {
-		return fmt.Errorf("this data already exists")
+		if !p.ReuseExisting {
+			return withKind(ErrDataExists, fmt.Errorf("this data already exists"))
+		}
+		return r.reuseDataset(datakey, name, res)
 	}
-
-	name := p.Name
-	if name == "" && filename != "" {
-		name = detect.Camelize(filename)
+	if _, err := r.repo.GetPath(name); !errors.Is(err, repo.ErrNotFound) {
+		if err != nil {
+			return fmt.Errorf("error checking dataset name: %s", err.Error())
+		}
+		return withKind(repo.ErrNameTaken, fmt.Errorf("name '%s' already exists", name))
 	}
 
 	ds := &dataset.Dataset{}
-	if p.URL != "" {
-		ds.DownloadURL = p.URL
+	if ckands != nil {
+		ds = ckands
+	}
+	if dataURL != "" {
+		ds.DownloadURL = dataURL
 		// if we're adding from a dataset url, set a default accrual periodicity of once a week
 		// this'll set us up to re-check urls over time
-		// TODO - make this configurable via a param?
-		ds.AccrualPeriodicity = "R/P1W"
+		if ds.AccrualPeriodicity == "" {
+			ds.AccrualPeriodicity = DefaultAccrualPeriodicity
+		}
 	}
 	if p.Metadata != nil {
-		if err := json.NewDecoder(p.Metadata).Decode(ds); err != nil {
-			return fmt.Errorf("error parsing metadata json: %s", err.Error())
+		if err := decodeMetadata(p.Metadata, ds); err != nil {
+			return err
 		}
 	}
+	if p.AccrualPeriodicity != "" {
+		ds.AccrualPeriodicity = p.AccrualPeriodicity
+	}
 
 	ds.Timestamp = time.Now().In(time.UTC)
 	if ds.Title == "" {
 		ds.Title = name
 	}
 	ds.Data = datakey.String()
+	ds.Length = data.size
 	if ds.Structure == nil {
 		ds.Structure = &dataset.Structure{}
 	}
 	ds.Structure.Assign(st, ds.Structure)
 
 	if err := validate.Dataset(ds); err != nil {
-		return err
+		return withKind(ErrInvalidFormat, err)
 	}
 
 	dskey, err := dsfs.SaveDataset(store, ds, true)
@@ -259,18 +712,137 @@ func (r *DatasetRequests) InitDataset(p *InitDatasetParams, res *repo.DatasetRef
 	}
 
 	*res = repo.DatasetRef{
-		Name:    p.Name,
+		Name:    name,
 		Path:    dskey,
 		Dataset: ds,
 	}
+	return updateSearchIndex(r.repo, datastore.NewKey(""), res)
+}
+
+// httpGet issues a GET request for url that's cancelled when ctx is done. a
+// nil ctx never cancels
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// InitDatasetsParams defines parameters for initializing many datasets at once
+type InitDatasetsParams struct {
+	Params []*InitDatasetParams
+}
+
+// InitDatasetResult is the outcome of initializing one dataset of a batch.
+// exactly one of Ref or Error is set
+type InitDatasetResult struct {
+	Ref   *repo.DatasetRef `json:"ref,omitempty"`
+	Error string           `json:"error,omitempty"`
+}
+
+// InitDatasets initializes a batch of datasets in sequence. a failure only
+// fails it's own item, res will contain one result for each param, in the
+// order given, so a partial import can be retried
+func (r *DatasetRequests) InitDatasets(p *InitDatasetsParams, res *[]*InitDatasetResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.InitDatasets", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	if len(p.Params) == 0 {
+		return fmt.Errorf("at least one dataset is required")
+	}
+
+	results := make([]*InitDatasetResult, len(p.Params))
+	for i, ip := range p.Params {
+		ref := &repo.DatasetRef{}
+		if ip == nil {
+			results[i] = &InitDatasetResult{Error: "missing dataset parameters"}
+		} else if err := r.InitDataset(ip, ref); err != nil {
+			results[i] = &InitDatasetResult{Error: err.Error()}
+		} else {
+			results[i] = &InitDatasetResult{Ref: ref}
+		}
+	}
+
+	*res = results
 	return nil
 }
 
+// reuseDataset sets res to the dataset of already-stored data. if none of
+// the repo's names refer to that dataset, name is added for it
+func (r *DatasetRequests) reuseDataset(datakey datastore.Key, name string, res *repo.DatasetRef) error {
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.repo.Namespace(count, 0)
+	if err != nil {
+		return fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+	for _, ref := range refs {
+		ds, err := loadDataset(r.repo, ref.Path)
+		if err != nil {
+			return fmt.Errorf("error loading dataset '%s': %s", ref.Name, err.Error())
+		}
+		if ds.Data == datakey.String() {
+			*res = repo.DatasetRef{Name: ref.Name, Path: ref.Path, Dataset: ds}
+			return nil
+		}
+	}
+
+	// the data belongs to an unnamed version, find it in the repo graph
+	nodes, err := r.repo.Graph()
+	if err != nil {
+		return fmt.Errorf("error getting repo graph: %s", err.Error())
+	}
+	for path, node := range nodes {
+		if node.Type != dsgraph.NtDataset {
+			continue
+		}
+		for _, l := range node.Links {
+			if l.To.Type != dsgraph.NtData || l.To.Path != datakey.String() {
+				continue
+			}
+			if name == "" {
+				return fmt.Errorf("a name is required to reuse existing data")
+			}
+			if err := validate.ValidName(name); err != nil {
+				return fmt.Errorf("invalid name: %s", err.Error())
+			}
+			dspath := datastore.NewKey(path)
+			ds, err := loadDataset(r.repo, dspath)
+			if err != nil {
+				return fmt.Errorf("error loading dataset: %s", err.Error())
+			}
+			if err := r.repo.PutName(name, dspath); err != nil {
+				return fmt.Errorf("error adding dataset name to repo: %s", err.Error())
+			}
+			*res = repo.DatasetRef{Name: name, Path: dspath, Dataset: ds}
+			return updateSearchIndex(r.repo, datastore.NewKey(""), res)
+		}
+	}
+	return fmt.Errorf("no dataset found for existing data '%s'", datakey.String())
+}
+
 // UpdateParams defines permeters for Dataset Updates
 type UpdateParams struct {
-	Changes      *dataset.Dataset // all dataset changes. required.
-	DataFilename string           // filename for new data. optional.
-	Data         io.Reader        // stream of complete dataset update. optional.
+	Changes *dataset.Dataset // all dataset changes. required.
+	// Name of the dataset to update, used to find the latest version when
+	// Changes.Previous is empty
+	Name         string
+	DataFilename string    // filename for new data. optional.
+	Data         io.Reader // stream of complete dataset update. optional.
+	// Force allows new data with a different number of columns than the
+	// previous version
+	Force bool
 }
 
 // Update adds a history entry, updating a dataset
@@ -279,6 +851,10 @@ func (r *DatasetRequests) Update(p *UpdateParams, res *repo.DatasetRef) (err err
 		return r.cli.Call("DatasetRequests.Update", p, res)
 	}
 
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
 	var (
 		name     string
 		prevpath datastore.Key
@@ -286,53 +862,102 @@ func (r *DatasetRequests) Update(p *UpdateParams, res *repo.DatasetRef) (err err
 	store := r.repo.Store()
 	ds := &dataset.Dataset{}
 
-	rt, ref := dsfs.RefType(p.Changes.Previous.String())
-	// allows using dataset names as "previous" fields
-	if rt == "name" {
-		name = ref
-		prevpath, err = r.repo.GetPath(strings.Trim(ref, "/"))
-		if err != nil {
-			return fmt.Errorf("error getting previous dataset path: %s", err.Error())
+	if p.Changes == nil {
+		return withKind(ErrInvalidParams, fmt.Errorf("changes are required to update a dataset"))
+	}
+	prevstr := p.Changes.Previous.String()
+	if prevstr == "" || prevstr == "/" {
+		if p.Name == "" {
+			return withKind(ErrInvalidParams, fmt.Errorf("either a previous path or a name is required to update a dataset"))
 		}
-	} else {
-		prevpath = datastore.NewKey(ref)
-		// attempt to grab name for later if path is provided
+		// updating by name applies changes to the latest version
+		prevstr = p.Name
+	}
+
+	// allows using dataset names as "previous" fields
+	prevref, err := ref.ParseRef(prevstr)
+	if err != nil {
+		return withKind(ErrInvalidParams, fmt.Errorf("error getting previous dataset path: %s", err.Error()))
+	}
+	prevpath, err = resolveRef(r.repo, prevref)
+	if err != nil {
+		return fmt.Errorf("error getting previous dataset path: %w", err)
+	}
+	if prevref.IsPath() {
+		// attempt to grab name for later if path is provided
 		name, _ = r.repo.GetName(prevpath)
+	} else {
+		name = prevref.Name
 	}
 
 	// read previous changes
 	prev, err := r.repo.GetDataset(prevpath)
 	if err != nil {
-		return fmt.Errorf("error getting previous dataset: %s", err.Error())
+		return fmt.Errorf("error getting previous dataset: %w", err)
 	}
 
 	// add all previous fields and any changes
 	ds.Assign(prev, p.Changes)
 
-	// store file if one is provided
+	// store file if one is provided, recomputing structure from the new data
 	if p.Data != nil {
-		data, err := ioutil.ReadAll(p.Data)
+		filename := p.DataFilename
+		if filename == "" && prev.Structure != nil {
+			filename = "data." + prev.Structure.Format.String()
+		}
+
+		data, err := spoolData(p.Data)
 		if err != nil {
 			return fmt.Errorf("error reading data: %s", err.Error())
 		}
+		defer data.Close()
 
-		path, err := store.Put(memfs.NewMemfileReader(p.DataFilename, p.Data), false)
+		format, err := detect.ExtensionDataFormat(filename)
+		if err != nil {
+			return withKind(ErrInvalidFormat, fmt.Errorf("error detecting format extension: %s", err.Error()))
+		}
+		if err = validateDataFormat(format, data); err != nil {
+			return withKind(ErrInvalidFormat, err)
+		}
+		st, err := detect.FromReader(filename, bytes.NewReader(data.prefix))
+		if err != nil {
+			return withKind(ErrInvalidFormat, fmt.Errorf("error determining dataset schema: %s", err.Error()))
+		}
+		if err = validate.Structure(st); err != nil {
+			return withKind(ErrInvalidFormat, fmt.Errorf("invalid structure: %s", err.Error()))
+		}
+		if err = checkSchemaChange(prev.Structure, st, p.Force); err != nil {
+			return withKind(ErrInvalidFormat, err)
+		}
+
+		dr, err := data.Reader()
+		if err != nil {
+			return err
+		}
+		path, err := store.Put(memfs.NewMemfileReader("data."+st.Format.String(), dr), false)
 		if err != nil {
 			return fmt.Errorf("error putting data in store: %s", err.Error())
 		}
 
 		ds.Data = path.String()
-		ds.Length = len(data)
+		ds.Length = data.size
+		// explicit structure changes still apply on top of the detected structure
+		ds.Structure = st
+		if p.Changes.Structure != nil {
+			ds.Structure.Assign(p.Changes.Structure)
+		}
 	}
 
-	if strings.HasSuffix(prevpath.String(), dsfs.PackageFileDataset.String()) {
-		ds.Previous = datastore.NewKey(strings.TrimSuffix(prevpath.String(), "/"+dsfs.PackageFileDataset.String()))
-	} else {
-		ds.Previous = prevpath
+	if changed, err := datasetChanged(prev, ds); err != nil {
+		return err
+	} else if !changed {
+		return ErrNoChanges
 	}
 
+	ds.Previous = datastore.NewKey(ref.RootPath(prevpath.String()))
+
 	if err := validate.Dataset(ds); err != nil {
-		return err
+		return withKind(ErrInvalidFormat, err)
 	}
 
 	// TODO - should this go into the save method?
@@ -343,10 +968,7 @@ func (r *DatasetRequests) Update(p *UpdateParams, res *repo.DatasetRef) (err err
 	}
 
 	if name != "" {
-		if err := r.repo.DeleteName(name); err != nil {
-			return err
-		}
-		if err := r.repo.PutName(name, dspath); err != nil {
+		if err := repo.UpdateName(r.repo, name, dspath); err != nil {
 			return err
 		}
 	}
@@ -357,42 +979,283 @@ func (r *DatasetRequests) Update(p *UpdateParams, res *repo.DatasetRef) (err err
 		Dataset: ds,
 	}
 
+	// only named versions are searchable
+	if name != "" {
+		return updateSearchIndex(r.repo, prevpath, res)
+	}
+	return nil
+}
+
+// ErrNoChanges is returned by Update when changes match the previous version
+var ErrNoChanges = fmt.Errorf("no changes")
+
+// datasetChanged compares the contents of two versions of a dataset.
+// history & commit details are ignored, which always differ between versions
+func datasetChanged(prev, ds *dataset.Dataset) (bool, error) {
+	a, b := *prev, *ds
+	for _, d := range []*dataset.Dataset{&a, &b} {
+		d.Previous = datastore.NewKey("")
+		d.Timestamp = time.Time{}
+		d.Commit = nil
+	}
+
+	ad, err := json.Marshal(&a)
+	if err != nil {
+		return false, fmt.Errorf("error encoding previous dataset: %s", err.Error())
+	}
+	bd, err := json.Marshal(&b)
+	if err != nil {
+		return false, fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+	return !bytes.Equal(ad, bd), nil
+}
+
+// checkSchemaChange errors if the column count of an updated structure
+// differs from the previous structure, unless force is set
+func checkSchemaChange(prev, st *dataset.Structure, force bool) error {
+	if force || prev == nil || prev.Schema == nil || st.Schema == nil {
+		return nil
+	}
+	if len(prev.Schema.Fields) != len(st.Schema.Fields) {
+		return fmt.Errorf("new data has %d columns, previous version has %d. use force to update anyway", len(st.Schema.Fields), len(prev.Schema.Fields))
+	}
 	return nil
 }
 
 // RenameParams defines parameters for Dataset renaming
 type RenameParams struct {
 	Current, New string
+	// CurrentPath renames the dataset at a path in place of Current. a
+	// dataset without a name is given New
+	CurrentPath datastore.Key
 }
 
-// Rename changes a user's given name for a dataset
+// Rename changes a user's given name for a dataset. the current name is only
+// removed once the new name is in place, so a failed rename leaves the
+// dataset named as it was. renaming a dataset by path names it if it has no
+// current name
 func (r *DatasetRequests) Rename(p *RenameParams, res *repo.DatasetRef) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Rename", p, res)
 	}
 
-	if p.Current == "" {
-		return fmt.Errorf("current name is required to rename a dataset")
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+	return r.rename(p, res, true)
+}
+
+// rename performs a Rename, taking a snapshot first if snapshot is true.
+// BulkRename snapshots once for the whole batch instead of once per rename
+func (r *DatasetRequests) rename(p *RenameParams, res *repo.DatasetRef, snapshot bool) (err error) {
+	// empty paths decode as "/"
+	nopath := p.CurrentPath.String() == "" || p.CurrentPath.String() == "/"
+	if p.Current == "" && nopath {
+		return withKind(ErrInvalidParams, fmt.Errorf("either a current name or path is required to rename a dataset"))
+	}
+	if p.Current != "" && !nopath {
+		return withKind(ErrInvalidParams, fmt.Errorf("only one of a current name or path can be used to rename a dataset"))
 	}
 
 	if err := validate.ValidName(p.New); err != nil {
+		return withKind(ErrInvalidParams, err)
+	}
+
+	current := p.Current
+	var path datastore.Key
+	if nopath {
+		if path, err = r.repo.GetPath(current); err != nil {
+			return fmt.Errorf("error getting dataset: %w", err)
+		}
+	} else {
+		if path, err = resolvePath(r.repo, p.CurrentPath); err != nil {
+			return err
+		}
+		if current, err = r.repo.GetName(path); err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("error getting dataset name: %s", err.Error())
+		}
+	}
+
+	if _, err := r.repo.GetPath(p.New); !errors.Is(err, repo.ErrNotFound) {
+		return withKind(repo.ErrNameTaken, fmt.Errorf("name '%s' already exists", p.New))
+	}
+
+	if snapshot {
+		from := current
+		if from == "" {
+			from = path.String()
+		}
+		if err := snapshotBeforeMutation(r.repo, fmt.Sprintf("rename %s to %s", from, p.New)); err != nil {
+			return err
+		}
+	}
+
+	if current == "" {
+		return r.nameDataset(path, p.New, res)
+	}
+
+	// names in an alias group are added as an alias before deleting the
+	// current name, which keeps the new name in the group
+	if aliases, aerr := r.repo.Aliases(current); aerr == nil && len(aliases) > 1 {
+		err = r.repo.AddAlias(current, p.New)
+	} else {
+		err = r.repo.PutName(p.New, path)
+	}
+	if err != nil {
+		return r.revertRename(p.New, fmt.Errorf("error adding name '%s': %s", p.New, err.Error()))
+	}
+	if err := r.repo.DeleteName(current); err != nil {
+		return r.revertRename(p.New, fmt.Errorf("error removing name '%s': %s", current, err.Error()))
+	}
+	if err := moveVisibility(r.repo, current, p.New); err != nil {
+		return fmt.Errorf("error moving dataset visibility: %s", err.Error())
+	}
+
+	ds, err := dsfs.LoadDataset(r.repo.Store(), path)
+	if err != nil {
 		return err
 	}
 
-	if _, err := r.repo.GetPath(p.New); err != repo.ErrNotFound {
-		return fmt.Errorf("name '%s' already exists", p.New)
+	*res = repo.DatasetRef{
+		Name:    p.New,
+		Path:    path,
+		Dataset: ds,
 	}
+	return nil
+}
 
-	path, err := r.repo.GetPath(p.Current)
+// nameDataset gives the unnamed dataset at path a name. named datasets are
+// searchable, so the dataset is added to the search index
+func (r *DatasetRequests) nameDataset(path datastore.Key, name string, res *repo.DatasetRef) error {
+	ds, err := loadDataset(r.repo, path)
 	if err != nil {
-		return fmt.Errorf("error getting dataset: %s", err.Error())
+		return fmt.Errorf("error loading dataset: %w", err)
+	}
+	if err := r.repo.PutName(name, path); err != nil {
+		return fmt.Errorf("error adding name '%s': %s", name, err.Error())
 	}
-	if err := r.repo.DeleteName(p.Current); err != nil {
+
+	*res = repo.DatasetRef{
+		Name:    name,
+		Path:    path,
+		Dataset: ds,
+	}
+	return updateSearchIndex(r.repo, datastore.NewKey(""), res)
+}
+
+// revertRename removes a name added by a rename that couldn't be completed,
+// returning the error that stopped the rename
+func (r *DatasetRequests) revertRename(name string, err error) error {
+	if rerr := r.repo.DeleteName(name); rerr != nil && !errors.Is(rerr, repo.ErrNotFound) {
+		return fmt.Errorf("%s. error reverting name '%s': %s", err.Error(), name, rerr.Error())
+	}
+	return err
+}
+
+// BulkRenameParams defines parameters for renaming many datasets at once
+type BulkRenameParams struct {
+	Renames []RenameParams
+}
+
+// BulkRename applies a batch of renames. All renames are validated before
+// any are applied, so a batch with any invalid or colliding name changes nothing.
+// res will contain one reference for each rename, in the order given. renames
+// are by current name, CurrentPath isn't supported
+func (r *DatasetRequests) BulkRename(p *BulkRenameParams, res *[]*repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.BulkRename", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
 		return err
 	}
-	if err := r.repo.PutName(p.New, path); err != nil {
+
+	current := map[string]bool{}
+	renamed := map[string]bool{}
+	for i, rn := range p.Renames {
+		if rn.Current == "" {
+			return withKind(ErrInvalidParams, fmt.Errorf("rename %d: current name is required to rename a dataset", i))
+		}
+		if err := validate.ValidName(rn.New); err != nil {
+			return withKind(ErrInvalidParams, fmt.Errorf("rename %d: %s", i, err.Error()))
+		}
+		if current[rn.Current] {
+			return withKind(ErrInvalidParams, fmt.Errorf("rename %d: dataset '%s' is renamed more than once", i, rn.Current))
+		}
+		if renamed[rn.New] {
+			return withKind(ErrInvalidParams, fmt.Errorf("rename %d: name '%s' is used more than once", i, rn.New))
+		}
+		if _, err := r.repo.GetPath(rn.Current); err != nil {
+			return fmt.Errorf("rename %d: error getting dataset '%s': %w", i, rn.Current, err)
+		}
+		if _, err := r.repo.GetPath(rn.New); !errors.Is(err, repo.ErrNotFound) {
+			return withKind(repo.ErrNameTaken, fmt.Errorf("rename %d: name '%s' already exists", i, rn.New))
+		}
+		current[rn.Current] = true
+		renamed[rn.New] = true
+	}
+
+	if err := snapshotBeforeMutation(r.repo, fmt.Sprintf("rename %d datasets", len(p.Renames))); err != nil {
+		return err
+	}
+
+	refs := make([]*repo.DatasetRef, len(p.Renames))
+	for i, rn := range p.Renames {
+		ref := &repo.DatasetRef{}
+		rp := rn
+		if err := r.rename(&rp, ref, false); err != nil {
+			// undo renames that have already been applied, newest first
+			for j := i - 1; j >= 0; j-- {
+				undo := &RenameParams{Current: p.Renames[j].New, New: p.Renames[j].Current}
+				if uerr := r.rename(undo, &repo.DatasetRef{}, false); uerr != nil {
+					return fmt.Errorf("rename %d: %s. error reverting rename '%s': %s", i, err.Error(), undo.Current, uerr.Error())
+				}
+			}
+			return fmt.Errorf("rename %d: %w", i, err)
+		}
+		refs[i] = ref
+	}
+
+	*res = refs
+	return nil
+}
+
+// AliasParams defines parameters for aliasing a dataset
+type AliasParams struct {
+	Name, Alias string
+}
+
+// AddAlias links a new name to an existing dataset name. aliases move
+// together when a dataset is updated or renamed
+func (r *DatasetRequests) AddAlias(p *AliasParams, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.AddAlias", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	if p.Name == "" {
+		return withKind(ErrInvalidParams, fmt.Errorf("name is required to alias a dataset"))
+	}
+	if err := validate.ValidName(p.Alias); err != nil {
+		return withKind(ErrInvalidParams, err)
+	}
+	if _, err := r.repo.GetPath(p.Alias); !errors.Is(err, repo.ErrNotFound) {
+		return withKind(repo.ErrNameTaken, fmt.Errorf("name '%s' already exists", p.Alias))
+	}
+
+	path, err := r.repo.GetPath(p.Name)
+	if err != nil {
+		return fmt.Errorf("error getting dataset: %w", err)
+	}
+	if err := snapshotBeforeMutation(r.repo, fmt.Sprintf("alias %s as %s", p.Name, p.Alias)); err != nil {
 		return err
 	}
+	if err := r.repo.AddAlias(p.Name, p.Alias); err != nil {
+		return fmt.Errorf("error adding alias: %w", err)
+	}
 
 	ds, err := dsfs.LoadDataset(r.repo.Store(), path)
 	if err != nil {
@@ -400,7 +1263,7 @@ func (r *DatasetRequests) Rename(p *RenameParams, res *repo.DatasetRef) (err err
 	}
 
 	*res = repo.DatasetRef{
-		Name:    p.New,
+		Name:    p.Alias,
 		Path:    path,
 		Dataset: ds,
 	}
@@ -411,65 +1274,304 @@ func (r *DatasetRequests) Rename(p *RenameParams, res *repo.DatasetRef) (err err
 type DeleteParams struct {
 	Path datastore.Key
 	Name string
+	// All unpins every version in the dataset's history, not just the head.
+	// versions that other names refer to are kept
+	All bool
+	// Purge removes references to the dataset that outlive it: logged
+	// queries saved under the dataset's name, and the cached dataset
+	Purge bool
 }
 
-// Delete a dataset
-func (r *DatasetRequests) Delete(p *DeleteParams, ok *bool) (err error) {
+// Delete a dataset name, see DeleteWithResult
+func (r *DatasetRequests) Delete(p *DeleteParams, ok *bool) error {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.List", p, ok)
+		return r.cli.Call("DatasetRequests.Delete", p, ok)
 	}
+	if err := r.DeleteWithResult(p, &DeleteResult{}); err != nil {
+		return err
+	}
+	*ok = true
+	return nil
+}
+
+// DeleteResult is the outcome of deleting a dataset
+type DeleteResult struct {
+	// Unpinned is the number of versions released from the store
+	Unpinned int
+}
 
-	if p.Name == "" && p.Path.String() == "" {
-		return fmt.Errorf("either name or path is required")
+// DeleteWithResult deletes a dataset name, reporting how many versions were
+// released from the store. Without a name, the first name that refers to the
+// path is deleted. Content is only unpinned once no names refer to it, and
+// when snapshots are enabled, once the snapshot taken before the delete is
+// dropped
+func (r *DatasetRequests) DeleteWithResult(p *DeleteParams, res *DeleteResult) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.DeleteWithResult", p, res)
 	}
 
-	if p.Path.String() == "" {
-		p.Path, err = r.repo.GetPath(p.Name)
-		if err != nil {
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	// empty paths decode as "/"
+	nopath := p.Path.String() == "" || p.Path.String() == "/"
+	if p.Name == "" && nopath {
+		return withKind(ErrInvalidParams, fmt.Errorf("either name or path is required"))
+	}
+
+	// a path alone deletes the first name that refers to it
+	if p.Name == "" {
+		if p.Name, err = r.repo.GetName(p.Path); err != nil {
 			return
 		}
+	} else if p.Path, err = r.repo.GetPath(p.Name); err != nil {
+		return
 	}
 
-	p.Name, err = r.repo.GetName(p.Path)
+	// content stays pinned while other names, like aliases, still refer to it
+	named, err := namedPaths(r.repo, p.Name)
 	if err != nil {
 		return
 	}
 
-	if pinner, ok := r.repo.Store().(cafs.Pinner); ok {
-		path := datastore.NewKey(strings.TrimSuffix(p.Path.String(), "/"+dsfs.PackageFileDataset.String()))
-		if err = pinner.Unpin(path, true); err != nil {
-			return
-		}
+	released, err := releasedVersions(r.repo, named, p.Path, p.All)
+	if err != nil {
+		return
+	}
+
+	if err = snapshotBeforeMutation(r.repo, fmt.Sprintf("delete %s", p.Name), released...); err != nil {
+		return
+	}
+	if err = unpinReleased(r.repo, released); err != nil {
+		return
 	}
 
 	if err = r.repo.DeleteName(p.Name); err != nil {
 		return
 	}
+	if p.Purge {
+		if err = purgeReferences(r.repo, p.Name, p.Path); err != nil {
+			return
+		}
+	}
+	if !named[p.Path.String()] {
+		if err = updateSearchIndex(r.repo, p.Path, nil); err != nil {
+			return
+		}
+	}
+	if err = moveVisibility(r.repo, p.Name, ""); err != nil {
+		return
+	}
+	if err = repo.LogEvent(r.repo, &repo.Event{Type: repo.ETDatasetDeleted, Name: p.Name, Path: p.Path}); err != nil {
+		return
+	}
 
-	*ok = true
+	*res = DeleteResult{}
+	if _, ok := r.repo.Store().(cafs.Pinner); ok {
+		res.Unpinned = len(released)
+	}
 	return nil
 }
 
+// purgeReferences removes query log items saved under name & the cache entry
+// for path
+func purgeReferences(r repo.Repo, name string, path datastore.Key) error {
+	if err := r.DeleteQueryLogs(name); err != nil {
+		return fmt.Errorf("error deleting query logs: %s", err.Error())
+	}
+	if err := r.Cache().DeleteDataset(path); err != nil {
+		return fmt.Errorf("error deleting cached dataset: %s", err.Error())
+	}
+	return nil
+}
+
+// namedPaths gives the set of paths names other than except refer to
+func namedPaths(r repo.Repo, except string) (map[string]bool, error) {
+	count, err := r.NameCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.Namespace(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+	named := map[string]bool{}
+	for _, ref := range refs {
+		if ref != nil && ref.Name != except {
+			named[ref.Path.String()] = true
+		}
+	}
+	return named, nil
+}
+
+// releasedVersions gives the versions deleting the dataset at path releases:
+// path and, if all is set, each previous version until the start of the
+// history or a version in named
+func releasedVersions(r repo.Repo, named map[string]bool, path datastore.Key, all bool) ([]datastore.Key, error) {
+	released := []datastore.Key{}
+	seen := map[string]bool{}
+	for !named[path.String()] && !seen[path.String()] {
+		released = append(released, path)
+		seen[path.String()] = true
+		if !all {
+			break
+		}
+
+		ds, err := loadDataset(r, path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		if ds.Previous.String() == "" || ds.Previous.String() == "/" {
+			break
+		}
+		path = previousPath(ds)
+	}
+	return released, nil
+}
+
 // StructuredDataParams defines parameters for retrieving
 // structured data (which is the kind of data datasets contain)
 type StructuredDataParams struct {
-	Format        dataset.DataFormat
+	Format dataset.DataFormat
+	// FormatConfig configures output in Format. csv output without a
+	// FormatConfig leads with a header row
 	FormatConfig  dataset.FormatConfig
 	Path          datastore.Key
 	Limit, Offset int
 	All           bool
+	// Filters restricts results to rows matching all filters. Limit & Offset
+	// apply to matching rows
+	Filters []*Filter
+	// Charset transcodes data from utf-8 to the named character encoding.
+	// only text formats (csv) can be transcoded
+	Charset string
+	// Delimiter separates fields of csv output, defaults to a comma
+	Delimiter rune
+	// MaxBytes stops output once rows totalling roughly MaxBytes have been
+	// written, alongside Limit. at least one row is always written. zero or
+	// less removes the byte budget
+	MaxBytes int
+	// Fields selects the columns to return by field name, in the order given.
+	// empty returns all columns
+	Fields []string
+	// Where is a where clause like probability_of_automation>0.98, only
+	// rows that match it are returned. see ParseWhere for the syntax. Where
+	// applies alongside Filters
+	Where string
+	// JSONLines writes json data as newline-delimited json, one object per
+	// row, ignoring FormatConfig. see NDJSONContentType
+	JSONLines bool
+	// Writer receives json lines data as rows are read. if Writer is nil, or
+	// when reading over RPC, data is returned as StructuredData.Data bytes
+	Writer io.Writer
 }
 
 // StructuredData combines data with it's hashed path
 type StructuredData struct {
 	Path datastore.Key `json:"path"`
-	Data interface{}   `json:"data"`
+	// Name is the name of the dataset in this repo, if it has one
+	Name string      `json:"name,omitempty"`
+	Data interface{} `json:"data"`
+	// Invalid counts rows excluded by filters because a filtered cell
+	// couldn't be parsed as it's field type. truncated data stops reading
+	// early, so only rows read are counted
+	Invalid int `json:"invalid,omitempty"`
+	// Truncated is true when more rows were requested than the maximum
+	// allowed by the server or fit in the requested byte budget, and rows were
+	// left out. clients should page through data to get the remaining rows
+	Truncated bool `json:"truncated,omitempty"`
+	// NextOffset is the offset of the first row left out of truncated data
+	NextOffset int `json:"nextOffset,omitempty"`
+}
+
+// rowWriter writes rows of structured data
+type rowWriter interface {
+	WriteRow(row [][]byte) error
+	Close() error
+}
+
+// errByteBudget stops iterating rows once a byte budget is spent
+var errByteBudget = fmt.Errorf("byte budget reached")
+
+// errRowCap stops iterating rows once a row past the row ceiling is found
+var errRowCap = fmt.Errorf("row cap reached")
+
+// rowSize approximates the number of bytes a row takes up when written,
+// counting cell contents and a delimiter per cell
+func rowSize(row [][]byte) int {
+	size := 0
+	for _, cell := range row {
+		size += len(cell) + 1
+	}
+	return size
+}
+
+// maxDataRows caps the number of rows a single StructuredData call can return
+var maxDataRows = 0
+
+// SetMaxDataRows sets the most rows a single StructuredData call will return,
+// regardless of the requested limit or the All param. A max of zero or less
+// removes the cap
+func SetMaxDataRows(max int) {
+	maxDataRows = max
+}
+
+// MaxDataRows gives the most rows a single StructuredData call will return,
+// zero if there's no cap
+func MaxDataRows() int {
+	return maxDataRows
 }
 
 // StructuredData retrieves dataset data
 func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *StructuredData) (err error) {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.StructuredData", p, data)
+		// writers can't cross the RPC boundary, data is written once it's
+		// returned instead
+		args := *p
+		args.Writer = nil
+		if err := r.cli.Call("DatasetRequests.StructuredData", &args, data); err != nil {
+			return err
+		}
+		if p.JSONLines && p.Writer != nil {
+			lines, ok := data.Data.([]byte)
+			if !ok {
+				return fmt.Errorf("error writing data: unexpected data type %T", data.Data)
+			}
+			if _, err := p.Writer.Write(lines); err != nil {
+				return fmt.Errorf("error writing data: %s", err.Error())
+			}
+			data.Data = nil
+		}
+		return nil
+	}
+	if p.JSONLines && p.Format != dataset.JSONDataFormat {
+		return fmt.Errorf("json lines are only supported for json data")
+	}
+
+	if p.Charset != "" {
+		if p.Format != dataset.CSVDataFormat {
+			return fmt.Errorf("charset is only supported for csv data")
+		}
+		if _, _, err := LookupCharset(p.Charset); err != nil {
+			return err
+		}
+	}
+	if p.Delimiter != 0 {
+		if p.Format != dataset.CSVDataFormat {
+			return fmt.Errorf("delimiter is only supported for csv data")
+		}
+		if !validDelimiter(p.Delimiter) {
+			return fmt.Errorf("invalid delimiter %q", p.Delimiter)
+		}
+	}
+
+	path, err := resolvePath(r.repo, p.Path)
+	if err != nil {
+		return err
+	}
+	if err := r.checkVisible(path); err != nil {
+		return fmt.Errorf("error loading dataset '%s': %w", path.String(), err)
 	}
 
 	var (
@@ -478,52 +1580,214 @@ func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *Structur
 		store = r.repo.Store()
 	)
 
-	ds, err := dsfs.LoadDataset(store, p.Path)
+	// wrap errors with the dataset & the stage that failed, so a message
+	// like "datastore: key not found" says which dataset couldn't be read
+	ident := fmt.Sprintf("'%s'", path.String())
+	if name, err := r.repo.GetName(path); err == nil && name != "" {
+		ident = fmt.Sprintf("%s (%s)", name, path.String())
+	}
+	wrap := func(stage string, err error) error {
+		return fmt.Errorf("error %s dataset %s: %w", stage, ident, err)
+	}
+
+	ds, err := dsfs.LoadDataset(store, path)
 	if err != nil {
-		return err
+		return wrap("loading", err)
 	}
 
-	if p.All {
-		file, err = dsfs.LoadData(store, ds)
-	} else {
-		d, err = dsfs.LoadRows(store, ds, p.Limit, p.Offset)
-		file = memfs.NewMemfileBytes("data", d)
+	var filters []*rowFilter
+	pf := p.Filters
+	if p.Where != "" {
+		where, err := ParseWhere(p.Where)
+		if err != nil {
+			return err
+		}
+		pf = append(append([]*Filter{}, p.Filters...), where)
+	}
+	if len(pf) > 0 {
+		if ds.Structure == nil {
+			return fmt.Errorf("cannot filter a dataset without a structure")
+		}
+		if filters, err = newRowFilters(ds.Structure.Schema, pf); err != nil {
+			return err
+		}
 	}
 
+	var (
+		fieldIndexes []int
+		fieldSchema  *dataset.Schema
+	)
+	if len(p.Fields) > 0 {
+		if ds.Structure == nil {
+			return fmt.Errorf("cannot select fields of a dataset without a structure")
+		}
+		if fieldIndexes, fieldSchema, err = selectFields(ds.Structure.Schema, p.Fields); err != nil {
+			return err
+		}
+	}
+
+	parts, err := loadPartitions(store, ds)
 	if err != nil {
-		return err
+		return wrap("loading data for", err)
+	}
+
+	// requests over the row ceiling are capped, reading one row past the
+	// ceiling to check if rows are left out
+	all, limit, capped := p.All, p.Limit, false
+	if maxDataRows > 0 && (all || limit > maxDataRows) {
+		all, limit, capped = false, maxDataRows, true
+	}
+
+	// filtered & partitioned results must read all data, paginating over matched rows
+	paginate := len(filters) > 0 || parts != nil || capped
+	if parts == nil {
+		if all || paginate {
+			file, err = dsfs.LoadData(store, ds)
+		} else {
+			d, err = dsfs.LoadRows(store, ds, limit, p.Offset)
+			file = memfs.NewMemfileBytes("data", d)
+		}
+
+		if err != nil {
+			return wrap("loading data for", err)
+		}
+	}
+
+	// csv output leads with a header row of field names unless the request
+	// configures csv output itself. xlsx spreadsheets are converted from csv
+	format, formatConfig := p.Format, p.FormatConfig
+	if format == dataset.XLSDataFormat {
+		format, formatConfig = dataset.CSVDataFormat, nil
+	}
+	if format == dataset.CSVDataFormat && formatConfig == nil {
+		formatConfig = &dataset.CSVOptions{HeaderRow: true}
 	}
 
 	st := &dataset.Structure{}
 	st.Assign(ds.Structure, &dataset.Structure{
-		Format:       p.Format,
-		FormatConfig: p.FormatConfig,
+		Format:       format,
+		FormatConfig: formatConfig,
 	})
+	if fieldSchema != nil {
+		st.Schema = fieldSchema
+	}
 
-	buf, err := dsio.NewStructuredBuffer(st)
-	if err != nil {
-		return fmt.Errorf("error allocating result buffer: %s", err)
+	var (
+		buf   rowWriter
+		sb    *dsio.StructuredBuffer
+		lines = &bytes.Buffer{}
+	)
+	if p.JSONLines {
+		w := p.Writer
+		if w == nil {
+			w = lines
+		}
+		buf, err = newJSONLinesWriter(st.Schema, w)
+	} else {
+		sb, err = dsio.NewStructuredBuffer(st)
+		buf = sb
 	}
-	rr, err := dsio.NewRowReader(ds.Structure, file)
 	if err != nil {
-		return fmt.Errorf("error allocating data reader: %s", err)
+		return wrap("formatting", fmt.Errorf("error allocating result buffer: %w", err))
 	}
-	if err = dsio.EachRow(rr, func(i int, row [][]byte, err error) error {
+	matched, invalid, truncated := 0, 0, false
+	written, size := 0, 0
+	eachRow := func(i int, row [][]byte, err error) error {
 		if err != nil {
 			return err
 		}
+		if len(filters) > 0 {
+			match, bad := filterRow(filters, row)
+			if bad {
+				invalid++
+			}
+			if !match {
+				return nil
+			}
+		}
+		if paginate {
+			matched++
+			if !all && matched > p.Offset+limit {
+				if capped {
+					truncated = true
+					return errRowCap
+				}
+				return nil
+			}
+			if !all && matched <= p.Offset {
+				return nil
+			}
+		}
+		if fieldIndexes != nil {
+			row = projectRow(row, fieldIndexes)
+		}
+		if p.MaxBytes > 0 {
+			rs := rowSize(row)
+			if written > 0 && size+rs > p.MaxBytes {
+				truncated = true
+				return errByteBudget
+			}
+			size += rs
+		}
+		written++
 		return buf.WriteRow(row)
-	}); err != nil {
-		return fmt.Errorf("row iteration error: %s", err.Error())
+	}
+
+	if parts != nil {
+		if err := eachPartitionRow(store, ds.Structure, parts, eachRow); err != nil && err != errByteBudget && err != errRowCap {
+			return wrap("reading rows of", fmt.Errorf("row iteration error: %w", err))
+		}
+	} else {
+		rr, err := dsio.NewRowReader(ds.Structure, file)
+		if err != nil {
+			return wrap("reading rows of", fmt.Errorf("error allocating data reader: %w", err))
+		}
+		if err := dsio.EachRow(rr, eachRow); err != nil && err != errByteBudget && err != errRowCap {
+			return wrap("reading rows of", fmt.Errorf("row iteration error: %w", err))
+		}
 	}
 
 	if err := buf.Close(); err != nil {
-		return fmt.Errorf("error closing row buffer: %s", err.Error())
+		return wrap("formatting", fmt.Errorf("error closing row buffer: %w", err))
+	}
+
+	var out interface{}
+	switch {
+	case p.JSONLines:
+		if p.Writer == nil {
+			out = lines.Bytes()
+		}
+	case p.Format == dataset.CSVDataFormat:
+		csvData := sb.Bytes()
+		if p.Delimiter != 0 {
+			if csvData, err = delimitCSV(csvData, p.Delimiter); err != nil {
+				return wrap("formatting", err)
+			}
+		}
+		out = csvData
+		if p.Charset != "" {
+			if out, err = EncodeCharset(p.Charset, csvData); err != nil {
+				return wrap("formatting", err)
+			}
+		}
+	case p.Format == dataset.XLSDataFormat:
+		if out, err = CSVToXLSX(sb.Bytes()); err != nil {
+			return wrap("formatting", err)
+		}
+	default:
+		out = json.RawMessage(sb.Bytes())
 	}
 
+	name, _ := r.repo.GetName(path)
 	*data = StructuredData{
-		Path: p.Path,
-		Data: json.RawMessage(buf.Bytes()),
+		Path:      path,
+		Name:      name,
+		Data:      out,
+		Invalid:   invalid,
+		Truncated: truncated,
+	}
+	if truncated {
+		data.NextOffset = p.Offset + written
 	}
 	return nil
 }
@@ -532,52 +1796,164 @@ func (r *DatasetRequests) StructuredData(p *StructuredDataParams, data *Structur
 type AddParams struct {
 	Name string
 	Hash string
+	// Timeout limits how long fetching the dataset can take. zero waits
+	// until the fetch finishes
+	Timeout time.Duration
+	// Context cancels fetching when done. Context isn't sent over RPC, remote
+	// calls are limited by Timeout alone
+	Context context.Context `json:"-"`
 }
 
-// AddDataset adds an existing dataset to a peer's repository
+// ErrFetchTimeout is returned when fetching a dataset runs past it's time limit
+var ErrFetchTimeout = fmt.Errorf("fetch timed out")
+
+// AddDataset adds an existing dataset to a peer's repository. the dataset is
+// fetched if the store is a cafs.Fetcher & pinned if it's a cafs.Pinner, the
+// store must be at least one of the two. adding is idempotent: datasets
+// already in the store aren't fetched again, and adding a dataset a name
+// already refers to gives the existing reference
 func (r *DatasetRequests) AddDataset(p *AddParams, res *repo.DatasetRef) (err error) {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.AddDataset", p, res)
+		args := *p
+		args.Context = nil
+		return r.cli.Call("DatasetRequests.AddDataset", &args, res)
 	}
 
-	fs, ok := r.repo.Store().(*ipfs.Filestore)
-	if !ok {
-		return fmt.Errorf("can only add datasets when running an IPFS filestore")
+	if err := r.checkOwner(); err != nil {
+		return err
 	}
 
-	// _, cleaned := dsfs.RefType(p.Hash)
-	key := datastore.NewKey(strings.TrimSuffix(p.Hash, "/"+dsfs.PackageFileDataset.String()))
-	_, err = fs.Fetch(cafs.SourceAny, key)
-	if err != nil {
-		return fmt.Errorf("error fetching file: %s", err.Error())
+	fs := r.repo.Store()
+	fetcher, canFetch := fs.(cafs.Fetcher)
+	pinner, canPin := fs.(cafs.Pinner)
+	if !canFetch && !canPin {
+		return fmt.Errorf("can only add datasets when running a store that can fetch or pin data")
 	}
 
-	err = fs.Pin(key, true)
-	if err != nil {
-		return fmt.Errorf("error pinning root key: %s", err.Error())
+	key := datastore.NewKey(ref.RootPath(p.Hash))
+	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
+
+	// adding a dataset that's already named is a no-op, but a name can't be
+	// taken from another dataset
+	if prev, err := r.repo.GetPath(p.Name); err == nil && !prev.Equal(path) {
+		return withKind(repo.ErrNameTaken, fmt.Errorf("name '%s' already exists", p.Name))
 	}
 
-	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
-	err = r.repo.PutName(p.Name, path)
-	if err != nil {
-		return fmt.Errorf("error putting dataset name in repo: %s", err.Error())
+	// a previous add may have stopped after fetching, only fetch what's missing
+	if has, _ := fs.Has(path); !has && canFetch {
+		ctx := p.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if p.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+			defer cancel()
+		}
+		if err = fetchDataset(ctx, fetcher, key); err != nil {
+			return err
+		}
 	}
 
+	if canPin {
+		if err = pinner.Pin(key, true); err != nil {
+			return fmt.Errorf("error pinning root key: %s", err.Error())
+		}
+	}
+
+	// stores that can't fetch must already hold the dataset, load it before
+	// naming it so missing datasets don't leave a name behind
 	ds, err := dsfs.LoadDataset(fs, path)
 	if err != nil {
 		return fmt.Errorf("error loading newly saved dataset path: %s", path.String())
 	}
 
+	if name, err := r.repo.GetName(path); err == nil {
+		*res = repo.DatasetRef{
+			Name:    name,
+			Path:    path,
+			Dataset: ds,
+		}
+		return nil
+	}
+
+	err = r.repo.PutName(p.Name, path)
+	if err != nil {
+		return fmt.Errorf("error putting dataset name in repo: %s", err.Error())
+	}
+
 	*res = repo.DatasetRef{
 		Name:    p.Name,
 		Path:    path,
 		Dataset: ds,
 	}
-	return
+	return updateSearchIndex(r.repo, datastore.NewKey(""), res)
+}
+
+// fetchDataset fetches the dataset at key, giving up when ctx is done
+func fetchDataset(ctx context.Context, fetcher cafs.Fetcher, key datastore.Key) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := fetcher.Fetch(cafs.SourceAny, key)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error fetching file: %s", err.Error())
+		}
+		return nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrFetchTimeout
+		}
+		return fmt.Errorf("fetch cancelled: %s", ctx.Err().Error())
+	}
+}
+
+// AddDatasetResult is the outcome of adding one dataset of a batch.
+// exactly one of Ref or Error is set
+type AddDatasetResult struct {
+	Ref   *repo.DatasetRef `json:"ref,omitempty"`
+	Error string           `json:"error,omitempty"`
+}
+
+// AddDatasets adds a batch of existing datasets to a peer's repository,
+// fetching & pinning each hash in sequence. a failure only fails it's own
+// item, res will contain one result for each param, in the order given
+func (r *DatasetRequests) AddDatasets(p *[]AddParams, res *[]*AddDatasetResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.AddDatasets", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	if len(*p) == 0 {
+		return fmt.Errorf("at least one dataset is required")
+	}
+
+	results := make([]*AddDatasetResult, len(*p))
+	for i, ap := range *p {
+		ref := &repo.DatasetRef{}
+		if ap.Hash == "" {
+			results[i] = &AddDatasetResult{Error: "hash is required"}
+		} else if err := r.AddDataset(&ap, ref); err != nil {
+			results[i] = &AddDatasetResult{Error: err.Error()}
+		} else {
+			results[i] = &AddDatasetResult{Ref: ref}
+		}
+	}
+
+	*res = results
+	return nil
 }
 
 // ValidateDatasetParams defines paremeters for dataset
-// data validation
+// data validation. Datasets already in the store are validated
+// by Path or Name, incoming data is read from URL or Data
 type ValidateDatasetParams struct {
 	Name         string
 	URL          string
@@ -585,38 +1961,182 @@ type ValidateDatasetParams struct {
 	DataFilename string
 	Data         io.Reader
 	Metadata     io.Reader
+	// Context cancels fetching from URL when done. Context isn't sent over
+	// RPC
+	Context context.Context `json:"-"`
 }
 
-// Validate gives a dataset of errors and issues for a given dataset
-func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *dataset.Dataset) (err error) {
+// ValidationError is a problem found while validating a dataset.
+// problems with a dataset's structure have a Row of -1
+type ValidationError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Validate gives the errors found in a dataset's structure & rows. an error
+// is only returned if the dataset can't be checked at all
+func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *[]ValidationError) (err error) {
 	if r.cli != nil {
-		return r.cli.Call("DatasetRequests.Validate", p, errors)
+		args := *p
+		args.Context = nil
+		return r.cli.Call("DatasetRequests.Validate", &args, errors)
 	}
 
-	// store := Store(cmd, args)
-	// errs, err := history.Validate(store)
-	// ExitIfErr(err)
+	var (
+		store = r.repo.Store()
+		st    *dataset.Structure
+		each  func(fn dsio.DataIteratorFunc) error
+		errs  = []ValidationError{}
+	)
 
-	// if cmd.Flag("check-links").Value.String() == "true" {
-	// 	validation, data, count, err := ds.ValidateDeadLinks(Cache())
-	// 	ExitIfErr(err)
-	// 	if count > 0 {
-	// 		PrintResults(validation, data, dataset.CsvDataFormat)
-	// 	} else {
-	// 		PrintSuccess("✔ All good!")
-	// 	}
-	// }
+	if p.Path.String() != "" || (p.Name != "" && p.URL == "" && p.Data == nil) {
+		path := p.Path
+		if path.String() == "" {
+			if path, err = resolveRef(r.repo, ref.Ref{Name: p.Name}); err != nil {
+				return err
+			}
+		}
+		if err := r.checkVisible(path); err != nil {
+			return err
+		}
+		ds, err := loadDataset(r.repo, path)
+		if err != nil {
+			return fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		if ds.Structure == nil {
+			return fmt.Errorf("dataset has no structure to validate against")
+		}
+		st = ds.Structure
+		each = func(fn dsio.DataIteratorFunc) error {
+			return eachDataRow(store, ds, fn)
+		}
+	} else {
+		var (
+			rdr      io.Reader
+			filename = p.DataFilename
+		)
+		if p.URL != "" {
+			res, err := httpGet(p.Context, p.URL)
+			if err != nil {
+				return fmt.Errorf("error fetching url: %s", err.Error())
+			}
+			defer res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				return fmt.Errorf("error fetching url: %s", res.Status)
+			}
+			rdr = res.Body
+			filename = urlFilename(p.URL)
+		} else if p.Data != nil {
+			rdr = p.Data
+		} else {
+			return fmt.Errorf("a path, name, file, or url is required to validate a dataset")
+		}
 
-	// if p.Data != nil {
-	// 	errr, count, err := validate.Data(r)
-	// }
+		// incoming data is read the way InitDataset reads it, so data that
+		// validates can be added
+		if rdr, filename, err = gunzipData(rdr, filename); err != nil {
+			return err
+		}
+		if isJSONLines(filename) {
+			rdr = jsonLinesToArray(rdr)
+			filename = jsonArrayFilename(filename)
+		}
+		if isXLSX(filename) {
+			if rdr, err = xlsxToCSV(rdr, ""); err != nil {
+				return err
+			}
+			filename = xlsxCSVFilename(filename)
+		}
 
-	// validation, data, count, err := ds.ValidateData(Cache())
-	// ExitIfErr(err)
-	// if count > 0 {
-	// 	PrintResults(validation, data, dataset.CsvDataFormat)
-	// } else {
-	// 	PrintSuccess("✔ All good!")
-	// }
-	return fmt.Errorf("not finished")
+		data, err := spoolData(rdr)
+		if err != nil {
+			return fmt.Errorf("error reading file: %s", err.Error())
+		}
+		defer data.Close()
+
+		format, detectFilename, err := detectDataFormat(filename, data.prefix)
+		if err != nil {
+			return err
+		}
+		if err := validateDataFormat(format, data); err != nil {
+			errs = append(errs, ValidationError{Row: -1, Message: err.Error()})
+		}
+		if st, err = detect.FromReader(detectFilename, bytes.NewReader(data.prefix)); err != nil {
+			return fmt.Errorf("error determining dataset schema: %s", err.Error())
+		}
+		if p.Metadata != nil {
+			ds := &dataset.Dataset{}
+			if err := decodeMetadata(p.Metadata, ds); err != nil {
+				return err
+			}
+			if ds.Structure != nil {
+				st.Assign(ds.Structure)
+			}
+		}
+		each = func(fn dsio.DataIteratorFunc) error {
+			dr, err := data.Reader()
+			if err != nil {
+				return err
+			}
+			rr, err := dsio.NewRowReader(st, dr)
+			if err != nil {
+				return err
+			}
+			return dsio.EachRow(rr, fn)
+		}
+	}
+
+	if err := validate.Structure(st); err != nil {
+		errs = append(errs, ValidationError{Row: -1, Message: fmt.Sprintf("invalid structure: %s", err.Error())})
+	}
+	errs = append(errs, validateRows(st, each)...)
+
+	*errors = errs
+	return nil
+}
+
+// validateRows checks each row of data against the fields of a structure,
+// collecting problems instead of stopping at the first one
+func validateRows(st *dataset.Structure, each func(fn dsio.DataIteratorFunc) error) (errs []ValidationError) {
+	// without a schema there's nothing to check values against, rows are
+	// only read to find unreadable ones
+	var fields []*dataset.Field
+	if st.Schema != nil {
+		fields = st.Schema.Fields
+	}
+
+	rows := 0
+	err := each(func(i int, row [][]byte, err error) error {
+		rows = i + 1
+		if err != nil {
+			errs = append(errs, ValidationError{Row: i, Message: err.Error()})
+			return nil
+		}
+		if st.Schema == nil {
+			return nil
+		}
+		if len(row) != len(fields) {
+			errs = append(errs, ValidationError{Row: i, Message: fmt.Sprintf("expected %d values, got %d", len(fields), len(row))})
+		}
+		for j, cell := range row {
+			// empty cells are missing values, not type errors
+			if j >= len(fields) || len(cell) == 0 {
+				continue
+			}
+			f := fields[j]
+			if f.Type == datatypes.Unknown || f.Type == datatypes.Any {
+				continue
+			}
+			if _, err := f.Type.Parse(cell); err != nil {
+				errs = append(errs, ValidationError{Row: i, Column: f.Name, Message: fmt.Sprintf("invalid %s value '%s'", f.Type.String(), string(cell))})
+			}
+		}
+		return nil
+	})
+	// readers stop at unreadable rows, report where reading stopped
+	if err != nil {
+		errs = append(errs, ValidationError{Row: rows, Message: err.Error()})
+	}
+	return errs
 }
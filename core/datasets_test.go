@@ -2,17 +2,70 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/ref"
 	"github.com/qri-io/qri/repo"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
 
+func TestDatasetRequestsGetHashPrefix(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	// drop the trailing characters of the movies hash
+	i := strings.Index(path.String(), "/Qm")
+	prefix := datastore.NewKey(path.String()[:i+12])
+
+	cases := []struct {
+		p    *GetDatasetParams
+		path datastore.Key
+		err  string
+	}{
+		{&GetDatasetParams{Path: prefix}, path, ""},
+		{&GetDatasetParams{Path: datastore.NewKey("/map/QmNotAMatch")}, datastore.NewKey(""), "error resolving hash prefix '/map/QmNotAMatch': repo: not found"},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Get(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && !got.Path.Equal(c.path) {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, c.path, got.Path)
+		}
+	}
+}
+
 func TestDatasetRequestsInit(t *testing.T) {
 	badDataFile := testrepo.BadDataFile
 	jobsByAutomationFile := testrepo.JobsByAutomationFile
@@ -26,7 +79,7 @@ func TestDatasetRequestsInit(t *testing.T) {
 		err string
 	}{
 		{&InitDatasetParams{}, nil, "either a file or a url is required to create a dataset"},
-		{&InitDatasetParams{Data: badDataFile}, nil, "error detecting format extension: no file extension provided"},
+		{&InitDatasetParams{Data: badDataFile}, nil, "error detecting data format: no file extension provided, data doesn't look like csv or json"},
 		{&InitDatasetParams{DataFilename: badDataFile.FileName(), Data: badDataFile}, nil, "invalid data format: error reading first row of csv: EOF"},
 		// Ensure that DataFormat validation is being called
 		{&InitDatasetParams{DataFilename: badDataFormatFile.FileName(),
@@ -41,6 +94,8 @@ func TestDatasetRequestsInit(t *testing.T) {
 		{&InitDatasetParams{DataFilename: jobsByAutomationFile.FileName(), Data: jobsByAutomationFile}, nil, ""},
 		// Ensure that we can't double-add data
 		{&InitDatasetParams{DataFilename: jobsByAutomationFile2.FileName(), Data: jobsByAutomationFile2}, nil, "this data already exists"},
+		// names can't be taken from existing datasets
+		{&InitDatasetParams{DataFilename: "taken.csv", Name: "movies", Data: memfs.NewMemfileBytes("taken.csv", []byte("a,b\n1,2\n"))}, nil, "name 'movies' already exists"},
 	}
 
 	mr, err := testrepo.NewTestRepo()
@@ -58,301 +113,324 @@ func TestDatasetRequestsInit(t *testing.T) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
 			continue
 		}
+		if c.err != "" {
+			continue
+		}
+
+		// initialized datasets should round-trip through the repo
+		stored := &repo.DatasetRef{}
+		if err := req.Get(&GetDatasetParams{Path: got.Path}, stored); err != nil {
+			t.Errorf("case %d error getting dataset: %s", i, err.Error())
+			continue
+		}
+		if err := repo.CompareDatasetRefStructural(got, stored); err != nil {
+			t.Errorf("case %d stored dataset mismatch: %s", i, err.Error())
+		}
 	}
 }
 
-func TestDatasetRequestsList(t *testing.T) {
-	var (
-		movies, counter, cities, archive *repo.DatasetRef
-	)
-
+func TestDatasetRequestsExists(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
-
-	refs, err := mr.Namespace(30, 0)
+	moviesPath, err := mr.GetPath("movies")
 	if err != nil {
-		t.Errorf("error getting namespace: %s", err.Error())
+		t.Errorf("error getting movies path: %s", err.Error())
 		return
 	}
 
-	for _, ref := range refs {
-		switch ref.Name {
-		case "movies":
-			movies = ref
-		case "counter":
-			counter = ref
-		case "cities":
-			cities = ref
-		case "archive":
-			archive = ref
-		}
-	}
-
 	cases := []struct {
-		p   *ListParams
-		res []*repo.DatasetRef
-		err string
+		p      *GetDatasetParams
+		exists bool
+		err    string
 	}{
-		{&ListParams{OrderBy: "", Limit: 1, Offset: 0}, nil, ""},
-		{&ListParams{OrderBy: "chaos", Limit: 1, Offset: -50}, nil, ""},
-		{&ListParams{OrderBy: "", Limit: 30, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
-		{&ListParams{OrderBy: "timestamp", Limit: 30, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
-		// TODO: re-enable {&ListParams{OrderBy: "name", Limit: 30, Offset: 0}, []*repo.DatasetRef{cities, counter, movies}, ""},
+		{&GetDatasetParams{}, false, "either a path or a name is required to check if a dataset exists"},
+		{&GetDatasetParams{Name: "movies"}, true, ""},
+		{&GetDatasetParams{Name: "movies@1"}, true, ""},
+		{&GetDatasetParams{Name: "movies@99"}, false, ""},
+		{&GetDatasetParams{Name: "not_a_dataset"}, false, ""},
+		{&GetDatasetParams{Path: moviesPath}, true, ""},
+		{&GetDatasetParams{Path: datastore.NewKey("/map/QmNotADataset")}, false, ""},
 	}
 
 	req := NewDatasetRequests(mr, nil)
 	for i, c := range cases {
-		got := []*repo.DatasetRef{}
-		err := req.List(c.p, &got)
-
+		got := false
+		err := req.Exists(c.p, &got)
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
 			continue
 		}
-
-		if c.err == "" && c.res != nil {
-			if len(c.res) != len(got) {
-				t.Errorf("case %d response length mismatch. expected %d, got: %d", i, len(c.res), len(got))
-				continue
-			}
-			for j, expect := range c.res {
-				if err := repo.CompareDatasetRef(expect, got[j]); err != nil {
-					t.Errorf("case %d expected dataset error. index %d mismatch: %s", i, j, err.Error())
-					continue
-				}
-			}
+		if got != c.exists {
+			t.Errorf("case %d exists mismatch. expected: %t, got: %t", i, c.exists, got)
 		}
 	}
 }
 
-func TestDatasetRequestsGet(t *testing.T) {
+func TestDatasetRequestsInitCancelled(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
-	path, err := mr.GetPath("movies")
-	if err != nil {
-		t.Errorf("error getting path: %s", err.Error())
-		return
+	req := NewDatasetRequests(mr, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a,b\n1,2\n"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &InitDatasetParams{Name: "cancelled", URL: server.URL + "/data.csv", Context: ctx}
+	if err := req.InitDataset(p, &repo.DatasetRef{}); err == nil || !strings.HasPrefix(err.Error(), "error fetching url: ") {
+		t.Errorf("expected cancelled download to error, got: %s", err)
 	}
-	moviesDs, err := dsfs.LoadDataset(mr.Store(), path)
+	if _, err := mr.GetPath("cancelled"); err == nil {
+		t.Errorf("expected cancelled dataset not to be added")
+	}
+
+	p = &InitDatasetParams{Name: "downloaded", URL: server.URL + "/data.csv", Context: context.Background()}
+	if err := req.InitDataset(p, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+	}
+}
+
+func TestDatasetRequestsInitAccrualPeriodicity(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
 	if err != nil {
-		t.Errorf("error loading dataset: %s", err.Error())
+		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
+	req := NewDatasetRequests(mr, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a,b\n1,2\n" + r.URL.Path + ",3\n"))
+	}))
+	defer server.Close()
+
+	data := func(row string) *InitDatasetParams {
+		return &InitDatasetParams{DataFilename: "data.csv", Data: strings.NewReader("a,b\n" + row + "\n")}
+	}
+
 	cases := []struct {
-		p   *GetDatasetParams
-		res *dataset.Dataset
-		err string
+		p       *InitDatasetParams
+		name    string
+		accrual string
+		expect  string
+		err     string
 	}{
-		//TODO: probably delete some of these
-		{&GetDatasetParams{Path: datastore.NewKey("abc"), Name: "ABC", Hash: "123"}, nil, "error loading dataset: error getting file bytes: datastore: key not found"},
-		{&GetDatasetParams{Path: path, Name: "ABC", Hash: "123"}, nil, ""},
-		{&GetDatasetParams{Path: path, Name: "movies", Hash: "123"}, moviesDs, ""},
-		{&GetDatasetParams{Path: path, Name: "cats", Hash: "123"}, moviesDs, ""},
+		{data("1,2"), "every_week", "every week", "", "invalid accrual periodicity 'every week': expected a repeating interval like R/P1W"},
+		{data("1,2"), "bad_duration", "R/P1X", "", "invalid accrual periodicity 'R/P1X': invalid duration 'P1X'"},
+		{data("1,2"), "daily", "R/P1D", "R/P1D", ""},
+		{data("3,4"), "file", "", "", ""},
+		{&InitDatasetParams{URL: server.URL + "/weekly.csv"}, "weekly_url", "", DefaultAccrualPeriodicity, ""},
+		{&InitDatasetParams{URL: server.URL + "/daily.csv"}, "daily_url", "R/P1D", "R/P1D", ""},
 	}
 
-	req := NewDatasetRequests(mr, nil)
 	for i, c := range cases {
+		c.p.Name = c.name
+		c.p.AccrualPeriodicity = c.accrual
 		got := &repo.DatasetRef{}
-		err := req.Get(c.p, got)
+		err := req.InitDataset(c.p, got)
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
 			continue
 		}
-		// if got != c.res && c.checkResult == true {
-		// 	t.Errorf("case %d result mismatch: \nexpected \n\t%s, \n\ngot: \n%s", i, c.res, got)
-		// }
+		if c.err != "" {
+			continue
+		}
+		if got.Dataset.AccrualPeriodicity != c.expect {
+			t.Errorf("case %d accrual periodicity mismatch. expected: '%s', got: '%s'", i, c.expect, got.Dataset.AccrualPeriodicity)
+		}
 	}
 }
 
-func TestDatasetRequestsUpdate(t *testing.T) {
+func TestDatasetRequestsInitDatasets(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
-	// path, err := mr.GetPath("movies")
-	// if err != nil {
-	// 	t.Errorf("error getting path: %s", err.Error())
-	// 	return
-	// }
-	// moviesDs, err := dsfs.LoadDataset(mr.Store(), path)
-	// if err != nil {
-	// 	t.Errorf("error loading dataset: %s", err.Error())
-	// 	return
-	// }
-	cases := []struct {
-		p   *UpdateParams
-		res *repo.DatasetRef
-		err string
-	}{
-	//TODO: probably delete some of these
-	// {&UpdateParams{Path: datastore.NewKey("abc"), Name: "ABC", Hash: "123"}, nil, "error loading dataset: error getting file bytes: datastore: key not found"},
-	// {&UpdateParams{Path: path, Name: "ABC", Hash: "123"}, nil, ""},
-	// {&UpdateParams{Path: path, Name: "movies", Hash: "123"}, moviesDs, ""},
-	// {&UpdateParams{Path: path, Name: "cats", Hash: "123"}, moviesDs, ""},
+	req := NewDatasetRequests(mr, nil)
+
+	if err := req.InitDatasets(&InitDatasetsParams{}, &[]*InitDatasetResult{}); err == nil || err.Error() != "at least one dataset is required" {
+		t.Errorf("expected empty batch to error, got: %s", err)
 	}
 
-	req := NewDatasetRequests(mr, nil)
-	for i, c := range cases {
-		got := &repo.DatasetRef{}
-		err := req.Update(c.p, got)
-		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
-			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
-			continue
+	p := &InitDatasetsParams{
+		Params: []*InitDatasetParams{
+			{Name: "first", DataFilename: "first.csv", Data: memfs.NewMemfileBytes("first.csv", []byte("a,b\n1,2\n"))},
+			{DataFilename: testrepo.BadDataFormatFile.FileName(), Data: memfs.NewMemfileBytes(testrepo.BadDataFormatFile.FileName(), testrepo.BadDataFormatData)},
+			nil,
+			{Name: "second", DataFilename: "second.csv", Data: memfs.NewMemfileBytes("second.csv", []byte("c,d\n3,4\n"))},
+		},
+	}
+	errs := []string{
+		"",
+		"invalid data format: error: inconsistent column length on line 2 of length 3 (rather than 4). ensure all csv columns same length",
+		"missing dataset parameters",
+		"",
+	}
+
+	got := []*InitDatasetResult{}
+	if err := req.InitDatasets(p, &got); err != nil {
+		t.Errorf("error initializing datasets: %s", err.Error())
+		return
+	}
+	if len(got) != len(errs) {
+		t.Errorf("result count mismatch. expected: %d, got: %d", len(errs), len(got))
+		return
+	}
+	for i, res := range got {
+		if res.Error != errs[i] {
+			t.Errorf("result %d error mismatch. expected: %s, got: %s", i, errs[i], res.Error)
+		}
+		if (res.Ref != nil) != (errs[i] == "") {
+			t.Errorf("result %d expected a ref only on success", i)
+		}
+	}
+	for _, name := range []string{"first", "second"} {
+		if _, err := mr.GetPath(name); err != nil {
+			t.Errorf("expected dataset '%s' to be initialized: %s", name, err.Error())
 		}
-		// if got != c.res && c.checkResult == true {
-		// 	t.Errorf("case %d result mismatch: \nexpected \n\t%s, \n\ngot: \n%s", i, c.res, got)
-		// }
 	}
 }
 
-func TestDatasetRequestsRename(t *testing.T) {
+func TestDatasetRequestsInitReuseExisting(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
 
-	cases := []struct {
-		p   *RenameParams
-		res string
-		err string
-	}{
-		{&RenameParams{}, "", "current name is required to rename a dataset"},
-		{&RenameParams{Current: "movies", New: "new movies"}, "", "error: illegal name 'new movies', names must start with a letter and consist of only a-z,0-9, and _. max length 144 characters"},
-		{&RenameParams{Current: "movies", New: "new_movies"}, "new_movies", ""},
-		{&RenameParams{Current: "new_movies", New: "new_movies"}, "", "name 'new_movies' already exists"},
-	}
-
+	filename := testrepo.JobsByAutomationFile.FileName()
 	req := NewDatasetRequests(mr, nil)
-	for i, c := range cases {
-		got := &repo.DatasetRef{}
-		err := req.Rename(c.p, got)
+	first := &repo.DatasetRef{}
+	p := &InitDatasetParams{Name: "jobs", DataFilename: filename, Data: memfs.NewMemfileBytes(filename, testrepo.JobsByAutomationData)}
+	if err := req.InitDataset(p, first); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
 
-		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
-			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
-			continue
-		}
+	p = &InitDatasetParams{Name: "jobs_again", DataFilename: filename, Data: memfs.NewMemfileBytes(filename, testrepo.JobsByAutomationData)}
+	if err := req.InitDataset(p, &repo.DatasetRef{}); err == nil || err.Error() != "this data already exists" {
+		t.Errorf("expected re-initializing without ReuseExisting to error, got: %s", err)
+	}
 
-		if got.Name != c.res {
-			t.Errorf("case %d response name mismatch. expected: '%s', got: '%s'", i, c.err, err)
-			continue
-		}
+	second := &repo.DatasetRef{}
+	p = &InitDatasetParams{Name: "jobs_again", DataFilename: filename, Data: memfs.NewMemfileBytes(filename, testrepo.JobsByAutomationData), ReuseExisting: true}
+	if err := req.InitDataset(p, second); err != nil {
+		t.Errorf("error re-initializing dataset: %s", err.Error())
+		return
+	}
+	if !second.Path.Equal(first.Path) {
+		t.Errorf("path mismatch. expected: %s, got: %s", first.Path, second.Path)
+	}
+	if second.Name != "jobs" {
+		t.Errorf("name mismatch. expected: jobs, got: %s", second.Name)
+	}
+	if second.Dataset == nil {
+		t.Errorf("expected reused dataset to be set")
 	}
 }
 
-func TestDatasetRequestsDelete(t *testing.T) {
+func TestDatasetRequestsInitDataPath(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
-	path, err := mr.GetPath("movies")
+
+	datapath, err := mr.Store().Put(memfs.NewMemfileBytes("stored.csv", []byte("a,b,c\n1,2,3\n4,5,6\n")), false)
 	if err != nil {
-		t.Errorf("error getting path: %s", err.Error())
+		t.Errorf("error putting data: %s", err.Error())
 		return
 	}
 
 	cases := []struct {
-		p   *DeleteParams
-		res *dataset.Dataset
+		p   *InitDatasetParams
 		err string
 	}{
-		{&DeleteParams{}, nil, "either name or path is required"},
-		{&DeleteParams{Path: datastore.NewKey("abc"), Name: "ABC"}, nil, "repo: not found"},
-		{&DeleteParams{Path: path}, nil, ""},
+		{&InitDatasetParams{DataPath: datapath, URL: "http://example.com/data.csv"}, "only one of a url, a file, a data path, or a file path can be used to create a dataset"},
+		{&InitDatasetParams{DataPath: datapath, Data: memfs.NewMemfileBytes("data.csv", []byte("a,b\n"))}, "only one of a url, a file, a data path, or a file path can be used to create a dataset"},
+		{&InitDatasetParams{DataPath: datastore.NewKey("/map/QmNotAPath")}, "error getting data path: datastore: key not found"},
+		{&InitDatasetParams{Name: "stored", DataPath: datapath, DataFilename: "stored.csv"}, ""},
 	}
 
 	req := NewDatasetRequests(mr, nil)
 	for i, c := range cases {
-		got := false
-		err := req.Delete(c.p, &got)
-
+		got := &repo.DatasetRef{}
+		err := req.InitDataset(c.p, got)
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
 			continue
 		}
+		if c.err == "" && got.Dataset.Data != datapath.String() {
+			t.Errorf("case %d data path mismatch. expected: %s, got: %s", i, datapath.String(), got.Dataset.Data)
+		}
 	}
 }
 
-func TestDatasetRequestsStructuredData(t *testing.T) {
+func TestDatasetRequestsInitDataFilepath(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
-	moviesPath, err := mr.GetPath("movies")
+
+	dir, err := ioutil.TempDir("", "init_data_filepath")
 	if err != nil {
-		t.Errorf("error getting movies path: %s", err.Error())
+		t.Errorf("error creating temp dir: %s", err.Error())
 		return
 	}
-	archivePath, err := mr.GetPath("archive")
-	if err != nil {
-		t.Errorf("error getting archive path: %s", err.Error())
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "local.csv")
+	if err := ioutil.WriteFile(path, []byte("a,b,c\n7,8,9\n10,11,12\n"), os.ModePerm); err != nil {
+		t.Errorf("error writing data file: %s", err.Error())
 		return
 	}
-	var df1 = dataset.JSONDataFormat
+
 	cases := []struct {
-		p        *StructuredDataParams
-		resCount int
-		err      string
+		p   *InitDatasetParams
+		err string
 	}{
-		{&StructuredDataParams{}, 0, "error getting file bytes: datastore: key not found"},
-		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: 5, Offset: 0, All: false}, 5, ""},
-		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: false}, 0, ""},
-		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
-		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
-		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
-		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
-		{&StructuredDataParams{Format: dataset.JSONDataFormat, Path: archivePath, Limit: 0, Offset: 0, All: true}, 0, ""},
+		{&InitDatasetParams{DataFilepath: path, URL: "http://example.com/data.csv"}, "only one of a url, a file, a data path, or a file path can be used to create a dataset"},
+		{&InitDatasetParams{DataFilepath: dir}, fmt.Sprintf("'%s' is a directory, not a data file", dir)},
+		{&InitDatasetParams{DataFilepath: filepath.Join(dir, "missing.csv")}, fmt.Sprintf("error opening file: open %s: no such file or directory", filepath.Join(dir, "missing.csv"))},
+		{&InitDatasetParams{Name: "local", DataFilepath: path}, ""},
 	}
 
 	req := NewDatasetRequests(mr, nil)
 	for i, c := range cases {
-		got := &StructuredData{}
-		err := req.StructuredData(c.p, got)
-
+		got := &repo.DatasetRef{}
+		err := req.InitDataset(c.p, got)
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
-			continue
-		}
-
-		if got.Data == nil && c.resCount == 0 {
-			continue
-		}
-
-		switch c.p.Format {
-		default:
-			// default should be json format
-			_, err := json.Marshal(got.Data)
-			if err != nil {
-				t.Errorf("case %d error parsing response data: %s", i, err.Error())
-				continue
-			}
-		case dataset.CSVDataFormat:
-			r := csv.NewReader(bytes.NewBuffer(got.Data.([]byte)))
-			_, err := r.ReadAll()
-			if err != nil {
-				t.Errorf("case %d error parsing response data: %s", err.Error())
-				continue
-			}
 		}
 	}
 }
 
-func TestDatasetRequestsAddDataset(t *testing.T) {
+func TestDatasetRequestsValidate(t *testing.T) {
+	badDataFormatFile := memfs.NewMemfileBytes(testrepo.BadDataFormatFile.FileName(), testrepo.BadDataFormatData)
+	badStructureFile := memfs.NewMemfileBytes(testrepo.BadStructureFile.FileName(), testrepo.BadStructureData)
+
 	cases := []struct {
-		p   *AddParams
-		res *repo.DatasetRef
-		err string
+		p     *ValidateDatasetParams
+		count int
+		first string
+		err   string
 	}{
-		{&AddParams{Name: "abc", Hash: "hash###"}, nil, "can only add datasets when running an IPFS filestore"},
+		{&ValidateDatasetParams{}, 0, "", "a path, name, file, or url is required to validate a dataset"},
+		{&ValidateDatasetParams{Name: "not_a_dataset"}, 0, "", "repo: not found"},
+		{&ValidateDatasetParams{Name: "cities"}, 0, "", ""},
+		{&ValidateDatasetParams{DataFilename: badDataFormatFile.FileName(), Data: badDataFormatFile}, 2,
+			"invalid data format: error: inconsistent column length on line 2 of length 3 (rather than 4). ensure all csv columns same length", ""},
+		{&ValidateDatasetParams{DataFilename: badStructureFile.FileName(), Data: badStructureFile}, 1,
+			"invalid structure: error: cannot use the same name, 'colb' more than once", ""},
 	}
 
 	mr, err := testrepo.NewTestRepo()
@@ -363,12 +441,1368 @@ func TestDatasetRequestsAddDataset(t *testing.T) {
 
 	req := NewDatasetRequests(mr, nil)
 	for i, c := range cases {
-		got := &repo.DatasetRef{}
-		err := req.AddDataset(c.p, got)
+		got := []ValidationError{}
+		err := req.Validate(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != c.count {
+			t.Errorf("case %d error count mismatch. expected: %d, got: %d", i, c.count, len(got))
+			continue
+		}
+		if c.count > 0 && got[0].Message != c.first {
+			t.Errorf("case %d message mismatch. expected: %s, got: %s", i, c.first, got[0].Message)
+		}
+	}
+}
+
+func TestDatasetRequestsValidateURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.csv.gz":
+			w.Write(gzipBytes(t, []byte("a,b\n1,2\n3,4\n")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		p     *ValidateDatasetParams
+		count int
+		err   string
+	}{
+		{&ValidateDatasetParams{URL: server.URL + "/data.csv.gz?dl=1"}, 0, ""},
+		{&ValidateDatasetParams{URL: server.URL + "/missing.csv"}, 0, "error fetching url: 404 Not Found"},
+	}
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := []ValidationError{}
+		err := req.Validate(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != c.count {
+			t.Errorf("case %d error count mismatch. expected: %d, got: %d: %v", i, c.count, len(got), got)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &ValidateDatasetParams{URL: server.URL + "/data.csv.gz", Context: ctx}
+	if err := req.Validate(p, &[]ValidationError{}); err == nil || !strings.HasPrefix(err.Error(), "error fetching url: ") {
+		t.Errorf("expected cancelled fetch to error, got: %s", err)
+	}
+}
+
+func TestValidateRowsNoSchema(t *testing.T) {
+	each := func(fn dsio.DataIteratorFunc) error {
+		for i, row := range [][][]byte{{[]byte("a")}, {[]byte("b"), []byte("c")}} {
+			if err := fn(i, row, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if errs := validateRows(&dataset.Structure{Format: dataset.CSVDataFormat}, each); len(errs) != 0 {
+		t.Errorf("expected rows without a schema to have no errors, got: %v", errs)
+	}
+}
+
+func TestDatasetRequestsList(t *testing.T) {
+	var (
+		movies, counter, cities, archive *repo.DatasetRef
+	)
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	refs, err := mr.Namespace(30, 0)
+	if err != nil {
+		t.Errorf("error getting namespace: %s", err.Error())
+		return
+	}
+
+	for _, ref := range refs {
+		switch ref.Name {
+		case "movies":
+			movies = ref
+		case "counter":
+			counter = ref
+		case "cities":
+			cities = ref
+		case "archive":
+			archive = ref
+		}
+	}
+
+	cases := []struct {
+		p   *ListParams
+		res []*repo.DatasetRef
+		err string
+	}{
+		{&ListParams{OrderBy: "", Limit: 1, Offset: 0}, nil, ""},
+		{&ListParams{OrderBy: "chaos", Limit: 1, Offset: -50}, nil, ""},
+		{&ListParams{OrderBy: "", Limit: 30, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
+		{&ListParams{OrderBy: "timestamp", Limit: 30, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
+		{&ListParams{OrderBy: "", Limit: 30, Offset: 30}, []*repo.DatasetRef{}, ""},
+		{&ListParams{OrderBy: "name", Limit: 30, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
+		{&ListParams{OrderBy: "-name", Limit: 30, Offset: 0}, []*repo.DatasetRef{movies, counter, cities, archive}, ""},
+		{&ListParams{OrderBy: "-name", Limit: 2, Offset: 1}, []*repo.DatasetRef{counter, cities}, ""},
+		{&ListParams{OrderBy: "-timestamp", Limit: 30, Offset: 0}, []*repo.DatasetRef{movies, counter, cities, archive}, ""},
+		{&ListParams{OrderBy: "length", Limit: 30, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
+		{&ListParams{OrderBy: "", Limit: 0, Offset: 0}, []*repo.DatasetRef{archive, cities, counter, movies}, ""},
+		{&ListParams{OrderBy: "", Limit: -1, Offset: 2}, []*repo.DatasetRef{counter, movies}, ""},
+		{&ListParams{OrderBy: "-name", Limit: 0, Offset: 1}, []*repo.DatasetRef{counter, cities, archive}, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := []*repo.DatasetRef{}
+		err := req.List(c.p, &got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+
+		if c.err == "" && c.res != nil {
+			if len(c.res) != len(got) {
+				t.Errorf("case %d response length mismatch. expected %d, got: %d", i, len(c.res), len(got))
+				continue
+			}
+			for j, expect := range c.res {
+				if err := repo.CompareDatasetRef(expect, got[j]); err != nil {
+					t.Errorf("case %d expected dataset error. index %d mismatch: %s", i, j, err.Error())
+					continue
+				}
+			}
+		}
+	}
+}
+
+func TestDatasetRequestsListPage(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	total, err := mr.NameCount()
+	if err != nil {
+		t.Errorf("error getting name count: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p       *ListParams
+		count   int
+		hasMore bool
+	}{
+		{&ListParams{Limit: 1, Offset: 0}, 1, true},
+		{&ListParams{Limit: total - 1, Offset: 1}, total - 1, false},
+		{&ListParams{Limit: total, Offset: 0}, total, false},
+		{&ListParams{Limit: 10, Offset: total + 10}, 0, false},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &DatasetList{}
+		if err := req.ListPage(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got.Datasets) != c.count {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, c.count, len(got.Datasets))
+		}
+		if got.Total != total {
+			t.Errorf("case %d total mismatch. expected: %d, got: %d", i, total, got.Total)
+		}
+		if got.HasMore != c.hasMore {
+			t.Errorf("case %d hasMore mismatch. expected: %t, got: %t", i, c.hasMore, got.HasMore)
+		}
+	}
+}
+
+func TestDatasetRequestsGet(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	moviesDs, err := dsfs.LoadDataset(mr.Store(), path)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	cases := []struct {
+		p    *GetDatasetParams
+		res  *dataset.Dataset
+		name string
+		err  string
+	}{
+		//TODO: probably delete some of these
+		{&GetDatasetParams{}, nil, "", "either a path or a name is required to get a dataset"},
+		{&GetDatasetParams{Path: datastore.NewKey("abc"), Name: "ABC", Hash: "123"}, nil, "", "error loading dataset: error getting file bytes: datastore: key not found"},
+		{&GetDatasetParams{Path: path, Name: "ABC", Hash: "123"}, nil, "movies", ""},
+		{&GetDatasetParams{Path: path, Name: "movies", Hash: "123"}, moviesDs, "movies", ""},
+		{&GetDatasetParams{Path: path, Name: "cats", Hash: "123"}, moviesDs, "movies", ""},
+		{&GetDatasetParams{Name: "movies"}, moviesDs, "movies", ""},
+		{&GetDatasetParams{Name: "movies@1"}, moviesDs, "movies", ""},
+		{&GetDatasetParams{Name: "not_a_dataset"}, nil, "", "error getting dataset path: repo: not found"},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Get(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && got.Name != c.name {
+			t.Errorf("case %d name mismatch. expected: %s, got: %s", i, c.name, got.Name)
+		}
+		if c.err == "" && c.res != nil && !got.Path.Equal(path) {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, path, got.Path)
+		}
+		// if got != c.res && c.checkResult == true {
+		// 	t.Errorf("case %d result mismatch: \nexpected \n\t%s, \n\ngot: \n%s", i, c.res, got)
+		// }
+	}
+}
+
+func TestDatasetRequestsUpdate(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	// path, err := mr.GetPath("movies")
+	// if err != nil {
+	// 	t.Errorf("error getting path: %s", err.Error())
+	// 	return
+	// }
+	// moviesDs, err := dsfs.LoadDataset(mr.Store(), path)
+	// if err != nil {
+	// 	t.Errorf("error loading dataset: %s", err.Error())
+	// 	return
+	// }
+	cases := []struct {
+		p   *UpdateParams
+		res *repo.DatasetRef
+		err string
+	}{
+	//TODO: probably delete some of these
+	// {&UpdateParams{Path: datastore.NewKey("abc"), Name: "ABC", Hash: "123"}, nil, "error loading dataset: error getting file bytes: datastore: key not found"},
+	// {&UpdateParams{Path: path, Name: "ABC", Hash: "123"}, nil, ""},
+	// {&UpdateParams{Path: path, Name: "movies", Hash: "123"}, moviesDs, ""},
+	// {&UpdateParams{Path: path, Name: "cats", Hash: "123"}, moviesDs, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Update(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		// if got != c.res && c.checkResult == true {
+		// 	t.Errorf("case %d result mismatch: \nexpected \n\t%s, \n\ngot: \n%s", i, c.res, got)
+		// }
+	}
+}
+
+func TestDatasetRequestsUpdateData(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	data := []byte("title,year\nthe matrix,1999\nalien,1979\n")
+	extraColumn := []byte("title,year,rating\nthe matrix,1999,5\nalien,1979,5\n")
+	cases := []struct {
+		filename string
+		data     []byte
+		force    bool
+		fields   int
+		err      string
+	}{
+		{"abc.csv", testrepo.BadDataFormatData, false, 0, "invalid data format: error: inconsistent column length on line 2 of length 3 (rather than 4). ensure all csv columns same length"},
+		{"badStructure.csv", testrepo.BadStructureData, false, 0, "invalid structure: error: cannot use the same name, 'colb' more than once"},
+		{"movies.csv", data, false, 2, ""},
+		{"movies.csv", extraColumn, false, 0, "new data has 3 columns, previous version has 2. use force to update anyway"},
+		{"movies.csv", extraColumn, true, 3, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		p := &UpdateParams{
+			Changes:      &dataset.Dataset{Previous: path},
+			DataFilename: c.filename,
+			Data:         memfs.NewMemfileBytes(c.filename, c.data),
+			Force:        c.force,
+		}
+		got := &repo.DatasetRef{}
+		err := req.Update(p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Dataset.Length != len(c.data) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.data), got.Dataset.Length)
+		}
+		if len(got.Dataset.Structure.Schema.Fields) != c.fields {
+			t.Errorf("case %d field count mismatch. expected: %d, got: %d", i, c.fields, len(got.Dataset.Structure.Schema.Fields))
+		}
+		f, err := mr.Store().Get(datastore.NewKey(got.Dataset.Data))
+		if err != nil {
+			t.Errorf("case %d error getting data: %s", i, err.Error())
+			continue
+		}
+		stored, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Errorf("case %d error reading data: %s", i, err.Error())
+			continue
+		}
+		if !bytes.Equal(stored, c.data) {
+			t.Errorf("case %d data mismatch. expected: %s, got: %s", i, string(c.data), string(stored))
+		}
+	}
+}
+
+func TestDatasetRequestsUpdateByName(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p     *UpdateParams
+		title string
+		err   string
+	}{
+		{&UpdateParams{}, "", "changes are required to update a dataset"},
+		{&UpdateParams{Changes: &dataset.Dataset{Title: "movies"}}, "", "either a previous path or a name is required to update a dataset"},
+		{&UpdateParams{Name: "not_a_dataset", Changes: &dataset.Dataset{Title: "movies"}}, "", "error getting previous dataset path: repo: not found"},
+		{&UpdateParams{Name: "movies", Changes: &dataset.Dataset{Title: "movies by name"}}, "movies by name", ""},
+		// changing nothing shouldn't create a new version
+		{&UpdateParams{Name: "movies", Changes: &dataset.Dataset{Title: "movies by name"}}, "", "no changes"},
+		{&UpdateParams{Name: "movies", Changes: &dataset.Dataset{}}, "", "no changes"},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Update(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Dataset.Title != c.title {
+			t.Errorf("case %d title mismatch. expected: %s, got: %s", i, c.title, got.Dataset.Title)
+		}
+		if got.Dataset.Previous.String() != ref.RootPath(path.String()) {
+			t.Errorf("case %d expected previous to be the latest version. expected: %s, got: %s", i, ref.RootPath(path.String()), got.Dataset.Previous)
+		}
+		if latest, err := mr.GetPath("movies"); err != nil || !latest.Equal(got.Path) {
+			t.Errorf("case %d expected name to point to the updated dataset", i)
+		}
+	}
+}
+
+func TestDatasetRequestsRename(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+	// updating movies leaves the first version without a name
+	unnamedPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	if err := req.Update(&UpdateParams{Changes: &dataset.Dataset{Title: "movies v2", Previous: unnamedPath}}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error updating movies: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p   *RenameParams
+		res string
+		err string
+	}{
+		{&RenameParams{}, "", "either a current name or path is required to rename a dataset"},
+		{&RenameParams{Current: "movies", CurrentPath: citiesPath, New: "films"}, "", "only one of a current name or path can be used to rename a dataset"},
+		{&RenameParams{Current: "movies", New: "new movies"}, "", "error: illegal name 'new movies', names must start with a letter and consist of only a-z,0-9, and _. max length 144 characters"},
+		{&RenameParams{Current: "movies", New: "new_movies"}, "new_movies", ""},
+		{&RenameParams{Current: "new_movies", New: "new_movies"}, "", "name 'new_movies' already exists"},
+		{&RenameParams{CurrentPath: citiesPath, New: "new_movies"}, "", "name 'new_movies' already exists"},
+		{&RenameParams{CurrentPath: citiesPath, New: "new_cities"}, "new_cities", ""},
+		{&RenameParams{CurrentPath: unnamedPath, New: "old_movies"}, "old_movies", ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Rename(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+
+		if got.Name != c.res {
+			t.Errorf("case %d response name mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+	}
+
+	// renaming by path moves an existing name
+	if _, err := mr.GetPath("cities"); err != repo.ErrNotFound {
+		t.Errorf("expected renamed dataset's current name to be removed, got: %v", err)
+	}
+	if path, err := mr.GetPath("new_cities"); err != nil || !path.Equal(citiesPath) {
+		t.Errorf("expected new_cities to refer to %s, got: %s, %v", citiesPath, path, err)
+	}
+	// and names datasets without one, leaving other versions' names alone
+	if path, err := mr.GetPath("old_movies"); err != nil || !path.Equal(unnamedPath) {
+		t.Errorf("expected old_movies to refer to %s, got: %s, %v", unnamedPath, path, err)
+	}
+	if path, err := mr.GetPath("new_movies"); err != nil || path.Equal(unnamedPath) {
+		t.Errorf("expected new_movies to refer to the latest version, got: %s, %v", path, err)
+	}
+}
+
+// putNameFailRepo wraps a repo, failing all calls to PutName
+type putNameFailRepo struct {
+	repo.Repo
+}
+
+func (r *putNameFailRepo) PutName(name string, path datastore.Key) error {
+	return fmt.Errorf("put name failed")
+}
+
+func TestDatasetRequestsRenamePutNameFailure(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(&putNameFailRepo{mr}, nil)
+	err = req.Rename(&RenameParams{Current: "movies", New: "new_movies"}, &repo.DatasetRef{})
+	expect := "error adding name 'new_movies': put name failed"
+	if err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %s, got: %s", expect, err)
+		return
+	}
+
+	got, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("expected current name to be intact after a failed rename, got: %s", err.Error())
+		return
+	}
+	if !got.Equal(path) {
+		t.Errorf("path mismatch. expected: %s, got: %s", path, got)
+	}
+	if _, err := mr.GetPath("new_movies"); err != repo.ErrNotFound {
+		t.Errorf("expected new name not to exist after a failed rename, got: %v", err)
+	}
+}
+
+func TestDatasetRequestsBulkRename(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p   *BulkRenameParams
+		res []string
+		err string
+	}{
+		{&BulkRenameParams{}, []string{}, ""},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {}}}, nil, "rename 1: current name is required to rename a dataset"},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {Current: "cities", New: "new cities"}}}, nil, "rename 1: error: illegal name 'new cities', names must start with a letter and consist of only a-z,0-9, and _. max length 144 characters"},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {Current: "cities", New: "films"}}}, nil, "rename 1: name 'films' is used more than once"},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {Current: "movies", New: "movies_2"}}}, nil, "rename 1: dataset 'movies' is renamed more than once"},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {Current: "cities", New: "counter"}}}, nil, "rename 1: name 'counter' already exists"},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {Current: "not_a_dataset", New: "towns"}}}, nil, "rename 1: error getting dataset 'not_a_dataset': repo: not found"},
+		{&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}, {Current: "cities", New: "towns"}}}, []string{"films", "towns"}, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := []*repo.DatasetRef{}
+		err := req.BulkRename(c.p, &got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+
+		if c.err != "" {
+			// failed batches shouldn't rename anything
+			for _, rn := range c.p.Renames {
+				if _, err := mr.GetPath(rn.New); err != repo.ErrNotFound && rn.New != "counter" {
+					t.Errorf("case %d expected failed batch not to add name '%s'", i, rn.New)
+				}
+			}
+			continue
+		}
+
+		if len(got) != len(c.res) {
+			t.Errorf("case %d result length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+			continue
+		}
+		for j, name := range c.res {
+			if got[j].Name != name {
+				t.Errorf("case %d result %d name mismatch. expected: '%s', got: '%s'", i, j, name, got[j].Name)
+			}
+		}
+	}
+}
+
+func TestDatasetRequestsAddAlias(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p   *AliasParams
+		res string
+		err string
+	}{
+		{&AliasParams{}, "", "name is required to alias a dataset"},
+		{&AliasParams{Name: "movies", Alias: "new films"}, "", "error: illegal name 'new films', names must start with a letter and consist of only a-z,0-9, and _. max length 144 characters"},
+		{&AliasParams{Name: "movies", Alias: "cities"}, "", "name 'cities' already exists"},
+		{&AliasParams{Name: "not_a_dataset", Alias: "films"}, "", "error getting dataset: repo: not found"},
+		{&AliasParams{Name: "movies", Alias: "films"}, "films", ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.AddAlias(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got.Name != c.res {
+			t.Errorf("case %d response name mismatch. expected: '%s', got: '%s'", i, c.res, got.Name)
+		}
+	}
+
+	// updating a dataset should move all aliases
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	updated := &repo.DatasetRef{}
+	if err := req.Update(&UpdateParams{Changes: &dataset.Dataset{Title: "updated movies", Previous: path}}, updated); err != nil {
+		t.Errorf("error updating dataset: %s", err.Error())
+		return
+	}
+	for _, name := range []string{"movies", "films"} {
+		if got, err := mr.GetPath(name); err != nil || !got.Equal(updated.Path) {
+			t.Errorf("expected alias '%s' to move to updated path %s, got: %s (err: %v)", name, updated.Path, got, err)
+		}
+	}
+
+	// renaming should keep the new name in the alias group
+	if err := req.Rename(&RenameParams{Current: "movies", New: "flicks"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error renaming dataset: %s", err.Error())
+		return
+	}
+	aliases, err := mr.Aliases("films")
+	if err != nil {
+		t.Errorf("error getting aliases: %s", err.Error())
+		return
+	}
+	if len(aliases) != 2 || aliases[0] != "films" || aliases[1] != "flicks" {
+		t.Errorf("alias group mismatch. expected: [films flicks], got: %v", aliases)
+	}
+}
+
+func TestDatasetRequestsDelete(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p   *DeleteParams
+		res *dataset.Dataset
+		err string
+	}{
+		{&DeleteParams{}, nil, "either name or path is required"},
+		{&DeleteParams{Path: datastore.NewKey("abc"), Name: "ABC"}, nil, "repo: not found"},
+		{&DeleteParams{Path: path}, nil, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := false
+		err := req.Delete(c.p, &got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+	}
+}
+
+// pinRepo wraps a repo, recording pins & unpins made through it's store
+type pinRepo struct {
+	repo.Repo
+	store *pinStore
+}
+
+func (r *pinRepo) Store() cafs.Filestore { return r.store }
+
+type pinStore struct {
+	cafs.Filestore
+	pinned   []string
+	unpinned []string
+}
+
+func (s *pinStore) Pin(key datastore.Key, recursive bool) error {
+	s.pinned = append(s.pinned, key.String())
+	return nil
+}
+
+func (s *pinStore) Unpin(key datastore.Key, recursive bool) error {
+	s.unpinned = append(s.unpinned, key.String())
+	return nil
+}
+
+// storeRepo wraps a repo, swapping out it's store
+type storeRepo struct {
+	repo.Repo
+	store cafs.Filestore
+}
+
+func (r *storeRepo) Store() cafs.Filestore { return r.store }
+
+// plainStore hides any methods of a store beyond cafs.Filestore
+type plainStore struct {
+	cafs.Filestore
+}
+
+// fetchStore is a pinStore that records fetches
+type fetchStore struct {
+	*pinStore
+	fetched []string
+}
+
+func (s *fetchStore) Fetch(source cafs.Source, key datastore.Key) (cafs.File, error) {
+	s.fetched = append(s.fetched, key.String())
+	return nil, nil
+}
+
+func TestDatasetRequestsDeleteAll(t *testing.T) {
+	cases := []struct {
+		all      bool
+		unpinned int
+	}{
+		{false, 1},
+		{true, 3},
+	}
+
+	for i, c := range cases {
+		mr, err := testrepo.NewTestRepo()
+		if err != nil {
+			t.Errorf("error allocating test repo: %s", err.Error())
+			return
+		}
+		pr := &pinRepo{Repo: mr, store: &pinStore{Filestore: mr.Store()}}
+		req := NewDatasetRequests(pr, nil)
+
+		// movies has one version, add two more
+		for _, title := range []string{"movies v2", "movies v3"} {
+			path, err := mr.GetPath("movies")
+			if err != nil {
+				t.Errorf("case %d error getting path: %s", i, err.Error())
+				return
+			}
+			if err := req.Update(&UpdateParams{Changes: &dataset.Dataset{Title: title, Previous: path}}, &repo.DatasetRef{}); err != nil {
+				t.Errorf("case %d error updating dataset: %s", i, err.Error())
+				return
+			}
+		}
+
+		got := &DeleteResult{}
+		if err := req.DeleteWithResult(&DeleteParams{Name: "movies", All: c.all}, got); err != nil {
+			t.Errorf("case %d error deleting dataset: %s", i, err.Error())
+			continue
+		}
+		if got.Unpinned != c.unpinned {
+			t.Errorf("case %d unpinned count mismatch. expected: %d, got: %d", i, c.unpinned, got.Unpinned)
+		}
+		if len(pr.store.unpinned) != c.unpinned {
+			t.Errorf("case %d store unpin count mismatch. expected: %d, got: %d", i, c.unpinned, len(pr.store.unpinned))
+		}
+	}
+}
+
+func TestDatasetRequestsDeleteAlias(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	if err := mr.AddAlias("movies", "films"); err != nil {
+		t.Errorf("error adding alias: %s", err.Error())
+		return
+	}
+
+	pr := &pinRepo{Repo: mr, store: &pinStore{Filestore: mr.Store()}}
+	req := NewDatasetRequests(pr, nil)
+	got := &DeleteResult{}
+	if err := req.DeleteWithResult(&DeleteParams{Name: "films", All: true}, got); err != nil {
+		t.Errorf("error deleting alias: %s", err.Error())
+		return
+	}
+	if _, err := mr.GetPath("films"); err != repo.ErrNotFound {
+		t.Errorf("expected films to be deleted. got: %v", err)
+	}
+	if p, err := mr.GetPath("movies"); err != nil || !p.Equal(path) {
+		t.Errorf("expected movies to keep path %s. got: %s, %v", path, p, err)
+	}
+	if got.Unpinned != 0 || len(pr.store.unpinned) != 0 {
+		t.Errorf("expected content another name refers to to stay pinned. unpinned: %d", got.Unpinned)
+	}
+}
+
+func TestDatasetRequestsDeletePurge(t *testing.T) {
+	cases := []struct {
+		purge  bool
+		logs   int
+		cached bool
+	}{
+		{false, 3, true},
+		{true, 1, false},
+	}
+
+	for i, c := range cases {
+		mr, err := testrepo.NewTestRepo()
+		if err != nil {
+			t.Errorf("error allocating test repo: %s", err.Error())
+			return
+		}
+		path, err := mr.GetPath("movies")
+		if err != nil {
+			t.Errorf("error getting path: %s", err.Error())
+			return
+		}
+
+		now := time.Now()
+		for j, item := range []*repo.QueryLogItem{
+			{Query: "select * from movies", Name: "movies"},
+			{Query: "select title from movies", Name: "movies"},
+			{Query: "select * from counter", Name: "counter_q"},
+		} {
+			item.Time = now.Add(time.Duration(j) * time.Second)
+			if err := mr.LogQuery(item); err != nil {
+				t.Errorf("error logging query: %s", err.Error())
+				return
+			}
+		}
+		if err := mr.Cache().PutDataset(path, &dataset.Dataset{Title: "movies"}); err != nil {
+			t.Errorf("error caching dataset: %s", err.Error())
+			return
+		}
+
+		req := NewDatasetRequests(mr, nil)
+		got := false
+		if err := req.Delete(&DeleteParams{Name: "movies", Purge: c.purge}, &got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+
+		logs, err := mr.ListQueryLogs(10, 0)
+		if err != nil {
+			t.Errorf("case %d error listing query logs: %s", i, err.Error())
+			continue
+		}
+		if len(logs) != c.logs {
+			t.Errorf("case %d query log count mismatch. expected: %d, got: %d", i, c.logs, len(logs))
+		}
+		for _, item := range logs {
+			if c.purge && item.Name == "movies" {
+				t.Errorf("case %d expected query '%s' to be purged", i, item.Query)
+			}
+		}
+		if _, err := mr.Cache().GetDataset(path); (err == nil) != c.cached {
+			t.Errorf("case %d expected cached to be %t", i, c.cached)
+		}
+	}
+}
+
+func TestDatasetRequestsDeleteRPC(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	cli := newTestRPCClient(t, NewDatasetRequests(mr, nil))
+	defer cli.Close()
+
+	req := NewDatasetRequests(nil, cli)
+	got := false
+	if err := req.Delete(&DeleteParams{Name: "movies"}, &got); err != nil {
+		t.Errorf("error deleting over rpc: %s", err.Error())
+		return
+	}
+	if !got {
+		t.Errorf("expected delete over rpc to report success")
+	}
+	if _, err := mr.GetPath("movies"); err != repo.ErrNotFound {
+		t.Errorf("expected movies to be deleted. got: %s", err)
+	}
+
+	res := &DeleteResult{}
+	if err := req.DeleteWithResult(&DeleteParams{Name: "cities"}, res); err != nil {
+		t.Errorf("error deleting with result over rpc: %s", err.Error())
+		return
+	}
+	if _, err := mr.GetPath("cities"); err != repo.ErrNotFound {
+		t.Errorf("expected cities to be deleted. got: %s", err)
+	}
+}
+
+func TestDatasetRequestsStructuredData(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	archivePath, err := mr.GetPath("archive")
+	if err != nil {
+		t.Errorf("error getting archive path: %s", err.Error())
+		return
+	}
+	var df1 = dataset.JSONDataFormat
+	cases := []struct {
+		p        *StructuredDataParams
+		resCount int
+		err      string
+	}{
+		{&StructuredDataParams{}, 0, "error loading dataset '': error getting file bytes: datastore: key not found"},
+		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: 5, Offset: 0, All: false}, 5, ""},
+		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: false}, 0, ""},
+		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
+		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
+		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
+		{&StructuredDataParams{Format: df1, Path: moviesPath, Limit: -5, Offset: -100, All: true}, 0, ""},
+		{&StructuredDataParams{Format: dataset.JSONDataFormat, Path: archivePath, Limit: 0, Offset: 0, All: true}, 0, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+
+		if got.Data == nil && c.resCount == 0 {
+			continue
+		}
+
+		switch c.p.Format {
+		default:
+			// default should be json format
+			_, err := json.Marshal(got.Data)
+			if err != nil {
+				t.Errorf("case %d error parsing response data: %s", i, err.Error())
+				continue
+			}
+		case dataset.CSVDataFormat:
+			r := csv.NewReader(bytes.NewBuffer(got.Data.([]byte)))
+			_, err := r.ReadAll()
+			if err != nil {
+				t.Errorf("case %d error parsing response data: %s", err.Error())
+				continue
+			}
+		}
+	}
+}
+
+func TestDatasetRequestsStructuredDataCSVHeader(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), citiesPath)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	header := strings.Join(ds.Structure.Schema.FieldNames(), ",")
+
+	cases := []struct {
+		p      *StructuredDataParams
+		rows   int
+		header string
+	}{
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: citiesPath, Limit: 2}, 3, header},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: citiesPath, Limit: 2, Offset: 2}, 3, header},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, FormatConfig: &dataset.CSVOptions{HeaderRow: false}, Path: citiesPath, Limit: 2}, 2, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		if err := req.StructuredData(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		records, err := csv.NewReader(bytes.NewReader(got.Data.([]byte))).ReadAll()
+		if err != nil {
+			t.Errorf("case %d error parsing csv: %s", i, err.Error())
+			continue
+		}
+		if len(records) != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, len(records))
+			continue
+		}
+		if c.header != "" && strings.Join(records[0], ",") != c.header {
+			t.Errorf("case %d header mismatch. expected: %s, got: %s", i, c.header, strings.Join(records[0], ","))
+		}
+	}
+}
+
+func TestDatasetRequestsStructuredDataErrors(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	missingPath := datastore.NewKey("/map/missing")
+	if err := mr.PutName("missing", datastore.NewKey("/map/missing_named")); err != nil {
+		t.Errorf("error adding name: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p        *StructuredDataParams
+		contains []string
+	}{
+		{&StructuredDataParams{Path: missingPath}, []string{"error loading dataset", "/map/missing"}},
+		{&StructuredDataParams{Path: datastore.NewKey("/map/missing_named")}, []string{"error loading dataset", "missing (/map/missing_named)"}},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+		if err == nil {
+			t.Errorf("case %d expected error, got nil", i)
+			continue
+		}
+		for _, str := range c.contains {
+			if !strings.Contains(err.Error(), str) {
+				t.Errorf("case %d expected error '%s' to contain '%s'", i, err.Error(), str)
+			}
+		}
+		if errors.Unwrap(err) == nil {
+			t.Errorf("case %d expected error to wrap the underlying error", i)
+		}
+	}
+}
+
+func TestDatasetRequestsStructuredDataMaxRows(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	counterPath, err := mr.GetPath("counter")
+	if err != nil {
+		t.Errorf("error getting counter path: %s", err.Error())
+		return
+	}
+
+	SetMaxDataRows(20)
+	defer SetMaxDataRows(0)
+
+	df := dataset.JSONDataFormat
+	cases := []struct {
+		p         *StructuredDataParams
+		resCount  int
+		truncated bool
+	}{
+		{&StructuredDataParams{Format: df, Path: moviesPath, All: true}, 20, true},
+		{&StructuredDataParams{Format: df, Path: moviesPath, Limit: 100}, 20, true},
+		{&StructuredDataParams{Format: df, Path: moviesPath, Limit: 5}, 5, false},
+		{&StructuredDataParams{Format: df, Path: counterPath, All: true}, 20, false},
+		{&StructuredDataParams{Format: df, Path: counterPath, Limit: 100, Offset: 5}, 15, false},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		if err := req.StructuredData(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+
+		rows := []interface{}{}
+		if err := json.Unmarshal(got.Data.(json.RawMessage), &rows); err != nil {
+			t.Errorf("case %d error parsing response data: %s", i, err.Error())
+			continue
+		}
+		if len(rows) != c.resCount {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.resCount, len(rows))
+		}
+		if got.Truncated != c.truncated {
+			t.Errorf("case %d truncated mismatch. expected: %t, got: %t", i, c.truncated, got.Truncated)
+		}
+	}
+}
+
+func TestDatasetRequestsStructuredDataMaxRowsStopsReading(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	// the last row is malformed, reading stops at the row cap before it
+	datakey, err := mr.Store().Put(memfs.NewMemfileBytes("data.csv", []byte("a\n1\n2\n3\n4\"x\n")), false)
+	if err != nil {
+		t.Errorf("error putting data: %s", err.Error())
+		return
+	}
+	ds := &dataset.Dataset{
+		Data: datakey.String(),
+		Structure: &dataset.Structure{
+			Format:       dataset.CSVDataFormat,
+			FormatConfig: &dataset.CSVOptions{HeaderRow: true},
+			Schema:       &dataset.Schema{Fields: []*dataset.Field{{Name: "a"}}},
+		},
+	}
+	dspath, err := dsfs.SaveDataset(mr.Store(), ds, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+
+	SetMaxDataRows(2)
+	defer SetMaxDataRows(0)
+
+	got := &StructuredData{}
+	req := NewDatasetRequests(mr, nil)
+	if err := req.StructuredData(&StructuredDataParams{Format: dataset.JSONDataFormat, Path: dspath, All: true}, got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if !got.Truncated || got.NextOffset != 2 {
+		t.Errorf("expected data truncated at offset 2, got truncated: %t, next offset: %d", got.Truncated, got.NextOffset)
+	}
+}
+
+func TestDatasetRequestsStructuredDataMaxBytes(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+
+	df := dataset.JSONDataFormat
+	cases := []struct {
+		p          *StructuredDataParams
+		resCount   int
+		truncated  bool
+		nextOffset int
+	}{
+		{&StructuredDataParams{Format: df, Path: citiesPath, All: true, MaxBytes: 1}, 1, true, 1},
+		{&StructuredDataParams{Format: df, Path: citiesPath, All: true, MaxBytes: 60}, 2, true, 2},
+		{&StructuredDataParams{Format: df, Path: citiesPath, Limit: 10, Offset: 2, MaxBytes: 60}, 2, true, 4},
+		{&StructuredDataParams{Format: df, Path: citiesPath, Limit: 1, MaxBytes: 60}, 1, false, 0},
+		{&StructuredDataParams{Format: df, Path: citiesPath, All: true, MaxBytes: 1000}, 5, false, 0},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		if err := req.StructuredData(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+
+		rows := []interface{}{}
+		if err := json.Unmarshal(got.Data.(json.RawMessage), &rows); err != nil {
+			t.Errorf("case %d error parsing response data: %s", i, err.Error())
+			continue
+		}
+		if len(rows) != c.resCount {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.resCount, len(rows))
+		}
+		if got.Truncated != c.truncated {
+			t.Errorf("case %d truncated mismatch. expected: %t, got: %t", i, c.truncated, got.Truncated)
+		}
+		if got.NextOffset != c.nextOffset {
+			t.Errorf("case %d next offset mismatch. expected: %d, got: %d", i, c.nextOffset, got.NextOffset)
+		}
+	}
+}
+
+func TestDatasetRequestsAddDataset(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	plain := NewDatasetRequests(&storeRepo{Repo: mr, store: plainStore{mr.Store()}}, nil)
+	if err := plain.AddDataset(&AddParams{Name: "abc", Hash: path.String()}, &repo.DatasetRef{}); err == nil || err.Error() != "can only add datasets when running a store that can fetch or pin data" {
+		t.Errorf("expected adding to a store that can't fetch or pin to error, got: %s", err)
+	}
+
+	cases := []struct {
+		p    *AddParams
+		name string
+		err  string
+	}{
+		{&AddParams{Name: "abc", Hash: "hash###"}, "", "error loading newly saved dataset path: /hash###/dataset.json"},
+		// movies is already named, adding it again gives the existing reference
+		{&AddParams{Name: "movies_copy", Hash: path.String()}, "movies", ""},
+		{&AddParams{Name: "movies_root", Hash: ref.RootPath(path.String())}, "movies", ""},
+	}
+
+	fs := &fetchStore{pinStore: &pinStore{Filestore: mr.Store()}}
+	req := NewDatasetRequests(&storeRepo{Repo: mr, store: fs}, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.AddDataset(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if _, err := mr.GetPath(c.p.Name); err == nil {
+			t.Errorf("case %d expected %s not to be added as a name", i, c.p.Name)
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Name != c.name || !got.Path.Equal(path) {
+			t.Errorf("case %d ref mismatch. expected: %s@%s, got: %s@%s", i, c.name, path, got.Name, got.Path)
+		}
+	}
+	if len(fs.pinned) != 2 {
+		t.Errorf("expected added datasets to be pinned, got: %v", fs.pinned)
+	}
+	// movies is already in the store, only the missing dataset is fetched
+	if len(fs.fetched) != 1 || fs.fetched[0] != "/hash###" {
+		t.Errorf("expected only missing datasets to be fetched, got: %v", fs.fetched)
+	}
+}
+
+func TestFetchDataset(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, done := context.WithTimeout(context.Background(), time.Millisecond)
+	defer done()
+
+	cases := []struct {
+		ctx     context.Context
+		fetcher cafs.Fetcher
+		err     string
+	}{
+		{context.Background(), fetcherFunc(func() error { return nil }), ""},
+		{context.Background(), fetcherFunc(func() error { return fmt.Errorf("not found") }), "error fetching file: not found"},
+		{ctx, fetcherFunc(func() error { select {} }), "fetch cancelled: context canceled"},
+		{expired, fetcherFunc(func() error { select {} }), "fetch timed out"},
+	}
 
+	for i, c := range cases {
+		err := fetchDataset(c.ctx, c.fetcher, datastore.NewKey("/map/a"))
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+}
+
+// fetcherFunc fetches by calling itself
+type fetcherFunc func() error
+
+func (f fetcherFunc) Fetch(source cafs.Source, key datastore.Key) (cafs.File, error) {
+	return nil, f()
+}
+
+func TestDatasetRequestsAddDatasets(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(&storeRepo{Repo: mr, store: plainStore{mr.Store()}}, nil)
+
+	if err := req.AddDatasets(&[]AddParams{}, &[]*AddDatasetResult{}); err == nil || err.Error() != "at least one dataset is required" {
+		t.Errorf("expected empty batch to error, got: %s", err)
+	}
+
+	p := []AddParams{
+		{Name: "abc", Hash: "hash###"},
+		{Name: "def"},
+	}
+	errs := []string{
+		"can only add datasets when running a store that can fetch or pin data",
+		"hash is required",
+	}
+
+	got := []*AddDatasetResult{}
+	if err := req.AddDatasets(&p, &got); err != nil {
+		t.Errorf("error adding datasets: %s", err.Error())
+		return
+	}
+	if len(got) != len(errs) {
+		t.Errorf("result count mismatch. expected: %d, got: %d", len(errs), len(got))
+		return
+	}
+	for i, res := range got {
+		if res.Error != errs[i] {
+			t.Errorf("result %d error mismatch. expected: %s, got: %s", i, errs[i], res.Error)
+		}
+		if res.Ref != nil {
+			t.Errorf("result %d expected no ref on error", i)
+		}
+	}
+}
+
+func TestDatasetOrders(t *testing.T) {
+	jan := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+	apples := &repo.DatasetRef{Name: "apples", Dataset: &dataset.Dataset{Timestamp: feb, Length: 10}}
+	bananas := &repo.DatasetRef{Name: "Bananas", Dataset: &dataset.Dataset{Timestamp: jan, Length: 10}}
+	cherries := &repo.DatasetRef{Name: "cherries", Dataset: &dataset.Dataset{Timestamp: jan, Length: 5}}
+
+	cases := []struct {
+		order  string
+		expect []*repo.DatasetRef
+	}{
+		{"name", []*repo.DatasetRef{apples, bananas, cherries}},
+		{"-name", []*repo.DatasetRef{cherries, bananas, apples}},
+		{"timestamp", []*repo.DatasetRef{bananas, cherries, apples}},
+		{"-timestamp", []*repo.DatasetRef{apples, cherries, bananas}},
+		{"length", []*repo.DatasetRef{cherries, apples, bananas}},
+		{"-length", []*repo.DatasetRef{bananas, apples, cherries}},
+	}
+
+	for i, c := range cases {
+		less := datasetOrder(c.order)
+		if less == nil {
+			t.Errorf("case %d expected '%s' to be a valid order", i, c.order)
 			continue
 		}
+		got := []*repo.DatasetRef{c.expect[2], c.expect[0], c.expect[1]}
+		sort.SliceStable(got, func(i, j int) bool { return less(got[i], got[j]) })
+		for j, ref := range c.expect {
+			if got[j] != ref {
+				t.Errorf("case %d (%s) index %d mismatch. expected: %s, got: %s", i, c.order, j, ref.Name, got[j].Name)
+			}
+		}
+	}
+
+	for _, order := range []string{"", "chaos", "-chaos", "-"} {
+		if datasetOrder(order) != nil {
+			t.Errorf("expected '%s' to be an unknown order", order)
+		}
 	}
 }
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// validDelimiter reports whether d can separate csv fields
+func validDelimiter(d rune) bool {
+	return d != 0 && d != '"' && d != '\r' && d != '\n' && utf8.ValidRune(d) && d != unicode.ReplacementChar
+}
+
+// delimitCSV rewrites comma-separated data to separate fields with delim
+func delimitCSV(data []byte, delim rune) ([]byte, error) {
+	if delim == ',' {
+		return data, nil
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Comma = delim
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading csv: %s", err.Error())
+		}
+		if err := w.Write(rec); err != nil {
+			return nil, fmt.Errorf("error writing csv: %s", err.Error())
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("error writing csv: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
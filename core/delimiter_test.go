@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDelimitCSV(t *testing.T) {
+	cases := []struct {
+		in    string
+		delim rune
+		out   string
+	}{
+		{"a,b\n1,2\n", ',', "a,b\n1,2\n"},
+		{"a,b\n1,2\n", '\t', "a\tb\n1\t2\n"},
+		{"a,b\n\"x,y\",\"z\tw\"\n", '\t', "a\tb\nx,y\t\"z\tw\"\n"},
+		{"a,b\n1,2\n", '|', "a|b\n1|2\n"},
+	}
+
+	for i, c := range cases {
+		got, err := delimitCSV([]byte(c.in), c.delim)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if string(got) != c.out {
+			t.Errorf("case %d output mismatch. expected: %q, got: %q", i, c.out, string(got))
+		}
+	}
+}
+
+func TestDatasetRequestsStructuredDataDelimiter(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), citiesPath)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	header := strings.Join(ds.Structure.Schema.FieldNames(), "\t")
+
+	cases := []struct {
+		p      *StructuredDataParams
+		rows   int
+		header string
+		err    string
+	}{
+		{&StructuredDataParams{Format: dataset.JSONDataFormat, Path: citiesPath, Limit: 2, Delimiter: '\t'}, 0, "", "delimiter is only supported for csv data"},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: citiesPath, Limit: 2, Delimiter: '\n'}, 0, "", "invalid delimiter '\\n'"},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, Path: citiesPath, Limit: 2, Delimiter: '\t'}, 3, header, ""},
+		{&StructuredDataParams{Format: dataset.CSVDataFormat, FormatConfig: &dataset.CSVOptions{HeaderRow: false}, Path: citiesPath, Limit: 2, Delimiter: '\t'}, 2, "", ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		rdr := csv.NewReader(bytes.NewReader(got.Data.([]byte)))
+		rdr.Comma = '\t'
+		records, err := rdr.ReadAll()
+		if err != nil {
+			t.Errorf("case %d error parsing tab-delimited data: %s", i, err.Error())
+			continue
+		}
+		if len(records) != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, len(records))
+			continue
+		}
+		if c.header != "" && strings.Join(records[0], "\t") != c.header {
+			t.Errorf("case %d header mismatch. expected: %s, got: %s", i, c.header, strings.Join(records[0], "\t"))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/datatypes"
+)
+
+// DistinctParams defines parameters for getting the distinct values of a column
+type DistinctParams struct {
+	Path   datastore.Key
+	Column string
+	// MaxValues caps the number of distinct values returned, defaults to
+	// DefaultMaxColumnValues
+	MaxValues int
+}
+
+// DistinctResponse is the set of distinct values in a column
+type DistinctResponse struct {
+	Column string `json:"column"`
+	// Values are the distinct values of the column, sorted by the column's type
+	Values []string `json:"values"`
+	// Truncated is true when the column has more distinct values than were
+	// returned. values are collected in the order they appear in the data
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DistinctValues reads a dataset's data in a single pass, collecting the
+// distinct values of a column
+func (r *DatasetRequests) DistinctValues(p *DistinctParams, res *DistinctResponse) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.DistinctValues", p, res)
+	}
+
+	max := p.MaxValues
+	if max <= 0 {
+		max = DefaultMaxColumnValues
+	}
+
+	ds, index, err := r.loadColumn(p.Path, p.Column)
+	if err != nil {
+		return err
+	}
+
+	var (
+		seen      = map[string]bool{}
+		values    = []string{}
+		truncated bool
+	)
+	if err := eachDataRow(r.repo.Store(), ds, func(i int, row [][]byte, err error) error {
+		if err != nil {
+			return err
+		}
+		if index >= len(row) || seen[string(row[index])] {
+			return nil
+		}
+		if len(values) == max {
+			truncated = true
+			return nil
+		}
+		val := string(row[index])
+		seen[val] = true
+		values = append(values, val)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error reading data: %s", err.Error())
+	}
+
+	sortTypedStrings(ds.Structure.Schema.Fields[index].Type, values)
+	*res = DistinctResponse{
+		Column:    p.Column,
+		Values:    values,
+		Truncated: truncated,
+	}
+	return nil
+}
+
+// sortTypedStrings sorts values by the type they represent, so integers sort
+// numerically & dates chronologically. values that can't be parsed as the
+// type sort first, in string order
+func sortTypedStrings(t datatypes.Type, values []string) {
+	parsed := make([]interface{}, len(values))
+	for i, v := range values {
+		if pv, err := parseTypedValue(t, v); err == nil {
+			parsed[i] = pv
+		}
+	}
+
+	sort.Sort(typedStrings{values, parsed})
+}
+
+// typedStrings sorts strings alongside their parsed values
+type typedStrings struct {
+	values []string
+	parsed []interface{}
+}
+
+func (s typedStrings) Len() int { return len(s.values) }
+func (s typedStrings) Swap(i, j int) {
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+	s.parsed[i], s.parsed[j] = s.parsed[j], s.parsed[i]
+}
+func (s typedStrings) Less(i, j int) bool {
+	a, b := s.parsed[i], s.parsed[j]
+	switch {
+	case a == nil && b == nil:
+		return strings.Compare(s.values[i], s.values[j]) < 0
+	case a == nil:
+		return true
+	case b == nil:
+		return false
+	}
+	return compareTypedValues(a, b) < 0
+}
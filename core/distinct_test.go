@@ -0,0 +1,94 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qri-io/dataset/datatypes"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsDistinctValues(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p         *DistinctParams
+		values    []string
+		truncated bool
+		err       string
+	}{
+		{&DistinctParams{Path: citiesPath}, nil, false, "column name is required"},
+		{&DistinctParams{Path: citiesPath, Column: "not_a_column"}, nil, false, "column 'not_a_column' not found"},
+		{&DistinctParams{Path: citiesPath, Column: "in_usa"}, []string{"false", "true"}, false, ""},
+		{&DistinctParams{Path: citiesPath, Column: "pop"}, []string{"35000", "250000", "300000", "8500000", "40000000"}, false, ""},
+		{&DistinctParams{Path: citiesPath, Column: "avg_age", MaxValues: 2}, []string{"44.4", "55.5"}, true, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &DistinctResponse{}
+		err := req.DistinctValues(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if fmt.Sprintf("%v", got.Values) != fmt.Sprintf("%v", c.values) {
+			t.Errorf("case %d values mismatch. expected: %v, got: %v", i, c.values, got.Values)
+		}
+		if got.Truncated != c.truncated {
+			t.Errorf("case %d truncated mismatch. expected: %t, got: %t", i, c.truncated, got.Truncated)
+		}
+	}
+
+	// high-cardinality columns are capped
+	got := &DistinctResponse{}
+	if err := req.DistinctValues(&DistinctParams{Path: moviesPath, Column: "movie_title", MaxValues: 25}, got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if !got.Truncated {
+		t.Errorf("expected high-cardinality column to be truncated")
+	}
+	if len(got.Values) != 25 {
+		t.Errorf("expected values to be capped at 25, got: %d", len(got.Values))
+	}
+}
+
+func TestSortTypedStrings(t *testing.T) {
+	cases := []struct {
+		t      datatypes.Type
+		in     []string
+		expect []string
+	}{
+		{datatypes.Integer, []string{"10", "9", "", "100"}, []string{"", "9", "10", "100"}},
+		{datatypes.Float, []string{"1.5", "-2", "0.25"}, []string{"-2", "0.25", "1.5"}},
+		{datatypes.String, []string{"b", "c", "a"}, []string{"a", "b", "c"}},
+		{datatypes.Boolean, []string{"true", "false"}, []string{"false", "true"}},
+		{datatypes.Date, []string{"2017-12-01", "2016-01-01", "n/a"}, []string{"n/a", "2016-01-01", "2017-12-01"}},
+	}
+
+	for i, c := range cases {
+		sortTypedStrings(c.t, c.in)
+		if fmt.Sprintf("%v", c.in) != fmt.Sprintf("%v", c.expect) {
+			t.Errorf("case %d order mismatch. expected: %v, got: %v", i, c.expect, c.in)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrInvalidParams is the kind of error returned for missing or invalid
+	// request parameters
+	ErrInvalidParams = fmt.Errorf("invalid params")
+	// ErrInvalidFormat is the kind of error returned for data that can't be
+	// read, or doesn't make a valid dataset
+	ErrInvalidFormat = fmt.Errorf("invalid format")
+	// ErrDataExists is the kind of error returned when initializing a dataset
+	// with data the repo already has
+	ErrDataExists = fmt.Errorf("data already exists")
+	// ErrForbidden is the kind of error returned for requests only the repo
+	// owner can make
+	ErrForbidden = fmt.Errorf("forbidden")
+)
+
+// kindError gives an error a kind that can be checked with errors.Is,
+// without changing it's message. errors returned by dataset requests have one
+// of the kinds above, or wrap a repo error like repo.ErrNotFound or
+// repo.ErrNameTaken, when the cause is known. kinds don't survive rpc calls,
+// only messages do
+type kindError struct {
+	kind error
+	err  error
+}
+
+// withKind wraps err with kind. a nil err stays nil
+func withKind(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap gives the wrapped error, so errors.Is can match it's cause
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is the kind of e
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
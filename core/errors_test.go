@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestWithKind(t *testing.T) {
+	if err := withKind(ErrInvalidParams, nil); err != nil {
+		t.Errorf("expected nil error to stay nil, got: %s", err.Error())
+	}
+
+	err := withKind(ErrInvalidFormat, fmt.Errorf("error loading dataset: %w", repo.ErrNotFound))
+	if err.Error() != "error loading dataset: repo: not found" {
+		t.Errorf("expected message to be unchanged, got: %s", err.Error())
+	}
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("expected error to be ErrInvalidFormat")
+	}
+	if !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("expected error to wrap repo.ErrNotFound")
+	}
+	if errors.Is(err, ErrDataExists) {
+		t.Errorf("expected error not to be ErrDataExists")
+	}
+}
+
+func TestDatasetRequestsErrorKinds(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	data := []byte("city,pop\ntoronto,40000000\n")
+	if err := req.InitDataset(&InitDatasetParams{Name: "kinds", DataFilename: "kinds.csv", Data: bytes.NewReader(data)}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		call func() error
+		kind error
+		err  string
+	}{
+		{func() error { return req.Get(&GetDatasetParams{}, &repo.DatasetRef{}) }, ErrInvalidParams, "either a path or a name is required to get a dataset"},
+		{func() error { return req.Get(&GetDatasetParams{Name: "not_a_dataset"}, &repo.DatasetRef{}) }, repo.ErrNotFound, "error getting dataset path: repo: not found"},
+		{func() error {
+			return req.InitDataset(&InitDatasetParams{DataFilename: "kinds.csv", Data: bytes.NewReader(data)}, &repo.DatasetRef{})
+		}, ErrDataExists, "this data already exists"},
+		{func() error {
+			return req.InitDataset(&InitDatasetParams{Name: "empty", DataFilename: "empty.csv", Data: bytes.NewReader(nil)}, &repo.DatasetRef{})
+		}, ErrInvalidFormat, ""},
+		{func() error { return req.InitDataset(&InitDatasetParams{Name: "no_data"}, &repo.DatasetRef{}) }, ErrInvalidParams, "either a file or a url is required to create a dataset"},
+		{func() error { return req.Update(&UpdateParams{}, &repo.DatasetRef{}) }, ErrInvalidParams, "changes are required to update a dataset"},
+		{func() error {
+			return req.Update(&UpdateParams{Name: "not_a_dataset", Changes: &dataset.Dataset{}}, &repo.DatasetRef{})
+		}, repo.ErrNotFound, "error getting previous dataset path: repo: not found"},
+		{func() error {
+			return req.Update(&UpdateParams{Name: "kinds", Changes: &dataset.Dataset{}, DataFilename: "kinds.csv", Data: bytes.NewReader([]byte("a,b,c\n1,2,3\n"))}, &repo.DatasetRef{})
+		}, ErrInvalidFormat, "new data has 3 columns, previous version has 2. use force to update anyway"},
+		{func() error { return req.Delete(&DeleteParams{}, new(bool)) }, ErrInvalidParams, "either name or path is required"},
+		{func() error {
+			return req.Delete(&DeleteParams{Path: datastore.NewKey("/map/QmNotADataset")}, new(bool))
+		}, repo.ErrNotFound, ""},
+		{func() error { return req.Rename(&RenameParams{New: "films"}, &repo.DatasetRef{}) }, ErrInvalidParams, "either a current name or path is required to rename a dataset"},
+		{func() error {
+			return req.Rename(&RenameParams{Current: "not_a_dataset", New: "films"}, &repo.DatasetRef{})
+		}, repo.ErrNotFound, "error getting dataset: repo: not found"},
+		{func() error { return req.Rename(&RenameParams{Current: "kinds", New: "movies"}, &repo.DatasetRef{}) }, repo.ErrNameTaken, "name 'movies' already exists"},
+		{func() error {
+			return req.BulkRename(&BulkRenameParams{Renames: []RenameParams{{Current: "kinds", New: "movies"}}}, &[]*repo.DatasetRef{})
+		}, repo.ErrNameTaken, "rename 0: name 'movies' already exists"},
+		{func() error { return req.AddAlias(&AliasParams{Name: "kinds", Alias: "movies"}, &repo.DatasetRef{}) }, repo.ErrNameTaken, "name 'movies' already exists"},
+		{func() error { return req.SetVisibility(&VisibilityParams{}, &repo.DatasetRef{}) }, ErrInvalidParams, "name is required"},
+		{func() error {
+			return NewPublicDatasetRequests(mr).SetVisibility(&VisibilityParams{Name: "kinds", Visibility: "private"}, &repo.DatasetRef{})
+		}, ErrForbidden, "dataset visibility can only be set by the repo owner"},
+	}
+
+	for i, c := range cases {
+		err := c.call()
+		if err == nil {
+			t.Errorf("case %d expected error", i)
+			continue
+		}
+		if !errors.Is(err, c.kind) {
+			t.Errorf("case %d expected error to be '%s', got: %s", i, c.kind, err.Error())
+		}
+		// messages are kept as they were before errors had kinds
+		if c.err != "" && err.Error() != c.err {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err.Error())
+		}
+	}
+}
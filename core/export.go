@@ -0,0 +1,210 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/repo"
+)
+
+// ExportParams defines parameters for the Export method
+type ExportParams struct {
+	Name string
+	Path datastore.Key
+	// Writer receives the zip archive. if Writer is nil, or when exporting
+	// over RPC, the archive is returned as ExportResult.Zip
+	Writer io.Writer
+	// History adds each previous version of the dataset to the archive,
+	// under history/N/ where N counts versions back from the exported one
+	History bool
+}
+
+// ExportResult describes an exported dataset archive
+type ExportResult struct {
+	Name string
+	Path datastore.Key
+	// Filename is a suggested name for the archive, eg: movies.zip
+	Filename string
+	// Zip is the archive itself, only set if ExportParams.Writer is nil
+	Zip []byte
+}
+
+// Export writes a zip archive of a dataset, containing the dataset
+// definition as dataset.json & it's data as data.[format]. archives are named
+// after the dataset's name in the repo. archives of the same dataset are
+// byte-for-byte identical
+func (r *DatasetRequests) Export(p *ExportParams, res *ExportResult) error {
+	if r.cli != nil {
+		// writers can't cross the RPC boundary, zip bytes are returned instead
+		w := p.Writer
+		args := &ExportParams{Name: p.Name, Path: p.Path, History: p.History}
+		if err := r.cli.Call("DatasetRequests.Export", args, res); err != nil {
+			return err
+		}
+		if w != nil {
+			if _, err := w.Write(res.Zip); err != nil {
+				return fmt.Errorf("error writing archive: %s", err.Error())
+			}
+			res.Zip = nil
+		}
+		return nil
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&GetDatasetParams{Name: p.Name, Path: p.Path}, ref); err != nil {
+		return err
+	}
+
+	w := p.Writer
+	buf := &bytes.Buffer{}
+	if w == nil {
+		w = buf
+	}
+	if err := writeExportArchive(r.repo, ref, p.History, w); err != nil {
+		return err
+	}
+
+	name := ref.Name
+	if name == "" {
+		name = "dataset"
+	}
+	*res = ExportResult{
+		Name:     ref.Name,
+		Path:     ref.Path,
+		Filename: name + ".zip",
+	}
+	if p.Writer == nil {
+		res.Zip = buf.Bytes()
+	}
+	return nil
+}
+
+// writeExportArchive writes a dataset's definition & data to w as a zip,
+// followed by each previous version if history is set. file times are set to
+// the dataset's timestamp to keep archives reproducible
+func writeExportArchive(r repo.Repo, ref *repo.DatasetRef, history bool, w io.Writer) error {
+	modified := ref.Dataset.Timestamp
+	if modified.IsZero() {
+		modified = time.Unix(0, 0).In(time.UTC)
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeExportVersion(zw, r, ref, "", modified); err != nil {
+		return err
+	}
+
+	if history {
+		seen := map[string]bool{ref.Path.String(): true}
+		ds := ref.Dataset
+		for i := 1; ds.Previous.String() != "" && ds.Previous.String() != "/"; i++ {
+			path := previousPath(ds)
+			if seen[path.String()] {
+				return fmt.Errorf("dataset history has a cycle at %s", path.String())
+			}
+			seen[path.String()] = true
+
+			prev, err := loadDataset(r, path)
+			if err != nil {
+				return fmt.Errorf("error loading previous version: %s", err.Error())
+			}
+			if err := writeExportVersion(zw, r, &repo.DatasetRef{Path: path, Dataset: prev}, fmt.Sprintf("history/%d/", i), modified); err != nil {
+				return err
+			}
+			ds = prev
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error writing archive: %s", err.Error())
+	}
+	return nil
+}
+
+// writeExportVersion adds the definition & data of a single dataset version
+// to an archive, with filenames starting with prefix
+func writeExportVersion(zw *zip.Writer, r repo.Repo, ref *repo.DatasetRef, prefix string, modified time.Time) error {
+	ds := ref.Dataset
+	create := func(name string) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: prefix + name, Method: zip.Deflate, Modified: modified})
+	}
+
+	dsdata, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding dataset: %s", err.Error())
+	}
+
+	dsw, err := create(dsfs.PackageFileDataset.String())
+	if err != nil {
+		return fmt.Errorf("error writing archive: %s", err.Error())
+	}
+	if _, err := dsw.Write(dsdata); err != nil {
+		return fmt.Errorf("error writing archive: %s", err.Error())
+	}
+
+	if ds.Data != "" && ds.Structure != nil {
+		dataw, err := create(fmt.Sprintf("data.%s", ds.Structure.Format.String()))
+		if err != nil {
+			return fmt.Errorf("error writing archive: %s", err.Error())
+		}
+		if err := writeExportData(r, ref, dataw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openData gives a reader of the raw data of a dataset, joining partitioned
+// data like writeExportData. closing the reader stops reading data
+func openData(r repo.Repo, ds *dataset.Dataset) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeExportData(r, &repo.DatasetRef{Dataset: ds}, pw))
+	}()
+	return pr
+}
+
+// writeExportData writes the raw data of a dataset. partitioned data is
+// joined into a single file
+func writeExportData(r repo.Repo, ref *repo.DatasetRef, w io.Writer) error {
+	store := r.Store()
+	ds := ref.Dataset
+
+	parts, err := loadPartitions(store, ds)
+	if err != nil {
+		return err
+	}
+	if parts == nil {
+		file, err := dsfs.LoadData(store, ds)
+		if err != nil {
+			return fmt.Errorf("error loading data: %s", err.Error())
+		}
+		defer file.Close()
+		if _, err := io.Copy(w, file); err != nil {
+			return fmt.Errorf("error writing data: %s", err.Error())
+		}
+		return nil
+	}
+
+	rw, err := dsio.NewRowWriter(ds.Structure, w)
+	if err != nil {
+		return fmt.Errorf("error allocating data writer: %s", err.Error())
+	}
+	err = eachPartitionRow(store, ds.Structure, parts, func(i int, row [][]byte, err error) error {
+		if err != nil {
+			return err
+		}
+		return rw.WriteRow(row)
+	})
+	if err != nil {
+		return fmt.Errorf("error writing data: %s", err.Error())
+	}
+	return rw.Close()
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsExport(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p        *ExportParams
+		filename string
+		files    []string
+		err      string
+	}{
+		{&ExportParams{Name: "not_a_dataset"}, "", nil, "error getting dataset path: repo: not found"},
+		{&ExportParams{Name: "cities"}, "cities.zip", []string{"dataset.json", "data.csv"}, ""},
+		{&ExportParams{Path: citiesPath}, "cities.zip", []string{"dataset.json", "data.csv"}, ""},
+		{&ExportParams{Name: "cities", Writer: &bytes.Buffer{}}, "cities.zip", []string{"dataset.json", "data.csv"}, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	var prev []byte
+	for i, c := range cases {
+		got := &ExportResult{}
+		err := req.Export(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Filename != c.filename {
+			t.Errorf("case %d filename mismatch. expected: %s, got: %s", i, c.filename, got.Filename)
+		}
+
+		data := got.Zip
+		if buf, ok := c.p.Writer.(*bytes.Buffer); ok {
+			if got.Zip != nil {
+				t.Errorf("case %d expected no zip bytes when exporting to a writer", i)
+			}
+			data = buf.Bytes()
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Errorf("case %d error reading zip: %s", i, err.Error())
+			continue
+		}
+		if len(zr.File) != len(c.files) {
+			t.Errorf("case %d file count mismatch. expected: %d, got: %d", i, len(c.files), len(zr.File))
+			continue
+		}
+		for j, f := range zr.File {
+			if f.Name != c.files[j] {
+				t.Errorf("case %d file %d name mismatch. expected: %s, got: %s", i, j, c.files[j], f.Name)
+			}
+		}
+
+		// exports of the same dataset should be identical
+		if prev != nil && !bytes.Equal(prev, data) {
+			t.Errorf("case %d expected archive to match previous export", i)
+		}
+		prev = data
+	}
+}
+
+func TestDatasetRequestsExportHistory(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	// movies has one version, add another
+	req := NewDatasetRequests(mr, nil)
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+	if err := req.Update(&UpdateParams{Changes: &dataset.Dataset{Title: "movies v2", Previous: path}}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error updating dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		history bool
+		files   []string
+	}{
+		{false, []string{"dataset.json", "data.csv"}},
+		{true, []string{"dataset.json", "data.csv", "history/1/dataset.json", "history/1/data.csv"}},
+	}
+
+	for i, c := range cases {
+		got := &ExportResult{}
+		if err := req.Export(&ExportParams{Name: "movies", History: c.history}, got); err != nil {
+			t.Errorf("case %d error exporting: %s", i, err.Error())
+			continue
+		}
+		if got.Filename != "movies.zip" {
+			t.Errorf("case %d filename mismatch. expected: movies.zip, got: %s", i, got.Filename)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(got.Zip), int64(len(got.Zip)))
+		if err != nil {
+			t.Errorf("case %d error reading zip: %s", i, err.Error())
+			continue
+		}
+		if len(zr.File) != len(c.files) {
+			t.Errorf("case %d file count mismatch. expected: %d, got: %d", i, len(c.files), len(zr.File))
+			continue
+		}
+		for j, f := range zr.File {
+			if f.Name != c.files[j] {
+				t.Errorf("case %d file %d name mismatch. expected: %s, got: %s", i, j, c.files[j], f.Name)
+			}
+		}
+	}
+
+	// versioned names export that version
+	got := &ExportResult{}
+	if err := req.Export(&ExportParams{Name: "movies@1"}, got); err != nil {
+		t.Errorf("error exporting version: %s", err.Error())
+		return
+	}
+	if !got.Path.Equal(path) {
+		t.Errorf("versioned export path mismatch. expected: %s, got: %s", path, got.Path)
+	}
+}
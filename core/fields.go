@@ -0,0 +1,51 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset"
+)
+
+// selectFields finds the index of each named field in a schema, returning a
+// schema of just those fields in the order given
+func selectFields(schema *dataset.Schema, names []string) ([]int, *dataset.Schema, error) {
+	if schema == nil {
+		return nil, nil, fmt.Errorf("cannot select fields of a dataset without a schema")
+	}
+
+	indexes := make([]int, len(names))
+	selected := &dataset.Schema{Fields: make([]*dataset.Field, len(names))}
+	for i, name := range names {
+		indexes[i] = -1
+		for j, f := range schema.Fields {
+			if f.Name == name {
+				indexes[i] = j
+				selected.Fields[i] = f
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			valid := make([]string, len(schema.Fields))
+			for j, f := range schema.Fields {
+				valid[j] = f.Name
+			}
+			return nil, nil, fmt.Errorf("invalid field '%s'. valid fields are: %s", name, strings.Join(valid, ", "))
+		}
+	}
+	return indexes, selected, nil
+}
+
+// projectRow gives the cells of row at indexes. indexes past the end of a
+// short row give empty cells
+func projectRow(row [][]byte, indexes []int) [][]byte {
+	projected := make([][]byte, len(indexes))
+	for i, idx := range indexes {
+		if idx < len(row) {
+			projected[i] = row[idx]
+		} else {
+			projected[i] = []byte{}
+		}
+	}
+	return projected
+}
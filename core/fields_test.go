@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestProjectRow(t *testing.T) {
+	row := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	cases := []struct {
+		indexes []int
+		expect  string
+	}{
+		{[]int{}, ""},
+		{[]int{0}, "a"},
+		{[]int{2, 0}, "c,a"},
+		{[]int{1, 1}, "b,b"},
+		{[]int{0, 3}, "a,"},
+	}
+
+	for i, c := range cases {
+		got := projectRow(row, c.indexes)
+		cells := make([]string, len(got))
+		for j, cell := range got {
+			cells[j] = string(cell)
+		}
+		if strings.Join(cells, ",") != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, strings.Join(cells, ","))
+		}
+	}
+}
+
+func TestDatasetRequestsStructuredDataFields(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	filename := testrepo.JobsByAutomationFile.FileName()
+	ref := &repo.DatasetRef{}
+	p := &InitDatasetParams{Name: "jobs", DataFilename: filename, Data: memfs.NewMemfileBytes(filename, testrepo.JobsByAutomationData)}
+	if err := req.InitDataset(p, ref); err != nil {
+		t.Errorf("error initializing jobs dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		fields []string
+		header string
+		err    string
+	}{
+		{[]string{"job_title", "rank"}, "job_title,rank", ""},
+		{[]string{"soc_code", "probability_of_automation"}, "soc_code,probability_of_automation", ""},
+		{[]string{"rank", "salary"}, "", "invalid field 'salary'. valid fields are: rank, probability_of_automation, soc_code, job_title"},
+	}
+
+	for i, c := range cases {
+		got := &StructuredData{}
+		err := req.StructuredData(&StructuredDataParams{Format: dataset.CSVDataFormat, Path: ref.Path, Limit: 5, Fields: c.fields}, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		records, err := csv.NewReader(bytes.NewReader(got.Data.([]byte))).ReadAll()
+		if err != nil {
+			t.Errorf("case %d error parsing csv: %s", i, err.Error())
+			continue
+		}
+		if len(records) != 6 {
+			t.Errorf("case %d row count mismatch. expected: 6, got: %d", i, len(records))
+			continue
+		}
+		if strings.Join(records[0], ",") != c.header {
+			t.Errorf("case %d header mismatch. expected: %s, got: %s", i, c.header, strings.Join(records[0], ","))
+		}
+		for j, rec := range records {
+			if len(rec) != 2 {
+				t.Errorf("case %d row %d expected 2 columns, got: %d", i, j, len(rec))
+			}
+		}
+	}
+}
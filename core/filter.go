@@ -0,0 +1,239 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+)
+
+// FilterOp is a comparison operator for filtering rows of structured data
+type FilterOp string
+
+const (
+	// FilterOpEq matches values equal to the filter value
+	FilterOpEq FilterOp = "eq"
+	// FilterOpNeq matches values not equal to the filter value
+	FilterOpNeq FilterOp = "neq"
+	// FilterOpGt matches values greater than the filter value
+	FilterOpGt FilterOp = "gt"
+	// FilterOpGte matches values greater than or equal to the filter value
+	FilterOpGte FilterOp = "gte"
+	// FilterOpLt matches values less than the filter value
+	FilterOpLt FilterOp = "lt"
+	// FilterOpLte matches values less than or equal to the filter value
+	FilterOpLte FilterOp = "lte"
+)
+
+// Filter is a predicate on a single field of structured data. Cell values
+// are parsed to the type the dataset structure declares for Field before
+// comparison, so numeric, date & boolean fields compare by value.
+// fields of any other type compare lexically
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value string
+}
+
+// FilterError is returned for filters that are malformed or don't apply to
+// the fields of a dataset
+type FilterError struct {
+	msg string
+}
+
+// Error implements the error interface
+func (e *FilterError) Error() string { return e.msg }
+
+func filterErrorf(format string, args ...interface{}) error {
+	return &FilterError{msg: fmt.Sprintf(format, args...)}
+}
+
+// ParseFilter reads a filter from a string of the form field:op:value
+func ParseFilter(str string) (*Filter, error) {
+	parts := strings.SplitN(str, ":", 3)
+	if len(parts) != 3 {
+		return nil, filterErrorf("invalid filter '%s'. filters should be of the form field:op:value", str)
+	}
+	return &Filter{Field: parts[0], Op: FilterOp(parts[1]), Value: parts[2]}, nil
+}
+
+// whereOps maps comparison operators of where clauses to filter operators
+var whereOps = map[string]FilterOp{
+	"=":  FilterOpEq,
+	"!=": FilterOpNeq,
+	">":  FilterOpGt,
+	">=": FilterOpGte,
+	"<":  FilterOpLt,
+	"<=": FilterOpLte,
+}
+
+// ParseWhere reads a filter from a where clause of the form
+// field<op>value, eg: probability_of_automation>0.98. op is one of
+// =, !=, <, >, <= or >=. space around the operator is ignored
+func ParseWhere(str string) (*Filter, error) {
+	start := strings.IndexAny(str, "=!<>")
+	if start < 0 {
+		return nil, filterErrorf("invalid where clause '%s'. clauses should be of the form field<op>value, eg: rank<10", str)
+	}
+	end := start
+	for end < len(str) && strings.IndexByte("=!<>", str[end]) >= 0 {
+		end++
+	}
+
+	field := strings.TrimSpace(str[:start])
+	if field == "" {
+		return nil, filterErrorf("invalid where clause '%s'. clauses should be of the form field<op>value, eg: rank<10", str)
+	}
+	op, ok := whereOps[str[start:end]]
+	if !ok {
+		return nil, filterErrorf("invalid where operator '%s'", str[start:end])
+	}
+	return &Filter{Field: field, Op: op, Value: strings.TrimSpace(str[end:])}, nil
+}
+
+// rowFilter is a Filter bound to a column of a dataset schema
+type rowFilter struct {
+	*Filter
+	index int
+	typ   datatypes.Type
+	value interface{}
+}
+
+// newRowFilters binds filters to the fields of a schema, checking that each
+// filter references a valid field & has a value of the field's type
+func newRowFilters(schema *dataset.Schema, filters []*Filter) ([]*rowFilter, error) {
+	rfs := make([]*rowFilter, len(filters))
+	for i, f := range filters {
+		switch f.Op {
+		case FilterOpEq, FilterOpNeq, FilterOpGt, FilterOpGte, FilterOpLt, FilterOpLte:
+		default:
+			return nil, filterErrorf("invalid filter operator '%s'", f.Op)
+		}
+
+		rf := &rowFilter{Filter: f, index: -1}
+		if schema != nil {
+			for j, field := range schema.Fields {
+				if field.Name == f.Field {
+					rf.index = j
+					rf.typ = field.Type
+					break
+				}
+			}
+		}
+		if rf.index < 0 {
+			return nil, filterErrorf("invalid filter: field '%s' not found", f.Field)
+		}
+
+		val, err := parseTypedValue(rf.typ, f.Value)
+		if err != nil {
+			return nil, filterErrorf("invalid filter value '%s' for field '%s': %s", f.Value, f.Field, err.Error())
+		}
+		rf.value = val
+		rfs[i] = rf
+	}
+	return rfs, nil
+}
+
+// filterRow checks a row against a set of filters. invalid is true if any
+// cell can't be parsed as the type it's field declares, invalid rows never match
+func filterRow(filters []*rowFilter, row [][]byte) (match, invalid bool) {
+	for _, f := range filters {
+		if f.index >= len(row) {
+			return false, true
+		}
+		val, err := parseTypedValue(f.typ, string(row[f.index]))
+		if err != nil {
+			return false, true
+		}
+		if !f.match(compareTypedValues(val, f.value)) {
+			return false, false
+		}
+	}
+	return true, false
+}
+
+// match checks the result of comparing a value to the filter value
+// against the filter operator
+func (f *rowFilter) match(cmp int) bool {
+	switch f.Op {
+	case FilterOpEq:
+		return cmp == 0
+	case FilterOpNeq:
+		return cmp != 0
+	case FilterOpGt:
+		return cmp > 0
+	case FilterOpGte:
+		return cmp >= 0
+	case FilterOpLt:
+		return cmp < 0
+	case FilterOpLte:
+		return cmp <= 0
+	}
+	return false
+}
+
+// dateLayouts are the formats accepted when parsing date values
+var dateLayouts = []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05", "01/02/2006"}
+
+// parseTypedValue parses a string to a go value for the given type.
+// types without a parser are returned as strings
+func parseTypedValue(t datatypes.Type, str string) (interface{}, error) {
+	trimmed := strings.TrimSpace(str)
+	switch t {
+	case datatypes.Integer:
+		if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return float64(i), nil
+		}
+		return strconv.ParseFloat(trimmed, 64)
+	case datatypes.Float:
+		return strconv.ParseFloat(trimmed, 64)
+	case datatypes.Boolean:
+		return strconv.ParseBool(trimmed)
+	case datatypes.Date:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, trimmed); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date")
+	default:
+		return str, nil
+	}
+}
+
+// compareTypedValues compares two values produced by parseTypedValue,
+// returning -1, 0, or 1. a & b must be the same type
+func compareTypedValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case float64:
+		bv := b.(float64)
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+		return 0
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		} else if !av {
+			return -1
+		}
+		return 1
+	case time.Time:
+		bv := b.(time.Time)
+		if av.Before(bv) {
+			return -1
+		} else if av.After(bv) {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	return 0
+}
@@ -0,0 +1,230 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestParseFilter(t *testing.T) {
+	cases := []struct {
+		str string
+		res *Filter
+		err string
+	}{
+		{"", nil, "invalid filter ''. filters should be of the form field:op:value"},
+		{"pop:gt", nil, "invalid filter 'pop:gt'. filters should be of the form field:op:value"},
+		{"pop:gt:100", &Filter{"pop", FilterOpGt, "100"}, ""},
+		{"date:lt:2017-01-01T01:00:00Z", &Filter{"date", FilterOpLt, "2017-01-01T01:00:00Z"}, ""},
+	}
+
+	for i, c := range cases {
+		got, err := ParseFilter(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.res != nil && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %v, got: %v", i, c.res, got)
+		}
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	cases := []struct {
+		str string
+		res *Filter
+		err string
+	}{
+		{"", nil, "invalid where clause ''. clauses should be of the form field<op>value, eg: rank<10"},
+		{"rank", nil, "invalid where clause 'rank'. clauses should be of the form field<op>value, eg: rank<10"},
+		{">10", nil, "invalid where clause '>10'. clauses should be of the form field<op>value, eg: rank<10"},
+		{"rank=>10", nil, "invalid where operator '=>'"},
+		{"rank==10", nil, "invalid where operator '=='"},
+		{"rank!10", nil, "invalid where operator '!'"},
+		{"rank=10", &Filter{"rank", FilterOpEq, "10"}, ""},
+		{"rank != 10", &Filter{"rank", FilterOpNeq, "10"}, ""},
+		{"probability_of_automation>0.98", &Filter{"probability_of_automation", FilterOpGt, "0.98"}, ""},
+		{"rank>=10", &Filter{"rank", FilterOpGte, "10"}, ""},
+		{"rank<10", &Filter{"rank", FilterOpLt, "10"}, ""},
+		{"rank<=10", &Filter{"rank", FilterOpLte, "10"}, ""},
+		{"job_title=", &Filter{"job_title", FilterOpEq, ""}, ""},
+	}
+
+	for i, c := range cases {
+		got, err := ParseWhere(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if err != nil {
+			if _, ok := err.(*FilterError); !ok {
+				t.Errorf("case %d expected a FilterError, got: %T", i, err)
+			}
+			continue
+		}
+		if *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %v, got: %v", i, c.res, got)
+		}
+	}
+}
+
+func TestStructuredDataWhere(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	filename := testrepo.JobsByAutomationFile.FileName()
+	ref := &repo.DatasetRef{}
+	p := &InitDatasetParams{Name: "jobs", DataFilename: filename, Data: memfs.NewMemfileBytes(filename, testrepo.JobsByAutomationData)}
+	if err := req.InitDataset(p, ref); err != nil {
+		t.Errorf("error initializing jobs dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p    *StructuredDataParams
+		rows int
+		err  string
+	}{
+		{&StructuredDataParams{Where: "salary>100"}, 0, "invalid filter: field 'salary' not found"},
+		{&StructuredDataParams{Where: "rank=>100"}, 0, "invalid where operator '=>'"},
+		{&StructuredDataParams{Where: "rank>one"}, 0, "invalid filter value 'one' for field 'rank': strconv.ParseFloat: parsing \"one\": invalid syntax"},
+		{&StructuredDataParams{Where: "probability_of_automation>0.98"}, 12, ""},
+		{&StructuredDataParams{Where: "probability_of_automation>=0.98"}, 30, ""},
+		{&StructuredDataParams{Where: "probability_of_automation<0.99"}, 18, ""},
+		{&StructuredDataParams{Where: "probability_of_automation != 0.99"}, 18, ""},
+		{&StructuredDataParams{Where: "probability_of_automation=0.99", Fields: []string{"job_title"}}, 12, ""},
+		{&StructuredDataParams{Where: "probability_of_automation>0.98", Filters: []*Filter{{"rank", FilterOpGt, "700"}}}, 2, ""},
+	}
+
+	for i, c := range cases {
+		c.p.Format = dataset.JSONDataFormat
+		c.p.Path = ref.Path
+		c.p.All = true
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		rows := []interface{}{}
+		if err := json.Unmarshal(got.Data.(json.RawMessage), &rows); err != nil {
+			t.Errorf("case %d error parsing response data: %s", i, err.Error())
+			continue
+		}
+		if len(rows) != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, len(rows))
+		}
+	}
+}
+
+func TestCompareTypedValues(t *testing.T) {
+	cases := []struct {
+		typ  datatypes.Type
+		a, b string
+		cmp  int
+	}{
+		// lexically "9" > "10", numerically it's not
+		{datatypes.Integer, "9", "10", -1},
+		{datatypes.Integer, " 10", "10", 0},
+		{datatypes.Float, "0.99", "0.098", 1},
+		{datatypes.Boolean, "false", "true", -1},
+		{datatypes.Boolean, "TRUE", "true", 0},
+		{datatypes.Date, "2017-01-02", "2017-01-01T01:00:00Z", 1},
+		{datatypes.String, "9", "10", 1},
+		{datatypes.String, "a ", "a", 1},
+	}
+
+	for i, c := range cases {
+		a, err := parseTypedValue(c.typ, c.a)
+		if err != nil {
+			t.Errorf("case %d error parsing '%s': %s", i, c.a, err.Error())
+			continue
+		}
+		b, err := parseTypedValue(c.typ, c.b)
+		if err != nil {
+			t.Errorf("case %d error parsing '%s': %s", i, c.b, err.Error())
+			continue
+		}
+		if got := compareTypedValues(a, b); got != c.cmp {
+			t.Errorf("case %d comparison mismatch. expected: %d, got: %d", i, c.cmp, got)
+		}
+	}
+}
+
+func TestStructuredDataFilters(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p       *StructuredDataParams
+		rows    int
+		invalid int
+		err     string
+	}{
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"not_a_field", FilterOpEq, "1"}}}, 0, 0, "invalid filter: field 'not_a_field' not found"},
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"pop", "bad_op", "1"}}}, 0, 0, "invalid filter operator 'bad_op'"},
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"pop", FilterOpGt, "one"}}}, 0, 0, "invalid filter value 'one' for field 'pop': strconv.ParseFloat: parsing \"one\": invalid syntax"},
+		// a lexical comparison would match all 5 cities
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"pop", FilterOpGt, "1000000"}}}, 2, 0, ""},
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"avg_age", FilterOpLt, "50"}}}, 2, 0, ""},
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"in_usa", FilterOpEq, "true"}}}, 4, 0, ""},
+		{&StructuredDataParams{Path: citiesPath, Limit: 50, Filters: []*Filter{{"in_usa", FilterOpEq, "true"}, {"avg_age", FilterOpGte, "50"}}}, 2, 0, ""},
+		{&StructuredDataParams{Path: citiesPath, Limit: 2, Offset: 3, Filters: []*Filter{{"in_usa", FilterOpEq, "true"}}}, 1, 0, ""},
+		{&StructuredDataParams{Path: citiesPath, All: true, Filters: []*Filter{{"city", FilterOpEq, "toronto"}}}, 1, 0, ""},
+		// movies with an empty duration are invalid
+		{&StructuredDataParams{Path: moviesPath, Limit: 100, Filters: []*Filter{{"duration", FilterOpGt, "180"}}}, 66, 15, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		c.p.Format = dataset.JSONDataFormat
+		got := &StructuredData{}
+		err := req.StructuredData(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		rows := []interface{}{}
+		if err := json.Unmarshal(got.Data.(json.RawMessage), &rows); err != nil {
+			t.Errorf("case %d error parsing response data: %s", i, err.Error())
+			continue
+		}
+		if len(rows) != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, len(rows))
+		}
+		if got.Invalid != c.invalid {
+			t.Errorf("case %d invalid count mismatch. expected: %d, got: %d", i, c.invalid, got.Invalid)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+)
+
+// GCParams defines parameters for collecting garbage
+type GCParams struct {
+	// Depth is the number of versions behind the head of each named dataset
+	// to keep. heads are always kept
+	Depth int
+	// Confirm must be set to unpin & remove anything. unconfirmed collection
+	// only reports the versions that would be unpinned
+	Confirm bool
+}
+
+// GCResult reports the outcome of collecting garbage
+type GCResult struct {
+	// Unpinned lists root paths of the dataset versions that were unpinned,
+	// or would be unpinned if collection wasn't confirmed
+	Unpinned []string
+	// Blocks & Bytes count the data removed from the store
+	Blocks int
+	Bytes  int64
+}
+
+// GC unpins dataset versions that are more than Depth versions behind the
+// head of every named dataset they belong to, along with versions no name
+// refers to, then removes unpinned blocks from the store if the repo
+// supports it
+func (r *DatasetRequests) GC(p *GCParams, res *GCResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.GC", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	if p.Depth < 0 {
+		return fmt.Errorf("depth can't be negative")
+	}
+	pinner, ok := r.repo.Store().(cafs.Pinner)
+	if !ok {
+		return fmt.Errorf("can only collect garbage when running a store that supports pinning")
+	}
+
+	named, err := namedVersions(r.repo)
+	if err != nil {
+		return err
+	}
+	candidates, err := orphanPaths(r.repo, named)
+	if err != nil {
+		return err
+	}
+	for path, depth := range named {
+		if depth > p.Depth {
+			candidates = append(candidates, path)
+		}
+	}
+	sort.Strings(candidates)
+
+	store := r.repo.Store()
+	unpin := []string{}
+	for _, path := range candidates {
+		// versions removed by an earlier collection can linger in the repo graph
+		has, err := store.Has(datastore.NewKey(path))
+		if err != nil {
+			return fmt.Errorf("error checking store for dataset %s: %s", path, err.Error())
+		}
+		if has {
+			unpin = append(unpin, path)
+		}
+	}
+
+	result := GCResult{Unpinned: unpin}
+	if !p.Confirm {
+		*res = result
+		return nil
+	}
+
+	for _, path := range unpin {
+		if err := pinner.Unpin(datastore.NewKey(path), true); err != nil {
+			return fmt.Errorf("error unpinning dataset %s: %s", path, err.Error())
+		}
+	}
+	if gc, ok := r.repo.(repo.GarbageCollector); ok {
+		if result.Blocks, result.Bytes, err = gc.CollectGarbage(); err != nil {
+			return err
+		}
+	}
+
+	*res = result
+	return nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsGC(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	pr := &pinRepo{Repo: mr, store: &pinStore{Filestore: mr.Store()}}
+	req := NewDatasetRequests(pr, nil)
+
+	// movies has one version, add two more
+	versions := []string{}
+	for _, title := range []string{"movies v2", "movies v3"} {
+		path, err := mr.GetPath("movies")
+		if err != nil {
+			t.Errorf("error getting path: %s", err.Error())
+			return
+		}
+		versions = append([]string{ref.RootPath(path.String())}, versions...)
+		if err := req.Update(&UpdateParams{Changes: &dataset.Dataset{Title: title, Previous: path}}, &repo.DatasetRef{}); err != nil {
+			t.Errorf("error updating dataset: %s", err.Error())
+			return
+		}
+	}
+
+	if err := NewDatasetRequests(mr, nil).GC(&GCParams{Confirm: true}, &GCResult{}); err == nil || err.Error() != "can only collect garbage when running a store that supports pinning" {
+		t.Errorf("expected store without pinning to error, got: %v", err)
+	}
+
+	cases := []struct {
+		p        *GCParams
+		unpinned []string
+		err      string
+	}{
+		{&GCParams{Depth: -1}, nil, "depth can't be negative"},
+		{&GCParams{Depth: 2, Confirm: true}, []string{}, ""},
+		{&GCParams{Depth: 1, Confirm: true}, versions[1:], ""},
+		{&GCParams{Depth: 0}, versions, ""},
+		{&GCParams{Depth: 0, Confirm: true}, versions, ""},
+	}
+
+	for i, c := range cases {
+		pr.store.unpinned = nil
+		got := &GCResult{}
+		err := req.GC(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if len(got.Unpinned) != len(c.unpinned) {
+			t.Errorf("case %d unpinned length mismatch. expected: %d, got: %d", i, len(c.unpinned), len(got.Unpinned))
+			continue
+		}
+		expect := map[string]bool{}
+		for _, path := range c.unpinned {
+			expect[path] = true
+		}
+		for _, path := range got.Unpinned {
+			if !expect[path] {
+				t.Errorf("case %d unexpected unpinned path: %s", i, path)
+			}
+		}
+
+		if c.p.Confirm && len(pr.store.unpinned) != len(c.unpinned) {
+			t.Errorf("case %d expected %d unpins, got: %d", i, len(c.unpinned), len(pr.store.unpinned))
+		}
+		if !c.p.Confirm && len(pr.store.unpinned) != 0 {
+			t.Errorf("case %d expected unconfirmed collection not to unpin, got: %d unpins", i, len(pr.store.unpinned))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// gzipMagic are the leading bytes of gzip-compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gunzipData transparently decompresses gzipped data, detected either by a
+// .gz filename extension or by sniffing the leading bytes of rdr. the
+// returned filename drops any .gz extension so the inner format can be
+// detected. data that isn't gzipped is returned as-is
+func gunzipData(rdr io.Reader, filename string) (io.Reader, string, error) {
+	suffix := strings.ToLower(filepath.Ext(filename)) == ".gz"
+
+	magic := make([]byte, len(gzipMagic))
+	if rs, ok := rdr.(io.ReadSeeker); ok {
+		// files can be read in place by spoolData, so avoid wrapping them
+		// unless they're actually gzipped
+		offset, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, filename, fmt.Errorf("error reading data: %s", err.Error())
+		}
+		n, _ := io.ReadFull(rs, magic)
+		magic = magic[:n]
+		if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+			return nil, filename, fmt.Errorf("error reading data: %s", err.Error())
+		}
+	} else {
+		br := bufio.NewReader(rdr)
+		magic, _ = br.Peek(len(gzipMagic))
+		rdr = br
+	}
+
+	if !suffix && !bytes.Equal(magic, gzipMagic) {
+		return rdr, filename, nil
+	}
+
+	zr, err := gzip.NewReader(rdr)
+	if err != nil {
+		return nil, filename, fmt.Errorf("error reading gzip data: %s", err.Error())
+	}
+	if suffix {
+		filename = filename[:len(filename)-len(".gz")]
+	}
+	return zr, filename, nil
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("error compressing data: %s", err.Error())
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("error compressing data: %s", err.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestGunzipData(t *testing.T) {
+	data := []byte("a,b\n1,2\n")
+	gz := gzipBytes(t, data)
+
+	cases := []struct {
+		filename, inner string
+		in, out         []byte
+		err             string
+	}{
+		{"data.csv", "data.csv", data, data, ""},
+		{"data.csv.gz", "data.csv", gz, data, ""},
+		{"data.csv.GZ", "data.csv", gz, data, ""},
+		{"data.csv", "data.csv", gz, data, ""},
+		{"data.csv.gz", "data.csv.gz", []byte("a,b,c\n1,2,3\n4,5,6\n"), nil, "error reading gzip data: gzip: invalid header"},
+	}
+
+	for i, c := range cases {
+		rdr, inner, err := gunzipData(bytes.NewBuffer(c.in), c.filename)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if inner != c.inner {
+			t.Errorf("case %d filename mismatch. expected: %s, got: %s", i, c.inner, inner)
+		}
+		got, err := ioutil.ReadAll(rdr)
+		if err != nil {
+			t.Errorf("case %d error reading data: %s", i, err.Error())
+			continue
+		}
+		if !bytes.Equal(got, c.out) {
+			t.Errorf("case %d data mismatch. expected: %q, got: %q", i, string(c.out), string(got))
+		}
+	}
+}
+
+func TestDatasetRequestsInitGzip(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	gz := gzipBytes(t, testrepo.JobsByAutomationData)
+	filename := testrepo.JobsByAutomationFile.FileName()
+
+	cases := []struct {
+		name, filename string
+	}{
+		// detected by extension
+		{"jobs_gz", filename + ".gz"},
+		// detected by sniffing
+		{"jobs_sniffed", filename},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		// datasets with the same data can't be added twice, start fresh
+		if i > 0 {
+			if err := req.Delete(&DeleteParams{Name: cases[i-1].name}, new(bool)); err != nil {
+				t.Errorf("case %d error deleting dataset: %s", i, err.Error())
+				continue
+			}
+		}
+
+		got := &repo.DatasetRef{}
+		p := &InitDatasetParams{Name: c.name, DataFilename: c.filename, Data: memfs.NewMemfileBytes(c.filename, gz)}
+		if err := req.InitDataset(p, got); err != nil {
+			t.Errorf("case %d error initializing dataset: %s", i, err.Error())
+			continue
+		}
+
+		f, err := mr.Store().Get(datastore.NewKey(got.Dataset.Data))
+		if err != nil {
+			t.Errorf("case %d error getting data: %s", i, err.Error())
+			continue
+		}
+		stored, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Errorf("case %d error reading data: %s", i, err.Error())
+			continue
+		}
+		if !bytes.Equal(stored, testrepo.JobsByAutomationData) {
+			t.Errorf("case %d expected decompressed data to be stored", i)
+		}
+		if got.Dataset.Length != len(testrepo.JobsByAutomationData) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(testrepo.JobsByAutomationData), got.Dataset.Length)
+		}
+	}
+}
+
+func TestURLFilename(t *testing.T) {
+	cases := []struct {
+		url, filename string
+	}{
+		{"http://example.com/data.csv", "data.csv"},
+		{"http://example.com/jobs/data.csv.gz", "data.csv.gz"},
+		{"http://example.com/data.csv.gz?raw=true", "data.csv.gz"},
+		{"http://example.com/data.json#rows", "data.json"},
+	}
+
+	for i, c := range cases {
+		if got := urlFilename(c.url); got != c.filename {
+			t.Errorf("case %d filename mismatch. expected: %s, got: %s", i, c.filename, got)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"net/rpc"
 
 	"github.com/ipfs/go-datastore"
-	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/repo"
 )
 
@@ -14,6 +13,8 @@ import (
 type HistoryRequests struct {
 	repo repo.Repo
 	cli  *rpc.Client
+	// publicOnly hides private datasets, see NewPublicHistoryRequests
+	publicOnly bool
 }
 
 // CoreRequestsName implements the Requets interface
@@ -27,6 +28,7 @@ func NewHistoryRequests(r repo.Repo, cli *rpc.Client) *HistoryRequests {
 	}
 	return &HistoryRequests{
 		repo: r,
+		cli:  cli,
 	}
 }
 
@@ -37,36 +39,169 @@ type LogParams struct {
 	Path datastore.Key
 }
 
-// Log returns the history of changes for a given dataset
+// Log returns the history of changes for a given dataset, newest first.
+// Offset skips that many versions, a Limit of zero or less returns all
+// remaining versions
 func (d *HistoryRequests) Log(params *LogParams, res *[]*repo.DatasetRef) (err error) {
 	if d.cli != nil {
 		return d.cli.Call("HistoryRequests.Log", params, res)
 	}
 
-	log := []*repo.DatasetRef{}
-	limit := params.Limit
-	ref := &repo.DatasetRef{Path: params.Path}
+	page := &LogPage{}
+	if err := d.LogPage(params, page); err != nil {
+		return err
+	}
+	*res = page.Log
+	return nil
+}
+
+// LogPage is a page of a dataset's history
+type LogPage struct {
+	Log []*repo.DatasetRef `json:"log"`
+	// Total is the number of versions in the full history. it's only known
+	// when the history ends within the requested page, and is zero otherwise
+	Total int `json:"total,omitempty"`
+}
+
+// LogPage returns a page of the history of changes for a given dataset,
+// like Log, with the length of the history if the page reaches it's end
+func (d *HistoryRequests) LogPage(params *LogParams, res *LogPage) (err error) {
+	if d.cli != nil {
+		return d.cli.Call("HistoryRequests.LogPage", params, res)
+	}
 
 	if params.Path.String() == "" {
 		return fmt.Errorf("path is required")
 	}
+	params.EnsureDefaults()
 
-	for {
-		ref.Dataset, err = dsfs.LoadDataset(d.repo.Store(), ref.Path)
+	path, err := resolvePath(d.repo, params.Path)
+	if err != nil {
+		return err
+	}
+	if err := d.checkVisible(path); err != nil {
+		return err
+	}
+
+	var (
+		log = []*repo.DatasetRef{}
+		// seen guards against corrupt histories that point back into themselves
+		seen = map[string]bool{}
+	)
+	for i := 0; ; i++ {
+		if seen[path.String()] {
+			return fmt.Errorf("dataset history has a cycle at %s", path.String())
+		}
+		seen[path.String()] = true
+
+		ds, err := loadDataset(d.repo, path)
 		if err != nil {
 			return err
 		}
-		log = append(log, ref)
+		if i >= params.Offset {
+			log = append(log, &repo.DatasetRef{Path: path, Dataset: ds})
+		}
 
-		limit--
-		if limit == 0 || ref.Dataset.Previous.String() == "" {
+		if ds.Previous.String() == "" || ds.Previous.String() == "/" {
+			*res = LogPage{Log: log, Total: i + 1}
+			return nil
+		}
+		if params.Limit > 0 && len(log) == params.Limit {
 			break
 		}
-		// TODO - clean this up
-		_, cleaned := dsfs.RefType(ref.Dataset.Previous.String())
-		ref = &repo.DatasetRef{Path: datastore.NewKey(cleaned)}
+		path = previousPath(ds)
 	}
 
-	*res = log
+	*res = LogPage{Log: log}
 	return nil
 }
+
+// Descendants returns the versions that came after the dataset at
+// params.Path, oldest first, ending with the current head of a name. an empty
+// result means params.Path is a current head. repo.ErrNotFound is returned if
+// params.Path isn't in the history of any named dataset
+func (d *HistoryRequests) Descendants(params *LogParams, res *[]*repo.DatasetRef) (err error) {
+	if d.cli != nil {
+		return d.cli.Call("HistoryRequests.Descendants", params, res)
+	}
+
+	// empty paths decode as "/"
+	if params.Path.String() == "" || params.Path.String() == "/" {
+		return fmt.Errorf("path is required")
+	}
+
+	path, err := resolvePath(d.repo, params.Path)
+	if err != nil {
+		return err
+	}
+	if err := d.checkVisible(path); err != nil {
+		return err
+	}
+
+	count, err := d.repo.NameCount()
+	if err != nil {
+		return fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	heads, err := d.repo.Namespace(count, 0)
+	if err != nil {
+		return fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+
+	for _, head := range heads {
+		if d.publicOnly {
+			// public versions can be continued by private datasets
+			if v, err := repo.NameVisibility(d.repo, head.Name); err != nil {
+				return fmt.Errorf("error checking dataset visibility: %s", err.Error())
+			} else if v == repo.VisibilityPrivate {
+				continue
+			}
+		}
+		chain, err := d.descendantsFrom(head, path)
+		if err != nil {
+			return err
+		}
+		if chain != nil {
+			*res = chain
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+// descendantsFrom walks back from head looking for path, returning the
+// versions after path oldest first, or nil if path isn't in head's history
+func (d *HistoryRequests) descendantsFrom(head *repo.DatasetRef, path datastore.Key) ([]*repo.DatasetRef, error) {
+	var (
+		newer = []*repo.DatasetRef{}
+		seen  = map[string]bool{}
+		at    = head.Path
+	)
+	for {
+		if at.Equal(path) {
+			// newer was collected newest first
+			for i, j := 0, len(newer)-1; i < j; i, j = i+1, j-1 {
+				newer[i], newer[j] = newer[j], newer[i]
+			}
+			return newer, nil
+		}
+		if seen[at.String()] {
+			return nil, fmt.Errorf("dataset history has a cycle at %s", at.String())
+		}
+		seen[at.String()] = true
+
+		ds, err := loadDataset(d.repo, at)
+		if err != nil {
+			return nil, err
+		}
+		ref := &repo.DatasetRef{Path: at, Dataset: ds}
+		if len(newer) == 0 {
+			ref.Name = head.Name
+		}
+		newer = append(newer, ref)
+
+		if ds.Previous.String() == "" || ds.Previous.String() == "/" {
+			return nil, nil
+		}
+		at = previousPath(ds)
+	}
+}
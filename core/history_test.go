@@ -1,9 +1,12 @@
 package core
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/repo"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
@@ -28,6 +31,10 @@ func TestHistoryRequestsLog(t *testing.T) {
 		{&LogParams{}, nil, "path is required"},
 		{&LogParams{Path: datastore.NewKey("/badpath")}, nil, "error getting file bytes: datastore: key not found"},
 		{&LogParams{Path: path}, []*repo.DatasetRef{&repo.DatasetRef{Path: path}}, ""},
+		{&LogParams{Path: path, ListParams: ListParams{Limit: 1}}, []*repo.DatasetRef{&repo.DatasetRef{Path: path}}, ""},
+		{&LogParams{Path: path, ListParams: ListParams{Offset: 1}}, []*repo.DatasetRef{}, ""},
+		{&LogParams{Path: path, ListParams: ListParams{Limit: 0, Offset: -1}}, []*repo.DatasetRef{&repo.DatasetRef{Path: path}}, ""},
+		{&LogParams{Path: path, ListParams: ListParams{Limit: -1}}, []*repo.DatasetRef{&repo.DatasetRef{Path: path}}, ""},
 	}
 
 	req := NewHistoryRequests(mr, nil)
@@ -41,8 +48,233 @@ func TestHistoryRequestsLog(t *testing.T) {
 		}
 
 		if len(c.res) != len(got) {
-			t.Errorf("case %d log count mismatch. expected: %d, got: %d", len(c.res), len(got))
+			t.Errorf("case %d log count mismatch. expected: %d, got: %d", i, len(c.res), len(got))
 			continue
 		}
 	}
 }
+
+func TestHistoryRequestsLogRPC(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	cli := newTestRPCClient(t, NewHistoryRequests(mr, nil))
+	defer cli.Close()
+
+	req := NewHistoryRequests(nil, cli)
+	got := []*repo.DatasetRef{}
+	if err := req.Log(&LogParams{Path: path}, &got); err != nil {
+		t.Errorf("error getting log over rpc: %s", err.Error())
+		return
+	}
+	if len(got) != 1 {
+		t.Errorf("log count mismatch. expected: 1, got: %d", len(got))
+		return
+	}
+	if !got[0].Path.Equal(path) {
+		t.Errorf("log path mismatch. expected: %s, got: %s", path, got[0].Path)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected supplying both a repo & client to panic")
+		}
+	}()
+	NewHistoryRequests(mr, cli)
+}
+
+func TestHistoryRequestsLogPage(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p     *LogParams
+		count int
+		total int
+		err   string
+	}{
+		{&LogParams{}, 0, 0, "path is required"},
+		{&LogParams{Path: path}, 1, 1, ""},
+		{&LogParams{Path: path, ListParams: ListParams{Limit: 10}}, 1, 1, ""},
+		{&LogParams{Path: path, ListParams: ListParams{Limit: 10, Offset: 5}}, 0, 1, ""},
+	}
+
+	req := NewHistoryRequests(mr, nil)
+	for i, c := range cases {
+		got := &LogPage{}
+		err := req.LogPage(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+
+		if len(got.Log) != c.count {
+			t.Errorf("case %d log count mismatch. expected: %d, got: %d", i, c.count, len(got.Log))
+		}
+		if got.Total != c.total {
+			t.Errorf("case %d total mismatch. expected: %d, got: %d", i, c.total, got.Total)
+		}
+	}
+}
+
+func TestHistoryRequestsLogOffset(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	// movies has one version, add four more. versions lists paths newest-first
+	dsr := NewDatasetRequests(mr, nil)
+	for i := 2; i <= 5; i++ {
+		path, err := mr.GetPath("movies")
+		if err != nil {
+			t.Errorf("error getting path: %s", err.Error())
+			return
+		}
+		if err := dsr.Update(&UpdateParams{Changes: &dataset.Dataset{Title: fmt.Sprintf("movies v%d", i), Previous: path}}, &repo.DatasetRef{}); err != nil {
+			t.Errorf("error updating dataset: %s", err.Error())
+			return
+		}
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	req := NewHistoryRequests(mr, nil)
+	versions := []*repo.DatasetRef{}
+	if err := req.Log(&LogParams{Path: path}, &versions); err != nil {
+		t.Errorf("error getting log: %s", err.Error())
+		return
+	}
+	if len(versions) != 5 {
+		t.Errorf("expected 5 versions, got: %d", len(versions))
+		return
+	}
+
+	cases := []struct {
+		limit, offset int
+		expect        []int
+	}{
+		{2, 0, []int{0, 1}},
+		{2, 2, []int{2, 3}},
+		{2, 4, []int{4}},
+		{0, 3, []int{3, 4}},
+		{2, 5, []int{}},
+	}
+
+	for i, c := range cases {
+		got := []*repo.DatasetRef{}
+		if err := req.Log(&LogParams{Path: path, ListParams: ListParams{Limit: c.limit, Offset: c.offset}}, &got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got) != len(c.expect) {
+			t.Errorf("case %d log count mismatch. expected: %d, got: %d", i, len(c.expect), len(got))
+			continue
+		}
+		for j, idx := range c.expect {
+			if !got[j].Path.Equal(versions[idx].Path) {
+				t.Errorf("case %d entry %d path mismatch. expected: %s, got: %s", i, j, versions[idx].Path, got[j].Path)
+			}
+		}
+	}
+}
+
+func TestHistoryRequestsDescendants(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	// movies has one version, add two more
+	dsr := NewDatasetRequests(mr, nil)
+	for i := 2; i <= 3; i++ {
+		path, err := mr.GetPath("movies")
+		if err != nil {
+			t.Errorf("error getting path: %s", err.Error())
+			return
+		}
+		if err := dsr.Update(&UpdateParams{Changes: &dataset.Dataset{Title: fmt.Sprintf("movies v%d", i), Previous: path}}, &repo.DatasetRef{}); err != nil {
+			t.Errorf("error updating dataset: %s", err.Error())
+			return
+		}
+	}
+	head, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	req := NewHistoryRequests(mr, nil)
+	versions := []*repo.DatasetRef{}
+	if err := req.Log(&LogParams{Path: head}, &versions); err != nil {
+		t.Errorf("error getting log: %s", err.Error())
+		return
+	}
+	v1, v2, v3 := versions[2].Path, versions[1].Path, versions[0].Path
+
+	// a dataset that no name refers to
+	orphan := &repo.DatasetRef{}
+	if err := dsr.InitDataset(&InitDatasetParams{Name: "orphan", DataFilename: "orphan.csv", Data: memfs.NewMemfileBytes("orphan.csv", []byte("a,b\n1,2\n"))}, orphan); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	if err := mr.DeleteName("orphan"); err != nil {
+		t.Errorf("error deleting name: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		path   datastore.Key
+		expect []datastore.Key
+		err    string
+	}{
+		{datastore.NewKey(""), nil, "path is required"},
+		{orphan.Path, nil, repo.ErrNotFound.Error()},
+		{v1, []datastore.Key{v2, v3}, ""},
+		{v2, []datastore.Key{v3}, ""},
+		{v3, []datastore.Key{}, ""},
+	}
+
+	for i, c := range cases {
+		got := []*repo.DatasetRef{}
+		err := req.Descendants(&LogParams{Path: c.path}, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != len(c.expect) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.expect), len(got))
+			continue
+		}
+		for j, path := range c.expect {
+			if !got[j].Path.Equal(path) {
+				t.Errorf("case %d version %d path mismatch. expected: %s, got: %s", i, j, path, got[j].Path)
+			}
+		}
+		if len(got) > 0 && got[len(got)-1].Name != "movies" {
+			t.Errorf("case %d expected the head to be named movies, got: '%s'", i, got[len(got)-1].Name)
+		}
+	}
+}
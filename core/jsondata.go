@@ -0,0 +1,281 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+)
+
+// isJSONLines reports whether filename names newline-delimited json data
+func isJSONLines(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".jsonl" || ext == ".ndjson"
+}
+
+// jsonLinesToArray reads newline-delimited json, one value per line, as a
+// json array of those values. blank lines are skipped. lines that aren't
+// valid json fail the read
+func jsonLinesToArray(rdr io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		sc := bufio.NewScanner(rdr)
+		sc.Buffer(make([]byte, 64*1024), detectPrefixSize)
+
+		w.WriteByte('[')
+		line, rows := 0, 0
+		for sc.Scan() {
+			line++
+			val := bytes.TrimSpace(sc.Bytes())
+			if len(val) == 0 {
+				continue
+			}
+			if !json.Valid(val) {
+				pw.CloseWithError(fmt.Errorf("invalid json on line %d", line))
+				return
+			}
+			if rows > 0 {
+				w.WriteByte(',')
+			}
+			w.Write(val)
+			rows++
+		}
+		if err := sc.Err(); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		w.WriteByte(']')
+		pw.CloseWithError(w.Flush())
+	}()
+	return pr
+}
+
+// jsonArrayFilename swaps the extension of a json lines filename for .json
+func jsonArrayFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".json"
+}
+
+// validateJSONRecords checks that the records of json array data are
+// consistent. records must all be arrays or all be objects. arrays must be
+// the same length as the first record, objects can leave out keys of the
+// first record but can't add new ones. data that isn't an array is left
+// alone
+func validateJSONRecords(rdr io.Reader) error {
+	dec := json.NewDecoder(rdr)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil
+	}
+
+	var (
+		kind   byte
+		length int
+		keys   map[string]bool
+	)
+	for row := 0; dec.More(); row++ {
+		raw := json.RawMessage{}
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("invalid data format: error reading row %d: %s", row, err.Error())
+		}
+		rec := bytes.TrimSpace(raw)
+		if len(rec) == 0 || (rec[0] != '[' && rec[0] != '{') {
+			return fmt.Errorf("invalid data format: row %d isn't an array or object", row)
+		}
+		if row == 0 {
+			kind = rec[0]
+		} else if rec[0] != kind {
+			return fmt.Errorf("invalid data format: row %d is a different kind of record than row 0", row)
+		}
+
+		if rec[0] == '[' {
+			vals := []json.RawMessage{}
+			if err := json.Unmarshal(rec, &vals); err != nil {
+				return fmt.Errorf("invalid data format: error reading row %d: %s", row, err.Error())
+			}
+			if row == 0 {
+				length = len(vals)
+			} else if len(vals) != length {
+				return fmt.Errorf("invalid data format: row %d has %d fields, expected %d", row, len(vals), length)
+			}
+			continue
+		}
+
+		obj := map[string]json.RawMessage{}
+		if err := json.Unmarshal(rec, &obj); err != nil {
+			return fmt.Errorf("invalid data format: error reading row %d: %s", row, err.Error())
+		}
+		if row == 0 {
+			keys = map[string]bool{}
+			for key := range obj {
+				keys[key] = true
+			}
+			continue
+		}
+		for key := range obj {
+			if !keys[key] {
+				return fmt.Errorf("invalid data format: row %d has unexpected field '%s'", row, key)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonObjectFields lists fields named by the keys of the first record in
+// json array data, in the order keys appear. ok is false if data doesn't
+// start with an object record
+func jsonObjectFields(data []byte) (fields []*dataset.Field, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, false
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, isKey := tok.(string)
+		if !isKey {
+			return nil, false
+		}
+		val := json.RawMessage{}
+		if err := dec.Decode(&val); err != nil {
+			return nil, false
+		}
+		fields = append(fields, &dataset.Field{Name: key, Type: jsonValueType(val)})
+	}
+	return fields, len(fields) > 0
+}
+
+// jsonValueType gives the datatype of a raw json value
+func jsonValueType(val json.RawMessage) datatypes.Type {
+	val = bytes.TrimSpace(val)
+	if len(val) == 0 {
+		return datatypes.Any
+	}
+	switch val[0] {
+	case '"':
+		return datatypes.String
+	case '{', '[':
+		return datatypes.JSON
+	case 't', 'f':
+		return datatypes.Boolean
+	case 'n':
+		return datatypes.Any
+	}
+	if bytes.ContainsAny(val, ".eE") {
+		return datatypes.Float
+	}
+	return datatypes.Integer
+}
+
+// NDJSONContentType is the media type of newline-delimited json
+const NDJSONContentType = "application/x-ndjson"
+
+// jsonLinesFlushRows is the number of rows written between flushes of
+// writers that support flushing
+const jsonLinesFlushRows = 100
+
+// jsonLinesWriter writes rows as newline-delimited json objects keyed by
+// field name. writers that implement http.Flusher are flushed as rows are
+// written, so rows reach readers before all data has been read
+type jsonLinesWriter struct {
+	w      io.Writer
+	fields []*dataset.Field
+	keys   [][]byte
+	rows   int
+	buf    bytes.Buffer
+}
+
+func newJSONLinesWriter(schema *dataset.Schema, w io.Writer) (*jsonLinesWriter, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("json lines output requires a schema")
+	}
+	jw := &jsonLinesWriter{w: w, fields: schema.Fields, keys: make([][]byte, len(schema.Fields))}
+	for i, f := range schema.Fields {
+		key, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		jw.keys[i] = key
+	}
+	return jw, nil
+}
+
+// WriteRow writes a row as a single line json object
+func (jw *jsonLinesWriter) WriteRow(row [][]byte) error {
+	jw.buf.Reset()
+	jw.buf.WriteByte('{')
+	for i, f := range jw.fields {
+		if i > 0 {
+			jw.buf.WriteByte(',')
+		}
+		jw.buf.Write(jw.keys[i])
+		jw.buf.WriteByte(':')
+		var cell []byte
+		if i < len(row) {
+			cell = row[i]
+		}
+		jw.buf.Write(jsonCell(f.Type, cell))
+	}
+	jw.buf.WriteString("}\n")
+	if _, err := jw.w.Write(jw.buf.Bytes()); err != nil {
+		return err
+	}
+
+	jw.rows++
+	if jw.rows%jsonLinesFlushRows == 0 {
+		jw.flush()
+	}
+	return nil
+}
+
+// Close flushes any rows that haven't been flushed
+func (jw *jsonLinesWriter) Close() error {
+	jw.flush()
+	return nil
+}
+
+func (jw *jsonLinesWriter) flush() {
+	if f, ok := jw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// jsonCell encodes a cell as a json value of the type it's field declares.
+// cells that aren't valid values of their type are encoded as strings, empty
+// cells of any type but string are null
+func jsonCell(t datatypes.Type, cell []byte) []byte {
+	trimmed := bytes.TrimSpace(cell)
+	if len(trimmed) == 0 && t != datatypes.String {
+		return []byte("null")
+	}
+
+	switch t {
+	case datatypes.Integer, datatypes.Float:
+		if _, err := strconv.ParseFloat(string(trimmed), 64); err == nil && json.Valid(trimmed) {
+			return trimmed
+		}
+	case datatypes.Boolean:
+		if b, err := strconv.ParseBool(string(trimmed)); err == nil {
+			return []byte(strconv.FormatBool(b))
+		}
+	case datatypes.JSON:
+		if json.Valid(trimmed) {
+			return trimmed
+		}
+	}
+	str, _ := json.Marshal(string(cell))
+	return str
+}
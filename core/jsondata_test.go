@@ -0,0 +1,309 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestJSONLinesToArray(t *testing.T) {
+	cases := []struct {
+		in, out string
+		err     string
+	}{
+		{"", "[]", ""},
+		{"{\"a\":1}\n", `[{"a":1}]`, ""},
+		{"{\"a\":1}\n\n  {\"a\":2}  \n[3]", `[{"a":1},{"a":2},[3]]`, ""},
+		{"{\"a\":1}\n{\"a\":\n", "", "invalid json on line 2"},
+	}
+
+	for i, c := range cases {
+		got, err := ioutil.ReadAll(jsonLinesToArray(strings.NewReader(c.in)))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && string(got) != c.out {
+			t.Errorf("case %d output mismatch. expected: %s, got: %s", i, c.out, string(got))
+		}
+	}
+}
+
+func TestValidateJSONRecords(t *testing.T) {
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{`{"a":1}`, ""},
+		{`[]`, ""},
+		{`[{"a":1,"b":2},{"a":3},{"b":4,"a":5}]`, ""},
+		{`[[1,2],[3,4]]`, ""},
+		{`[{"a":1},{"a":2,"c":3}]`, "invalid data format: row 1 has unexpected field 'c'"},
+		{`[[1,2],[3,4,5]]`, "invalid data format: row 1 has 3 fields, expected 2"},
+		{`[[1,2],{"a":1}]`, "invalid data format: row 1 is a different kind of record than row 0"},
+		{`[1,2]`, "invalid data format: row 0 isn't an array or object"},
+	}
+
+	for i, c := range cases {
+		err := validateJSONRecords(strings.NewReader(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+}
+
+func TestJSONObjectFields(t *testing.T) {
+	cases := []struct {
+		data   string
+		fields []*dataset.Field
+	}{
+		{`[[1,2]]`, nil},
+		{`{"a":1}`, nil},
+		{`[{}]`, nil},
+		// only the first record needs to be complete
+		{`[{"name":"a","count":1,"ratio":0.5,"ok":true,"tags":["x"],"note":null},{"name":`, []*dataset.Field{
+			{Name: "name", Type: datatypes.String},
+			{Name: "count", Type: datatypes.Integer},
+			{Name: "ratio", Type: datatypes.Float},
+			{Name: "ok", Type: datatypes.Boolean},
+			{Name: "tags", Type: datatypes.JSON},
+			{Name: "note", Type: datatypes.Any},
+		}},
+	}
+
+	for i, c := range cases {
+		got, ok := jsonObjectFields([]byte(c.data))
+		if ok != (c.fields != nil) {
+			t.Errorf("case %d expected ok to be %t", i, c.fields != nil)
+			continue
+		}
+		if len(got) != len(c.fields) {
+			t.Errorf("case %d field count mismatch. expected: %d, got: %d", i, len(c.fields), len(got))
+			continue
+		}
+		for j, f := range c.fields {
+			if got[j].Name != f.Name || got[j].Type != f.Type {
+				t.Errorf("case %d field %d mismatch. expected: %s %s, got: %s %s", i, j, f.Name, f.Type, got[j].Name, got[j].Type)
+			}
+		}
+	}
+}
+
+func TestDatasetRequestsInitJSON(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cities := []byte(`[{"city":"toronto","pop":2731571},{"city":"new york","pop":8550405}]`)
+	citiesLines := []byte("{\"city\":\"chicago\",\"pop\":2720546}\n{\"city\":\"houston\",\"pop\":2296224}\n")
+
+	cases := []struct {
+		filename string
+		data     []byte
+		fields   []string
+		err      string
+	}{
+		{"json_cities.json", cities, []string{"city", "pop"}, ""},
+		{"jsonl_cities.jsonl", citiesLines, []string{"city", "pop"}, ""},
+		{"ragged.json", []byte(`[{"city":"toronto"},{"town":"guelph"}]`), nil, "invalid data format: row 1 has unexpected field 'town'"},
+		{"bad_lines.jsonl", []byte("{\"city\":\"toronto\"}\nnot json\n"), nil, "error reading file: invalid json on line 2"},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		p := &InitDatasetParams{DataFilename: c.filename, Data: memfs.NewMemfileBytes(c.filename, c.data)}
+		err := req.InitDataset(p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		st := got.Dataset.Structure
+		if st.Format != dataset.JSONDataFormat {
+			t.Errorf("case %d format mismatch. expected: %s, got: %s", i, dataset.JSONDataFormat, st.Format)
+		}
+		names := st.Schema.FieldNames()
+		if strings.Join(names, ",") != strings.Join(c.fields, ",") {
+			t.Errorf("case %d field names mismatch. expected: %v, got: %v", i, c.fields, names)
+		}
+	}
+}
+
+func TestJSONCell(t *testing.T) {
+	cases := []struct {
+		t      datatypes.Type
+		cell   string
+		expect string
+	}{
+		{datatypes.String, "a", `"a"`},
+		{datatypes.String, "", `""`},
+		{datatypes.String, `say "hi"`, `"say \"hi\""`},
+		{datatypes.Integer, "702", "702"},
+		{datatypes.Integer, " 702 ", "702"},
+		{datatypes.Integer, "", "null"},
+		{datatypes.Integer, "NaN", `"NaN"`},
+		{datatypes.Float, "0.99", "0.99"},
+		{datatypes.Float, "1e3", "1e3"},
+		{datatypes.Float, ".5", `".5"`},
+		{datatypes.Boolean, "TRUE", "true"},
+		{datatypes.Boolean, "yes", `"yes"`},
+		{datatypes.JSON, `{"a":1}`, `{"a":1}`},
+		{datatypes.JSON, `{a:1}`, `"{a:1}"`},
+		{datatypes.Date, "2017-01-01", `"2017-01-01"`},
+	}
+
+	for i, c := range cases {
+		if got := string(jsonCell(c.t, []byte(c.cell))); got != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+// flushRecorder counts flushes of a buffer
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() { f.flushes++ }
+
+func TestJSONLinesWriter(t *testing.T) {
+	if _, err := newJSONLinesWriter(nil, ioutil.Discard); err == nil || err.Error() != "json lines output requires a schema" {
+		t.Errorf("expected missing schema to error, got: %s", err)
+	}
+
+	schema := &dataset.Schema{Fields: []*dataset.Field{
+		{Name: "rank", Type: datatypes.Integer},
+		{Name: "job_title", Type: datatypes.String},
+	}}
+	w := &flushRecorder{}
+	jw, err := newJSONLinesWriter(schema, w)
+	if err != nil {
+		t.Errorf("error allocating writer: %s", err.Error())
+		return
+	}
+
+	rows := [][][]byte{
+		{[]byte("702"), []byte("Telemarketers")},
+		{[]byte("701")},
+	}
+	for _, row := range rows {
+		if err := jw.WriteRow(row); err != nil {
+			t.Errorf("error writing row: %s", err.Error())
+			return
+		}
+	}
+	expect := "{\"rank\":702,\"job_title\":\"Telemarketers\"}\n{\"rank\":701,\"job_title\":\"\"}\n"
+	if w.String() != expect {
+		t.Errorf("output mismatch. expected: %q, got: %q", expect, w.String())
+	}
+	if w.flushes != 0 {
+		t.Errorf("expected no flushes before %d rows, got: %d", jsonLinesFlushRows, w.flushes)
+	}
+
+	for i := 0; i < jsonLinesFlushRows; i++ {
+		if err := jw.WriteRow(rows[0]); err != nil {
+			t.Errorf("error writing row: %s", err.Error())
+			return
+		}
+	}
+	if w.flushes != 1 {
+		t.Errorf("expected a flush every %d rows, got: %d flushes", jsonLinesFlushRows, w.flushes)
+	}
+	if err := jw.Close(); err != nil {
+		t.Errorf("error closing writer: %s", err.Error())
+	}
+	if w.flushes != 2 {
+		t.Errorf("expected close to flush, got: %d flushes", w.flushes)
+	}
+}
+
+func TestDatasetRequestsStructuredDataJSONLines(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	filename := testrepo.JobsByAutomationFile.FileName()
+	ref := &repo.DatasetRef{}
+	p := &InitDatasetParams{Name: "jobs", DataFilename: filename, Data: memfs.NewMemfileBytes(filename, testrepo.JobsByAutomationData)}
+	if err := req.InitDataset(p, ref); err != nil {
+		t.Errorf("error initializing jobs dataset: %s", err.Error())
+		return
+	}
+
+	if err := req.StructuredData(&StructuredDataParams{Format: dataset.CSVDataFormat, Path: ref.Path, JSONLines: true}, &StructuredData{}); err == nil || err.Error() != "json lines are only supported for json data" {
+		t.Errorf("expected json lines of csv data to error, got: %s", err)
+	}
+
+	cases := []struct {
+		p     *StructuredDataParams
+		lines int
+	}{
+		{&StructuredDataParams{Limit: 5}, 5},
+		{&StructuredDataParams{Limit: 5, Offset: 28}, 2},
+		{&StructuredDataParams{All: true}, 30},
+		{&StructuredDataParams{All: true, Where: "probability_of_automation>0.98", Fields: []string{"job_title"}}, 12},
+	}
+
+	for i, c := range cases {
+		c.p.Format = dataset.JSONDataFormat
+		c.p.Path = ref.Path
+		c.p.JSONLines = true
+
+		// data is returned without a writer, streamed with one
+		got := &StructuredData{}
+		if err := req.StructuredData(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		returned := got.Data.([]byte)
+
+		w := &bytes.Buffer{}
+		c.p.Writer = w
+		got = &StructuredData{}
+		if err := req.StructuredData(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error writing: %s", i, err.Error())
+			continue
+		}
+		if got.Data != nil {
+			t.Errorf("case %d expected written data not to be returned", i)
+		}
+		if !bytes.Equal(returned, w.Bytes()) {
+			t.Errorf("case %d expected written & returned data to match", i)
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+		if len(lines) != c.lines {
+			t.Errorf("case %d line count mismatch. expected: %d, got: %d", i, c.lines, len(lines))
+			continue
+		}
+		for j, line := range lines {
+			obj := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(line), &obj); err != nil {
+				t.Errorf("case %d line %d isn't a json object: %s", i, j, err.Error())
+				break
+			}
+			if len(c.p.Fields) > 0 && len(obj) != len(c.p.Fields) {
+				t.Errorf("case %d line %d expected %d fields, got: %d", i, j, len(c.p.Fields), len(obj))
+			}
+		}
+	}
+}
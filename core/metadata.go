@@ -0,0 +1,80 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset"
+)
+
+// metadataFields are the lowercased json field names metadata can set on a
+// dataset. "qri" is the type marker written to every encoded dataset
+var metadataFields = jsonFieldNames(reflect.TypeOf(dataset.Dataset{}), "qri")
+
+// jsonFieldNames lists the lowercased json field names of a struct type,
+// plus any extra names
+func jsonFieldNames(t reflect.Type, extra ...string) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+	for _, name := range extra {
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+// decodeMetadata strictly decodes json metadata into ds. datasets decode
+// themselves, keeping fields they don't know as meta, so unknown fields are
+// checked for here instead of with json.Decoder.DisallowUnknownFields.
+// fields are decoded one at a time first so errors name the offending field
+func decodeMetadata(r io.Reader, ds *dataset.Dataset) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading metadata: %s", err.Error())
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("error parsing metadata json: %s", err.Error())
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !metadataFields[strings.ToLower(key)] {
+			return fmt.Errorf("invalid metadata field '%s': unknown field", key)
+		}
+		field, err := json.Marshal(map[string]json.RawMessage{key: fields[key]})
+		if err != nil {
+			return fmt.Errorf("invalid metadata field '%s': %s", key, err.Error())
+		}
+		if err := json.Unmarshal(field, &dataset.Dataset{}); err != nil {
+			return fmt.Errorf("invalid metadata field '%s': %s", key, err.Error())
+		}
+	}
+
+	if err := json.Unmarshal(data, ds); err != nil {
+		return fmt.Errorf("error parsing metadata json: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDecodeMetadata(t *testing.T) {
+	cases := []struct {
+		metadata string
+		err      string
+	}{
+		{`{}`, ""},
+		{`{"title":"movies","description":"a list of movies","keywords":["film"]}`, ""},
+		{`{"Title":"movies","qri":"ds:0"}`, ""},
+		{`{"title":"movies","titel":"typo"}`, "invalid metadata field 'titel': unknown field"},
+		{`{"title":`, "error parsing metadata json: unexpected end of JSON input"},
+	}
+
+	for i, c := range cases {
+		err := decodeMetadata(strings.NewReader(c.metadata), &dataset.Dataset{})
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+}
+
+func TestDatasetRequestsInitMetadata(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cases := []struct {
+		name     string
+		metadata string
+		title    string
+		err      string
+	}{
+		{"with_meta", `{"title":"Metadata Test","description":"testing metadata"}`, "Metadata Test", ""},
+		{"unknown_meta", `{"title":"Metadata Test","descripton":"typo"}`, "", "invalid metadata field 'descripton': unknown field"},
+		{"bad_meta", `{"title":"Metadata Test",}`, "", "error parsing metadata json: invalid character '}' looking for beginning of object key string"},
+	}
+
+	for i, c := range cases {
+		data := []byte("a,b\n" + c.name + ",1\n")
+		p := &InitDatasetParams{
+			Name:         c.name,
+			DataFilename: c.name + ".csv",
+			Data:         memfs.NewMemfileBytes(c.name+".csv", data),
+			Metadata:     strings.NewReader(c.metadata),
+		}
+		got := &repo.DatasetRef{}
+		err := req.InitDataset(p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			if _, err := mr.GetPath(c.name); err == nil {
+				t.Errorf("case %d expected dataset with invalid metadata not to be saved", i)
+			}
+			continue
+		}
+		if got.Dataset.Title != c.title {
+			t.Errorf("case %d title mismatch. expected: %s, got: %s", i, c.title, got.Dataset.Title)
+		}
+	}
+}
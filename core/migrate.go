@@ -0,0 +1,172 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+)
+
+// deprecatedDatasetFields maps dataset fields used by older versions of qri
+// to the fields that replaced them
+var deprecatedDatasetFields = map[string]string{
+	"query":         "transform",
+	"abstractQuery": "abstractTransform",
+}
+
+// MigrateParams defines parameters for migrating datasets to the current format
+type MigrateParams struct {
+	// DryRun reports datasets that need migration without changing anything
+	DryRun bool
+}
+
+// Migration describes a dataset that uses a deprecated format
+type Migration struct {
+	Name string        `json:"name"`
+	Path datastore.Key `json:"path"`
+	// NewPath is the path of the migrated dataset, empty for dry runs
+	NewPath datastore.Key `json:"newPath,omitempty"`
+	// Changes lists the changes required to migrate the dataset
+	Changes []string `json:"changes"`
+}
+
+// Migrate scans all named datasets for ones that use a deprecated dataset
+// format, rewriting each to the current format as a new version that
+// references the deprecated version as it's previous version, and moving
+// the dataset's name to the new version
+func (r *DatasetRequests) Migrate(p *MigrateParams, res *[]*Migration) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Migrate", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	store := r.repo.Store()
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.repo.Namespace(count, 0)
+	if err != nil {
+		return fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+
+	migrations := []*Migration{}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		data, err := loadDatasetJSON(store, ref.Path)
+		if err != nil {
+			return fmt.Errorf("error loading dataset '%s': %s", ref.Name, err.Error())
+		}
+		migrated, changes, err := migrateDatasetJSON(data)
+		if err != nil {
+			return fmt.Errorf("error migrating dataset '%s': %s", ref.Name, err.Error())
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		m := &Migration{Name: ref.Name, Path: ref.Path, Changes: changes}
+		if !p.DryRun {
+			if m.NewPath, err = r.saveMigratedDataset(ref, migrated); err != nil {
+				return fmt.Errorf("error migrating dataset '%s': %s", ref.Name, err.Error())
+			}
+		}
+		migrations = append(migrations, m)
+	}
+
+	*res = migrations
+	return nil
+}
+
+// saveMigratedDataset writes a migrated dataset as a new version of ref
+func (r *DatasetRequests) saveMigratedDataset(ref *repo.DatasetRef, data []byte) (datastore.Key, error) {
+	ds := &dataset.Dataset{}
+	if err := json.Unmarshal(data, ds); err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error reading migrated dataset: %s", err.Error())
+	}
+	return r.saveVersion(ref, ds)
+}
+
+// saveVersion saves ds as a new version of the dataset prev points to, moving
+// prev's name (if any) to the new version
+func (r *DatasetRequests) saveVersion(prev *repo.DatasetRef, ds *dataset.Dataset) (datastore.Key, error) {
+	ds.Previous = datastore.NewKey(ref.RootPath(prev.Path.String()))
+	ds.Timestamp = time.Now().In(time.UTC)
+
+	path, err := dsfs.SaveDataset(r.repo.Store(), ds, true)
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error saving dataset: %s", err.Error())
+	}
+	if err := r.repo.PutDataset(path, ds); err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error putting dataset in repo: %s", err.Error())
+	}
+	if prev.Name != "" {
+		if err := repo.UpdateName(r.repo, prev.Name, path); err != nil {
+			return datastore.NewKey(""), err
+		}
+		if err := updateSearchIndex(r.repo, prev.Path, &repo.DatasetRef{Name: prev.Name, Path: path, Dataset: ds}); err != nil {
+			return datastore.NewKey(""), err
+		}
+	}
+	return path, nil
+}
+
+// migrateDatasetJSON rewrites deprecated fields in an encoded dataset,
+// returning a list of the changes made. data is returned unchanged if
+// no fields are deprecated
+func migrateDatasetJSON(data []byte) ([]byte, []string, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, err
+	}
+
+	changes := []string{}
+	for old, current := range deprecatedDatasetFields {
+		val, ok := fields[old]
+		if !ok {
+			continue
+		}
+		if _, ok := fields[current]; ok {
+			changes = append(changes, fmt.Sprintf("removed deprecated field '%s'", old))
+		} else {
+			fields[current] = val
+			changes = append(changes, fmt.Sprintf("moved '%s' to '%s'", old, current))
+		}
+		delete(fields, old)
+	}
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+	sort.Strings(changes)
+
+	migrated, err := json.Marshal(fields)
+	return migrated, changes, err
+}
+
+// loadDatasetJSON reads the raw encoded dataset at path
+func loadDatasetJSON(store cafs.Filestore, path datastore.Key) ([]byte, error) {
+	if !strings.HasSuffix(path.String(), dsfs.PackageFileDataset.String()) {
+		if file, err := store.Get(path.ChildString(dsfs.PackageFileDataset.String())); err == nil {
+			return ioutil.ReadAll(file)
+		}
+	}
+	file, err := store.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(file)
+}
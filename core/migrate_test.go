@@ -0,0 +1,123 @@
+package core
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset/dsfs"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsMigrate(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	data, err := ioutil.ReadFile("testdata/legacy_dataset.json")
+	if err != nil {
+		t.Errorf("error reading fixture: %s", err.Error())
+		return
+	}
+	legacyPath, err := mr.Store().Put(memfs.NewMemfileBytes("dataset.json", data), true)
+	if err != nil {
+		t.Errorf("error putting fixture: %s", err.Error())
+		return
+	}
+	if err := mr.PutName("legacy", legacyPath); err != nil {
+		t.Errorf("error naming fixture: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+
+	// dry runs shouldn't change anything
+	res := []*Migration{}
+	if err := req.Migrate(&MigrateParams{DryRun: true}, &res); err != nil {
+		t.Errorf("error running dry run: %s", err.Error())
+		return
+	}
+	if len(res) != 1 || res[0].Name != "legacy" {
+		t.Errorf("expected dry run to report only the legacy dataset, got: %v", res)
+		return
+	}
+	if len(res[0].Changes) != 1 || res[0].Changes[0] != "moved 'query' to 'transform'" {
+		t.Errorf("dry run changes mismatch. got: %v", res[0].Changes)
+	}
+	if path, _ := mr.GetPath("legacy"); !path.Equal(legacyPath) {
+		t.Errorf("expected dry run not to move name. expected: %s, got: %s", legacyPath, path)
+	}
+
+	if err := req.Migrate(&MigrateParams{}, &res); err != nil {
+		t.Errorf("error migrating: %s", err.Error())
+		return
+	}
+	if len(res) != 1 || res[0].NewPath.String() == "" {
+		t.Errorf("expected one migrated dataset with a new path, got: %v", res)
+		return
+	}
+
+	path, err := mr.GetPath("legacy")
+	if err != nil {
+		t.Errorf("error getting migrated path: %s", err.Error())
+		return
+	}
+	if !path.Equal(res[0].NewPath) {
+		t.Errorf("expected name to move to migrated dataset. expected: %s, got: %s", res[0].NewPath, path)
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), path)
+	if err != nil {
+		t.Errorf("error loading migrated dataset: %s", err.Error())
+		return
+	}
+	if ds.Transform == nil || ds.Transform.Data != "select * from counter" {
+		t.Errorf("expected query to be migrated to transform, got: %v", ds.Transform)
+	}
+	if ds.Title != "legacy counter" {
+		t.Errorf("title mismatch. expected: 'legacy counter', got: '%s'", ds.Title)
+	}
+	if !ds.Previous.Equal(legacyPath) {
+		t.Errorf("expected previous to reference legacy dataset. expected: %s, got: %s", legacyPath, ds.Previous)
+	}
+
+	// migrated datasets shouldn't need migrating again
+	if err := req.Migrate(&MigrateParams{DryRun: true}, &res); err != nil {
+		t.Errorf("error running dry run: %s", err.Error())
+		return
+	}
+	if len(res) != 0 {
+		t.Errorf("expected no datasets to need migration, got: %d", len(res))
+	}
+}
+
+func TestMigrateDatasetJSON(t *testing.T) {
+	cases := []struct {
+		in, out string
+		changes int
+		err     string
+	}{
+		{`{`, "", 0, "unexpected end of JSON input"},
+		{`{"title":"a"}`, `{"title":"a"}`, 0, ""},
+		{`{"query":"/map/Qm"}`, `{"transform":"/map/Qm"}`, 1, ""},
+		{`{"query":"/map/a","transform":"/map/b"}`, `{"transform":"/map/b"}`, 1, ""},
+		{`{"abstractQuery":"/map/a","query":"/map/b"}`, `{"abstractTransform":"/map/a","transform":"/map/b"}`, 2, ""},
+	}
+
+	for i, c := range cases {
+		got, changes, err := migrateDatasetJSON([]byte(c.in))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if string(got) != c.out {
+			t.Errorf("case %d output mismatch. expected: %s, got: %s", i, c.out, string(got))
+		}
+		if len(changes) != c.changes {
+			t.Errorf("case %d changes count mismatch. expected: %d, got: %d", i, c.changes, len(changes))
+		}
+	}
+}
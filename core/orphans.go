@@ -0,0 +1,104 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsgraph"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+)
+
+// Orphans lists dataset versions in the repo graph that are neither the
+// head of a name nor a version in the history of a named dataset, ordered
+// by path. orphaned versions can be removed without changing any named
+// dataset. returned refs only have a Path
+func (r *DatasetRequests) Orphans(p *ListParams, res *[]*repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Orphans", p, res)
+	}
+
+	named, err := namedVersions(r.repo)
+	if err != nil {
+		return err
+	}
+	paths, err := orphanPaths(r.repo, named)
+	if err != nil {
+		return err
+	}
+
+	orphans := make([]*repo.DatasetRef, 0, len(paths))
+	for _, path := range paths {
+		key := datastore.NewKey(path)
+		if err := r.checkVisible(key); errors.Is(err, repo.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		orphans = append(orphans, &repo.DatasetRef{Path: key})
+	}
+
+	if p.Offset >= len(orphans) {
+		orphans = []*repo.DatasetRef{}
+	} else if p.Offset > 0 {
+		orphans = orphans[p.Offset:]
+	}
+	if p.Limit > 0 && p.Limit < len(orphans) {
+		orphans = orphans[:p.Limit]
+	}
+
+	*res = orphans
+	return nil
+}
+
+// orphanPaths gives the sorted root paths of dataset versions in the repo
+// graph that aren't in named
+func orphanPaths(r repo.Repo, named map[string]int) ([]string, error) {
+	nodes, err := r.Graph()
+	if err != nil && !strings.Contains(err.Error(), repo.ErrRepoEmpty.Error()) {
+		return nil, fmt.Errorf("error getting repo graph: %s", err.Error())
+	}
+
+	paths := []string{}
+	seen := map[string]bool{}
+	for path, node := range nodes {
+		if node.Type != dsgraph.NtDataset {
+			continue
+		}
+		root := ref.RootPath(path)
+		if _, isNamed := named[root]; root == "" || root == "/" || isNamed || seen[root] {
+			continue
+		}
+		seen[root] = true
+		paths = append(paths, root)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// namedVersions gives the root path of every version in the history of each
+// named dataset, mapped to the version's smallest distance from a named head
+func namedVersions(r repo.Repo) (map[string]int, error) {
+	named := map[string]int{}
+	mu := sync.Mutex{}
+	err := repo.WalkRepoDatasets(r, func(depth int, version *repo.DatasetRef, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		root := ref.RootPath(version.Path.String())
+		mu.Lock()
+		if d, ok := named[root]; !ok || depth < d {
+			named[root] = depth
+		}
+		mu.Unlock()
+		return true, nil
+	})
+	if err != nil && !errors.Is(err, repo.ErrRepoEmpty) {
+		return nil, fmt.Errorf("error walking named datasets: %s", err.Error())
+	}
+	return named, nil
+}
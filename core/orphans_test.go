@@ -0,0 +1,71 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset/dsgraph"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+// graphRepo wraps a repo, swapping out it's graph
+type graphRepo struct {
+	repo.Repo
+	graph map[string]*dsgraph.Node
+}
+
+func (r *graphRepo) Graph() (map[string]*dsgraph.Node, error) { return r.graph, nil }
+
+func TestDatasetRequestsOrphans(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	graph, err := mr.Graph()
+	if err != nil {
+		t.Errorf("error getting repo graph: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(&graphRepo{mr, graph}, nil)
+	got := []*repo.DatasetRef{}
+	if err := req.Orphans(&ListParams{}, &got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if len(got) != 0 {
+		t.Errorf("expected a repo where every version is named to have no orphans, got: %d", len(got))
+	}
+
+	// the graph still links to the dataset after it's name is removed
+	if err := mr.DeleteName("movies"); err != nil {
+		t.Errorf("error deleting name: %s", err.Error())
+		return
+	}
+	if err := req.Orphans(&ListParams{}, &got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if len(got) != 1 {
+		t.Errorf("expected 1 orphan, got: %d", len(got))
+		return
+	}
+	if got[0].Path.String() != ref.RootPath(moviesPath.String()) {
+		t.Errorf("orphan path mismatch. expected: %s, got: %s", moviesPath, got[0].Path)
+	}
+
+	if err := req.Orphans(&ListParams{Offset: 1}, &got); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if len(got) != 0 {
+		t.Errorf("expected offset past the last orphan to give no orphans, got: %d", len(got))
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"net/http"
+	"time"
 
 	util "github.com/datatogether/api/apiutil"
 )
@@ -25,6 +26,23 @@ type ListParams struct {
 	OrderBy string
 	Limit   int
 	Offset  int
+	// DueForUpdate lists only datasets that have gone a full repeating
+	// interval of their AccrualPeriodicity without a new version
+	DueForUpdate bool
+	// Before is the time DueForUpdate checks against, defaulting to now
+	Before time.Time
+}
+
+// EnsureDefaults normalizes list params in place. negative offsets start
+// from the beginning, and a Limit of zero or less means no limit: all items
+// past Offset are listed. non-positive limits are stored as zero
+func (lp *ListParams) EnsureDefaults() {
+	if lp.Offset < 0 {
+		lp.Offset = 0
+	}
+	if lp.Limit < 0 {
+		lp.Limit = 0
+	}
 }
 
 // NewListParams creates a ListParams from page & pagesize, pages are 1-indexed
@@ -37,8 +55,9 @@ func NewListParams(orderBy string, page, pageSize int) ListParams {
 		pageSize = DefaultPageSize
 	}
 	return ListParams{
-		Limit:  pageSize,
-		Offset: (page - 1) * pageSize,
+		OrderBy: orderBy,
+		Limit:   pageSize,
+		Offset:  (page - 1) * pageSize,
 	}
 }
 
@@ -7,6 +7,9 @@ import (
 )
 
 func ListParamsEqual(a, b ListParams) error {
+	if a.OrderBy != b.OrderBy {
+		return fmt.Errorf("ListParams.OrderBy fields not equal: '%s' != '%s'", a.OrderBy, b.OrderBy)
+	}
 	if a.Limit != b.Limit {
 		return fmt.Errorf("ListParams.Limit fields not equal: '%d' != '%d'", a.Limit, b.Limit)
 	}
@@ -27,6 +30,7 @@ func TestListParamsFromRequest(t *testing.T) {
 		{"abc.com/123/?pageSize=44&page=-22", ListParams{Limit: 44, Offset: 0}},
 		{"abc.com/123/?pageSize=pageSize&page=22", ListParams{Limit: DefaultPageSize, Offset: (22 - 1) * DefaultPageSize}},
 		{"abc.com/123/?pageSize=44&page=abc", ListParams{Limit: 44, Offset: 0}},
+		{"abc.com/123/?orderBy=-name", ListParams{OrderBy: "-name", Limit: DefaultPageSize, Offset: 0}},
 	}
 
 	for i, c := range cases {
@@ -67,3 +71,19 @@ func TestPage(t *testing.T) {
 		}
 	}
 }
+
+func TestListParamsEnsureDefaults(t *testing.T) {
+	cases := []struct {
+		input, res ListParams
+	}{
+		{ListParams{}, ListParams{}},
+		{ListParams{Limit: -10, Offset: -5}, ListParams{}},
+		{ListParams{OrderBy: "name", Limit: 25, Offset: 50}, ListParams{OrderBy: "name", Limit: 25, Offset: 50}},
+	}
+	for i, c := range cases {
+		c.input.EnsureDefaults()
+		if err := ListParamsEqual(c.res, c.input); err != nil {
+			t.Errorf("case [%d] error: %s", i, err.Error())
+		}
+	}
+}
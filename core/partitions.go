@@ -0,0 +1,248 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/validate"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+)
+
+// PartitionsKind marks a data file as a partition manifest
+const PartitionsKind = "qri:pt:0"
+
+// PartitionSchemeRows splits data into partitions by row range, with each
+// partition holding the rows that follow the previous partition
+const PartitionSchemeRows = "rows"
+
+// DataPartitions is a manifest of the files a dataset's data is split across.
+// A partitioned dataset's Data field points to a manifest instead of a data
+// file. Each partition is a complete data file in the format of the dataset's
+// structure, so appending data adds a partition without rewriting existing data
+type DataPartitions struct {
+	Qri        string   `json:"qri"`
+	Scheme     string   `json:"scheme"`
+	Partitions []string `json:"partitions"`
+}
+
+// loadPartitions reads the partition manifest for ds, returning nil if ds
+// stores it's data as a single file
+func loadPartitions(store cafs.Filestore, ds *dataset.Dataset) (*DataPartitions, error) {
+	if ds.Data == "" {
+		return nil, nil
+	}
+	file, err := store.Get(datastore.NewKey(ds.Data))
+	if err != nil {
+		return nil, fmt.Errorf("error getting data file: %s", err.Error())
+	}
+
+	rdr := bufio.NewReader(file)
+	head, _ := rdr.Peek(len(partitionsPrefix))
+	if !bytes.Equal(head, partitionsPrefix) {
+		return nil, nil
+	}
+
+	parts := &DataPartitions{}
+	if err := json.NewDecoder(rdr).Decode(parts); err != nil {
+		return nil, fmt.Errorf("error reading partitions: %s", err.Error())
+	}
+	return parts, nil
+}
+
+// partitionsPrefix is the start of every encoded manifest. checking for it
+// avoids parsing regular data files
+var partitionsPrefix = []byte(`{"qri":"` + PartitionsKind + `"`)
+
+// savePartitions writes a partition manifest to store
+func savePartitions(store cafs.Filestore, parts *DataPartitions) (datastore.Key, error) {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return datastore.NewKey(""), fmt.Errorf("error encoding partitions: %s", err.Error())
+	}
+	return store.Put(memfs.NewMemfileBytes("partitions.json", data), false)
+}
+
+// eachPartitionRow calls fn for each row in each partition, in order. row
+// indexes count from the first row of the first partition
+func eachPartitionRow(store cafs.Filestore, st *dataset.Structure, parts *DataPartitions, fn dsio.DataIteratorFunc) error {
+	offset := 0
+	for _, key := range parts.Partitions {
+		file, err := store.Get(datastore.NewKey(key))
+		if err != nil {
+			return fmt.Errorf("error getting partition '%s': %s", key, err.Error())
+		}
+		rr, err := dsio.NewRowReader(st, file)
+		if err != nil {
+			return fmt.Errorf("error allocating reader for partition '%s': %s", key, err.Error())
+		}
+		count := 0
+		if err := dsio.EachRow(rr, func(i int, row [][]byte, err error) error {
+			count++
+			return fn(offset+i, row, err)
+		}); err != nil {
+			return err
+		}
+		offset += count
+	}
+	return nil
+}
+
+// eachDataRow calls fn for each row of a dataset's data, reading across
+// partitions if the dataset is partitioned
+func eachDataRow(store cafs.Filestore, ds *dataset.Dataset, fn dsio.DataIteratorFunc) error {
+	parts, err := loadPartitions(store, ds)
+	if err != nil {
+		return err
+	}
+	if parts != nil {
+		return eachPartitionRow(store, ds.Structure, parts, fn)
+	}
+
+	file, err := dsfs.LoadData(store, ds)
+	if err != nil {
+		return fmt.Errorf("error loading data: %s", err.Error())
+	}
+	rr, err := dsio.NewRowReader(ds.Structure, file)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+	return dsio.EachRow(rr, fn)
+}
+
+// AppendParams defines parameters for appending data to a dataset
+type AppendParams struct {
+	Name         string    // name of the dataset to append to. required.
+	DataFilename string    // filename for appended data, used to detect the data format. optional.
+	Data         io.Reader // rows to append, in the format of the dataset. required.
+}
+
+// Append adds rows to a dataset as a new partition, creating a new version of
+// the dataset without rewriting existing data. Appending to a dataset that
+// stores data as a single file converts it to a partitioned dataset, with the
+// existing file as the first partition
+func (r *DatasetRequests) Append(p *AppendParams, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Append", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	if p.Name == "" {
+		return fmt.Errorf("name is required to append to a dataset")
+	}
+	if p.Data == nil {
+		return fmt.Errorf("data is required to append to a dataset")
+	}
+
+	store := r.repo.Store()
+	prevpath, err := r.repo.GetPath(p.Name)
+	if err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	prev, err := r.repo.GetDataset(prevpath)
+	if err != nil {
+		return fmt.Errorf("error getting dataset: %s", err.Error())
+	}
+	if prev.Structure == nil || prev.Data == "" {
+		return fmt.Errorf("can only append to datasets with data and a structure")
+	}
+
+	data, err := ioutil.ReadAll(p.Data)
+	if err != nil {
+		return fmt.Errorf("error reading data: %s", err.Error())
+	}
+	filename := p.DataFilename
+	if filename == "" {
+		filename = "data." + prev.Structure.Format.String()
+	}
+	if err := validate.DataFormat(prev.Structure.Format, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("invalid data format: %s", err.Error())
+	}
+	st, err := detect.FromReader(filename, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error determining data schema: %s", err.Error())
+	}
+	if err := compareSchemaFields(prev.Structure.Schema, st.Schema); err != nil {
+		return err
+	}
+
+	datakey, err := store.Put(memfs.NewMemfileBytes(filename, data), false)
+	if err != nil {
+		return fmt.Errorf("error putting data in store: %s", err.Error())
+	}
+
+	parts, err := loadPartitions(store, prev)
+	if err != nil {
+		return err
+	}
+	if parts == nil {
+		parts = &DataPartitions{
+			Qri:        PartitionsKind,
+			Scheme:     PartitionSchemeRows,
+			Partitions: []string{prev.Data},
+		}
+	}
+	parts.Partitions = append(parts.Partitions, datakey.String())
+	partskey, err := savePartitions(store, parts)
+	if err != nil {
+		return fmt.Errorf("error putting partitions in store: %s", err.Error())
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(prev)
+	ds.Data = partskey.String()
+	ds.Length = prev.Length + len(data)
+	ds.Previous = datastore.NewKey(ref.RootPath(prevpath.String()))
+
+	if err := validate.Dataset(ds); err != nil {
+		return err
+	}
+
+	ds.Timestamp = time.Now().In(time.UTC)
+	dspath, err := dsfs.SaveDataset(store, ds, true)
+	if err != nil {
+		return fmt.Errorf("error saving dataset: %s", err.Error())
+	}
+	if err := repo.UpdateName(r.repo, p.Name, dspath); err != nil {
+		return err
+	}
+
+	*res = repo.DatasetRef{
+		Name:    p.Name,
+		Path:    dspath,
+		Dataset: ds,
+	}
+	return updateSearchIndex(r.repo, prevpath, res)
+}
+
+// compareSchemaFields checks that appended data has the same fields as the
+// dataset it's being appended to
+func compareSchemaFields(a, b *dataset.Schema) error {
+	if a == nil || b == nil {
+		return nil
+	}
+	if len(a.Fields) != len(b.Fields) {
+		return fmt.Errorf("appended data has %d fields, dataset has %d", len(b.Fields), len(a.Fields))
+	}
+	for i, f := range a.Fields {
+		if b.Fields[i].Name != f.Name {
+			return fmt.Errorf("appended data field %d name mismatch. expected: '%s', got: '%s'", i, f.Name, b.Fields[i].Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsAppend(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p     *AppendParams
+		parts int
+		rows  int
+		err   string
+	}{
+		{&AppendParams{}, 0, 0, "name is required to append to a dataset"},
+		{&AppendParams{Name: "counter"}, 0, 0, "data is required to append to a dataset"},
+		{&AppendParams{Name: "not_a_dataset", Data: strings.NewReader("count\n21\n")}, 0, 0, "error getting dataset: repo: not found"},
+		{&AppendParams{Name: "counter", DataFilename: "data.csv", Data: strings.NewReader("number,other\n21,22\n")}, 0, 0, "appended data has 2 fields, dataset has 1"},
+		{&AppendParams{Name: "counter", DataFilename: "data.csv", Data: strings.NewReader("count\n21\n22\n")}, 2, 22, ""},
+		{&AppendParams{Name: "counter", DataFilename: "data.csv", Data: strings.NewReader("count\n23\n24\n25\n")}, 3, 25, ""},
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Append(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		parts, err := loadPartitions(mr.Store(), got.Dataset)
+		if err != nil {
+			t.Errorf("case %d error loading partitions: %s", i, err.Error())
+			continue
+		}
+		if parts == nil || len(parts.Partitions) != c.parts {
+			t.Errorf("case %d expected %d partitions, got: %v", i, c.parts, parts)
+			continue
+		}
+
+		data := &StructuredData{}
+		if err := req.StructuredData(&StructuredDataParams{Format: dataset.JSONDataFormat, Path: got.Path, All: true}, data); err != nil {
+			t.Errorf("case %d error reading data: %s", i, err.Error())
+			continue
+		}
+		rows := []interface{}{}
+		if err := json.Unmarshal(data.Data.(json.RawMessage), &rows); err != nil {
+			t.Errorf("case %d error parsing data: %s", i, err.Error())
+			continue
+		}
+		if len(rows) != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, len(rows))
+		}
+	}
+}
+
+func TestStructuredDataPartitions(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	ref := &repo.DatasetRef{}
+	if err := req.Append(&AppendParams{Name: "counter", DataFilename: "data.csv", Data: strings.NewReader("count\n21\n22\n23\n")}, ref); err != nil {
+		t.Errorf("error appending data: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		limit, offset int
+		expect        string
+	}{
+		{3, 0, "1\n2\n3\n"},
+		{3, 18, "19\n20\n21\n"},
+		{5, 20, "21\n22\n23\n"},
+		{5, 30, ""},
+	}
+
+	for i, c := range cases {
+		p := &StructuredDataParams{
+			Format:       dataset.CSVDataFormat,
+			FormatConfig: &dataset.CSVOptions{HeaderRow: false},
+			Path:         ref.Path,
+			Limit:        c.limit,
+			Offset:       c.offset,
+		}
+		got := &StructuredData{}
+		if err := req.StructuredData(p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if string(got.Data.([]byte)) != c.expect {
+			t.Errorf("case %d data mismatch. expected: %q, got: %q", i, c.expect, string(got.Data.([]byte)))
+		}
+	}
+}
+
+func TestPartitionedDataReads(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	ref := &repo.DatasetRef{}
+	if err := req.Append(&AppendParams{Name: "counter", DataFilename: "data.csv", Data: strings.NewReader("count\n21\n22\n23\n")}, ref); err != nil {
+		t.Errorf("error appending data: %s", err.Error())
+		return
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), ref.Path)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeExportData(mr, &repo.DatasetRef{Dataset: ds}, buf); err != nil {
+		t.Errorf("error writing data: %s", err.Error())
+		return
+	}
+	data := openData(mr, ds)
+	joined, err := ioutil.ReadAll(data)
+	data.Close()
+	if err != nil {
+		t.Errorf("error reading data: %s", err.Error())
+		return
+	}
+	if !bytes.Equal(joined, buf.Bytes()) {
+		t.Errorf("opened data mismatch. expected: %q, got: %q", buf.String(), string(joined))
+	}
+
+	st, err := req.sampleStructure(ds)
+	if err != nil {
+		t.Errorf("error sampling structure: %s", err.Error())
+		return
+	}
+	if st.Schema == nil || len(st.Schema.Fields) != 1 || st.Schema.Fields[0].Name != "count" {
+		t.Errorf("expected sampled schema to have a count field, got: %v", st.Schema)
+	}
+
+	q := &dataset.Transform{Resources: map[string]*dataset.Dataset{"counter": ds}}
+	exec, err := execTransform(mr, q)
+	if err != nil {
+		t.Errorf("error preparing transform: %s", err.Error())
+		return
+	}
+	res := exec.Resources["counter"]
+	if res.Data == ds.Data || q.Resources["counter"].Data != ds.Data {
+		t.Errorf("expected only the executed transform to read joined data")
+	}
+	file, err := mr.Store().Get(datastore.NewKey(res.Data))
+	if err != nil {
+		t.Errorf("error getting joined data: %s", err.Error())
+		return
+	}
+	if got, _ := ioutil.ReadAll(file); !bytes.Equal(got, buf.Bytes()) {
+		t.Errorf("joined data mismatch. expected: %q, got: %q", buf.String(), string(got))
+	}
+}
@@ -1,10 +1,13 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/rpc"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
@@ -18,6 +21,21 @@ import (
 type PeerRequests struct {
 	qriNode *p2p.QriNode
 	cli     *rpc.Client
+	// msgr sends messages to peers in place of qriNode when set, for tests
+	msgr messenger
+}
+
+// messenger sends a message to a peer & waits for the response
+type messenger interface {
+	SendMessage(id peer.ID, msg *p2p.Message) (*p2p.Message, error)
+	SendMessageContext(ctx context.Context, id peer.ID, msg *p2p.Message) (*p2p.Message, error)
+}
+
+func (d *PeerRequests) messenger() messenger {
+	if d.msgr != nil {
+		return d.msgr
+	}
+	return d.qriNode
 }
 
 // CoreRequestsName implements the Requets interface
@@ -36,15 +54,16 @@ func NewPeerRequests(node *p2p.QriNode, cli *rpc.Client) *PeerRequests {
 	}
 }
 
-// List lists Peers on the qri network
+// List lists Peers on the qri network. a Limit of zero or less lists all
+// peers
 func (d *PeerRequests) List(p *ListParams, res *[]*profile.Profile) error {
 	if d.cli != nil {
 		return d.cli.Call("PeerRequests.List", p, res)
 	}
 
+	p.EnsureDefaults()
 	r := d.qriNode.Repo
-	replies := make([]*profile.Profile, p.Limit)
-	i := 0
+	replies := []*profile.Profile{}
 
 	user, err := r.Profile()
 	if err != nil {
@@ -57,17 +76,16 @@ func (d *PeerRequests) List(p *ListParams, res *[]*profile.Profile) error {
 	}
 
 	for _, peer := range ps {
-		if i >= p.Limit {
+		if p.Limit > 0 && len(replies) >= p.Limit {
 			break
 		}
 		if peer.ID == user.ID {
 			continue
 		}
-		replies[i] = peer
-		i++
+		replies = append(replies, peer)
 	}
 
-	*res = replies[:i]
+	*res = replies
 	return nil
 }
 
@@ -82,6 +100,47 @@ func (d *PeerRequests) ConnectedPeers(limit *int, peers *[]string) error {
 	return nil
 }
 
+// ConnectedQriProfiles lists profiles of connected peers. peers connected
+// at the IPFS layer that don't have a qri profile are left out
+func (d *PeerRequests) ConnectedQriProfiles(limit *int, res *[]*profile.Profile) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.ConnectedQriProfiles", limit, res)
+	}
+
+	max := 0
+	if limit != nil {
+		max = *limit
+	}
+	*res = connectedProfiles(d.qriNode.Repo.Peers(), d.qriNode.ConnectedPeers(), max)
+	return nil
+}
+
+// connectedProfiles looks up profiles for a list of connected peer IDs,
+// skipping IDs without a profile & repeat IDs of peers with more than one
+// connection. limit caps the number of profiles if greater than zero
+func connectedProfiles(peers repo.Peers, ids []string, limit int) []*profile.Profile {
+	profiles := []*profile.Profile{}
+	seen := map[string]bool{}
+	for _, str := range ids {
+		if limit > 0 && len(profiles) >= limit {
+			break
+		}
+		if seen[str] {
+			continue
+		}
+		seen[str] = true
+
+		id, err := peer.IDB58Decode(str)
+		if err != nil {
+			continue
+		}
+		if pro, err := peers.GetPeer(id); err == nil && pro != nil {
+			profiles = append(profiles, pro)
+		}
+	}
+	return profiles
+}
+
 // ConnectToPeer attempts to create a connection with a peer for a given peer.ID
 func (d *PeerRequests) ConnectToPeer(pid *peer.ID, res *profile.Profile) error {
 	if d.cli != nil {
@@ -101,32 +160,39 @@ func (d *PeerRequests) ConnectToPeer(pid *peer.ID, res *profile.Profile) error {
 	return nil
 }
 
-// Get peer profile details
+// Get peer profile details. peers are matched by peer ID with p.Hash, or
+// by p.Username, returning repo.ErrNotFound if no peer matches
 func (d *PeerRequests) Get(p *GetParams, res *profile.Profile) error {
 	if d.cli != nil {
 		return d.cli.Call("PeerRequests.Get", p, res)
 	}
 
-	// TODO - restore
-	// peers, err := d.repo.Peers()
-	// if err != nil {
-	// 	fmt.Println(err.Error())
-	// 	return err
-	// }
+	if p.Hash == "" && p.Username == "" {
+		return fmt.Errorf("a peer id or username is required")
+	}
 
-	// for name, repo := range peers {
-	// 	if p.Hash == name ||
-	// 		p.Username == repo.Profile.Username {
-	// 		*res = *repo.Profile
-	// 	}
-	// }
+	peers := d.qriNode.Repo.Peers()
+	if p.Hash != "" {
+		if id, err := peer.IDB58Decode(p.Hash); err == nil {
+			if pro, err := peers.GetPeer(id); err == nil && pro != nil {
+				*res = *pro
+				return nil
+			}
+		}
+	}
 
-	// if res != nil {
-	// 	return nil
-	// }
+	ps, err := repo.QueryPeers(peers, query.Query{})
+	if err != nil {
+		return fmt.Errorf("error querying peers: %s", err.Error())
+	}
+	for _, pro := range ps {
+		if p.Hash != "" && pro.ID == p.Hash || p.Username != "" && pro.Username == p.Username {
+			*res = *pro
+			return nil
+		}
+	}
 
-	// TODO - ErrNotFound plz
-	return fmt.Errorf("Not Found")
+	return repo.ErrNotFound
 }
 
 // NamespaceParams defines params for the GetNamespace method
@@ -134,12 +200,19 @@ type NamespaceParams struct {
 	PeerID string
 	Limit  int
 	Offset int
+	// Context cancels the request to the peer when done. Context isn't sent
+	// over RPC
+	Context context.Context `json:"-"`
 }
 
-// GetNamespace lists a peer's named datasets
-func (d *PeerRequests) GetNamespace(p *NamespaceParams, res *[]*repo.DatasetRef) error {
+// GetNamespace lists a page of a peer's named datasets. res.Total is -1 for
+// peers that don't report how many datasets they list, HasMore is then a
+// guess from whether the page is full
+func (d *PeerRequests) GetNamespace(p *NamespaceParams, res *DatasetList) error {
 	if d.cli != nil {
-		return d.cli.Call("PeerRequests.GetNamespace", p, res)
+		args := *p
+		args.Context = nil
+		return d.cli.Call("PeerRequests.GetNamespace", &args, res)
 	}
 
 	id, err := peer.IDB58Decode(p.PeerID)
@@ -152,27 +225,208 @@ func (d *PeerRequests) GetNamespace(p *NamespaceParams, res *[]*repo.DatasetRef)
 		return err
 	}
 
-	r, err := d.qriNode.SendMessage(id, &p2p.Message{
+	limit, offset := p.Limit, p.Offset
+	if limit <= 0 {
+		// the default page size of peers
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	msg := &p2p.Message{
 		Phase: p2p.MpRequest,
 		Type:  p2p.MtDatasets,
 		Payload: &p2p.DatasetsReqParams{
-			Limit:  p.Limit,
-			Offset: p.Offset,
+			Limit:  limit,
+			Offset: offset,
+			Total:  true,
+		},
+	}
+	var r *p2p.Message
+	if p.Context != nil {
+		r, err = d.messenger().SendMessageContext(p.Context, id, msg)
+	} else {
+		r, err = d.messenger().SendMessage(id, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("error sending message to peer: %s", err.Error())
+	}
+
+	dsr, err := p2p.DecodeDatasetsRes(r.Payload)
+	if err != nil {
+		return fmt.Errorf("error parsing peer response: %s", err.Error())
+	}
+	refs := dsr.Datasets
+	if refs == nil {
+		refs = []*repo.DatasetRef{}
+	}
+
+	*res = DatasetList{
+		Datasets: refs,
+		Total:    dsr.Total,
+		HasMore:  offset+len(refs) < dsr.Total,
+	}
+	if dsr.Total < 0 {
+		res.HasMore = len(refs) >= limit
+	}
+	return nil
+}
+
+// SyncNamespace fetches all of a peer's named datasets, writing them to the
+// repo's cache so they show up in local searches & can be added later
+// without reaching the peer. p.Limit sets how many datasets are requested at
+// a time, p.Offset is ignored. if the repo records cache sources, datasets
+// cached from the peer before that it no longer lists are removed
+func (d *PeerRequests) SyncNamespace(p *NamespaceParams, res *[]*repo.DatasetRef) error {
+	if d.cli != nil {
+		args := *p
+		args.Context = nil
+		return d.cli.Call("PeerRequests.SyncNamespace", &args, res)
+	}
+
+	page := *p
+	if page.Limit <= 0 {
+		page.Limit = 50
+	}
+	refs := []*repo.DatasetRef{}
+	seen := map[string]bool{}
+	for page.Offset = 0; ; page.Offset += page.Limit {
+		got := &DatasetList{}
+		if err := d.GetNamespace(&page, got); err != nil {
+			return err
+		}
+		added := 0
+		for _, ref := range got.Datasets {
+			if ref == nil || seen[ref.Path.String()] {
+				continue
+			}
+			seen[ref.Path.String()] = true
+			refs = append(refs, ref)
+			added++
+		}
+		// peers that don't page results send the same datasets again
+		if added == 0 || !got.HasMore {
+			break
+		}
+	}
+
+	if err := cacheNamespace(d.qriNode.Repo, p.PeerID, refs); err != nil {
+		return err
+	}
+	*res = refs
+	return nil
+}
+
+// cacheNamespace writes datasets listed by a peer to the repo's cache &
+// search index. datasets cached from the peer before that aren't in refs are
+// removed, unless another peer lists them
+func cacheNamespace(r repo.Repo, peerID string, refs []*repo.DatasetRef) error {
+	cached := []*repo.DatasetRef{}
+	paths := []datastore.Key{}
+	for _, ref := range refs {
+		if ref.Dataset == nil {
+			continue
+		}
+		cached = append(cached, ref)
+		paths = append(paths, ref.Path)
+	}
+	if err := r.Cache().PutDatasets(cached); err != nil {
+		return fmt.Errorf("error caching datasets: %s", err.Error())
+	}
+	for _, ref := range cached {
+		// datasets this repo has a name for are already indexed
+		if _, err := r.GetName(ref.Path); err == nil {
+			continue
+		}
+		if err := updateSearchIndex(r, datastore.NewKey(""), ref); err != nil {
+			return err
+		}
+	}
+
+	cs, ok := r.(repo.CacheSources)
+	if !ok {
+		return nil
+	}
+	sources, err := cs.CachedPaths()
+	if err != nil {
+		return fmt.Errorf("error getting cache sources: %s", err.Error())
+	}
+	keep := map[string]bool{}
+	for id, ps := range sources {
+		if id != peerID {
+			for _, path := range ps {
+				keep[path.String()] = true
+			}
+		}
+	}
+	for _, path := range paths {
+		keep[path.String()] = true
+	}
+
+	for _, path := range sources[peerID] {
+		if keep[path.String()] {
+			continue
+		}
+		if err := r.Cache().DeleteDataset(path); err != nil {
+			return fmt.Errorf("error removing cached dataset: %s", err.Error())
+		}
+		if _, err := r.GetName(path); errors.Is(err, repo.ErrNotFound) {
+			if err := updateSearchIndex(r, path, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := cs.SetCachedPaths(peerID, paths); err != nil {
+		return fmt.Errorf("error recording cache sources: %s", err.Error())
+	}
+	return nil
+}
+
+// PeerDatasetParams defines params for the GetPeerDataset method
+type PeerDatasetParams struct {
+	PeerID string
+	Name   string
+}
+
+// GetPeerDataset fetches a single named dataset from a peer, without adding
+// the dataset to this repo
+func (d *PeerRequests) GetPeerDataset(p *PeerDatasetParams, res *repo.DatasetRef) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.GetPeerDataset", p, res)
+	}
+
+	if p.Name == "" {
+		return fmt.Errorf("dataset name is required")
+	}
+	id, err := peer.IDB58Decode(p.PeerID)
+	if err != nil {
+		return fmt.Errorf("error decoding peer Id: %s", err.Error())
+	}
+
+	r, err := d.messenger().SendMessage(id, &p2p.Message{
+		Phase: p2p.MpRequest,
+		Type:  p2p.MtDataset,
+		Payload: &p2p.DatasetReqParams{
+			Name: p.Name,
 		},
 	})
 	if err != nil {
 		return fmt.Errorf("error sending message to peer: %s", err.Error())
 	}
+	if r.Phase == p2p.MpError {
+		return fmt.Errorf("peer error: %v", r.Payload)
+	}
 
 	data, err := json.Marshal(r.Payload)
 	if err != nil {
 		return fmt.Errorf("error encoding peer response: %s", err.Error())
 	}
-	refs := []*repo.DatasetRef{}
-	if err := json.Unmarshal(data, &refs); err != nil {
+	ref := &repo.DatasetRef{}
+	if err := json.Unmarshal(data, ref); err != nil {
 		return fmt.Errorf("error parsing peer response: %s", err.Error())
 	}
 
-	*res = refs
+	*res = *ref
 	return nil
 }
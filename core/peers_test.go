@@ -1,41 +1,333 @@
 package core
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/analytics"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	testrepo "github.com/qri-io/qri/repo/test"
+
+	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
 )
 
 func TestPeerRequestsList(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	for _, pro := range []*profile.Profile{
+		{ID: "QmZ9Lq8Fgt4jKbBUHRoxLk7mDBxAzQW3vfyT3Ze4AJdnaC", Username: "steve"},
+		{ID: "QmYCvbfNbCwFR45HiNP45rwJgvatpiW38D961L5qAhUM5Y", Username: "janet"},
+	} {
+		id, err := peer.IDB58Decode(pro.ID)
+		if err != nil {
+			t.Errorf("error decoding peer id: %s", err.Error())
+			return
+		}
+		if err := mr.Peers().PutPeer(id, pro); err != nil {
+			t.Errorf("error putting peer: %s", err.Error())
+			return
+		}
+	}
+
 	cases := []struct {
-		p   *ListParams
-		res []*profile.Profile
-		err string
+		p     *ListParams
+		count int
+		err   string
 	}{
-		{&ListParams{}, nil, ""},
-		// {&ListParams{Data: badDataFile}, nil, "error determining dataset schema: no file extension provided"},
-		// {&ListParams{DataFilename: badDataFile.FileName(), Data: badDataFile}, nil, "error determining dataset schema: EOF"},
-		// {&ListParams{DataFilename: jobsByAutomationFile.FileName(), Data: jobsByAutomationFile}, nil, ""},
+		{&ListParams{}, 2, ""},
+		{&ListParams{Limit: -1}, 2, ""},
+		{&ListParams{Limit: 1}, 1, ""},
+		{&ListParams{Limit: 10}, 2, ""},
 		// TODO - need a test that confirms that this node's identity is never present in peers list
 	}
 
+	// TODO - need to upgrade this to include a mock node
+	req := NewPeerRequests(&p2p.QriNode{Repo: mr}, nil)
+	for i, c := range cases {
+		got := []*profile.Profile{}
+		err := req.List(c.p, &got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != c.count {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, c.count, len(got))
+		}
+	}
+}
+
+func TestPeerRequestsGet(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
 		t.Errorf("error allocating test repo: %s", err.Error())
 		return
 	}
 
-	// TODO - need to upgrade this to include a mock node
+	hash := "QmZ9Lq8Fgt4jKbBUHRoxLk7mDBxAzQW3vfyT3Ze4AJdnaC"
+	hash2 := "QmYCvbfNbCwFR45HiNP45rwJgvatpiW38D961L5qAhUM5Y"
+	for _, pro := range []*profile.Profile{
+		{ID: hash, Username: "steve"},
+		{ID: hash2, Username: "janet"},
+	} {
+		id, err := peer.IDB58Decode(pro.ID)
+		if err != nil {
+			t.Errorf("error decoding peer id: %s", err.Error())
+			return
+		}
+		if err := mr.Peers().PutPeer(id, pro); err != nil {
+			t.Errorf("error putting peer: %s", err.Error())
+			return
+		}
+	}
+
+	cases := []struct {
+		p        *GetParams
+		username string
+		err      string
+	}{
+		{&GetParams{}, "", "a peer id or username is required"},
+		{&GetParams{Hash: hash}, "steve", ""},
+		{&GetParams{Username: "steve"}, "steve", ""},
+		{&GetParams{Hash: hash2}, "janet", ""},
+		{&GetParams{Username: "janet"}, "janet", ""},
+		{&GetParams{Hash: "QmNotAPeer"}, "", repo.ErrNotFound.Error()},
+		{&GetParams{Username: "not_a_peer"}, "", repo.ErrNotFound.Error()},
+	}
+
 	req := NewPeerRequests(&p2p.QriNode{Repo: mr}, nil)
 	for i, c := range cases {
-		got := []*profile.Profile{}
-		err := req.List(c.p, &got)
+		got := &profile.Profile{}
+		err := req.Get(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && got.Username != c.username {
+			t.Errorf("case %d username mismatch. expected: %s, got: %s", i, c.username, got.Username)
+		}
+	}
+}
+
+// stubPeers is a peer store that only gets peers, from a map of profiles
+// keyed by peer ID
+type stubPeers struct {
+	repo.Peers
+	profiles map[string]*profile.Profile
+}
+
+func (s stubPeers) GetPeer(id peer.ID) (*profile.Profile, error) {
+	if pro, ok := s.profiles[id.Pretty()]; ok {
+		return pro, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func TestConnectedProfiles(t *testing.T) {
+	steve := "QmZ9Lq8Fgt4jKbBUHRoxLk7mDBxAzQW3vfyT3Ze4AJdnaC"
+	janet := "QmYCvbfNbCwFR45HiNP45rwJgvatpiW38D961L5qAhUM5Y"
+	ipfsOnly := "QmWGnEbtD2MTVEqTEbYjD7fD8nQ3HMuCC7yAm7s2g27rKg"
+	peers := stubPeers{profiles: map[string]*profile.Profile{
+		steve: {ID: steve, Username: "steve"},
+		janet: {ID: janet, Username: "janet"},
+	}}
+
+	cases := []struct {
+		ids    []string
+		limit  int
+		expect []string
+	}{
+		{nil, 0, []string{}},
+		{[]string{ipfsOnly}, 0, []string{}},
+		{[]string{steve, ipfsOnly, janet}, 0, []string{"steve", "janet"}},
+		{[]string{janet, janet, steve}, 0, []string{"janet", "steve"}},
+		{[]string{"not_a_peer_id", steve}, 0, []string{"steve"}},
+		{[]string{ipfsOnly, steve, janet}, 1, []string{"steve"}},
+	}
+
+	for i, c := range cases {
+		got := connectedProfiles(peers, c.ids, c.limit)
+		if len(got) != len(c.expect) {
+			t.Errorf("case %d profile count mismatch. expected: %d, got: %d", i, len(c.expect), len(got))
+			continue
+		}
+		for j, username := range c.expect {
+			if got[j].Username != username {
+				t.Errorf("case %d profile %d username mismatch. expected: %s, got: %s", i, j, username, got[j].Username)
+			}
+		}
+	}
+}
+
+func TestCacheNamespace(t *testing.T) {
+	mr, err := repo.NewMemRepo(&profile.Profile{Username: "test_user"}, memfs.NewMapstore(), repo.MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Errorf("error allocating repo: %s", err.Error())
+		return
+	}
+
+	refs := map[string]*repo.DatasetRef{}
+	for _, name := range []string{"a", "b", "c"} {
+		refs[name] = &repo.DatasetRef{Name: name, Path: datastore.NewKey("/map/" + name), Dataset: &dataset.Dataset{Title: name}}
+	}
+
+	// steps run in order, cached is every path in the cache after the step
+	cases := []struct {
+		peerID string
+		names  []string
+		cached []string
+	}{
+		{"steve", []string{"a", "b"}, []string{"a", "b"}},
+		{"janet", []string{"b", "c"}, []string{"a", "b", "c"}},
+		// janet still lists b
+		{"steve", []string{"a"}, []string{"a", "b", "c"}},
+		{"janet", []string{}, []string{"a"}},
+		{"steve", []string{}, []string{}},
+	}
+
+	for i, c := range cases {
+		listed := []*repo.DatasetRef{}
+		for _, name := range c.names {
+			listed = append(listed, refs[name])
+		}
+		if err := cacheNamespace(mr, c.peerID, listed); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+
+		for name, ref := range refs {
+			_, err := mr.Cache().GetDataset(ref.Path)
+			expect := false
+			for _, n := range c.cached {
+				expect = expect || n == name
+			}
+			if (err == nil) != expect {
+				t.Errorf("case %d expected %s cached to be %t", i, name, expect)
+			}
+		}
+	}
+
+	sources, err := mr.(repo.CacheSources).CachedPaths()
+	if err != nil {
+		t.Errorf("error getting cache sources: %s", err.Error())
+		return
+	}
+	if len(sources) != 0 {
+		t.Errorf("expected peers without cached datasets to be removed from cache sources, got: %d", len(sources))
+	}
+}
+
+// mockMessenger answers datasets requests with pages of refs, as a peer
+// would. legacy peers answer with a bare list & no total
+type mockMessenger struct {
+	refs   []*repo.DatasetRef
+	legacy bool
+	sent   int
+}
+
+func (m *mockMessenger) SendMessage(id peer.ID, msg *p2p.Message) (*p2p.Message, error) {
+	m.sent++
+	p, ok := msg.Payload.(*p2p.DatasetsReqParams)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type: %s", msg.Type)
+	}
+	page := []*repo.DatasetRef{}
+	for i := p.Offset; i < len(m.refs) && i < p.Offset+p.Limit; i++ {
+		page = append(page, m.refs[i])
+	}
 
+	var payload interface{} = page
+	if p.Total && !m.legacy {
+		payload = &p2p.DatasetsRes{Datasets: page, Total: len(m.refs)}
+	}
+	return &p2p.Message{Type: p2p.MtDatasets, Phase: p2p.MpResponse, Payload: payload}, nil
+}
+
+func (m *mockMessenger) SendMessageContext(ctx context.Context, id peer.ID, msg *p2p.Message) (*p2p.Message, error) {
+	return m.SendMessage(id, msg)
+}
+
+func TestPeerRequestsGetNamespace(t *testing.T) {
+	mr, err := repo.NewMemRepo(&profile.Profile{Username: "test_user"}, memfs.NewMapstore(), repo.MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Errorf("error allocating repo: %s", err.Error())
+		return
+	}
+	peerID := "QmZ9Lq8Fgt4jKbBUHRoxLk7mDBxAzQW3vfyT3Ze4AJdnaC"
+	id, err := peer.IDB58Decode(peerID)
+	if err != nil {
+		t.Errorf("error decoding peer id: %s", err.Error())
+		return
+	}
+	if err := mr.Peers().PutPeer(id, &profile.Profile{ID: peerID, Username: "steve"}); err != nil {
+		t.Errorf("error putting peer: %s", err.Error())
+		return
+	}
+
+	refs := []*repo.DatasetRef{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		refs = append(refs, &repo.DatasetRef{Name: name, Path: datastore.NewKey("/map/" + name), Dataset: &dataset.Dataset{Title: name}})
+	}
+
+	cases := []struct {
+		legacy        bool
+		limit, offset int
+		count, total  int
+		hasMore       bool
+		err           string
+	}{
+		{false, 2, 0, 2, 5, true, ""},
+		{false, 2, 4, 1, 5, false, ""},
+		{false, 0, 0, 5, 5, false, ""},
+		{false, 2, 10, 0, 5, false, ""},
+		{true, 2, 0, 2, -1, true, ""},
+		{true, 2, 4, 1, -1, false, ""},
+	}
+
+	for i, c := range cases {
+		req := NewPeerRequests(&p2p.QriNode{Repo: mr}, nil)
+		req.msgr = &mockMessenger{refs: refs, legacy: c.legacy}
+		got := &DatasetList{}
+		err := req.GetNamespace(&NamespaceParams{PeerID: peerID, Limit: c.limit, Offset: c.offset}, got)
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
 			continue
 		}
+		if len(got.Datasets) != c.count {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, c.count, len(got.Datasets))
+		}
+		if got.Total != c.total {
+			t.Errorf("case %d total mismatch. expected: %d, got: %d", i, c.total, got.Total)
+		}
+		if got.HasMore != c.hasMore {
+			t.Errorf("case %d hasMore mismatch. expected: %t, got: %t", i, c.hasMore, got.HasMore)
+		}
+	}
+
+	// syncing stops at the last page instead of requesting an empty one
+	req := NewPeerRequests(&p2p.QriNode{Repo: mr}, nil)
+	msgr := &mockMessenger{refs: refs}
+	req.msgr = msgr
+	synced := []*repo.DatasetRef{}
+	if err := req.SyncNamespace(&NamespaceParams{PeerID: peerID, Limit: 2}, &synced); err != nil {
+		t.Errorf("error syncing namespace: %s", err.Error())
+		return
+	}
+	if len(synced) != len(refs) {
+		t.Errorf("expected %d synced datasets, got: %d", len(refs), len(synced))
+	}
+	if msgr.sent != 3 {
+		t.Errorf("expected 3 requests to sync, got: %d", msgr.sent)
 	}
 }
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/ref"
+)
+
+// PinParams defines parameters for pinning & unpinning a dataset
+type PinParams struct {
+	Path datastore.Key
+}
+
+// Pin keeps the dataset at a path in the store without adding a name for it
+func (r *DatasetRequests) Pin(p *PinParams, ok *bool) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Pin", p, ok)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	pinner, key, err := r.pinTarget(p)
+	if err != nil {
+		return err
+	}
+	if err := pinner.Pin(key, true); err != nil {
+		return fmt.Errorf("error pinning dataset: %s", err.Error())
+	}
+
+	*ok = true
+	return nil
+}
+
+// Unpin releases a dataset pinned at a path, leaving any names for the
+// dataset in place
+func (r *DatasetRequests) Unpin(p *PinParams, ok *bool) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Unpin", p, ok)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	pinner, key, err := r.pinTarget(p)
+	if err != nil {
+		return err
+	}
+	if err := pinner.Unpin(key, true); err != nil {
+		return fmt.Errorf("error unpinning dataset: %s", err.Error())
+	}
+
+	*ok = true
+	return nil
+}
+
+// pinTarget gives the repo's store as a pinner & the root key of the dataset
+// p refers to
+func (r *DatasetRequests) pinTarget(p *PinParams) (cafs.Pinner, datastore.Key, error) {
+	if p.Path.String() == "" || p.Path.String() == "/" {
+		return nil, datastore.NewKey(""), fmt.Errorf("path is required")
+	}
+
+	pinner, ok := r.repo.Store().(cafs.Pinner)
+	if !ok {
+		return nil, datastore.NewKey(""), fmt.Errorf("can only pin datasets when running a store that supports pinning")
+	}
+
+	if _, err := loadDataset(r.repo, p.Path); err != nil {
+		return nil, datastore.NewKey(""), fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	return pinner, datastore.NewKey(ref.RootPath(p.Path.String())), nil
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/ref"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsPin(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	ok := false
+	if err := NewDatasetRequests(mr, nil).Pin(&PinParams{Path: path}, &ok); err == nil || err.Error() != "can only pin datasets when running a store that supports pinning" {
+		t.Errorf("expected pinning without a pinner to error, got: %s", err)
+	}
+
+	pr := &pinRepo{Repo: mr, store: &pinStore{Filestore: mr.Store()}}
+	req := NewDatasetRequests(pr, nil)
+
+	cases := []struct {
+		p   *PinParams
+		err string
+	}{
+		{&PinParams{}, "path is required"},
+		{&PinParams{Path: datastore.NewKey("/")}, "path is required"},
+		{&PinParams{Path: path}, ""},
+	}
+
+	for i, c := range cases {
+		for _, pin := range []func(*PinParams, *bool) error{req.Pin, req.Unpin} {
+			ok := false
+			err := pin(c.p, &ok)
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+				continue
+			}
+			if ok != (c.err == "") {
+				t.Errorf("case %d expected ok to be %t", i, c.err == "")
+			}
+		}
+	}
+
+	root := ref.RootPath(path.String())
+	if len(pr.store.pinned) != 1 || pr.store.pinned[0] != root {
+		t.Errorf("expected %s to be pinned, got: %v", root, pr.store.pinned)
+	}
+	if len(pr.store.unpinned) != 1 || pr.store.unpinned[0] != root {
+		t.Errorf("expected %s to be unpinned, got: %v", root, pr.store.unpinned)
+	}
+	// pinning leaves names alone
+	if _, err := mr.GetPath("movies"); err != nil {
+		t.Errorf("expected movies to keep it's name: %s", err.Error())
+	}
+}
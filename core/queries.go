@@ -1,15 +1,20 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/rpc"
+	"runtime"
 	"time"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
 	"github.com/qri-io/cafs/memfs"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
 	sql "github.com/qri-io/dataset_sql"
+	"github.com/qri-io/qri/ref"
 	"github.com/qri-io/qri/repo"
 )
 
@@ -18,6 +23,8 @@ import (
 type QueryRequests struct {
 	repo repo.Repo
 	cli  *rpc.Client
+	// publicOnly hides private datasets, see NewPublicQueryRequests
+	publicOnly bool
 }
 
 // CoreRequestsName implements the Requets interface
@@ -42,6 +49,10 @@ func (r *QueryRequests) List(p *ListParams, res *[]*repo.DatasetRef) error {
 		return r.cli.Call("QueryRequests.List", p, res)
 	}
 
+	if r.publicOnly {
+		return errQueryLogsOwnerOnly
+	}
+
 	items, err := r.repo.ListQueryLogs(p.Limit, p.Offset)
 	if err != nil {
 		return fmt.Errorf("error getting query logs: %s", err.Error())
@@ -99,12 +110,174 @@ type RunParams struct {
 	sql.ExecOpt
 	SaveName string
 	Dataset  *dataset.Dataset
+	// Inputs maps table names used in the query to dataset names or paths,
+	// allowing a query to join multiple datasets under names of it's choosing.
+	// table names missing from Inputs are resolved through the namestore
+	Inputs map[string]string
+	// Timeout limits how long the query's response waits for results,
+	// overriding the default set with SetQueryTimeout. queries that time out
+	// stop at their next data read. zero uses the default
+	Timeout time.Duration
+	// Context cancels query execution when done. Context isn't sent over RPC,
+	// remote calls are limited by Timeout alone
+	Context context.Context `json:"-"`
+}
+
+// ErrQueryTimeout is returned when a query runs past it's time limit
+var ErrQueryTimeout = fmt.Errorf("query timed out")
+
+// queryTimeout is the default time limit for running a query
+var queryTimeout time.Duration
+
+// SetQueryTimeout sets the default time limit for running a query. A timeout
+// of zero or less lets queries run until they finish
+func SetQueryTimeout(d time.Duration) {
+	queryTimeout = d
+}
+
+// execResult holds the output of a query execution
+type execResult struct {
+	abst    *dataset.Transform
+	results []byte
+	err     error
+}
+
+// execSlots caps the number of query executions running at once. the
+// executor can't be stopped outright, so executions of queries that have
+// timed out keep their slot until they finish, which keeps repeated slow
+// queries from piling up
+var execSlots = make(chan struct{}, runtime.NumCPU())
+
+// execQuery runs a query, giving up when ctx is done. the timeout bounds the
+// response, not the work: the executor reads resources through a store that
+// fails reads once ctx is done, so cancelled queries stop at their next read,
+// but one that's already read it's data runs until it finishes. results of
+// queries that are cancelled are discarded
+func execQuery(ctx context.Context, store cafs.Filestore, q *dataset.Transform) (*dataset.Transform, []byte, error) {
+	if err := ctxError(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	select {
+	case execSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctxError(ctx)
+	}
+
+	done := make(chan execResult, 1)
+	go func() {
+		defer func() { <-execSlots }()
+		// TODO - detect data format from passed-in results structure
+		abst, results, err := sql.Exec(ctxStore{store, ctx}, q, func(o *sql.ExecOpt) {
+			o.Format = dataset.CSVDataFormat
+		})
+		done <- execResult{abst, results, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, nil, fmt.Errorf("error executing query: %s", res.err.Error())
+		}
+		return res.abst, res.results, nil
+	case <-ctx.Done():
+		return nil, nil, ctxError(ctx)
+	}
+}
+
+// ctxStore is a store that fails to get & read files once ctx is done
+type ctxStore struct {
+	cafs.Filestore
+	ctx context.Context
+}
+
+// Get gets a file that fails reads once ctx is done
+func (s ctxStore) Get(key datastore.Key) (cafs.File, error) {
+	if err := ctxError(s.ctx); err != nil {
+		return nil, err
+	}
+	f, err := s.Filestore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return ctxFile{f, s.ctx}, nil
+}
+
+// ctxFile is a file that fails reads once ctx is done
+type ctxFile struct {
+	cafs.File
+	ctx context.Context
+}
+
+func (f ctxFile) Read(p []byte) (int, error) {
+	if err := ctxError(f.ctx); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
+
+// execTransform gives a copy of q that reads partitioned resources from their
+// joined data, or q itself when no resource is partitioned. queries read
+// resource data directly, which for a partitioned dataset is it's manifest
+func execTransform(r repo.Repo, q *dataset.Transform) (*dataset.Transform, error) {
+	var (
+		store     = r.Store()
+		resources map[string]*dataset.Dataset
+	)
+	for name, res := range q.Resources {
+		parts, err := loadPartitions(store, res)
+		if err != nil {
+			return nil, err
+		}
+		if parts == nil {
+			continue
+		}
+		if resources == nil {
+			resources = map[string]*dataset.Dataset{}
+			for n, d := range q.Resources {
+				resources[n] = d
+			}
+		}
+
+		data := openData(r, res)
+		key, err := store.Put(memfs.NewMemfileReader("data."+res.Structure.Format.String(), data), false)
+		data.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error joining partitions of %s: %s", name, err.Error())
+		}
+		joined := &dataset.Dataset{}
+		joined.Assign(res)
+		joined.Data = key.String()
+		resources[name] = joined
+	}
+	if resources == nil {
+		return q, nil
+	}
+
+	exec := &dataset.Transform{}
+	exec.Assign(q)
+	exec.Resources = resources
+	return exec, nil
+}
+
+// ctxError translates the error of a done context
+func ctxError(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return ErrQueryTimeout
+	default:
+		return fmt.Errorf("query cancelled: %s", ctx.Err().Error())
+	}
 }
 
 // Run executes an SQL command against one or more existing datasets, returning a new dataset
 func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 	if r.cli != nil {
-		return r.cli.Call("QueryRequests.Run", p, res)
+		args := *p
+		args.Context = nil
+		return r.cli.Call("QueryRequests.Run", &args, res)
 	}
 
 	var (
@@ -118,6 +291,12 @@ func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 	if ds == nil {
 		return fmt.Errorf("dataset is required")
 	}
+	if r.publicOnly {
+		// given resources could refer to the data of private datasets
+		if p.SaveName != "" || ds.Transform != nil && ds.Transform.Resources != nil {
+			return withKind(ErrForbidden, fmt.Errorf("queries can only save results or give their own resources when run by the repo owner"))
+		}
+	}
 
 	ds.Timestamp = time.Now()
 
@@ -134,11 +313,12 @@ func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 		return fmt.Errorf("error getting statement table names: %s", err.Error())
 	}
 
+	resources := map[string]datastore.Key{}
 	if q.Resources == nil {
 		q.Resources = map[string]*dataset.Dataset{}
 		// collect table references
 		for _, name := range names {
-			path, err := r.repo.GetPath(name)
+			path, err := r.inputPath(name, p.Inputs)
 			if err != nil {
 				return fmt.Errorf("error getting path to dataset %s: %s", name, err.Error())
 			}
@@ -147,6 +327,7 @@ func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 				return fmt.Errorf("error loading dataset: %s", err.Error())
 			}
 			q.Resources[name] = d
+			resources[name] = path
 		}
 	}
 
@@ -176,13 +357,29 @@ func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 	// 	}
 	// }
 
-	// TODO - detect data format from passed-in results structure
-	abst, results, err = sql.Exec(store, q, func(o *sql.ExecOpt) {
-		o.Format = dataset.CSVDataFormat
-	})
+	ctx := p.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = queryTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	exec, err := execTransform(r.repo, q)
 	if err != nil {
-		return fmt.Errorf("error executing query: %s", err.Error())
+		return err
 	}
+	abst, results, err = execQuery(ctx, store, exec)
+	if err != nil {
+		return err
+	}
+	q.Structure = exec.Structure
 
 	// TODO - move this into setting on the dataset outparam
 	ds.Structure = q.Structure
@@ -217,11 +414,17 @@ func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 	}
 
 	ref := &repo.DatasetRef{Name: p.SaveName, Path: dspath, Dataset: ds}
+	if p.SaveName != "" {
+		if err := updateSearchIndex(r.repo, datastore.NewKey(""), ref); err != nil {
+			return err
+		}
+	}
 	item := &repo.QueryLogItem{
 		Query:       ds.QueryString,
 		Name:        p.SaveName,
 		Key:         qrpath,
 		DatasetPath: dspath,
+		Resources:   resources,
 		Time:        time.Now(),
 	}
 	if err := r.repo.LogQuery(item); err != nil {
@@ -232,9 +435,126 @@ func (r *QueryRequests) Run(p *RunParams, res *repo.DatasetRef) error {
 	return nil
 }
 
+// QueryValidateParams defines parameters for the Validate method
+type QueryValidateParams struct {
+	Dataset *dataset.Dataset
+	// Inputs maps table names used in the query to dataset names or paths,
+	// same as RunParams.Inputs
+	Inputs map[string]string
+}
+
+// QueryValidation is the result of validating a query
+type QueryValidation struct {
+	Valid bool `json:"valid"`
+	// Errors lists problems with the query. a valid query has no errors
+	Errors []string `json:"errors,omitempty"`
+	// Inputs maps table names in the query to the dataset paths they resolved to
+	Inputs map[string]datastore.Key `json:"inputs,omitempty"`
+	// Structure is the inferred structure of query output
+	Structure *dataset.Structure `json:"structure,omitempty"`
+}
+
+// Validate checks a query without executing it, parsing the statement,
+// resolving the datasets it references & inferring the structure of it's
+// output. Only dataset definitions are loaded, no data is read. Problems with
+// the query are reported as validation errors, Validate only errors when a
+// query can't be checked at all
+func (r *QueryRequests) Validate(p *QueryValidateParams, res *QueryValidation) error {
+	if r.cli != nil {
+		return r.cli.Call("QueryRequests.Validate", p, res)
+	}
+
+	ds := p.Dataset
+	if ds == nil {
+		return fmt.Errorf("dataset is required")
+	}
+
+	q := &dataset.Transform{
+		Syntax: "sql",
+		Data:   ds.QueryString,
+	}
+	if ds.Transform != nil {
+		q.Assign(ds.Transform)
+	}
+
+	v := QueryValidation{Inputs: map[string]datastore.Key{}}
+	names, err := sql.StatementTableNames(q.Data)
+	if err != nil {
+		v.Errors = append(v.Errors, fmt.Sprintf("error parsing query: %s", err.Error()))
+		*res = v
+		return nil
+	}
+
+	if q.Resources == nil {
+		q.Resources = map[string]*dataset.Dataset{}
+		for _, name := range names {
+			path, err := r.inputPath(name, p.Inputs)
+			if errors.Is(err, repo.ErrNotFound) {
+				v.Errors = append(v.Errors, fmt.Sprintf("table not found: %s", name))
+				continue
+			} else if err != nil {
+				v.Errors = append(v.Errors, fmt.Sprintf("error resolving table %s: %s", name, err.Error()))
+				continue
+			}
+			d, err := dsfs.LoadDataset(r.repo.Store(), path)
+			if err != nil {
+				v.Errors = append(v.Errors, fmt.Sprintf("error loading dataset for table %s: %s", name, err.Error()))
+				continue
+			}
+			q.Resources[name] = d
+			v.Inputs[name] = path
+		}
+	}
+
+	if len(v.Errors) == 0 {
+		// formatting prepares the statement against the structures of it's
+		// inputs, which sets the structure of the query result
+		if _, _, _, err := sql.Format(q); err != nil {
+			v.Errors = append(v.Errors, fmt.Sprintf("invalid query: %s", err.Error()))
+		} else {
+			v.Structure = q.Structure
+		}
+	}
+
+	v.Valid = len(v.Errors) == 0
+	*res = v
+	return nil
+}
+
+// inputPath resolves a table name in a query to a dataset path, checking
+// explicit inputs before falling back to the namestore. private datasets
+// aren't found when r only exposes public datasets
+func (r *QueryRequests) inputPath(name string, inputs map[string]string) (datastore.Key, error) {
+	path, err := r.resolveInput(name, inputs)
+	if err != nil || !r.publicOnly {
+		return path, err
+	}
+	if err := checkPathVisible(r.repo, path); err != nil {
+		return datastore.NewKey(""), err
+	}
+	return path, nil
+}
+
+// resolveInput resolves a table name in a query to a dataset path
+func (r *QueryRequests) resolveInput(name string, inputs map[string]string) (datastore.Key, error) {
+	str, ok := inputs[name]
+	if !ok {
+		return r.repo.GetPath(name)
+	}
+
+	rf, err := ref.ParseRef(str)
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+	return resolveRef(r.repo, rf)
+}
+
 // DatasetQueriesParams defines params for the DatasetQueries method
 type DatasetQueriesParams struct {
-	Path    string
+	Path string
+	// Name lists logged queries that saved results under a dataset name
+	// instead of queries that reference Path
+	Name    string
 	Orderby string
 	Limit   int
 	Offset  int
@@ -246,6 +566,13 @@ func (r *QueryRequests) DatasetQueries(p *DatasetQueriesParams, res *[]*repo.Dat
 		return r.cli.Call("QueryRequests.DatasetQueries", p, res)
 	}
 
+	if r.publicOnly {
+		return errQueryLogsOwnerOnly
+	}
+
+	if p.Name != "" {
+		return r.namedDatasetQueries(p, res)
+	}
 	if p.Path == "" {
 		return fmt.Errorf("path is required")
 	}
@@ -281,3 +608,27 @@ func (r *QueryRequests) DatasetQueries(p *DatasetQueriesParams, res *[]*repo.Dat
 	*res = list
 	return nil
 }
+
+// namedDatasetQueries lists logged queries for a dataset name
+func (r *QueryRequests) namedDatasetQueries(p *DatasetQueriesParams, res *[]*repo.DatasetRef) error {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+
+	items, err := r.repo.QueryLogsForDataset(p.Name, limit, p.Offset)
+	if err != nil {
+		return fmt.Errorf("error getting query logs: %s", err.Error())
+	}
+
+	list := make([]*repo.DatasetRef, len(items))
+	for i, item := range items {
+		list[i] = &repo.DatasetRef{Name: item.Name, Path: item.DatasetPath}
+		if ds, err := dsfs.LoadDataset(r.repo.Store(), item.DatasetPath); err == nil {
+			list[i].Dataset = ds
+		}
+	}
+
+	*res = list
+	return nil
+}
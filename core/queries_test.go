@@ -1,11 +1,15 @@
 package core
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"github.com/qri-io/dataset/dsfs"
 	"testing"
+	"time"
 
+	"github.com/qri-io/cafs/memfs"
 	"github.com/qri-io/dataset"
 	sql "github.com/qri-io/dataset_sql"
 	"github.com/qri-io/qri/repo"
@@ -29,8 +33,8 @@ func TestList(t *testing.T) {
 		res *[]*repo.DatasetRef
 		err string
 	}{
-		{&ListParams{"", 15, 1}, &[]*repo.DatasetRef{}, ""},
-		{&ListParams{"", 50, 50}, &[]*repo.DatasetRef{}, ""},
+		{&ListParams{Limit: 15, Offset: 1}, &[]*repo.DatasetRef{}, ""},
+		{&ListParams{Limit: 50, Offset: 50}, &[]*repo.DatasetRef{}, ""},
 	}
 	for i, c := range cases {
 		got := c.res
@@ -100,14 +104,22 @@ func TestRun(t *testing.T) {
 		t.Errorf("error: expected non-nil result from NewQueryRequests()")
 		return
 	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
 	cases := []struct {
 		p   *RunParams
 		res *repo.DatasetRef
 		err string
 	}{
-		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", nil}, &repo.DatasetRef{}, "dataset is required"},
-		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", &dataset.Dataset{}}, &repo.DatasetRef{}, "error getting statement table names: syntax error at position 2"},
-		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", &dataset.Dataset{QueryString: "select * from movies limit 5"}}, &repo.DatasetRef{Dataset: moviesDs}, ""},
+		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", nil, nil, 0, nil}, &repo.DatasetRef{}, "dataset is required"},
+		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", &dataset.Dataset{}, nil, 0, nil}, &repo.DatasetRef{}, "error getting statement table names: syntax error at position 2"},
+		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", &dataset.Dataset{QueryString: "select * from movies limit 5"}, nil, 0, nil}, &repo.DatasetRef{Dataset: moviesDs}, ""},
+		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", &dataset.Dataset{QueryString: "select * from movies limit 5"}, nil, 0, cancelled}, &repo.DatasetRef{}, "query cancelled: context canceled"},
+		{&RunParams{sql.ExecOpt{Format: dataset.CSVDataFormat}, "", &dataset.Dataset{QueryString: "select * from movies limit 5"}, nil, 0, expired}, &repo.DatasetRef{}, "query timed out"},
 		// TODO: add more tests
 
 	}
@@ -153,6 +165,135 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunJoin(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	cpath, err := mr.GetPath("counter")
+	if err != nil {
+		t.Errorf("error getting counter path: %s", err.Error())
+		return
+	}
+
+	req := NewQueryRequests(mr, nil)
+	cases := []struct {
+		inputs map[string]string
+		rows   int
+		err    string
+	}{
+		{map[string]string{"places": "not_a_dataset", "nums": "counter"}, 0, "error getting path to dataset places: repo: not found"},
+		{map[string]string{"places": "cities"}, 0, "error getting path to dataset nums: repo: not found"},
+		{map[string]string{"places": "cities", "nums": "counter"}, 10, ""},
+		{map[string]string{"places": "cities", "nums": cpath.String()}, 10, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Run(&RunParams{
+			ExecOpt: sql.ExecOpt{Format: dataset.CSVDataFormat},
+			Dataset: &dataset.Dataset{
+				QueryString: "select places.city, nums.count from places, nums where nums.count < 3",
+			},
+			Inputs: c.inputs,
+		}, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		f, err := dsfs.LoadData(mr.Store(), got.Dataset)
+		if err != nil {
+			t.Errorf("case %d error loading data: %s", i, err.Error())
+			continue
+		}
+		rows, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Errorf("case %d error reading csv data: %s", i, err.Error())
+			continue
+		}
+		if len(rows) != c.rows {
+			t.Errorf("case %d row count mismatch. expected: %d, got: %d", i, c.rows, len(rows))
+			continue
+		}
+		for j, row := range rows {
+			if len(row) != 2 {
+				t.Errorf("case %d row %d expected 2 joined columns, got: %d", i, j, len(row))
+				break
+			}
+		}
+
+		item, err := mr.QueryLogItem(&repo.QueryLogItem{DatasetPath: got.Path})
+		if err != nil {
+			t.Errorf("case %d error getting query log item: %s", i, err.Error())
+			continue
+		}
+		if len(item.Resources) != 2 {
+			t.Errorf("case %d expected logged query to reference both inputs", i)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewQueryRequests(mr, nil)
+
+	cases := []struct {
+		p      *QueryValidateParams
+		valid  bool
+		errors []string
+		err    string
+	}{
+		{&QueryValidateParams{}, false, nil, "dataset is required"},
+		{&QueryValidateParams{Dataset: &dataset.Dataset{QueryString: "select * from movies"}}, true, nil, ""},
+		{&QueryValidateParams{Dataset: &dataset.Dataset{QueryString: "select * from not_a_dataset"}}, false, []string{"table not found: not_a_dataset"}, ""},
+		{&QueryValidateParams{
+			Dataset: &dataset.Dataset{QueryString: "select places.city from places, nums"},
+			Inputs:  map[string]string{"places": "cities"},
+		}, false, []string{"table not found: nums"}, ""},
+		{&QueryValidateParams{
+			Dataset: &dataset.Dataset{QueryString: "select places.city from places, nums"},
+			Inputs:  map[string]string{"places": "cities", "nums": "counter"},
+		}, true, nil, ""},
+	}
+
+	for i, c := range cases {
+		got := &QueryValidation{}
+		err := req.Validate(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Valid != c.valid {
+			t.Errorf("case %d valid mismatch. expected: %t, got: %t. errors: %v", i, c.valid, got.Valid, got.Errors)
+		}
+		if len(got.Errors) != len(c.errors) {
+			t.Errorf("case %d error count mismatch. expected: %d, got: %d", i, len(c.errors), len(got.Errors))
+			continue
+		}
+		for j, e := range c.errors {
+			if got.Errors[j] != e {
+				t.Errorf("case %d validation error %d mismatch. expected: %s, got: %s", i, j, e, got.Errors[j])
+			}
+		}
+		if c.valid && got.Structure == nil {
+			t.Errorf("case %d expected valid query to have an output structure", i)
+		}
+	}
+}
+
 func TestDatasetQueries(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
@@ -176,6 +317,10 @@ func TestDatasetQueries(t *testing.T) {
 		t.Errorf("error running query: %s", err.Error())
 		return
 	}
+	if err := mr.LogQuery(&repo.QueryLogItem{Query: "select * from movies", Name: "movies_query", DatasetPath: qres.Path, Time: time.Now()}); err != nil {
+		t.Errorf("error logging query: %s", err.Error())
+		return
+	}
 
 	cases := []struct {
 		p   *DatasetQueriesParams
@@ -184,6 +329,8 @@ func TestDatasetQueries(t *testing.T) {
 	}{
 		{&DatasetQueriesParams{}, []*repo.DatasetRef{}, "path is required"},
 		{&DatasetQueriesParams{Path: path.String()}, []*repo.DatasetRef{&repo.DatasetRef{}}, ""},
+		{&DatasetQueriesParams{Name: "not_a_dataset"}, []*repo.DatasetRef{}, ""},
+		{&DatasetQueriesParams{Name: "movies_query"}, []*repo.DatasetRef{&repo.DatasetRef{}}, ""},
 		// TODO: ALWAYS MOAR TESTS. OM NOM NOM FEED THE TEST MONSTER.
 	}
 
@@ -201,3 +348,33 @@ func TestDatasetQueries(t *testing.T) {
 		}
 	}
 }
+
+func TestCtxStore(t *testing.T) {
+	store := memfs.NewMapstore()
+	key, err := store.Put(memfs.NewMemfileBytes("data.csv", []byte("a,b\n1,2\n")), false)
+	if err != nil {
+		t.Errorf("error putting file: %s", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cs := ctxStore{store, ctx}
+	f, err := cs.Get(key)
+	if err != nil {
+		t.Errorf("error getting file: %s", err.Error())
+		return
+	}
+	if _, err := f.Read(make([]byte, 2)); err != nil {
+		t.Errorf("unexpected error reading file: %s", err.Error())
+	}
+
+	// cancelled queries stop at their next read
+	cancel()
+	if _, err := f.Read(make([]byte, 2)); err == nil || err.Error() != "query cancelled: context canceled" {
+		t.Errorf("expected reads to fail once cancelled, got: %v", err)
+	}
+	if _, err := cs.Get(key); err == nil || err.Error() != "query cancelled: context canceled" {
+		t.Errorf("expected gets to fail once cancelled, got: %v", err)
+	}
+}
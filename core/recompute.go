@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// RecomputeParams defines parameters for recomputing a dataset's length.
+// either Name or Path is required
+type RecomputeParams struct {
+	Name string
+	Path datastore.Key
+}
+
+// Recompute reads a dataset's data in a single pass to measure it's length
+// in bytes. If the length the dataset declares is missing or wrong, a
+// corrected version of the dataset is saved & the dataset's name is moved to
+// the new version. Datasets with an accurate length are left as-is
+func (r *DatasetRequests) Recompute(p *RecomputeParams, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Recompute", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	ref := &repo.DatasetRef{Name: p.Name, Path: p.Path}
+	if p.Name != "" {
+		if ref.Path, err = r.repo.GetPath(p.Name); err != nil {
+			return fmt.Errorf("error getting dataset '%s': %s", p.Name, err.Error())
+		}
+	} else if p.Path.String() != "" {
+		if ref.Path, err = resolvePath(r.repo, p.Path); err != nil {
+			return err
+		}
+		ref.Name, _ = r.repo.GetName(ref.Path)
+	} else {
+		return fmt.Errorf("either name or path is required")
+	}
+
+	if _, err = r.recompute(ref, false); err != nil {
+		return err
+	}
+	*res = *ref
+	return nil
+}
+
+// RecomputeAll recomputes the length of every named dataset, returning
+// references to the datasets that had to be corrected. When dryRun is true
+// datasets that need correcting are returned without changing anything
+func (r *DatasetRequests) RecomputeAll(dryRun *bool, res *[]*repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.RecomputeAll", dryRun, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	count, err := r.repo.NameCount()
+	if err != nil {
+		return fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.repo.Namespace(count, 0)
+	if err != nil {
+		return fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+
+	corrected := []*repo.DatasetRef{}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		changed, err := r.recompute(ref, dryRun != nil && *dryRun)
+		if err != nil {
+			return err
+		}
+		if changed {
+			corrected = append(corrected, ref)
+		}
+	}
+
+	*res = corrected
+	return nil
+}
+
+// recompute measures the data length of the dataset ref points to, saving a
+// corrected version if the declared length is wrong. ref is updated to point
+// to the corrected version. changed reports if the declared length was wrong
+func (r *DatasetRequests) recompute(ref *repo.DatasetRef, dryRun bool) (changed bool, err error) {
+	store := r.repo.Store()
+	ds, err := dsfs.LoadDataset(store, ref.Path)
+	if err != nil {
+		return false, fmt.Errorf("error loading dataset '%s': %s", ref.Path, err.Error())
+	}
+
+	length, err := dataLength(store, ds)
+	if err != nil {
+		return false, fmt.Errorf("error reading data for dataset '%s': %s", ref.Path, err.Error())
+	}
+	ref.Dataset = ds
+	if ds.Length == length {
+		return false, nil
+	}
+
+	ds.Length = length
+	if dryRun {
+		return true, nil
+	}
+	if ref.Path, err = r.saveVersion(ref, ds); err != nil {
+		return false, fmt.Errorf("error saving corrected dataset: %s", err.Error())
+	}
+	return true, nil
+}
+
+// dataLength counts the bytes of a dataset's data, adding up the length of
+// each partition if the dataset is partitioned
+func dataLength(store cafs.Filestore, ds *dataset.Dataset) (int, error) {
+	parts, err := loadPartitions(store, ds)
+	if err != nil {
+		return 0, err
+	}
+	keys := []string{ds.Data}
+	if parts != nil {
+		keys = parts.Partitions
+	}
+
+	length := 0
+	for _, key := range keys {
+		file, err := store.Get(datastore.NewKey(key))
+		if err != nil {
+			return 0, fmt.Errorf("error getting data file '%s': %s", key, err.Error())
+		}
+		n, err := io.Copy(ioutil.Discard, file)
+		if err != nil {
+			return 0, fmt.Errorf("error reading data file '%s': %s", key, err.Error())
+		}
+		length += int(n)
+	}
+	return length, nil
+}
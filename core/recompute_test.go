@@ -0,0 +1,114 @@
+package core
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsRecompute(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	data, err := ioutil.ReadFile("../repo/test/testdata/movies.csv")
+	if err != nil {
+		t.Errorf("error reading movies data: %s", err.Error())
+		return
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	cases := []struct {
+		p   *RecomputeParams
+		err string
+	}{
+		{&RecomputeParams{}, "either name or path is required"},
+		{&RecomputeParams{Name: "not_a_dataset"}, "error getting dataset 'not_a_dataset': repo: not found"},
+		{&RecomputeParams{Path: datastore.NewKey("/map/missing")}, "error loading dataset '/map/missing': error getting file bytes: datastore: key not found"},
+	}
+	for i, c := range cases {
+		err := req.Recompute(c.p, &repo.DatasetRef{})
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+
+	got := &repo.DatasetRef{}
+	if err := req.Recompute(&RecomputeParams{Name: "movies"}, got); err != nil {
+		t.Errorf("error recomputing: %s", err.Error())
+		return
+	}
+	if got.Dataset.Length != len(data) {
+		t.Errorf("length mismatch. expected: %d, got: %d", len(data), got.Dataset.Length)
+	}
+	if got.Path.Equal(moviesPath) {
+		t.Errorf("expected a corrected version to be saved")
+	}
+	if path, _ := mr.GetPath("movies"); !path.Equal(got.Path) {
+		t.Errorf("expected name to move to corrected version. expected: %s, got: %s", got.Path, path)
+	}
+
+	// recomputing an accurate dataset shouldn't change anything
+	again := &repo.DatasetRef{}
+	if err := req.Recompute(&RecomputeParams{Path: got.Path}, again); err != nil {
+		t.Errorf("error recomputing: %s", err.Error())
+		return
+	}
+	if !again.Path.Equal(got.Path) {
+		t.Errorf("expected accurate dataset to be left as-is. expected: %s, got: %s", got.Path, again.Path)
+	}
+}
+
+func TestDatasetRequestsRecomputeAll(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	count, err := mr.NameCount()
+	if err != nil {
+		t.Errorf("error getting name count: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	dryRun := true
+	res := []*repo.DatasetRef{}
+	if err := req.RecomputeAll(&dryRun, &res); err != nil {
+		t.Errorf("error running dry run: %s", err.Error())
+		return
+	}
+	if len(res) != count {
+		t.Errorf("expected all test datasets to need recomputing. expected: %d, got: %d", count, len(res))
+	}
+	for _, ref := range res {
+		if path, _ := mr.GetPath(ref.Name); !path.Equal(ref.Path) {
+			t.Errorf("expected dry run not to move name %s", ref.Name)
+		}
+	}
+
+	dryRun = false
+	if err := req.RecomputeAll(&dryRun, &res); err != nil {
+		t.Errorf("error recomputing: %s", err.Error())
+		return
+	}
+	if len(res) != count {
+		t.Errorf("recomputed count mismatch. expected: %d, got: %d", count, len(res))
+	}
+	if err := req.RecomputeAll(&dryRun, &res); err != nil {
+		t.Errorf("error recomputing: %s", err.Error())
+		return
+	}
+	if len(res) != 0 {
+		t.Errorf("expected no datasets to need recomputing after recompute, got: %d", len(res))
+	}
+}
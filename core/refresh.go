@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// RefreshParams defines parameters for re-fetching a dataset from it's
+// download url
+type RefreshParams struct {
+	// Name of the dataset to refresh. required
+	Name string
+	// Force re-fetches data before the dataset's accrual period has elapsed
+	Force bool
+	// Context cancels downloading when done. Context isn't sent over RPC
+	Context context.Context `json:"-"`
+}
+
+// Refresh re-downloads the data of a dataset with a DownloadURL once the
+// repeating interval of it's AccrualPeriodicity has elapsed since the latest
+// version or refresh, adding a new version if the data has changed. datasets
+// that aren't due for a refresh or have unchanged data are left as-is, and
+// res is set to the existing version. repos that support it record the time
+// of each refresh, whether or not the data changed
+func (r *DatasetRequests) Refresh(p *RefreshParams, res *repo.DatasetRef) error {
+	if r.cli != nil {
+		args := *p
+		args.Context = nil
+		return r.cli.Call("DatasetRequests.Refresh", &args, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	if p.Name == "" {
+		return fmt.Errorf("name is required to refresh a dataset")
+	}
+	path, err := r.repo.GetPath(p.Name)
+	if err != nil {
+		return fmt.Errorf("error getting dataset path: %s", err.Error())
+	}
+	store := r.repo.Store()
+	ds, err := dsfs.LoadDataset(store, path)
+	if err != nil {
+		return fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	current := repo.DatasetRef{Name: p.Name, Path: path, Dataset: ds}
+
+	if ds.DownloadURL == "" {
+		return fmt.Errorf("dataset '%s' has no download url to refresh from", p.Name)
+	}
+	if ds.Structure == nil {
+		return fmt.Errorf("dataset '%s' has no structure", p.Name)
+	}
+	period, err := parseRepeatingInterval(ds.AccrualPeriodicity)
+	if err != nil {
+		return fmt.Errorf("invalid accrual periodicity '%s': %s", ds.AccrualPeriodicity, err.Error())
+	}
+	if !p.Force && time.Since(lastRefreshed(r.repo, path, ds)) < period {
+		*res = current
+		return nil
+	}
+
+	attempted := time.Now().In(time.UTC)
+	resp, err := httpGet(p.Context, ds.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("error fetching url: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error fetching url: %s", resp.Status)
+	}
+	// unchanged data doesn't add a version, record the attempt so the
+	// dataset isn't due again until another period has passed
+	if rs, ok := r.repo.(repo.Refreshes); ok {
+		if err := rs.SetRefreshed(path, attempted); err != nil {
+			return fmt.Errorf("error recording refresh: %s", err.Error())
+		}
+	}
+
+	// process downloaded data the way InitDataset does, so unchanged data
+	// hashes to the same path
+	rdr, filename, err := gunzipData(resp.Body, urlFilename(ds.DownloadURL))
+	if err != nil {
+		return err
+	}
+	if isJSONLines(filename) {
+		rdr = jsonLinesToArray(rdr)
+	}
+	if isXLSX(filename) {
+		if rdr, err = xlsxToCSV(rdr, ""); err != nil {
+			return err
+		}
+	}
+	data, err := spoolData(rdr)
+	if err != nil {
+		return fmt.Errorf("error reading data: %s", err.Error())
+	}
+	defer data.Close()
+
+	datafile := "data." + ds.Structure.Format.String()
+	dr, err := data.Reader()
+	if err != nil {
+		return err
+	}
+	datakey, err := store.Put(memfs.NewMemfileReader(datafile, dr), false)
+	if err != nil {
+		return fmt.Errorf("error putting data in store: %s", err.Error())
+	}
+	if datakey.String() == ds.Data {
+		*res = current
+		return nil
+	}
+
+	if dr, err = data.Reader(); err != nil {
+		return err
+	}
+	return r.Update(&UpdateParams{
+		Changes:      &dataset.Dataset{Previous: path},
+		Name:         p.Name,
+		DataFilename: datafile,
+		Data:         dr,
+	}, res)
+}
+
+// dueForUpdate filters refs to datasets that have gone a full repeating
+// interval of their accrual periodicity without a new version or a refresh
+// as of at. datasets without a valid accrual periodicity are never due
+func dueForUpdate(r repo.Repo, refs []*repo.DatasetRef, at time.Time) []*repo.DatasetRef {
+	due := make([]*repo.DatasetRef, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Dataset == nil {
+			continue
+		}
+		period, err := parseRepeatingInterval(ref.Dataset.AccrualPeriodicity)
+		if err != nil {
+			continue
+		}
+		if !lastRefreshed(r, ref.Path, ref.Dataset).Add(period).After(at) {
+			due = append(due, ref)
+		}
+	}
+	return due
+}
+
+// lastRefreshed gives the time the dataset at path last had it's data
+// checked, the later of it's timestamp & the last refresh the repo recorded
+func lastRefreshed(r repo.Repo, path datastore.Key, ds *dataset.Dataset) time.Time {
+	if rs, ok := r.(repo.Refreshes); ok {
+		if t, err := rs.Refreshed(path); err == nil && t.After(ds.Timestamp) {
+			return t
+		}
+	}
+	return ds.Timestamp
+}
+
+// parseRepeatingInterval reads the duration of an ISO8601 repeating
+// interval, eg: R/P1W or R5/2008-03-01T13:00:00Z/P1Y2M10DT2H30M. years &
+// months are approximated as 365 & 30 days
+func parseRepeatingInterval(str string) (time.Duration, error) {
+	parts := strings.Split(str, "/")
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "R") {
+		return 0, fmt.Errorf("expected a repeating interval like R/P1W")
+	}
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, "P") {
+			return parseISODuration(part)
+		}
+	}
+	return 0, fmt.Errorf("repeating interval has no duration")
+}
+
+// parseISODuration reads an ISO8601 duration, eg: P1W or P1DT12H
+func parseISODuration(str string) (time.Duration, error) {
+	if len(str) < 3 || str[0] != 'P' {
+		return 0, fmt.Errorf("invalid duration '%s'", str)
+	}
+
+	const day = 24 * time.Hour
+	var (
+		d         time.Duration
+		num       = ""
+		inTime    = false
+		dateUnits = map[byte]time.Duration{'Y': 365 * day, 'M': 30 * day, 'W': 7 * day, 'D': day}
+		timeUnits = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	)
+	for i := 1; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c >= '0' && c <= '9' || c == '.':
+			num += string(c)
+		case c == 'T' && !inTime && num == "":
+			inTime = true
+		default:
+			units := dateUnits
+			if inTime {
+				units = timeUnits
+			}
+			unit, ok := units[c]
+			if !ok || num == "" {
+				return 0, fmt.Errorf("invalid duration '%s'", str)
+			}
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration '%s'", str)
+			}
+			d += time.Duration(n * float64(unit))
+			num = ""
+		}
+	}
+	if num != "" || d <= 0 {
+		return 0, fmt.Errorf("invalid duration '%s'", str)
+	}
+	return d, nil
+}
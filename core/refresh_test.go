@@ -0,0 +1,236 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/analytics"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestParseRepeatingInterval(t *testing.T) {
+	cases := []struct {
+		str string
+		d   time.Duration
+		err string
+	}{
+		{"", 0, "expected a repeating interval like R/P1W"},
+		{"P1W", 0, "expected a repeating interval like R/P1W"},
+		{"R/2008-03-01T13:00:00Z", 0, "repeating interval has no duration"},
+		{"R/P", 0, "invalid duration 'P'"},
+		{"R/P1X", 0, "invalid duration 'P1X'"},
+		{"R/P1", 0, "invalid duration 'P1'"},
+		{"R/PT", 0, "invalid duration 'PT'"},
+		{"R/P1W", 7 * 24 * time.Hour, ""},
+		{"R5/P1D", 24 * time.Hour, ""},
+		{"R/P1DT12H", 36 * time.Hour, ""},
+		{"R/PT1M", time.Minute, ""},
+		{"R/P1M", 30 * 24 * time.Hour, ""},
+		{"R/PT0.5H", 30 * time.Minute, ""},
+		{"R/2008-03-01T13:00:00Z/P1Y", 365 * 24 * time.Hour, ""},
+	}
+
+	for i, c := range cases {
+		got, err := parseRepeatingInterval(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got != c.d {
+			t.Errorf("case %d duration mismatch. expected: %s, got: %s", i, c.d, got)
+		}
+	}
+}
+
+func TestDueForUpdate(t *testing.T) {
+	mr, err := repo.NewMemRepo(&profile.Profile{Username: "test_user"}, memfs.NewMapstore(), repo.MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Errorf("error allocating repo: %s", err.Error())
+		return
+	}
+
+	now := time.Now()
+	ref := func(name, periodicity string, age time.Duration) *repo.DatasetRef {
+		return &repo.DatasetRef{Name: name, Path: datastore.NewKey("/map/" + name), Dataset: &dataset.Dataset{AccrualPeriodicity: periodicity, Timestamp: now.Add(-age)}}
+	}
+	refs := []*repo.DatasetRef{
+		ref("daily_stale", "R/P1D", 48*time.Hour),
+		ref("daily_fresh", "R/P1D", time.Hour),
+		ref("daily_refreshed", "R/P1D", 48*time.Hour),
+		ref("weekly", "R/P1W", 48*time.Hour),
+		ref("no_periodicity", "", 48*time.Hour),
+		ref("bad_periodicity", "R/P1X", 48*time.Hour),
+		{Name: "no_dataset"},
+	}
+	// refreshes that found unchanged data don't add versions
+	if err := mr.(repo.Refreshes).SetRefreshed(datastore.NewKey("/map/daily_refreshed"), now.Add(-2*time.Hour)); err != nil {
+		t.Errorf("error setting refreshed: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		at    time.Time
+		names []string
+	}{
+		{now, []string{"daily_stale"}},
+		{now.Add(-48 * time.Hour), []string{}},
+		{now.Add(24 * time.Hour), []string{"daily_stale", "daily_fresh", "daily_refreshed"}},
+		{now.Add(6 * 24 * time.Hour), []string{"daily_stale", "daily_fresh", "daily_refreshed", "weekly"}},
+	}
+
+	for i, c := range cases {
+		got := dueForUpdate(mr, refs, c.at)
+		if len(got) != len(c.names) {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, len(c.names), len(got))
+			continue
+		}
+		for j, name := range c.names {
+			if got[j].Name != name {
+				t.Errorf("case %d ref %d name mismatch. expected: %s, got: %s", i, j, name, got[j].Name)
+			}
+		}
+	}
+}
+
+func TestDatasetRequestsListDueForUpdate(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	p := &InitDatasetParams{
+		Name:               "daily",
+		DataFilename:       "daily.csv",
+		Data:               memfs.NewMemfileBytes("daily.csv", []byte("a,b\n1,2\n")),
+		AccrualPeriodicity: "R/P1D",
+	}
+	if err := req.InitDataset(p, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p     *ListParams
+		count int
+	}{
+		{&ListParams{DueForUpdate: true}, 0},
+		{&ListParams{DueForUpdate: true, Before: time.Now().Add(48 * time.Hour)}, 1},
+		{&ListParams{DueForUpdate: true, Before: time.Now().Add(48 * time.Hour), Offset: 1}, 0},
+	}
+
+	for i, c := range cases {
+		got := &DatasetList{}
+		if err := req.ListPage(c.p, got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got.Datasets) != c.count {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, c.count, len(got.Datasets))
+		}
+		if c.count > 0 && got.Datasets[0].Name != "daily" {
+			t.Errorf("case %d expected daily to be due, got: %s", i, got.Datasets[0].Name)
+		}
+		if c.p.Offset == 0 && got.Total != c.count {
+			t.Errorf("case %d total mismatch. expected: %d, got: %d", i, c.count, got.Total)
+		}
+	}
+}
+
+func TestDatasetRequestsRefresh(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	data := "a,b\n1,2\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(data))
+	}))
+	defer server.Close()
+
+	initial := &repo.DatasetRef{}
+	if err := req.InitDataset(&InitDatasetParams{Name: "refreshed", URL: server.URL + "/data.csv"}, initial); err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+
+	errCases := []struct {
+		p   *RefreshParams
+		err string
+	}{
+		{&RefreshParams{}, "name is required to refresh a dataset"},
+		{&RefreshParams{Name: "not_a_dataset"}, "error getting dataset path: repo: not found"},
+		{&RefreshParams{Name: "movies"}, "dataset 'movies' has no download url to refresh from"},
+	}
+	for i, c := range errCases {
+		err := req.Refresh(c.p, &repo.DatasetRef{})
+		if err == nil || err.Error() != c.err {
+			t.Errorf("error case %d mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+
+	// a dataset that was just added isn't due for a refresh
+	got := &repo.DatasetRef{}
+	if err := req.Refresh(&RefreshParams{Name: "refreshed"}, got); err != nil {
+		t.Errorf("error refreshing dataset: %s", err.Error())
+		return
+	}
+	if !got.Path.Equal(initial.Path) {
+		t.Errorf("expected refresh before the accrual period to keep path %s, got: %s", initial.Path, got.Path)
+	}
+
+	if _, err := mr.(repo.Refreshes).Refreshed(initial.Path); err != repo.ErrNotFound {
+		t.Errorf("expected a refresh that isn't due not to be recorded, got: %v", err)
+	}
+
+	// unchanged data is a no-op, but the attempt is recorded
+	before := time.Now()
+	got = &repo.DatasetRef{}
+	if err := req.Refresh(&RefreshParams{Name: "refreshed", Force: true}, got); err != nil {
+		t.Errorf("error refreshing unchanged dataset: %s", err.Error())
+		return
+	}
+	if !got.Path.Equal(initial.Path) {
+		t.Errorf("expected refresh of unchanged data to keep path %s, got: %s", initial.Path, got.Path)
+	}
+	if refreshed, err := mr.(repo.Refreshes).Refreshed(initial.Path); err != nil || refreshed.Before(before.Add(-time.Second)) {
+		t.Errorf("expected refresh of unchanged data to be recorded, got: %s, %v", refreshed, err)
+	}
+
+	data = "a,b\n1,2\n3,4\n"
+	got = &repo.DatasetRef{}
+	if err := req.Refresh(&RefreshParams{Name: "refreshed", Force: true}, got); err != nil {
+		t.Errorf("error refreshing changed dataset: %s", err.Error())
+		return
+	}
+	if got.Path.Equal(initial.Path) {
+		t.Errorf("expected refresh of changed data to add a new version")
+		return
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), got.Path)
+	if err != nil {
+		t.Errorf("error loading refreshed dataset: %s", err.Error())
+		return
+	}
+	if ds.Previous.String() != initial.Path.String() {
+		t.Errorf("previous mismatch. expected: %s, got: %s", initial.Path, ds.Previous)
+	}
+	if ds.DownloadURL != server.URL+"/data.csv" {
+		t.Errorf("expected refreshed dataset to keep download url, got: %s", ds.DownloadURL)
+	}
+	if path, err := mr.GetPath("refreshed"); err != nil || !path.Equal(got.Path) {
+		t.Errorf("expected name to move to the refreshed version, got: %s, %v", path, err)
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+)
+
+// resolveRef gives the path of the dataset a reference points to. hash
+// prefixes are expanded, and versioned names are resolved by walking back
+// through the named dataset's history
+func resolveRef(r repo.Repo, rf ref.Ref) (datastore.Key, error) {
+	if rf.IsPath() {
+		return resolvePath(r, datastore.NewKey(rf.Path))
+	}
+	if rf.Peer != "" {
+		return datastore.NewKey(""), fmt.Errorf("'%s' is a peer dataset, use peer requests to get it", rf.String())
+	}
+
+	path, err := r.GetPath(rf.Name)
+	if err != nil {
+		return path, err
+	}
+	if rf.Version == 0 {
+		return path, nil
+	}
+	return versionPath(r, path, rf.Version)
+}
+
+// versionPath finds the path of a numbered version of the dataset at path,
+// counting the first version as 1. versions past the latest are not found
+func versionPath(r repo.Repo, path datastore.Key, version int) (datastore.Key, error) {
+	paths := []datastore.Key{path}
+	for {
+		ds, err := loadDataset(r, path)
+		if err != nil {
+			return datastore.NewKey(""), fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		if ds.Previous.String() == "" || ds.Previous.String() == "/" {
+			break
+		}
+		path = previousPath(ds)
+		paths = append(paths, path)
+	}
+
+	if version > len(paths) {
+		return datastore.NewKey(""), withKind(repo.ErrNotFound, fmt.Errorf("version %d not found, dataset has %d versions", version, len(paths)))
+	}
+	return paths[len(paths)-version], nil
+}
+
+// previousPath gives the canonical path of a dataset's previous version
+func previousPath(ds *dataset.Dataset) datastore.Key {
+	if rf, err := ref.ParseRef(ds.Previous.String()); err == nil && rf.IsPath() {
+		return datastore.NewKey(rf.Path)
+	}
+	return ds.Previous
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/ref"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestResolveRef(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		ref  ref.Ref
+		path string
+		err  string
+	}{
+		{ref.Ref{Name: "movies"}, moviesPath.String(), ""},
+		{ref.Ref{Name: "movies", Version: 1}, moviesPath.String(), ""},
+		{ref.Ref{Name: "movies", Version: 2}, "", "version 2 not found, dataset has 1 versions"},
+		{ref.Ref{Name: "not_a_dataset"}, "", "repo: not found"},
+		{ref.Ref{Peer: "b5", Name: "movies"}, "", "'b5/movies' is a peer dataset, use peer requests to get it"},
+		{ref.Ref{Path: moviesPath.String()}, moviesPath.String(), ""},
+	}
+
+	for i, c := range cases {
+		got, err := resolveRef(mr, c.ref)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && got.String() != c.path {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, c.path, got.String())
+		}
+	}
+}
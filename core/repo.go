@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+	"net/rpc"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// RepoRequests encapsulates business logic for the repository as a whole
+type RepoRequests struct {
+	repo repo.Repo
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requets interface
+func (RepoRequests) CoreRequestsName() string { return "repo" }
+
+// NewRepoRequests creates a RepoRequests pointer from either a repo
+// or an rpc.Client
+func NewRepoRequests(r repo.Repo, cli *rpc.Client) *RepoRequests {
+	if r != nil && cli != nil {
+		panic(fmt.Errorf("both repo and client supplied to NewRepoRequests"))
+	}
+
+	return &RepoRequests{
+		repo: r,
+		cli:  cli,
+	}
+}
+
+// Size gives the number of bytes the repo occupies
+func (r *RepoRequests) Size(in *bool, res *int64) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.Size", in, res)
+	}
+
+	size, err := r.repo.Size()
+	if err != nil {
+		return fmt.Errorf("error getting repo size: %s", err.Error())
+	}
+	*res = size
+	return nil
+}
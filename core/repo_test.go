@@ -0,0 +1,41 @@
+package core
+
+import (
+	"testing"
+
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestRepoRequestsSize(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewRepoRequests(mr, nil)
+
+	in := true
+	var before int64
+	if err := req.Size(&in, &before); err != nil {
+		t.Errorf("error getting repo size: %s", err.Error())
+		return
+	}
+	if before <= 0 {
+		t.Errorf("expected a test repo with datasets to have a size greater than zero, got: %d", before)
+	}
+
+	dsr := NewDatasetRequests(mr, nil)
+	if err := dsr.Delete(&DeleteParams{Name: "movies"}, new(bool)); err != nil {
+		t.Errorf("error deleting dataset: %s", err.Error())
+		return
+	}
+
+	var after int64
+	if err := req.Size(&in, &after); err != nil {
+		t.Errorf("error getting repo size: %s", err.Error())
+		return
+	}
+	if after >= before {
+		t.Errorf("expected repo size to shrink after deleting a dataset. before: %d, after: %d", before, after)
+	}
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/dsio"
+)
+
+const (
+	// SampleHead infers types from rows at the start of a file. this is the
+	// fastest strategy, but misses values that only appear later in a file
+	SampleHead = "head"
+	// SampleRandom infers types from rows chosen at random from the whole file
+	SampleRandom = "random"
+	// SampleFull infers types from every row in a file
+	SampleFull = "full"
+)
+
+// DefaultSampleSize is the number of rows sampled by the head & random
+// sampling strategies when no sample size is given
+const DefaultSampleSize = 1000
+
+// errStopSampling stops iterating rows once a sample is complete
+var errStopSampling = fmt.Errorf("stop sampling")
+
+// refineSchema widens the field types of a detected structure to fit a
+// sample of rows from data chosen by strategy. types are only ever widened,
+// integer fields that contain floats become float fields, and fields with
+// values that don't match their type become string fields. data is read as
+// a stream, length is it's size in bytes
+func refineSchema(st *dataset.Structure, data io.Reader, length int, strategy string, size int) error {
+	switch strategy {
+	case SampleHead, SampleRandom, SampleFull:
+	default:
+		return fmt.Errorf("invalid sampling strategy '%s'. must be one of: %s, %s, %s", strategy, SampleHead, SampleRandom, SampleFull)
+	}
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	if size <= 0 {
+		size = DefaultSampleSize
+	}
+
+	widen := func(row [][]byte) {
+		for i, f := range st.Schema.Fields {
+			if i >= len(row) || len(bytes.TrimSpace(row[i])) == 0 {
+				continue
+			}
+			f.Type = widenType(f.Type, datatypes.ParseDatatype(bytes.TrimSpace(row[i])))
+		}
+	}
+
+	rr, err := dsio.NewRowReader(st, data)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+	if strategy == SampleFull {
+		// every row is used, so rows are checked as they're read
+		return dsio.EachRow(rr, func(i int, row [][]byte, err error) error {
+			if err != nil {
+				return err
+			}
+			widen(row)
+			return nil
+		})
+	}
+
+	rows, err := sampleRows(rr, length, strategy, size)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		widen(row)
+	}
+	return nil
+}
+
+// sampleRows reads the rows selected by the head or random sampling
+// strategies
+func sampleRows(rr dsio.RowReader, length int, strategy string, size int) ([][][]byte, error) {
+	var (
+		rows [][][]byte
+		// seed with the data length so the same data always gives the same sample
+		rnd = rand.New(rand.NewSource(int64(length)))
+	)
+
+	err := dsio.EachRow(rr, func(i int, row [][]byte, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strategy {
+		case SampleHead:
+			if i >= size {
+				return errStopSampling
+			}
+			rows = append(rows, row)
+		case SampleRandom:
+			// reservoir sampling keeps a uniform sample of size rows in a single pass
+			if i < size {
+				rows = append(rows, row)
+			} else if j := rnd.Intn(i + 1); j < size {
+				rows[j] = row
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errStopSampling {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// widenType gives the narrowest type that can hold values of both a & b
+func widenType(a, b datatypes.Type) datatypes.Type {
+	switch {
+	case a == b || b == datatypes.Unknown:
+		return a
+	case a == datatypes.Unknown:
+		return b
+	case a == datatypes.Integer && b == datatypes.Float || a == datatypes.Float && b == datatypes.Integer:
+		return datatypes.Float
+	default:
+		return datatypes.String
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestWidenType(t *testing.T) {
+	cases := []struct {
+		a, b, expect datatypes.Type
+	}{
+		{datatypes.Integer, datatypes.Integer, datatypes.Integer},
+		{datatypes.Integer, datatypes.Unknown, datatypes.Integer},
+		{datatypes.Unknown, datatypes.Float, datatypes.Float},
+		{datatypes.Integer, datatypes.Float, datatypes.Float},
+		{datatypes.Float, datatypes.Integer, datatypes.Float},
+		{datatypes.Integer, datatypes.Boolean, datatypes.String},
+		{datatypes.Boolean, datatypes.String, datatypes.String},
+	}
+
+	for i, c := range cases {
+		if got := widenType(c.a, c.b); got != c.expect {
+			t.Errorf("case %d type mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestInitDatasetSampleStrategy(t *testing.T) {
+	// a score column that's integer until the very last row
+	buf := &bytes.Buffer{}
+	buf.WriteString("id,score\n")
+	for i := 0; i < 5000; i++ {
+		buf.WriteString(fmt.Sprintf("%d,%d\n", i, i%100))
+	}
+	buf.WriteString("5000,99.5\n")
+	data := buf.Bytes()
+
+	cases := []struct {
+		strategy string
+		size     int
+		score    datatypes.Type
+		err      string
+	}{
+		{"bogus", 0, datatypes.Unknown, "error sampling data: invalid sampling strategy 'bogus'. must be one of: head, random, full"},
+		{SampleHead, 10, datatypes.Integer, ""},
+		{SampleFull, 0, datatypes.Float, ""},
+		{SampleRandom, 10000, datatypes.Float, ""},
+	}
+
+	for i, c := range cases {
+		mr, err := testrepo.NewTestRepo()
+		if err != nil {
+			t.Errorf("error allocating test repo: %s", err.Error())
+			return
+		}
+		req := NewDatasetRequests(mr, nil)
+
+		got := &repo.DatasetRef{}
+		err = req.InitDataset(&InitDatasetParams{
+			Name:           "scores",
+			DataFilename:   "scores.csv",
+			Data:           memfs.NewMemfileBytes("scores.csv", data),
+			SampleStrategy: c.strategy,
+			SampleSize:     c.size,
+		}, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if err := dsfs.DerefDatasetStructure(mr.Store(), got.Dataset); err != nil {
+			t.Errorf("case %d error dereferencing structure: %s", i, err.Error())
+			continue
+		}
+		fields := got.Dataset.Structure.Schema.Fields
+		if len(fields) != 2 {
+			t.Errorf("case %d expected 2 fields, got: %d", i, len(fields))
+			continue
+		}
+		if fields[1].Type != c.score {
+			t.Errorf("case %d score type mismatch. expected: %s, got: %s", i, c.score, fields[1].Type)
+		}
+	}
+}
+
+func TestInitDatasetSampleLargeFile(t *testing.T) {
+	// a score column that's integer until a row past the detection prefix
+	buf := &bytes.Buffer{}
+	buf.WriteString("id,score\n")
+	for i := 0; buf.Len() < detectPrefixSize*2; i++ {
+		buf.WriteString(fmt.Sprintf("%d,%d\n", i, i%100))
+	}
+	buf.WriteString("0,99.5\n")
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	got := &repo.DatasetRef{}
+	err = req.InitDataset(&InitDatasetParams{
+		Name:           "scores",
+		DataFilename:   "scores.csv",
+		Data:           bytes.NewReader(buf.Bytes()),
+		SampleStrategy: SampleFull,
+	}, got)
+	if err != nil {
+		t.Errorf("error initializing dataset: %s", err.Error())
+		return
+	}
+	if err := dsfs.DerefDatasetStructure(mr.Store(), got.Dataset); err != nil {
+		t.Errorf("error dereferencing structure: %s", err.Error())
+		return
+	}
+	fields := got.Dataset.Structure.Schema.Fields
+	if len(fields) != 2 {
+		t.Errorf("expected 2 fields, got: %d", len(fields))
+		return
+	}
+	if fields[1].Type != datatypes.Float {
+		t.Errorf("score type mismatch. expected: %s, got: %s", datatypes.Float, fields[1].Type)
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/qri/repo"
+)
+
+// JSONSchemaDraft is the JSON Schema version StructureSchema documents use
+const JSONSchemaDraft = "http://json-schema.org/draft-06/schema#"
+
+// StructureSchema is a JSON Schema document describing each row of a
+// dataset as an object keyed by field name
+type StructureSchema struct {
+	Schema     string                     `json:"$schema"`
+	Title      string                     `json:"title,omitempty"`
+	Type       string                     `json:"type"`
+	Properties map[string]*SchemaProperty `json:"properties"`
+	// Fields lists property names in column order, which json objects don't keep
+	Fields []string `json:"fields"`
+}
+
+// SchemaProperty describes a single field of a StructureSchema. an empty
+// Type allows any value
+type SchemaProperty struct {
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// Schema gives the structure of a dataset as a JSON Schema document. types
+// are inferred from a sample of rows for datasets that don't declare a schema
+func (r *DatasetRequests) Schema(p *GetDatasetParams, res *StructureSchema) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Schema", p, res)
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(p, ref); err != nil {
+		return err
+	}
+	ds := ref.Dataset
+	if ds.Structure == nil {
+		return fmt.Errorf("dataset has no structure")
+	}
+
+	st := ds.Structure
+	if st.Schema == nil || len(st.Schema.Fields) == 0 {
+		var err error
+		if st, err = r.sampleStructure(ds); err != nil {
+			return err
+		}
+	}
+
+	schema := StructureSchema{
+		Schema:     JSONSchemaDraft,
+		Title:      ds.Title,
+		Type:       "object",
+		Properties: map[string]*SchemaProperty{},
+		Fields:     []string{},
+	}
+	if st.Schema != nil {
+		for _, f := range st.Schema.Fields {
+			schema.Properties[f.Name] = schemaProperty(f.Type)
+			schema.Fields = append(schema.Fields, f.Name)
+		}
+	}
+
+	*res = schema
+	return nil
+}
+
+// sampleStructure detects the structure of a dataset from the leading rows
+// of it's data
+func (r *DatasetRequests) sampleStructure(ds *dataset.Dataset) (*dataset.Structure, error) {
+	file := openData(r.repo, ds)
+	defer file.Close()
+
+	sample, err := ioutil.ReadAll(io.LimitReader(file, int64(detectPrefixSize)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading data: %s", err.Error())
+	}
+	st, err := detect.FromReader("data."+ds.Structure.Format.String(), bytes.NewReader(trimPartialRow(sample)))
+	if err != nil {
+		return nil, fmt.Errorf("error determining dataset schema: %s", err.Error())
+	}
+	return st, nil
+}
+
+// schemaProperty translates a qri datatype to a JSON Schema property
+func schemaProperty(t datatypes.Type) *SchemaProperty {
+	switch t {
+	case datatypes.String:
+		return &SchemaProperty{Type: "string"}
+	case datatypes.Integer:
+		return &SchemaProperty{Type: "integer"}
+	case datatypes.Float:
+		return &SchemaProperty{Type: "number"}
+	case datatypes.Boolean:
+		return &SchemaProperty{Type: "boolean"}
+	case datatypes.Date:
+		return &SchemaProperty{Type: "string", Format: "date-time"}
+	default:
+		return &SchemaProperty{}
+	}
+}
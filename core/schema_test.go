@@ -0,0 +1,108 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/dsfs"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsSchema(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	// datasets created with InitDataset have detected schemas, save one
+	// without a schema to check types are inferred
+	datakey, err := mr.Store().Put(memfs.NewMemfileBytes("data.csv", []byte("name,count,ratio\na,1,0.5\nb,2,1.5\n")), false)
+	if err != nil {
+		t.Errorf("error putting data: %s", err.Error())
+		return
+	}
+	ds := &dataset.Dataset{
+		Title: "undeclared",
+		Data:  datakey.String(),
+		Structure: &dataset.Structure{
+			Format:       dataset.CSVDataFormat,
+			FormatConfig: &dataset.CSVOptions{HeaderRow: true},
+		},
+	}
+	dspath, err := dsfs.SaveDataset(mr.Store(), ds, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	if err := mr.PutName("undeclared", dspath); err != nil {
+		t.Errorf("error putting name: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p      *GetDatasetParams
+		fields []string
+		types  []string
+		err    string
+	}{
+		{&GetDatasetParams{Name: "not_a_dataset"}, nil, nil, "error getting dataset path: repo: not found"},
+		{&GetDatasetParams{Name: "movies"}, []string{"title", "duration"}, []string{"string", "integer"}, ""},
+		{&GetDatasetParams{Name: "undeclared"}, []string{"name", "count", "ratio"}, []string{"string", "integer", "number"}, ""},
+	}
+
+	for i, c := range cases {
+		got := &StructureSchema{}
+		err := req.Schema(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Type != "object" {
+			t.Errorf("case %d type mismatch. expected: object, got: %s", i, got.Type)
+		}
+		if len(got.Fields) != len(c.fields) {
+			t.Errorf("case %d field count mismatch. expected: %d, got: %d", i, len(c.fields), len(got.Fields))
+			continue
+		}
+		for j, name := range c.fields {
+			if got.Fields[j] != name {
+				t.Errorf("case %d field %d name mismatch. expected: %s, got: %s", i, j, name, got.Fields[j])
+				continue
+			}
+			if prop := got.Properties[name]; prop == nil || prop.Type != c.types[j] {
+				t.Errorf("case %d field %s type mismatch. expected: %s, got: %v", i, name, c.types[j], prop)
+			}
+		}
+	}
+}
+
+func TestSchemaProperty(t *testing.T) {
+	cases := []struct {
+		t      datatypes.Type
+		typ    string
+		format string
+	}{
+		{datatypes.Unknown, "", ""},
+		{datatypes.Any, "", ""},
+		{datatypes.String, "string", ""},
+		{datatypes.Integer, "integer", ""},
+		{datatypes.Float, "number", ""},
+		{datatypes.Boolean, "boolean", ""},
+		{datatypes.Date, "string", "date-time"},
+	}
+
+	for i, c := range cases {
+		got := schemaProperty(c.t)
+		if got.Type != c.typ || got.Format != c.format {
+			t.Errorf("case %d mismatch. expected: %s %s, got: %s %s", i, c.typ, c.format, got.Type, got.Format)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/rpc"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/fs"
@@ -81,3 +82,24 @@ func (d *SearchRequests) Reindex(p *ReindexSearchParams, done *bool) error {
 
 	return fmt.Errorf("search reindexing is currently only supported on file-system repos")
 }
+
+// updateSearchIndex patches the search index of repos that support
+// incremental indexing, removing prev & adding ref. an empty prev or nil ref
+// is skipped
+func updateSearchIndex(r repo.Repo, prev datastore.Key, ref *repo.DatasetRef) error {
+	si, ok := r.(repo.SearchIndexer)
+	if !ok {
+		return nil
+	}
+	if prev.String() != "" && prev.String() != "/" && (ref == nil || !prev.Equal(ref.Path)) {
+		if err := si.RemoveFromIndex(prev); err != nil {
+			return fmt.Errorf("error updating search index: %s", err.Error())
+		}
+	}
+	if ref != nil {
+		if err := si.IndexDataset(ref); err != nil {
+			return fmt.Errorf("error updating search index: %s", err.Error())
+		}
+	}
+	return nil
+}
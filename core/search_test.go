@@ -0,0 +1,107 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/analytics"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// indexingRepo records incremental search index changes
+type indexingRepo struct {
+	repo.Repo
+	indexed, removed []string
+}
+
+func (r *indexingRepo) IndexDataset(ref *repo.DatasetRef) error {
+	r.indexed = append(r.indexed, ref.Path.String())
+	return nil
+}
+
+func (r *indexingRepo) RemoveFromIndex(path datastore.Key) error {
+	r.removed = append(r.removed, path.String())
+	return nil
+}
+
+func TestUpdateSearchIndex(t *testing.T) {
+	a, b := datastore.NewKey("/map/a"), datastore.NewKey("/map/b")
+	cases := []struct {
+		prev             datastore.Key
+		ref              *repo.DatasetRef
+		indexed, removed int
+	}{
+		{datastore.NewKey(""), &repo.DatasetRef{Path: a}, 1, 0},
+		{a, &repo.DatasetRef{Path: b}, 1, 1},
+		{a, &repo.DatasetRef{Path: a}, 1, 0},
+		{a, nil, 0, 1},
+	}
+
+	for i, c := range cases {
+		r := &indexingRepo{}
+		if err := updateSearchIndex(r, c.prev, c.ref); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(r.indexed) != c.indexed {
+			t.Errorf("case %d indexed count mismatch. expected: %d, got: %d", i, c.indexed, len(r.indexed))
+		}
+		if len(r.removed) != c.removed {
+			t.Errorf("case %d removed count mismatch. expected: %d, got: %d", i, c.removed, len(r.removed))
+		}
+	}
+}
+
+func TestReindexSnapshot(t *testing.T) {
+	a, b, c := datastore.NewKey("/map/a"), datastore.NewKey("/map/b"), datastore.NewKey("/map/c")
+	prev := &repo.Snapshot{Refs: []*repo.DatasetRef{{Name: "a", Path: a}, {Name: "b", Path: b}}}
+	next := &repo.Snapshot{Refs: []*repo.DatasetRef{{Name: "b", Path: b}, {Name: "c", Path: c}, {Name: "c_alias", Path: c}}}
+
+	r := &indexingRepo{}
+	if err := reindexSnapshot(r, prev, next); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if len(r.indexed) != 1 || r.indexed[0] != c.String() {
+		t.Errorf("expected only %s to be indexed, got: %v", c, r.indexed)
+	}
+	if len(r.removed) != 1 || r.removed[0] != a.String() {
+		t.Errorf("expected only %s to be removed, got: %v", a, r.removed)
+	}
+}
+
+func TestDatasetRequestsDeleteSearchIndex(t *testing.T) {
+	mr, err := repo.NewMemRepo(&profile.Profile{}, memfs.NewMapstore(), repo.MemPeers{}, &analytics.Memstore{})
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path := datastore.NewKey("/map/a")
+	if err := mr.PutName("a", path); err != nil {
+		t.Errorf("error putting name: %s", err.Error())
+		return
+	}
+	if err := mr.AddAlias("a", "a_alias"); err != nil {
+		t.Errorf("error adding alias: %s", err.Error())
+		return
+	}
+
+	r := &indexingRepo{Repo: mr}
+	req := NewDatasetRequests(r, nil)
+	if err := req.Delete(&DeleteParams{Name: "a_alias"}, new(bool)); err != nil {
+		t.Errorf("error deleting alias: %s", err.Error())
+		return
+	}
+	if len(r.removed) != 0 {
+		t.Errorf("expected a path that's still named to stay indexed, removed: %v", r.removed)
+	}
+	if err := req.Delete(&DeleteParams{Name: "a"}, new(bool)); err != nil {
+		t.Errorf("error deleting dataset: %s", err.Error())
+		return
+	}
+	if len(r.removed) != 1 || r.removed[0] != path.String() {
+		t.Errorf("expected %s to be removed from the index, removed: %v", path, r.removed)
+	}
+}
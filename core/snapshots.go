@@ -0,0 +1,257 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+)
+
+// DefaultSnapshotLimit is the default number of namespace snapshots to keep
+const DefaultSnapshotLimit = 10
+
+// snapshotLimit is the number of snapshots to keep when snapshotting the
+// namespace before destructive operations. zero disables snapshots
+var snapshotLimit = 0
+
+// SetSnapshotLimit sets the number of namespace snapshots to keep. When
+// limit is greater than zero, core methods that delete, rename, alias or
+// squash datasets will snapshot the namespace first, dropping the oldest
+// snapshots beyond limit. a limit of zero or less disables snapshots
+func SetSnapshotLimit(limit int) {
+	snapshotLimit = limit
+}
+
+// snapshotsEnabled reports whether mutations to r are snapshotted
+func snapshotsEnabled(r repo.Repo) bool {
+	_, ok := r.(repo.Snapshots)
+	return ok && snapshotLimit > 0
+}
+
+// snapshotBeforeMutation records a snapshot of the repo namespace if
+// snapshots are enabled & supported by the repo. unpin lists versions the
+// mutation releases, they're unpinned once the snapshot is dropped
+func snapshotBeforeMutation(r repo.Repo, reason string, unpin ...datastore.Key) error {
+	if !snapshotsEnabled(r) {
+		return nil
+	}
+	snap, err := repo.TakeSnapshot(r, reason)
+	if err != nil {
+		return fmt.Errorf("error taking snapshot: %s", err.Error())
+	}
+	snap.Unpin = unpin
+	return keepSnapshot(r, snap)
+}
+
+// keepSnapshot stores snap, dropping the oldest snapshots beyond the limit
+func keepSnapshot(r repo.Repo, snap *repo.Snapshot) error {
+	ss := r.(repo.Snapshots)
+	if err := ss.PutSnapshot(snap); err != nil {
+		return fmt.Errorf("error saving snapshot: %s", err.Error())
+	}
+
+	snaps, err := ss.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("error listing snapshots: %s", err.Error())
+	}
+	if len(snaps) <= snapshotLimit {
+		return nil
+	}
+	for _, dropped := range snaps[snapshotLimit:] {
+		if err := ss.DeleteSnapshot(dropped.ID); err != nil {
+			return fmt.Errorf("error removing snapshot: %s", err.Error())
+		}
+	}
+	return releaseSnapshots(r, snaps[:snapshotLimit], snaps[snapshotLimit:])
+}
+
+// unpinReleased unpins versions a mutation released from the store. When
+// snapshots are enabled the versions are instead left for the snapshot taken
+// before the mutation to unpin once it's dropped
+func unpinReleased(r repo.Repo, versions []datastore.Key) error {
+	pinner, ok := r.Store().(cafs.Pinner)
+	if !ok || snapshotsEnabled(r) {
+		return nil
+	}
+	for _, version := range versions {
+		if err := pinner.Unpin(datastore.NewKey(ref.RootPath(version.String())), true); err != nil {
+			return fmt.Errorf("error unpinning dataset %s: %s", version.String(), err.Error())
+		}
+	}
+	return nil
+}
+
+// releaseSnapshots unpins versions released by the operations dropped
+// snapshots were taken before, unless a name or a kept snapshot still
+// refers to them
+func releaseSnapshots(r repo.Repo, kept, dropped []*repo.Snapshot) error {
+	pinner, ok := r.Store().(cafs.Pinner)
+	if !ok {
+		return nil
+	}
+	unpin := []datastore.Key{}
+	for _, snap := range dropped {
+		unpin = append(unpin, snap.Unpin...)
+	}
+	if len(unpin) == 0 {
+		return nil
+	}
+
+	retained, err := retainedVersions(r, kept)
+	if err != nil {
+		return err
+	}
+	for _, version := range unpin {
+		if retained[version.String()] {
+			continue
+		}
+		if err := pinner.Unpin(datastore.NewKey(ref.RootPath(version.String())), true); err != nil {
+			return fmt.Errorf("error unpinning dataset %s: %s", version.String(), err.Error())
+		}
+		// the same version can be released by more than one dropped snapshot
+		retained[version.String()] = true
+	}
+	return nil
+}
+
+// retainedVersions gives the set of versions in the history of any dataset
+// named in r or in snaps
+func retainedVersions(r repo.Repo, snaps []*repo.Snapshot) (map[string]bool, error) {
+	count, err := r.NameCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	heads, err := r.Namespace(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+	for _, snap := range snaps {
+		heads = append(heads, snap.Refs...)
+	}
+
+	retained := map[string]bool{}
+	for _, head := range heads {
+		if head == nil {
+			continue
+		}
+		path := head.Path
+		for !retained[path.String()] {
+			retained[path.String()] = true
+			// versions that can't be loaded have no history to walk
+			ds, err := loadDataset(r, path)
+			if err != nil || ds.Previous.String() == "" || ds.Previous.String() == "/" {
+				break
+			}
+			path = previousPath(ds)
+		}
+	}
+	return retained, nil
+}
+
+// ListSnapshots lists namespace snapshots, newest first
+func (r *DatasetRequests) ListSnapshots(p *ListParams, res *[]*repo.Snapshot) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ListSnapshots", p, res)
+	}
+
+	// snapshots record every name, including private ones
+	if r.publicOnly {
+		return withKind(ErrForbidden, fmt.Errorf("snapshots can only be listed by the repo owner"))
+	}
+
+	ss, ok := r.repo.(repo.Snapshots)
+	if !ok {
+		return fmt.Errorf("this repo doesn't support snapshots")
+	}
+	snaps, err := ss.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("error listing snapshots: %s", err.Error())
+	}
+
+	p.EnsureDefaults()
+	if p.Offset >= len(snaps) {
+		snaps = []*repo.Snapshot{}
+	} else {
+		snaps = snaps[p.Offset:]
+	}
+	if p.Limit > 0 && p.Limit < len(snaps) {
+		snaps = snaps[:p.Limit]
+	}
+
+	*res = snaps
+	return nil
+}
+
+// RestoreSnapshot rolls the namespace back to the snapshot with the given ID.
+// If snapshots are enabled the current namespace is snapshotted first, so a
+// restore can itself be undone
+func (r *DatasetRequests) RestoreSnapshot(id *string, res *repo.Snapshot) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.RestoreSnapshot", id, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	ss, ok := r.repo.(repo.Snapshots)
+	if !ok {
+		return fmt.Errorf("this repo doesn't support snapshots")
+	}
+	if id == nil || *id == "" {
+		return withKind(ErrInvalidParams, fmt.Errorf("snapshot id is required"))
+	}
+	snap, err := ss.GetSnapshot(*id)
+	if err != nil {
+		return fmt.Errorf("error getting snapshot: %w", err)
+	}
+
+	// the current namespace is only kept once the restore succeeds, so
+	// dropping old snapshots can't unpin content the restore brings back
+	prev, err := repo.TakeSnapshot(r.repo, fmt.Sprintf("restore snapshot %s", snap.ID))
+	if err != nil {
+		return fmt.Errorf("error taking snapshot: %s", err.Error())
+	}
+	if err := repo.RestoreSnapshot(r.repo, snap); err != nil {
+		return fmt.Errorf("error restoring snapshot: %s", err.Error())
+	}
+	if err := reindexSnapshot(r.repo, prev, snap); err != nil {
+		return err
+	}
+	if snapshotsEnabled(r.repo) {
+		if err := keepSnapshot(r.repo, prev); err != nil {
+			return err
+		}
+	}
+
+	*res = *snap
+	return nil
+}
+
+// reindexSnapshot updates the search index after the namespace moves from
+// prev to next, dropping paths next doesn't name & adding paths it does
+func reindexSnapshot(r repo.Repo, prev, next *repo.Snapshot) error {
+	before := map[string]bool{}
+	for _, ref := range prev.Refs {
+		before[ref.Path.String()] = true
+	}
+	after := map[string]bool{}
+	for _, ref := range next.Refs {
+		if !before[ref.Path.String()] && !after[ref.Path.String()] {
+			if err := updateSearchIndex(r, datastore.NewKey(""), &repo.DatasetRef{Name: ref.Name, Path: ref.Path}); err != nil {
+				return err
+			}
+		}
+		after[ref.Path.String()] = true
+	}
+	for path := range before {
+		if !after[path] {
+			if err := updateSearchIndex(r, datastore.NewKey(path), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
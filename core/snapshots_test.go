@@ -0,0 +1,127 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsSnapshots(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	SetSnapshotLimit(2)
+	defer SetSnapshotLimit(0)
+
+	req := NewDatasetRequests(mr, nil)
+	ok := false
+	if err := req.Delete(&DeleteParams{Name: "movies"}, &ok); err != nil {
+		t.Errorf("error deleting dataset: %s", err.Error())
+		return
+	}
+	refs := []*repo.DatasetRef{}
+	if err := req.BulkRename(&BulkRenameParams{Renames: []RenameParams{{Current: "cities", New: "towns"}}}, &refs); err != nil {
+		t.Errorf("error renaming datasets: %s", err.Error())
+		return
+	}
+
+	snaps := []*repo.Snapshot{}
+	if err := req.ListSnapshots(&ListParams{}, &snaps); err != nil {
+		t.Errorf("error listing snapshots: %s", err.Error())
+		return
+	}
+	if len(snaps) != 2 {
+		t.Errorf("expected 2 snapshots, got: %d", len(snaps))
+		return
+	}
+
+	// restore to before the delete
+	id := snaps[1].ID
+	res := &repo.Snapshot{}
+	if err := req.RestoreSnapshot(&id, res); err != nil {
+		t.Errorf("error restoring snapshot: %s", err.Error())
+		return
+	}
+	got, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("expected movies to be restored: %s", err.Error())
+		return
+	}
+	if !got.Equal(path) {
+		t.Errorf("restored path mismatch. expected: %s, got: %s", path, got)
+	}
+	if _, err := mr.GetPath("towns"); err != repo.ErrNotFound {
+		t.Errorf("expected rename after snapshot to be rolled back, got error: %s", err)
+	}
+
+	// restoring takes a snapshot, dropping the oldest
+	if err := req.ListSnapshots(&ListParams{}, &snaps); err != nil {
+		t.Errorf("error listing snapshots: %s", err.Error())
+		return
+	}
+	if len(snaps) != 2 {
+		t.Errorf("expected snapshots to be capped at 2, got: %d", len(snaps))
+	}
+
+	missing := "not_a_snapshot"
+	if err := req.RestoreSnapshot(&missing, res); err == nil || err.Error() != "error getting snapshot: repo: not found" {
+		t.Errorf("expected missing snapshot error, got: %s", err)
+	}
+}
+
+// snapshotPinRepo is a pinRepo that keeps snapshots
+type snapshotPinRepo struct {
+	*pinRepo
+	repo.Snapshots
+}
+
+func TestDatasetRequestsSnapshotsUnpin(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	path, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting path: %s", err.Error())
+		return
+	}
+
+	SetSnapshotLimit(1)
+	defer SetSnapshotLimit(0)
+
+	pr := &pinRepo{Repo: mr, store: &pinStore{Filestore: mr.Store()}}
+	req := NewDatasetRequests(&snapshotPinRepo{pinRepo: pr, Snapshots: mr.(repo.Snapshots)}, nil)
+	deleted := &DeleteResult{}
+	if err := req.DeleteWithResult(&DeleteParams{Name: "movies"}, deleted); err != nil {
+		t.Errorf("error deleting dataset: %s", err.Error())
+		return
+	}
+	if deleted.Unpinned != 1 {
+		t.Errorf("expected delete to release 1 version, got: %d", deleted.Unpinned)
+	}
+	// the snapshot taken before the delete keeps movies pinned
+	if len(pr.store.unpinned) != 0 {
+		t.Errorf("expected movies to stay pinned while snapshotted, unpinned: %v", pr.store.unpinned)
+	}
+
+	// renaming drops the delete snapshot, releasing movies
+	res := &repo.DatasetRef{}
+	if err := req.Rename(&RenameParams{Current: "cities", New: "towns"}, res); err != nil {
+		t.Errorf("error renaming dataset: %s", err.Error())
+		return
+	}
+	if len(pr.store.unpinned) != 1 || pr.store.unpinned[0] != ref.RootPath(path.String()) {
+		t.Errorf("expected dropping the snapshot to unpin %s, unpinned: %v", path, pr.store.unpinned)
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+)
+
+// sniffRows is the number of leading rows checked when sniffing csv data
+const sniffRows = 10
+
+// detectDataFormat determines the format of data from it's filename
+// extension, falling back to sniffing the leading bytes of data when
+// filename has no recognized extension, so data without a named file can
+// still be detected. the returned filename carries an extension for the
+// detected format, for use with detect.FromReader
+func detectDataFormat(filename string, prefix []byte) (dataset.DataFormat, string, error) {
+	format, err := detect.ExtensionDataFormat(filename)
+	if err == nil {
+		return format, filename, nil
+	}
+
+	sniffed, serr := sniffDataFormat(prefix)
+	if serr != nil {
+		return dataset.UnknownDataFormat, filename, fmt.Errorf("error detecting data format: %s, %s", err.Error(), serr.Error())
+	}
+	return sniffed, "data." + sniffed.String(), nil
+}
+
+// sniffDataFormat guesses the format of data from it's leading bytes.
+// data that opens with an array or object must parse as json, anything else
+// is checked for rows of csv with a consistent number of columns
+func sniffDataFormat(prefix []byte) (dataset.DataFormat, error) {
+	trimmed := bytes.TrimSpace(prefix)
+	if len(trimmed) == 0 {
+		return dataset.UnknownDataFormat, fmt.Errorf("data is empty")
+	}
+
+	if trimmed[0] == '[' || trimmed[0] == '{' {
+		if sniffJSON(trimmed) {
+			return dataset.JSONDataFormat, nil
+		}
+	} else if sniffCSV(trimmed) {
+		return dataset.CSVDataFormat, nil
+	}
+	return dataset.UnknownDataFormat, fmt.Errorf("data doesn't look like csv or json")
+}
+
+// sniffCSV reports whether the leading rows of data parse as csv with at
+// least two columns, & the same number of columns in each row
+func sniffCSV(data []byte) bool {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = 0
+	rows := 0
+	for ; rows < sniffRows; rows++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return false
+		}
+		if len(rec) < 2 {
+			return false
+		}
+	}
+	return rows > 0
+}
+
+// sniffJSON reports whether data opens with a valid json object, or an array
+// who's first element is valid json. data may be truncated, so only the
+// leading value is checked
+func sniffJSON(data []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return false
+	}
+	if tok == json.Delim('{') {
+		// skip the key of an object's leading entry
+		if _, err := dec.Token(); err != nil {
+			return false
+		}
+	}
+	if !dec.More() {
+		_, err := dec.Token()
+		return err == nil
+	}
+	var v interface{}
+	return dec.Decode(&v) == nil
+}
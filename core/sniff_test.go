@@ -0,0 +1,80 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestSniffDataFormat(t *testing.T) {
+	cases := []struct {
+		data   string
+		format dataset.DataFormat
+		err    string
+	}{
+		{"", dataset.UnknownDataFormat, "data is empty"},
+		{"a,b,c\n1,2,3\n4,5,6\n", dataset.CSVDataFormat, ""},
+		{"\n\"title\",\"duration\"\n\"a, movie\",120\n", dataset.CSVDataFormat, ""},
+		{`[{"a":1,"b":"x"},{"a":2,"b":"y"}]`, dataset.JSONDataFormat, ""},
+		{`[["a",1],["b",2]`, dataset.JSONDataFormat, ""},
+		{`{"a":{"b":1},"c":2}`, dataset.JSONDataFormat, ""},
+		{`[["a",1],["b",2]]`, dataset.JSONDataFormat, ""},
+		{`[{"a":1,`, dataset.UnknownDataFormat, "data doesn't look like csv or json"},
+		{"just some words\non a few lines\n", dataset.UnknownDataFormat, "data doesn't look like csv or json"},
+		{"a,b,c\n1,2\n", dataset.UnknownDataFormat, "data doesn't look like csv or json"},
+	}
+
+	for i, c := range cases {
+		got, err := sniffDataFormat([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got != c.format {
+			t.Errorf("case %d format mismatch. expected: %s, got: %s", i, c.format, got)
+		}
+	}
+}
+
+func TestDatasetRequestsInitSniffed(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cases := []struct {
+		name   string
+		data   []byte
+		format dataset.DataFormat
+		err    string
+	}{
+		{"sniffed_csv", testrepo.JobsByAutomationData, dataset.CSVDataFormat, ""},
+		{"sniffed_json", []byte(`[{"city":"toronto","pop":2731571},{"city":"new york","pop":8550405}]`), dataset.JSONDataFormat, ""},
+		// headerless json arrays have no field names to go on
+		{"sniffed_json_array", []byte(`[["toronto",2731571],["new york",8550405]]`), dataset.JSONDataFormat, ""},
+		{"bad_data_format", testrepo.BadDataFormatData, dataset.UnknownDataFormat, "error detecting data format: no file extension provided, data doesn't look like csv or json"},
+		// sniffing only picks a format, sniffed data is still validated
+		{"bad_structure", testrepo.BadStructureData, dataset.UnknownDataFormat, "invalid structure: error: cannot use the same name, 'colb' more than once"},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		p := &InitDatasetParams{Name: c.name, Data: memfs.NewMemfileBytes("", c.data)}
+		err := req.InitDataset(p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Dataset.Structure.Format != c.format {
+			t.Errorf("case %d format mismatch. expected: %s, got: %s", i, c.format, got.Dataset.Structure.Format)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/validate"
+)
+
+// detectPrefixSize is the number of leading bytes of incoming data held in
+// memory for format detection & schema sampling. data larger than this is
+// spooled to a temp file instead of being read into memory
+var detectPrefixSize = 1 << 20
+
+// spooledData is incoming data that may be too large to hold in memory
+type spooledData struct {
+	// prefix is the start of data. if file is nil it's the complete data,
+	// otherwise it's trimmed to end on a complete line
+	prefix []byte
+	// file holds the complete data when it's larger than detectPrefixSize
+	file *os.File
+	// size is the total length of data in bytes
+	size int
+	// temp is true if file was created by spoolData & should be removed
+	temp bool
+}
+
+// spoolData reads from rdr, keeping small data in memory & copying large
+// data to a temp file. large regular files are read in place instead of
+// being copied. callers must Close the result
+func spoolData(rdr io.Reader) (*spooledData, error) {
+	prefix, err := ioutil.ReadAll(io.LimitReader(rdr, int64(detectPrefixSize)))
+	if err != nil {
+		return nil, err
+	}
+	if len(prefix) < detectPrefixSize {
+		return &spooledData{prefix: prefix, size: len(prefix)}, nil
+	}
+
+	if f, ok := rdr.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			return &spooledData{prefix: trimPartialRow(prefix), file: f, size: int(fi.Size())}, nil
+		}
+	}
+
+	f, err := ioutil.TempFile("", "qri_data")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp file: %s", err.Error())
+	}
+	d := &spooledData{file: f, temp: true}
+	if _, err := f.Write(prefix); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("error writing temp file: %s", err.Error())
+	}
+	n, err := io.Copy(f, rdr)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	d.size = len(prefix) + int(n)
+
+	d.prefix = trimPartialRow(prefix)
+	return d, nil
+}
+
+// trimPartialRow drops any trailing partial row from a prefix of data so
+// detection only sees complete rows
+func trimPartialRow(prefix []byte) []byte {
+	if i := bytes.LastIndexByte(prefix, '\n'); i > 0 {
+		return prefix[:i+1]
+	}
+	return prefix
+}
+
+// Reader gives a reader of the complete data, starting from the beginning
+// with each call
+func (d *spooledData) Reader() (io.Reader, error) {
+	if d.file == nil {
+		return bytes.NewReader(d.prefix), nil
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error seeking temp file: %s", err.Error())
+	}
+	// hide Close from readers that close what they're given
+	return struct{ io.Reader }{d.file}, nil
+}
+
+// Close removes the temp file backing large data. files spoolData was given
+// are left for their owner to close
+func (d *spooledData) Close() error {
+	if d.file == nil || !d.temp {
+		return nil
+	}
+	d.file.Close()
+	return os.Remove(d.file.Name())
+}
+
+// validateDataFormat checks the complete data is well-formed for format.
+// records of json data must also be consistent with each other
+func validateDataFormat(format dataset.DataFormat, d *spooledData) error {
+	rdr, err := d.Reader()
+	if err != nil {
+		return err
+	}
+	if err := validate.DataFormat(format, rdr); err != nil {
+		return fmt.Errorf("invalid data format: %s", err.Error())
+	}
+	if format != dataset.JSONDataFormat {
+		return nil
+	}
+
+	if rdr, err = d.Reader(); err != nil {
+		return err
+	}
+	return validateJSONRecords(rdr)
+}
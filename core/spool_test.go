@@ -0,0 +1,184 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// csvRows generates at least size bytes of csv data, ending on a complete
+// row, without holding it in memory
+type csvRows struct {
+	size, read, row int
+	buf             []byte
+}
+
+func (r *csvRows) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.read >= r.size {
+			return 0, io.EOF
+		}
+		r.buf = []byte(fmt.Sprintf("%d,name_%d,%d.5\n", r.row, r.row, r.row))
+		r.row++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.read += n
+	return n, nil
+}
+
+func TestSpoolData(t *testing.T) {
+	defer func(size int) { detectPrefixSize = size }(detectPrefixSize)
+	detectPrefixSize = 64
+
+	cases := []struct {
+		rdr     io.Reader
+		size    int
+		spooled bool
+	}{
+		{strings.NewReader("a,b\n1,2\n"), 8, false},
+		{strings.NewReader(strings.Repeat("1,2\n", 15) + "123"), 63, false},
+		{strings.NewReader(strings.Repeat("1,2\n", 16)), 64, true},
+		{strings.NewReader(strings.Repeat("1,2\n", 1024)), 4096, true},
+	}
+
+	for i, c := range cases {
+		d, err := spoolData(c.rdr)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if d.size != c.size {
+			t.Errorf("case %d size mismatch. expected: %d, got: %d", i, c.size, d.size)
+		}
+		if (d.file != nil) != c.spooled {
+			t.Errorf("case %d spooled mismatch. expected: %t, got: %t", i, c.spooled, d.file != nil)
+		}
+		if c.spooled && d.prefix[len(d.prefix)-1] != '\n' {
+			t.Errorf("case %d expected prefix to end on a complete line", i)
+		}
+
+		// data should be readable from the start more than once
+		for j := 0; j < 2; j++ {
+			rdr, err := d.Reader()
+			if err != nil {
+				t.Errorf("case %d unexpected error getting reader: %s", i, err.Error())
+				break
+			}
+			data, err := ioutil.ReadAll(rdr)
+			if err != nil {
+				t.Errorf("case %d unexpected error reading: %s", i, err.Error())
+				break
+			}
+			if len(data) != c.size {
+				t.Errorf("case %d read %d length mismatch. expected: %d, got: %d", i, j, c.size, len(data))
+			}
+		}
+
+		if err := d.Close(); err != nil {
+			t.Errorf("case %d error closing: %s", i, err.Error())
+		}
+		if d.file != nil {
+			if _, err := os.Stat(d.file.Name()); !os.IsNotExist(err) {
+				t.Errorf("case %d expected temp file to be removed", i)
+			}
+		}
+	}
+}
+
+func TestSpoolDataFile(t *testing.T) {
+	defer func(size int) { detectPrefixSize = size }(detectPrefixSize)
+	detectPrefixSize = 64
+
+	tmp, err := ioutil.TempFile("", "spool_test")
+	if err != nil {
+		t.Errorf("error creating temp file: %s", err.Error())
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := tmp.WriteString(strings.Repeat("1,2\n", 1024)); err != nil {
+		t.Errorf("error writing temp file: %s", err.Error())
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		t.Errorf("error seeking temp file: %s", err.Error())
+		return
+	}
+
+	d, err := spoolData(tmp)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	// regular files should be read in place, not copied
+	if d.file != tmp {
+		t.Errorf("expected file to be read in place")
+	}
+	if d.size != 4096 {
+		t.Errorf("size mismatch. expected: %d, got: %d", 4096, d.size)
+	}
+	if err := d.Close(); err != nil {
+		t.Errorf("error closing: %s", err.Error())
+	}
+	if _, err := os.Stat(tmp.Name()); err != nil {
+		t.Errorf("expected file to be left in place: %s", err.Error())
+	}
+}
+
+// discardingStore wraps a filestore, counting & discarding the bytes of data
+// files so large data never has to fit in memory
+type discardingStore struct {
+	cafs.Filestore
+	written int64
+}
+
+func (s *discardingStore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	if !strings.HasPrefix(file.FileName(), "data.") {
+		return s.Filestore.Put(file, pin)
+	}
+	n, err := io.Copy(ioutil.Discard, file)
+	s.written += n
+	return datastore.NewKey(fmt.Sprintf("/map/data_%d", s.written)), err
+}
+
+func TestDatasetRequestsInitLargeFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large file test in short mode")
+	}
+
+	store := &discardingStore{Filestore: memfs.NewMapstore()}
+	mr, err := repo.NewMemRepo(&profile.Profile{}, store, nil, nil)
+	if err != nil {
+		t.Errorf("error allocating repo: %s", err.Error())
+		return
+	}
+
+	rows := &csvRows{size: 32 * detectPrefixSize}
+	p := &InitDatasetParams{
+		Name:         "large",
+		DataFilename: "large.csv",
+		Data:         rows,
+	}
+
+	res := &repo.DatasetRef{}
+	if err := NewDatasetRequests(mr, nil).InitDataset(p, res); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if store.written != int64(rows.read) {
+		t.Errorf("stored data length mismatch. expected: %d, got: %d", rows.read, store.written)
+	}
+	if res.Dataset.Length != rows.read {
+		t.Errorf("dataset length mismatch. expected: %d, got: %d", rows.read, res.Dataset.Length)
+	}
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+)
+
+// SquashParams defines parameters for DatasetRequests.Squash
+type SquashParams struct {
+	// Path of the dataset version to squash. Name is used if Path is empty
+	Path datastore.Key
+	Name string
+	// Onto is an ancestor to keep as the previous version of the squashed
+	// dataset. an empty Onto squashes the entire history
+	Onto datastore.Key
+	// Unpin removes dropped versions from the store. versions other names
+	// refer to are kept
+	Unpin bool
+}
+
+// Squash collapses the history of a dataset, like a git squash. a new
+// version is saved with the data & metadata of the dataset at p.Path, and a
+// Previous of p.Onto, dropping the versions in between. if the dataset is
+// named, the name is moved to the squashed version
+func (r *DatasetRequests) Squash(p *SquashParams, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Squash", p, res)
+	}
+
+	if err := r.checkOwner(); err != nil {
+		return err
+	}
+
+	path, name := p.Path, p.Name
+	if path.String() == "" || path.String() == "/" {
+		if name == "" {
+			return fmt.Errorf("either a path or a name is required to squash a dataset")
+		}
+		if path, err = r.repo.GetPath(name); err != nil {
+			return fmt.Errorf("error getting dataset path: %s", err.Error())
+		}
+	} else {
+		if path, err = resolvePath(r.repo, path); err != nil {
+			return err
+		}
+		name, _ = r.repo.GetName(path)
+	}
+
+	onto := ""
+	if p.Onto.String() != "" && p.Onto.String() != "/" {
+		onto = ref.RootPath(p.Onto.String())
+	}
+
+	head, dropped, err := squashedVersions(r.repo, path, onto)
+	if err != nil {
+		return err
+	}
+
+	// dropped versions other names refer to are kept
+	released := []datastore.Key{}
+	if p.Unpin {
+		named, err := namedPaths(r.repo, name)
+		if err != nil {
+			return err
+		}
+		for _, version := range dropped {
+			if !named[version.String()] {
+				released = append(released, version)
+			}
+		}
+	}
+
+	if err = snapshotBeforeMutation(r.repo, fmt.Sprintf("squash %s", name), released...); err != nil {
+		return
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(head)
+	ds.Previous = datastore.NewKey(onto)
+	ds.Timestamp = time.Now().In(time.UTC)
+	dspath, err := dsfs.SaveDataset(r.repo.Store(), ds, true)
+	if err != nil {
+		return fmt.Errorf("error saving dataset: %s", err.Error())
+	}
+
+	if name != "" {
+		if err = repo.UpdateName(r.repo, name, dspath); err != nil {
+			return
+		}
+		if err = updateSearchIndex(r.repo, path, &repo.DatasetRef{Name: name, Path: dspath, Dataset: ds}); err != nil {
+			return
+		}
+	}
+
+	if err = unpinReleased(r.repo, released); err != nil {
+		return
+	}
+
+	*res = repo.DatasetRef{
+		Name:    name,
+		Path:    dspath,
+		Dataset: ds,
+	}
+	return nil
+}
+
+// squashedVersions walks back from path until a version with a root path of
+// onto, or the start of the history if onto is empty. it returns the dataset
+// at path & the paths of the versions a squash drops, starting with path
+func squashedVersions(r repo.Repo, path datastore.Key, onto string) (*dataset.Dataset, []datastore.Key, error) {
+	var (
+		head    *dataset.Dataset
+		dropped = []datastore.Key{}
+		seen    = map[string]bool{}
+	)
+	for {
+		if seen[path.String()] {
+			return nil, nil, fmt.Errorf("dataset history has a cycle at %s", path.String())
+		}
+		seen[path.String()] = true
+
+		ds, err := loadDataset(r, path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading dataset: %s", err.Error())
+		}
+		if head == nil {
+			head = ds
+		}
+		dropped = append(dropped, path)
+
+		if ds.Previous.String() == "" || ds.Previous.String() == "/" {
+			if onto != "" {
+				return nil, nil, fmt.Errorf("'%s' isn't in the history of this dataset", onto)
+			}
+			break
+		}
+		path = previousPath(ds)
+		if onto != "" && ref.RootPath(path.String()) == onto {
+			break
+		}
+	}
+
+	// a single version already has the previous version a squash would give
+	if len(dropped) == 1 {
+		return nil, nil, fmt.Errorf("dataset has no versions to squash")
+	}
+	return head, dropped, nil
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/ref"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsSquash(t *testing.T) {
+	// setup gives a repo that records unpins with a three-version movies
+	// dataset, and the path of each version, oldest first
+	setup := func() (*pinRepo, []datastore.Key, error) {
+		mr, err := testrepo.NewTestRepo()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error allocating test repo: %s", err.Error())
+		}
+		pr := &pinRepo{Repo: mr, store: &pinStore{Filestore: mr.Store()}}
+		req := NewDatasetRequests(pr, nil)
+
+		versions := []datastore.Key{}
+		for i := 1; i <= 3; i++ {
+			path, err := pr.GetPath("movies")
+			if err != nil {
+				return nil, nil, fmt.Errorf("error getting path: %s", err.Error())
+			}
+			versions = append(versions, path)
+			if i == 3 {
+				break
+			}
+			if err := req.Update(&UpdateParams{Changes: &dataset.Dataset{Title: fmt.Sprintf("movies v%d", i+1), Previous: path}}, &repo.DatasetRef{}); err != nil {
+				return nil, nil, fmt.Errorf("error updating dataset: %s", err.Error())
+			}
+		}
+		return pr, versions, nil
+	}
+
+	cases := []struct {
+		p func(versions []datastore.Key) *SquashParams
+		// log is the length of the squashed history, unpinned the number of
+		// versions unpinned
+		log, unpinned int
+		err           string
+	}{
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{} }, 0, 0, "either a path or a name is required to squash a dataset"},
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{Name: "not_a_dataset"} }, 0, 0, "error getting dataset path: repo: not found"},
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{Name: "cities"} }, 0, 0, "dataset has no versions to squash"},
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{Name: "movies", Onto: v[1]} }, 0, 0, "dataset has no versions to squash"},
+		{func(v []datastore.Key) *SquashParams {
+			return &SquashParams{Name: "movies", Onto: datastore.NewKey("/map/QmNotAVersion")}
+		}, 0, 0, "'/map/QmNotAVersion' isn't in the history of this dataset"},
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{Name: "movies"} }, 1, 0, ""},
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{Path: v[2], Unpin: true} }, 1, 3, ""},
+		{func(v []datastore.Key) *SquashParams { return &SquashParams{Name: "movies", Onto: v[0], Unpin: true} }, 2, 2, ""},
+	}
+
+	for i, c := range cases {
+		pr, versions, err := setup()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		req := NewDatasetRequests(pr, nil)
+
+		got := &repo.DatasetRef{}
+		err = req.Squash(c.p(versions), got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+
+		if got.Dataset.Title != "movies v3" {
+			t.Errorf("case %d expected the squashed dataset to keep the latest metadata, got title: %s", i, got.Dataset.Title)
+		}
+		if path, err := pr.GetPath("movies"); err != nil || !path.Equal(got.Path) {
+			t.Errorf("case %d expected movies to be moved to %s, got: %s", i, got.Path, path)
+		}
+
+		log := []*repo.DatasetRef{}
+		if err := NewHistoryRequests(pr, nil).Log(&LogParams{Path: got.Path}, &log); err != nil {
+			t.Errorf("case %d error getting log: %s", i, err.Error())
+			continue
+		}
+		if len(log) != c.log {
+			t.Errorf("case %d log length mismatch. expected: %d, got: %d", i, c.log, len(log))
+		}
+		if c.log > 1 && ref.RootPath(log[1].Path.String()) != ref.RootPath(versions[0].String()) {
+			t.Errorf("case %d expected the squashed dataset to follow %s, got: %s", i, versions[0], log[1].Path)
+		}
+		if len(pr.store.unpinned) != c.unpinned {
+			t.Errorf("case %d unpinned count mismatch. expected: %d, got: %d", i, c.unpinned, len(pr.store.unpinned))
+		}
+	}
+}
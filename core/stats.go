@@ -0,0 +1,186 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/qri/repo"
+)
+
+// StatsParams defines parameters for getting summary statistics of a dataset
+type StatsParams struct {
+	// Path of the dataset. takes precedence over Name
+	Path datastore.Key
+	// Name gets a dataset by reference when Path is empty, eg: movies or movies@2
+	Name string
+}
+
+// DatasetStats summarizes the values of each column of a dataset
+type DatasetStats struct {
+	Rows    int            `json:"rows"`
+	Columns []*ColumnStats `json:"columns"`
+}
+
+// ColumnStats summarizes the values of a single column. Min, Max & Mean are
+// only set for numeric columns, Distinct only for string columns
+type ColumnStats struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Count is the number of non-null values in the column
+	Count int `json:"count"`
+	// Nulls is the number of empty values, numeric columns also count values
+	// that can't be parsed as numbers
+	Nulls int      `json:"nulls"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Mean  *float64 `json:"mean,omitempty"`
+	// Distinct is the number of distinct values in the column. columns with
+	// more than cardinalityEstimatorSize distinct values give an estimate
+	Distinct int `json:"distinct,omitempty"`
+}
+
+// Stats computes summary statistics for each column of a dataset, reading
+// data in a single pass. stats are cached by data hash in the repo's dataset
+// cache
+func (r *DatasetRequests) Stats(p *StatsParams, res *DatasetStats) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Stats", p, res)
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&GetDatasetParams{Path: p.Path, Name: p.Name}, ref); err != nil {
+		return err
+	}
+	ds := ref.Dataset
+	if ds.Structure == nil || ds.Structure.Schema == nil {
+		return fmt.Errorf("dataset has no schema")
+	}
+
+	key := ds.Data
+	if s, ok := cachedStats(r.repo, key); ok {
+		*res = *s
+		return nil
+	}
+
+	fields := ds.Structure.Schema.Fields
+	cols := make([]*columnSummary, len(fields))
+	for i, f := range fields {
+		cols[i] = newColumnSummary(f.Name, f.Type)
+	}
+
+	rows := 0
+	if err := eachDataRow(r.repo.Store(), ds, func(i int, row [][]byte, err error) error {
+		if err != nil {
+			return err
+		}
+		rows++
+		for j, col := range cols {
+			if j < len(row) {
+				col.add(string(row[j]))
+			} else {
+				col.add("")
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error reading data: %s", err.Error())
+	}
+
+	s := &DatasetStats{
+		Rows:    rows,
+		Columns: make([]*ColumnStats, len(cols)),
+	}
+	for i, col := range cols {
+		s.Columns[i] = col.stats()
+	}
+	cacheStats(r.repo, key, s)
+
+	*res = *copyStats(s)
+	return nil
+}
+
+// columnSummary accumulates stats for a column
+type columnSummary struct {
+	name        string
+	t           datatypes.Type
+	count       int
+	nulls       int
+	sum         float64
+	min, max    float64
+	cardinality *cardinalityEstimator
+}
+
+func newColumnSummary(name string, t datatypes.Type) *columnSummary {
+	cs := &columnSummary{name: name, t: t}
+	if t == datatypes.String {
+		cs.cardinality = newCardinalityEstimator(cardinalityEstimatorSize)
+	}
+	return cs
+}
+
+func (cs *columnSummary) numeric() bool {
+	return cs.t == datatypes.Integer || cs.t == datatypes.Float
+}
+
+func (cs *columnSummary) add(val string) {
+	if val == "" {
+		cs.nulls++
+		return
+	}
+
+	if cs.numeric() {
+		v, err := parseTypedValue(cs.t, val)
+		if err != nil {
+			cs.nulls++
+			return
+		}
+		num := v.(float64)
+		if cs.count == 0 || num < cs.min {
+			cs.min = num
+		}
+		if cs.count == 0 || num > cs.max {
+			cs.max = num
+		}
+		cs.sum += num
+	} else if cs.cardinality != nil {
+		cs.cardinality.add(val)
+	}
+	cs.count++
+}
+
+func (cs *columnSummary) stats() *ColumnStats {
+	s := &ColumnStats{
+		Name:  cs.name,
+		Type:  cs.t.String(),
+		Count: cs.count,
+		Nulls: cs.nulls,
+	}
+	if cs.numeric() && cs.count > 0 {
+		min, max, mean := cs.min, cs.max, cs.sum/float64(cs.count)
+		s.Min, s.Max, s.Mean = &min, &max, &mean
+	}
+	if cs.cardinality != nil {
+		s.Distinct = cs.cardinality.estimate()
+	}
+	return s
+}
+
+// copyStats copies dataset stats, so callers can't alter cached values
+func copyStats(s *DatasetStats) *DatasetStats {
+	cp := &DatasetStats{Rows: s.Rows, Columns: make([]*ColumnStats, len(s.Columns))}
+	for i, col := range s.Columns {
+		c := *col
+		c.Min, c.Max, c.Mean = copyFloat(col.Min), copyFloat(col.Max), copyFloat(col.Mean)
+		cp.Columns[i] = &c
+	}
+	return cp
+}
+
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := *f
+	return &v
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/dsfs"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsStats(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	datakey, err := mr.Store().Put(memfs.NewMemfileBytes("data.csv", []byte("name,count,ratio\na,1,0.5\nb,,1.5\nc,3,x\na,5,2\n")), false)
+	if err != nil {
+		t.Errorf("error putting data: %s", err.Error())
+		return
+	}
+	ds := &dataset.Dataset{
+		Title: "stats",
+		Data:  datakey.String(),
+		Structure: &dataset.Structure{
+			Format:       dataset.CSVDataFormat,
+			FormatConfig: &dataset.CSVOptions{HeaderRow: true},
+			Schema: &dataset.Schema{
+				Fields: []*dataset.Field{
+					{Name: "name", Type: datatypes.String},
+					{Name: "count", Type: datatypes.Integer},
+					{Name: "ratio", Type: datatypes.Float},
+				},
+			},
+		},
+	}
+	dspath, err := dsfs.SaveDataset(mr.Store(), ds, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	if err := mr.PutName("stats", dspath); err != nil {
+		t.Errorf("error putting name: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p    *StatsParams
+		rows int
+		cols []ColumnStats
+		err  string
+	}{
+		{&StatsParams{}, 0, nil, "either a path or a name is required to get a dataset"},
+		{&StatsParams{Name: "not_a_dataset"}, 0, nil, "error getting dataset path: repo: not found"},
+		{&StatsParams{Name: "stats"}, 4, []ColumnStats{
+			{Name: "name", Count: 4, Distinct: 3},
+			{Name: "count", Count: 3, Nulls: 1, Min: floatPtr(1), Max: floatPtr(5), Mean: floatPtr(3)},
+			{Name: "ratio", Count: 3, Nulls: 1, Min: floatPtr(0.5), Max: floatPtr(2), Mean: floatPtr(4.0 / 3.0)},
+		}, ""},
+		// cached by data hash
+		{&StatsParams{Path: dspath}, 4, nil, ""},
+	}
+
+	for i, c := range cases {
+		got := &DatasetStats{}
+		err := req.Stats(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got.Rows != c.rows {
+			t.Errorf("case %d rows mismatch. expected: %d, got: %d", i, c.rows, got.Rows)
+		}
+		for j, col := range c.cols {
+			if err := compareColumnStats(&col, got.Columns[j]); err != nil {
+				t.Errorf("case %d column %d mismatch: %s", i, j, err.Error())
+			}
+		}
+	}
+}
+
+func TestStatsCache(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	if _, ok := cachedStats(mr, "/map/data"); ok {
+		t.Errorf("expected no cached stats before caching")
+	}
+	cacheStats(mr, "/map/data", &DatasetStats{Rows: 1, Columns: []*ColumnStats{{Name: "a", Min: floatPtr(1)}}})
+
+	got, ok := cachedStats(mr, "/map/data")
+	if !ok {
+		t.Errorf("expected cached stats")
+		return
+	}
+	got.Rows = 2
+	got.Columns[0].Name = "changed"
+	*got.Columns[0].Min = 2
+
+	again, _ := cachedStats(mr, "/map/data")
+	if again.Rows != 1 || again.Columns[0].Name != "a" || *again.Columns[0].Min != 1 {
+		t.Errorf("expected cached stats to be unaffected by changes to returned stats")
+	}
+
+	// stats are kept apart from the dataset cached at the same path
+	if _, ok := cachedStats(mr, "/map/other"); ok {
+		t.Errorf("expected no cached stats for uncached data")
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func compareColumnStats(a, b *ColumnStats) error {
+	if a.Name != b.Name {
+		return fmt.Errorf("name mismatch. expected: %s, got: %s", a.Name, b.Name)
+	}
+	if a.Count != b.Count {
+		return fmt.Errorf("count mismatch. expected: %d, got: %d", a.Count, b.Count)
+	}
+	if a.Nulls != b.Nulls {
+		return fmt.Errorf("nulls mismatch. expected: %d, got: %d", a.Nulls, b.Nulls)
+	}
+	if a.Distinct != b.Distinct {
+		return fmt.Errorf("distinct mismatch. expected: %d, got: %d", a.Distinct, b.Distinct)
+	}
+	for _, f := range []struct {
+		name string
+		a, b *float64
+	}{{"min", a.Min, b.Min}, {"max", a.Max, b.Max}, {"mean", a.Mean, b.Mean}} {
+		if (f.a == nil) != (f.b == nil) {
+			return fmt.Errorf("%s mismatch. expected set: %t, got: %t", f.name, f.a != nil, f.b != nil)
+		}
+		if f.a != nil && *f.a != *f.b {
+			return fmt.Errorf("%s mismatch. expected: %f, got: %f", f.name, *f.a, *f.b)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+// NewPublicDatasetRequests creates a DatasetRequests pointer that only exposes
+// public datasets, for serving a repo to users other than it's owner.
+// private datasets are left out of lists & can't be read
+func NewPublicDatasetRequests(r repo.Repo) *DatasetRequests {
+	req := NewDatasetRequests(r, nil)
+	req.publicOnly = true
+	return req
+}
+
+// NewPublicHistoryRequests creates a HistoryRequests pointer that only
+// gives the history of public datasets
+func NewPublicHistoryRequests(r repo.Repo) *HistoryRequests {
+	req := NewHistoryRequests(r, nil)
+	req.publicOnly = true
+	return req
+}
+
+// NewPublicQueryRequests creates a QueryRequests pointer that can only query
+// public datasets. queries run through it can't save results, and the query
+// log isn't exposed
+func NewPublicQueryRequests(r repo.Repo) *QueryRequests {
+	req := NewQueryRequests(r, nil)
+	req.publicOnly = true
+	return req
+}
+
+// NewPublicActivityRequests creates an ActivityRequests pointer that leaves
+// private datasets out of activity. the query log isn't exposed
+func NewPublicActivityRequests(r repo.Repo) *ActivityRequests {
+	req := NewActivityRequests(r, nil)
+	req.publicOnly = true
+	return req
+}
+
+// checkVisible errors if path is a private dataset & r only exposes public
+// datasets. private datasets are reported as not found so requests can't be
+// used to probe for them
+func (r *DatasetRequests) checkVisible(path datastore.Key) error {
+	if !r.publicOnly {
+		return nil
+	}
+	return checkPathVisible(r.repo, path)
+}
+
+// checkVisible errors if path is a private dataset & d only exposes public
+// datasets
+func (d *HistoryRequests) checkVisible(path datastore.Key) error {
+	if !d.publicOnly {
+		return nil
+	}
+	return checkPathVisible(d.repo, path)
+}
+
+// checkPathVisible errors with repo.ErrNotFound if path is a version of a
+// private dataset
+func checkPathVisible(r repo.Repo, path datastore.Key) error {
+	v, err := repo.PathVisibility(r, path)
+	if err != nil {
+		return fmt.Errorf("error checking dataset visibility: %s", err.Error())
+	}
+	if v == repo.VisibilityPrivate {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// errOwnerOnly is returned by requests that change a repo when they're made
+// through requests that only expose public datasets
+var errOwnerOnly = withKind(ErrForbidden, fmt.Errorf("datasets can only be changed by the repo owner"))
+
+// errQueryLogsOwnerOnly is returned when listing logged queries through
+// requests that only expose public datasets. logged queries can read private
+// datasets
+var errQueryLogsOwnerOnly = withKind(ErrForbidden, fmt.Errorf("query logs can only be listed by the repo owner"))
+
+// checkOwner errors if r only exposes public datasets, which is never the
+// case for the repo owner
+func (r *DatasetRequests) checkOwner() error {
+	if r.publicOnly {
+		return errOwnerOnly
+	}
+	return nil
+}
+
+// VisibilityParams defines parameters for setting the visibility of a dataset
+type VisibilityParams struct {
+	Name       string
+	Visibility string
+}
+
+// SetVisibility sets the visibility of a named dataset
+func (r *DatasetRequests) SetVisibility(p *VisibilityParams, res *repo.DatasetRef) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.SetVisibility", p, res)
+	}
+
+	if r.publicOnly {
+		return withKind(ErrForbidden, fmt.Errorf("dataset visibility can only be set by the repo owner"))
+	}
+	vs, ok := r.repo.(repo.Visibilities)
+	if !ok {
+		return fmt.Errorf("this repo doesn't support dataset visibility")
+	}
+	if p.Name == "" {
+		return withKind(ErrInvalidParams, fmt.Errorf("name is required"))
+	}
+	v, err := repo.ParseVisibility(p.Visibility)
+	if err != nil {
+		return withKind(ErrInvalidParams, err)
+	}
+	path, err := r.repo.GetPath(p.Name)
+	if err != nil {
+		return fmt.Errorf("error getting dataset: %w", err)
+	}
+
+	if err := vs.SetVisibility(p.Name, v); err != nil {
+		return fmt.Errorf("error setting visibility: %s", err.Error())
+	}
+
+	*res = repo.DatasetRef{
+		Name: p.Name,
+		Path: path,
+	}
+	return nil
+}
+
+// moveVisibility moves any visibility set for a name to a new name, or
+// removes it when to is empty
+func moveVisibility(r repo.Repo, from, to string) error {
+	vs, ok := r.(repo.Visibilities)
+	if !ok {
+		return nil
+	}
+	v, err := vs.GetVisibility(from)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if to != "" {
+		if err := vs.SetVisibility(to, v); err != nil {
+			return err
+		}
+	}
+	return vs.DeleteVisibility(from)
+}
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsSetVisibility(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	cases := []struct {
+		p   *VisibilityParams
+		err string
+	}{
+		{&VisibilityParams{Visibility: "private"}, "name is required"},
+		{&VisibilityParams{Name: "movies", Visibility: "secret"}, "invalid visibility 'secret'. must be one of: public, private"},
+		{&VisibilityParams{Name: "not_a_dataset", Visibility: "private"}, "error getting dataset: repo: not found"},
+		{&VisibilityParams{Name: "movies", Visibility: "private"}, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.SetVisibility(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+	}
+
+	pub := NewPublicDatasetRequests(mr)
+	if err := pub.SetVisibility(&VisibilityParams{Name: "movies", Visibility: "public"}, &repo.DatasetRef{}); err == nil || err.Error() != "dataset visibility can only be set by the repo owner" {
+		t.Errorf("expected public requests to be unable to set visibility, got: %v", err)
+	}
+}
+
+func TestPublicDatasetRequests(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	citiesPath, err := mr.GetPath("cities")
+	if err != nil {
+		t.Errorf("error getting cities path: %s", err.Error())
+		return
+	}
+
+	owner := NewDatasetRequests(mr, nil)
+	if err := owner.SetVisibility(&VisibilityParams{Name: "movies", Visibility: "private"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+	pub := NewPublicDatasetRequests(mr)
+
+	ownerList := []*repo.DatasetRef{}
+	if err := owner.List(&ListParams{Limit: 10}, &ownerList); err != nil {
+		t.Errorf("error listing datasets: %s", err.Error())
+		return
+	}
+	pubList := []*repo.DatasetRef{}
+	if err := pub.List(&ListParams{Limit: 10}, &pubList); err != nil {
+		t.Errorf("error listing public datasets: %s", err.Error())
+		return
+	}
+	if len(pubList) != len(ownerList)-1 {
+		t.Errorf("expected public list to have one less dataset. expected: %d, got: %d", len(ownerList)-1, len(pubList))
+	}
+	for _, ref := range pubList {
+		if ref.Name == "movies" {
+			t.Errorf("private dataset shouldn't be listed publicly")
+		}
+	}
+
+	// public pages are filled from public datasets only
+	for i := range pubList {
+		page := &DatasetList{}
+		if err := pub.ListPage(&ListParams{Limit: 1, Offset: i}, page); err != nil {
+			t.Errorf("error listing public page %d: %s", i, err.Error())
+			return
+		}
+		if len(page.Datasets) != 1 || page.Datasets[0].Name != pubList[i].Name {
+			t.Errorf("public page %d mismatch. expected: %s, got: %v", i, pubList[i].Name, page.Datasets)
+		}
+		if page.Total != len(pubList) {
+			t.Errorf("public page %d total mismatch. expected: %d, got: %d", i, len(pubList), page.Total)
+		}
+		if page.HasMore != (i < len(pubList)-1) {
+			t.Errorf("public page %d hasMore mismatch. expected: %t, got: %t", i, i < len(pubList)-1, page.HasMore)
+		}
+	}
+
+	if err := owner.Get(&GetDatasetParams{Path: moviesPath}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("owner should be able to get a private dataset: %s", err.Error())
+	}
+	if err := pub.Get(&GetDatasetParams{Path: moviesPath}, &repo.DatasetRef{}); err == nil || err.Error() != "error loading dataset: repo: not found" {
+		t.Errorf("expected private dataset to not be found, got: %v", err)
+	}
+	if err := pub.Get(&GetDatasetParams{Path: citiesPath}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("unexpected error getting public dataset: %s", err.Error())
+	}
+
+	data := &StructuredData{}
+	if err := pub.StructuredData(&StructuredDataParams{Path: moviesPath, Limit: 1}, data); err == nil {
+		t.Errorf("expected error reading private dataset data")
+	}
+	if err := pub.Column(&ColumnParams{Path: moviesPath, Name: "movie_title"}, &Column{}); err == nil {
+		t.Errorf("expected error reading private dataset column")
+	}
+
+	// renaming a dataset keeps it's visibility
+	if err := owner.Rename(&RenameParams{Current: "movies", New: "films"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error renaming dataset: %s", err.Error())
+		return
+	}
+	if v, err := repo.NameVisibility(mr, "films"); err != nil || v != repo.VisibilityPrivate {
+		t.Errorf("expected renamed dataset to be private, got: %s, %v", v, err)
+	}
+}
+
+func TestPublicRequestsOwnerOnly(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	pub := NewPublicDatasetRequests(mr)
+	id := "snapshot"
+	dryRun := true
+
+	cases := []func() error{
+		func() error {
+			return pub.InitDataset(&InitDatasetParams{Name: "new_movies", DataFilename: "movies.csv"}, &repo.DatasetRef{})
+		},
+		func() error { return pub.Update(&UpdateParams{Name: "movies"}, &repo.DatasetRef{}) },
+		func() error {
+			return pub.Rename(&RenameParams{Current: "movies", New: "films"}, &repo.DatasetRef{})
+		},
+		func() error {
+			return pub.BulkRename(&BulkRenameParams{Renames: []RenameParams{{Current: "movies", New: "films"}}}, &[]*repo.DatasetRef{})
+		},
+		func() error { return pub.AddAlias(&AliasParams{Name: "movies", Alias: "films"}, &repo.DatasetRef{}) },
+		func() error { return pub.Delete(&DeleteParams{Name: "movies"}, new(bool)) },
+		func() error {
+			return pub.AddDataset(&AddParams{Name: "movies", Hash: moviesPath.String()}, &repo.DatasetRef{})
+		},
+		func() error { return pub.Pin(&PinParams{Path: moviesPath}, new(bool)) },
+		func() error { return pub.Unpin(&PinParams{Path: moviesPath}, new(bool)) },
+		func() error { return pub.GC(&GCParams{}, &GCResult{}) },
+		func() error { return pub.Migrate(&MigrateParams{DryRun: true}, &[]*Migration{}) },
+		func() error { return pub.Refresh(&RefreshParams{Name: "movies"}, &repo.DatasetRef{}) },
+		func() error { return pub.Recompute(&RecomputeParams{Name: "movies"}, &repo.DatasetRef{}) },
+		func() error { return pub.RecomputeAll(&dryRun, &[]*repo.DatasetRef{}) },
+		func() error { return pub.Squash(&SquashParams{Name: "movies"}, &repo.DatasetRef{}) },
+		func() error { return pub.RestoreSnapshot(&id, &repo.Snapshot{}) },
+	}
+
+	for i, c := range cases {
+		if err := c(); err == nil || !errors.Is(err, ErrForbidden) {
+			t.Errorf("case %d expected a forbidden error, got: %v", i, err)
+		}
+	}
+
+	if path, err := mr.GetPath("movies"); err != nil || !path.Equal(moviesPath) {
+		t.Errorf("expected movies to be unchanged, got: %s, %v", path, err)
+	}
+}
+
+func TestPublicHistoryAndQueryRequests(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	if err := NewDatasetRequests(mr, nil).SetVisibility(&VisibilityParams{Name: "movies", Visibility: "private"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+
+	hist := NewPublicHistoryRequests(mr)
+	if err := hist.Log(&LogParams{Path: moviesPath}, &[]*repo.DatasetRef{}); err != repo.ErrNotFound {
+		t.Errorf("expected private dataset history to not be found, got: %v", err)
+	}
+	if err := hist.Descendants(&LogParams{Path: moviesPath}, &[]*repo.DatasetRef{}); err != repo.ErrNotFound {
+		t.Errorf("expected private dataset descendants to not be found, got: %v", err)
+	}
+
+	q := NewPublicQueryRequests(mr)
+	if _, err := q.inputPath("movies", nil); err != repo.ErrNotFound {
+		t.Errorf("expected private dataset to not be queryable, got: %v", err)
+	}
+	if err := q.List(&ListParams{Limit: 10}, &[]*repo.DatasetRef{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected listing query logs to be forbidden, got: %v", err)
+	}
+	run := &RunParams{SaveName: "results", Dataset: &dataset.Dataset{QueryString: "select * from cities"}}
+	if err := q.Run(run, &repo.DatasetRef{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected saving query results to be forbidden, got: %v", err)
+	}
+}
+
+func TestPublicActivityRequests(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+	if err := NewDatasetRequests(mr, nil).SetVisibility(&VisibilityParams{Name: "movies", Visibility: "private"}, &repo.DatasetRef{}); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+	now := time.Now()
+	if err := mr.LogQuery(&repo.QueryLogItem{Query: "select * from movies", Name: "movies", DatasetPath: moviesPath, Key: datastore.NewKey("/q"), Time: now}); err != nil {
+		t.Errorf("error logging query: %s", err.Error())
+		return
+	}
+	if err := repo.LogEvent(mr, &repo.Event{Type: repo.ETDatasetDeleted, Name: "secret_movies", Path: moviesPath, Time: now}); err != nil {
+		t.Errorf("error logging event: %s", err.Error())
+		return
+	}
+
+	pub := NewPublicActivityRequests(mr)
+	got := []*Activity{}
+	if err := pub.List(&ActivityParams{}, &got); err != nil {
+		t.Errorf("error listing public activity: %s", err.Error())
+		return
+	}
+	if len(got) == 0 {
+		t.Errorf("expected public datasets to have activity")
+	}
+	for _, a := range got {
+		if a.Type == ActivityQuery || a.Name == "movies" || a.Name == "secret_movies" || a.Path.Equal(moviesPath) {
+			t.Errorf("private activity shouldn't be listed publicly: %#v", a)
+		}
+	}
+
+	if err := pub.List(&ActivityParams{Types: []string{ActivityQuery}}, &got); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected listing query activity to be forbidden, got: %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// XLSXContentType is the media type of xlsx spreadsheets
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxParts are the fixed parts of a single-sheet workbook, keyed by
+// their path in the xlsx zip archive
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="data" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// CSVToXLSX converts csv data to a single-sheet xlsx spreadsheet. numeric
+// cells are written as numbers, everything else as text
+func CSVToXLSX(data []byte) ([]byte, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv: %s", err.Error())
+	}
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, part := range xlsxParts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("error creating xlsx part: %s", err.Error())
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return nil, fmt.Errorf("error writing xlsx part: %s", err.Error())
+		}
+	}
+
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("error creating xlsx sheet: %s", err.Error())
+	}
+	if err := writeXLSXSheet(w, records); err != nil {
+		return nil, fmt.Errorf("error writing xlsx sheet: %s", err.Error())
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error closing xlsx: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXLSXSheet writes records as the rows of a worksheet
+func writeXLSXSheet(w io.Writer, records [][]string) error {
+	if _, err := io.WriteString(w, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+	for i, rec := range records {
+		if _, err := fmt.Fprintf(w, `<row r="%d">`, i+1); err != nil {
+			return err
+		}
+		for j, cell := range rec {
+			if cell == "" {
+				continue
+			}
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			if isXLSXNumber(cell) {
+				if _, err := fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, cell); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref); err != nil {
+				return err
+			}
+			if err := xml.EscapeText(w, []byte(cell)); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, `</t></is></c>`); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, `</row>`); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, `</sheetData></worksheet>`)
+	return err
+}
+
+// isXLSXNumber reports if a cell can be written as a spreadsheet number.
+// hex, NaN & infinite values are left as text
+func isXLSXNumber(cell string) bool {
+	f, err := strconv.ParseFloat(cell, 64)
+	return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) && !strings.ContainsAny(cell, "xX_")
+}
+
+// xlsxColumn gives the spreadsheet name of a zero-indexed column, eg: A, Z, AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// isXLSX reports whether filename names an excel workbook
+func isXLSX(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".xlsx"
+}
+
+// xlsxCSVFilename swaps the extension of a workbook filename for .csv
+func xlsxCSVFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".csv"
+}
+
+// xlsxToCSV reads a worksheet of an excel workbook as csv data. sheet names
+// the worksheet to read, workbooks with a single worksheet can leave sheet
+// blank. rows are padded to the width of the widest row. cells are read as
+// the values excel stores, so dates & times read as numbers
+func xlsxToCSV(rdr io.Reader, sheet string) (io.Reader, error) {
+	data, err := ioutil.ReadAll(rdr)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workbook: %s", err.Error())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid workbook: %s", err.Error())
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	wb := &xlsxWorkbook{}
+	if err := readXLSXFile(files, "xl/workbook.xml", wb); err != nil {
+		return nil, err
+	}
+	if len(wb.Sheets) == 0 {
+		return nil, fmt.Errorf("invalid workbook: workbook has no sheets")
+	}
+
+	names := make([]string, len(wb.Sheets))
+	for i, s := range wb.Sheets {
+		names[i] = s.Name
+	}
+	idx := -1
+	if sheet == "" {
+		if len(wb.Sheets) > 1 {
+			return nil, fmt.Errorf("workbook has %d sheets, a sheet name is required. sheets are: %s", len(wb.Sheets), strings.Join(names, ", "))
+		}
+		idx = 0
+	}
+	for i, name := range names {
+		if name == sheet {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("sheet '%s' not found. sheets are: %s", sheet, strings.Join(names, ", "))
+	}
+
+	ws := &xlsxWorksheet{}
+	if err := readXLSXFile(files, worksheetPath(files, wb.Sheets[idx], idx), ws); err != nil {
+		return nil, err
+	}
+	shared := &xlsxSharedStrings{}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXLSXFile(files, "xl/sharedStrings.xml", shared); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([][]string, len(ws.Rows))
+	width := 0
+	for i, row := range ws.Rows {
+		for j, c := range row.Cells {
+			col := j
+			if c.Ref != "" {
+				if col, err = xlsxColumnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(rows[i]) <= col {
+				rows[i] = append(rows[i], "")
+			}
+			if rows[i][col], err = c.value(shared.Items); err != nil {
+				return nil, fmt.Errorf("invalid workbook: cell %s: %s", c.Ref, err.Error())
+			}
+		}
+		if len(rows[i]) > width {
+			width = len(rows[i])
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	for _, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf, w.Error()
+}
+
+type xlsxWorkbook struct {
+	Sheets []xlsxSheet `xml:"sheets>sheet"`
+}
+
+type xlsxSheet struct {
+	Name string `xml:"name,attr"`
+	RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText is text that's either plain or split into rich text runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	str := t.T
+	for _, r := range t.Runs {
+		str += r.T
+	}
+	return str
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	V      string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+// value gives the text of a cell, looking up shared strings by index
+func (c xlsxCell) value(shared []xlsxText) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.V)
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", fmt.Errorf("invalid shared string index '%s'", c.V)
+		}
+		return shared[i].String(), nil
+	case "inlineStr":
+		return c.Inline.String(), nil
+	case "b":
+		if c.V == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	}
+	return c.V, nil
+}
+
+// worksheetPath finds the file of a sheet from the workbook's relationships,
+// falling back to the conventional name for the sheet's position
+func worksheetPath(files map[string]*zip.File, s xlsxSheet, idx int) string {
+	rels := &xlsxRelationships{}
+	if _, ok := files["xl/_rels/workbook.xml.rels"]; ok && s.RID != "" {
+		if err := readXLSXFile(files, "xl/_rels/workbook.xml.rels", rels); err == nil {
+			for _, rel := range rels.Relationships {
+				if rel.ID != s.RID {
+					continue
+				}
+				if strings.HasPrefix(rel.Target, "/") {
+					return strings.TrimPrefix(rel.Target, "/")
+				}
+				return path.Join("xl", rel.Target)
+			}
+		}
+	}
+	return fmt.Sprintf("xl/worksheets/sheet%d.xml", idx+1)
+}
+
+// readXLSXFile decodes an xml file in a workbook
+func readXLSXFile(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid workbook: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("invalid workbook: error opening %s: %s", name, err.Error())
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("invalid workbook: error reading %s: %s", name, err.Error())
+	}
+	return nil
+}
+
+// xlsxColumnIndex gives the zero-indexed column of a cell reference like C12
+func xlsxColumnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid workbook: invalid cell reference '%s'", ref)
+	}
+	return col - 1, nil
+}
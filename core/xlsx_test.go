@@ -0,0 +1,242 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestXLSXColumn(t *testing.T) {
+	cases := []struct {
+		i    int
+		name string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for i, c := range cases {
+		if got := xlsxColumn(c.i); got != c.name {
+			t.Errorf("case %d name mismatch. expected: %s, got: %s", i, c.name, got)
+		}
+	}
+}
+
+func TestCSVToXLSX(t *testing.T) {
+	data, err := CSVToXLSX([]byte("city,pop,note\ntoronto,40000000,\"a & b\"\nchicago,NaN,\n"))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Errorf("error reading xlsx zip: %s", err.Error())
+		return
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Errorf("error opening %s: %s", f.Name, err.Error())
+			return
+		}
+		body, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Errorf("error reading %s: %s", f.Name, err.Error())
+			return
+		}
+		files[f.Name] = string(body)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected xlsx to contain %s", name)
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, expect := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">city</t></is></c>`,
+		`<c r="B2"><v>40000000</v></c>`,
+		`<c r="C2" t="inlineStr"><is><t xml:space="preserve">a &amp; b</t></is></c>`,
+		`<c r="B3" t="inlineStr"><is><t xml:space="preserve">NaN</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, expect) {
+			t.Errorf("expected sheet to contain: %s", expect)
+		}
+	}
+	if strings.Contains(sheet, `r="C3"`) {
+		t.Errorf("expected empty cells to be left out")
+	}
+}
+
+const (
+	testSharedStrings = `<sst><si><t>name</t></si><si><t>count</t></si><si><r><t>rich </t></r><r><t>text</t></r></si></sst>`
+	testPeopleSheet   = `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>ok</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>1.5</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>a, b</t></is></c><c r="C3" t="b"><v>0</v></c></row>
+</sheetData></worksheet>`
+	testOtherSheet = `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>other</t></is></c></row></sheetData></worksheet>`
+)
+
+// testWorkbook builds an xlsx workbook with a worksheet for each of sheets,
+// named Sheet1, Sheet2, etc.
+func testWorkbook(t *testing.T, sheets ...string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	add := func(name, data string) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("error creating %s: %s", name, err.Error())
+		}
+		w.Write([]byte(data))
+	}
+
+	wb := `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`
+	rels := `<Relationships>`
+	for i, sheet := range sheets {
+		wb += fmt.Sprintf(`<sheet name="Sheet%d" sheetId="%d" r:id="rId%d"/>`, i+1, i+1, i+1)
+		// store sheets in reverse order to check relationships are followed
+		target := fmt.Sprintf("worksheets/sheet%d.xml", len(sheets)-i)
+		rels += fmt.Sprintf(`<Relationship Id="rId%d" Target="%s"/>`, i+1, target)
+		add("xl/"+target, sheet)
+	}
+	add("xl/workbook.xml", wb+`</sheets></workbook>`)
+	add("xl/_rels/workbook.xml.rels", rels+`</Relationships>`)
+	add("xl/sharedStrings.xml", testSharedStrings)
+
+	if err := zw.Close(); err != nil {
+		t.Fatalf("error closing workbook: %s", err.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestXLSXToCSV(t *testing.T) {
+	single := testWorkbook(t, testPeopleSheet)
+	multi := testWorkbook(t, testOtherSheet, testPeopleSheet)
+	people := "name,count,ok\nrich text,1.5,true\n\"a, b\",,false\n"
+	// workbooks written by CSVToXLSX read back as the same csv
+	roundtrip := "city,pop,note\ntoronto,40000000,a & b\nchicago,,\n"
+	written, err := CSVToXLSX([]byte(roundtrip))
+	if err != nil {
+		t.Errorf("error writing xlsx: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		data   []byte
+		sheet  string
+		expect string
+		err    string
+	}{
+		{[]byte("not a workbook"), "", "", "invalid workbook: zip: not a valid zip file"},
+		{single, "", people, ""},
+		{single, "Sheet1", people, ""},
+		{multi, "", "", "workbook has 2 sheets, a sheet name is required. sheets are: Sheet1, Sheet2"},
+		{multi, "Sheet1", "other\n", ""},
+		{multi, "Sheet2", people, ""},
+		{multi, "Sheet3", "", "sheet 'Sheet3' not found. sheets are: Sheet1, Sheet2"},
+		{written, "", roundtrip, ""},
+		{written, "data", roundtrip, ""},
+	}
+
+	for i, c := range cases {
+		rdr, err := xlsxToCSV(bytes.NewReader(c.data), c.sheet)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		got, err := ioutil.ReadAll(rdr)
+		if err != nil {
+			t.Errorf("case %d error reading csv: %s", i, err.Error())
+			continue
+		}
+		if string(got) != c.expect {
+			t.Errorf("case %d csv mismatch. expected:\n%s\ngot:\n%s", i, c.expect, string(got))
+		}
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	cases := []struct {
+		ref string
+		col int
+		err string
+	}{
+		{"A1", 0, ""},
+		{"C12", 2, ""},
+		{"Z3", 25, ""},
+		{"AA1", 26, ""},
+		{"AB7", 27, ""},
+		{"12", 0, "invalid workbook: invalid cell reference '12'"},
+	}
+
+	for i, c := range cases {
+		got, err := xlsxColumnIndex(c.ref)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got != c.col {
+			t.Errorf("case %d column mismatch. expected: %d, got: %d", i, c.col, got)
+		}
+	}
+}
+
+func TestDatasetRequestsInitXLSX(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	multi := testWorkbook(t, testOtherSheet, testPeopleSheet)
+
+	cases := []struct {
+		p      *InitDatasetParams
+		fields int
+		err    string
+	}{
+		{&InitDatasetParams{Name: "people", DataFilename: "people.xlsx", Data: bytes.NewReader(multi)}, 0, "workbook has 2 sheets, a sheet name is required. sheets are: Sheet1, Sheet2"},
+		{&InitDatasetParams{Name: "people", DataFilename: "people.csv", Data: bytes.NewReader([]byte("a,b\n1,2\n")), SheetName: "Sheet2"}, 0, "a sheet name can only be used with xlsx data"},
+		{&InitDatasetParams{Name: "people", DataFilename: "people.xlsx", Data: bytes.NewReader(multi), SheetName: "Sheet2"}, 3, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.InitDataset(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		st := got.Dataset.Structure
+		if st.Format != dataset.CSVDataFormat {
+			t.Errorf("case %d expected csv data, got: %s", i, st.Format)
+		}
+		if len(st.Schema.Fields) != c.fields {
+			t.Errorf("case %d expected %d fields, got: %d", i, c.fields, len(st.Schema.Fields))
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 
 	pstore "gx/ipfs/QmPgDWmTmuzvP7QE5zwo1TmjbJme9pmZHNujB2453jkCTr/go-libp2p-peerstore"
 	ipfscore "gx/ipfs/QmViBzgruNUoLNBnXcx8YWbDNwV8MNGEGKkLo6JGetygdw/go-ipfs/core"
@@ -51,8 +52,14 @@ func (n *QriNode) Bootstrap(boostrapAddrs []string, boostrapPeers chan pstore.Pe
 	}
 }
 
-// BootstrapIPFS connects this node to standard ipfs nodes for file exchange
+// BootstrapIPFS connects this node to standard ipfs nodes for file exchange.
+// calls made while a bootstrap is already running return immediately
 func (n *QriNode) BootstrapIPFS() {
+	if !atomic.CompareAndSwapInt32(&n.bootstrappingIPFS, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&n.bootstrappingIPFS, 0)
+
 	if node, err := n.IPFSNode(); err == nil {
 		if err := node.Bootstrap(ipfscore.DefaultBootstrapConfig); err != nil {
 			fmt.Errorf("IPFS bootsrap error: %s", err.Error())
@@ -3,6 +3,7 @@ package p2p
 import (
 	"crypto/rand"
 	"fmt"
+	"time"
 
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/cafs/ipfs"
@@ -36,6 +37,12 @@ type NodeCfg struct {
 	// Online is a flag for weather this node should connect
 	// to the distributed network
 	Online bool
+	// HealthCheckInterval is the time between connectivity checks, zero
+	// disables connectivity monitoring
+	HealthCheckInterval time.Duration
+	// MinConnectedPeers is the number of connected peers below which the
+	// node will re-bootstrap
+	MinConnectedPeers int
 }
 
 // DefaultNodeCfg generates sensible settings for a Qri Node
@@ -65,8 +72,10 @@ func DefaultNodeCfg() *NodeCfg {
 		// TODO - enabling this causes all nodes to broadcast
 		// on the same address, which isn't good. figure out why
 		// Port:     4444,
-		QriBootstrapAddrs: DefaultBootstrapAddresses,
-		Secure:            true,
+		QriBootstrapAddrs:   DefaultBootstrapAddresses,
+		Secure:              true,
+		HealthCheckInterval: DefaultHealthCheckInterval,
+		MinConnectedPeers:   DefaultMinConnectedPeers,
 	}
 }
 
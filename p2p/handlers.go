@@ -139,6 +139,37 @@ type DatasetsReqParams struct {
 	Query  string
 	Limit  int
 	Offset int
+	// Total asks for a DatasetsRes response with the number of datasets the
+	// peer lists, instead of a bare list. peers from before totals were added
+	// ignore it
+	Total bool
+}
+
+// DatasetsRes is the response to a datasets request that asks for a total
+type DatasetsRes struct {
+	Datasets []*repo.DatasetRef
+	// Total is the number of datasets the peer lists across all pages
+	Total int
+}
+
+// DecodeDatasetsRes reads the payload of a datasets response, which is a
+// DatasetsRes, or a bare list from peers that don't send totals. Total is -1
+// if the peer didn't send one
+func DecodeDatasetsRes(payload interface{}) (*DatasetsRes, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	res := &DatasetsRes{Total: -1}
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &res.Datasets)
+	} else {
+		err = json.Unmarshal(data, res)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 func (n *QriNode) handleDatasetsRequest(r *Message) *Message {
@@ -153,49 +184,146 @@ func (n *QriNode) handleDatasetsRequest(r *Message) *Message {
 		return nil
 	}
 
-	if p.Limit == 0 {
+	if p.Limit <= 0 {
 		p.Limit = 50
 	}
-	refs, err := n.Repo.Namespace(p.Limit, p.Offset)
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+	// page through public datasets, so pages are full & totals don't count
+	// private datasets
+	count, err := n.Repo.NameCount()
+	if err != nil {
+		n.log.Info("repo name count error:", err)
+		return nil
+	}
+	refs, err := n.Repo.Namespace(count, 0)
 	if err != nil {
 		n.log.Info("repo names error:", err)
 		return nil
 	}
 
-	// replies := make([]*repo.DatasetRef, p.Limit)
-	// i := 0
+	refs, err = publicRefs(n.Repo, refs)
+	if err != nil {
+		n.log.Info("error checking dataset visibility:", err.Error())
+		return nil
+	}
+
+	total := len(refs)
+	if p.Offset >= len(refs) {
+		refs = refs[:0]
+	} else {
+		refs = refs[p.Offset:]
+	}
+	if len(refs) > p.Limit {
+		refs = refs[:p.Limit]
+	}
+
 	for i, ref := range refs {
-		if i >= p.Limit {
-			break
-		}
 		ds, err := dsfs.LoadDataset(n.Repo.Store(), ref.Path)
 		if err != nil {
 			n.log.Info("error loading dataset at path:", ref.Path)
 			return nil
 		}
 		refs[i].Dataset = ds
-		// i++
 	}
 
-	// replies = replies[:i]
+	var payload interface{} = refs
+	if p.Total {
+		payload = &DatasetsRes{Datasets: refs, Total: total}
+	}
 	return &Message{
 		Type:    MtDatasets,
 		Phase:   MpResponse,
-		Payload: refs,
+		Payload: payload,
 	}
 }
 
 func (n *QriNode) handleDatasetsResponse(pi pstore.PeerInfo, r *Message) error {
-	data, err := json.Marshal(r.Payload)
+	res, err := DecodeDatasetsRes(r.Payload)
 	if err != nil {
 		return err
 	}
-	ds := []*repo.DatasetRef{}
-	if err := json.Unmarshal(data, &ds); err != nil {
-		return err
+
+	return n.Repo.Cache().PutDatasets(res.Datasets)
+}
+
+// DatasetReqParams encapsulates options for requesting a single dataset
+type DatasetReqParams struct {
+	Name string
+}
+
+func (n *QriNode) handleDatasetRequest(r *Message) *Message {
+	data, err := json.Marshal(r.Payload)
+	if err != nil {
+		n.log.Info(err.Error())
+		return nil
+	}
+	p := &DatasetReqParams{}
+	if err := json.Unmarshal(data, p); err != nil {
+		n.log.Info("unmarshal dataset request error:", err.Error())
+		return nil
 	}
 
-	return n.Repo.Cache().PutDatasets(ds)
+	path, err := n.Repo.GetPath(p.Name)
+	if err == nil {
+		// private datasets are reported as not found, peers shouldn't be
+		// able to tell a private dataset exists
+		var v repo.Visibility
+		if v, err = repo.NameVisibility(n.Repo, p.Name); err == nil && v == repo.VisibilityPrivate {
+			err = repo.ErrNotFound
+		}
+	}
+	if err != nil {
+		return &Message{
+			Phase:   MpError,
+			Type:    MtDataset,
+			Payload: fmt.Sprintf("error getting dataset '%s': %s", p.Name, err.Error()),
+		}
+	}
+	ds, err := dsfs.LoadDataset(n.Repo.Store(), path)
+	if err != nil {
+		n.log.Info("error loading dataset at path:", path)
+		return &Message{
+			Phase:   MpError,
+			Type:    MtDataset,
+			Payload: fmt.Sprintf("error loading dataset '%s'", p.Name),
+		}
+	}
+
+	return &Message{
+		Phase: MpResponse,
+		Type:  MtDataset,
+		Payload: &repo.DatasetRef{
+			Name:    p.Name,
+			Path:    path,
+			Dataset: ds,
+		},
+	}
+}
+
+// publicRefs removes references to private datasets from refs. only public
+// datasets are ever shared with peers
+func publicRefs(r repo.Repo, refs []*repo.DatasetRef) ([]*repo.DatasetRef, error) {
+	public := make([]*repo.DatasetRef, 0, len(refs))
+	for _, ref := range refs {
+		var (
+			v   repo.Visibility
+			err error
+		)
+		if ref.Name != "" {
+			v, err = repo.NameVisibility(r, ref.Name)
+		} else {
+			v, err = repo.PathVisibility(r, ref.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v != repo.VisibilityPrivate {
+			public = append(public, ref)
+		}
+	}
+	return public, nil
 }
 
 // Search broadcasts a search request to all connected peers, aggregating results
@@ -250,6 +378,10 @@ func (n *QriNode) handleSearchRequest(r *Message) *Message {
 			n.log.Info("search error:", err.Error())
 			return nil
 		}
+		if results, err = publicRefs(n.Repo, results); err != nil {
+			n.log.Info("error checking dataset visibility:", err.Error())
+			return nil
+		}
 		return &Message{
 			Phase:   MpResponse,
 			Type:    MtSearch,
@@ -0,0 +1,188 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestHandleDatasetRequest(t *testing.T) {
+	r, err := NewTestRepo()
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	datakey, err := r.Store().Put(memfs.NewMemfileBytes("cities.csv", []byte("city,pop\ntoronto,40000000\n")), true)
+	if err != nil {
+		t.Errorf("error putting data: %s", err.Error())
+		return
+	}
+	path, err := dsfs.SaveDataset(r.Store(), &dataset.Dataset{
+		Title: "cities",
+		Data:  datakey.String(),
+		Structure: &dataset.Structure{
+			Format: dataset.CSVDataFormat,
+			Schema: &dataset.Schema{
+				Fields: []*dataset.Field{
+					{Name: "city", Type: datatypes.String},
+					{Name: "pop", Type: datatypes.Integer},
+				},
+			},
+		},
+	}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	if err := r.PutName("cities", path); err != nil {
+		t.Errorf("error naming dataset: %s", err.Error())
+		return
+	}
+
+	if err := r.PutName("secret_cities", path); err != nil {
+		t.Errorf("error naming dataset: %s", err.Error())
+		return
+	}
+	if err := r.(repo.Visibilities).SetVisibility("secret_cities", repo.VisibilityPrivate); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+
+	node := &QriNode{log: log, Repo: r}
+
+	cases := []struct {
+		name  string
+		phase MsgPhase
+		title string
+	}{
+		{"cities", MpResponse, "cities"},
+		{"not_a_dataset", MpError, ""},
+		{"secret_cities", MpError, ""},
+	}
+
+	for i, c := range cases {
+		res := node.handleDatasetRequest(&Message{
+			Phase:   MpRequest,
+			Type:    MtDataset,
+			Payload: &DatasetReqParams{Name: c.name},
+		})
+		if res == nil {
+			t.Errorf("case %d expected a response", i)
+			continue
+		}
+		if res.Type != MtDataset {
+			t.Errorf("case %d message type mismatch. expected: %s, got: %s", i, MtDataset, res.Type)
+		}
+		if res.Phase != c.phase {
+			t.Errorf("case %d phase mismatch. expected: %d, got: %d", i, c.phase, res.Phase)
+			continue
+		}
+		if c.phase != MpResponse {
+			continue
+		}
+
+		ref, ok := res.Payload.(*repo.DatasetRef)
+		if !ok {
+			t.Errorf("case %d expected payload to be a dataset reference", i)
+			continue
+		}
+		if ref.Name != c.name {
+			t.Errorf("case %d name mismatch. expected: %s, got: %s", i, c.name, ref.Name)
+		}
+		if !ref.Path.Equal(path) {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, path, ref.Path)
+		}
+		if ref.Dataset == nil || ref.Dataset.Title != c.title {
+			t.Errorf("case %d expected dataset with title '%s'", i, c.title)
+		}
+	}
+}
+
+func TestHandleDatasetsRequest(t *testing.T) {
+	r, err := NewTestRepo()
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	datakey, err := r.Store().Put(memfs.NewMemfileBytes("cities.csv", []byte("city,pop\ntoronto,40000000\n")), true)
+	if err != nil {
+		t.Errorf("error putting data: %s", err.Error())
+		return
+	}
+	path, err := dsfs.SaveDataset(r.Store(), &dataset.Dataset{
+		Title: "cities",
+		Data:  datakey.String(),
+		Structure: &dataset.Structure{
+			Format: dataset.CSVDataFormat,
+			Schema: &dataset.Schema{
+				Fields: []*dataset.Field{
+					{Name: "city", Type: datatypes.String},
+					{Name: "pop", Type: datatypes.Integer},
+				},
+			},
+		},
+	}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	for _, name := range []string{"a", "b", "secret", "c"} {
+		if err := r.PutName(name, path); err != nil {
+			t.Errorf("error naming dataset: %s", err.Error())
+			return
+		}
+	}
+	if err := r.(repo.Visibilities).SetVisibility("secret", repo.VisibilityPrivate); err != nil {
+		t.Errorf("error setting visibility: %s", err.Error())
+		return
+	}
+
+	node := &QriNode{log: log, Repo: r}
+
+	// private datasets aren't listed or counted
+	cases := []struct {
+		p     *DatasetsReqParams
+		count int
+		total int
+	}{
+		{&DatasetsReqParams{}, 3, -1},
+		{&DatasetsReqParams{Total: true}, 3, 3},
+		{&DatasetsReqParams{Limit: 2, Total: true}, 2, 3},
+		{&DatasetsReqParams{Limit: 2, Offset: 2, Total: true}, 1, 3},
+		{&DatasetsReqParams{Offset: 5, Total: true}, 0, 3},
+	}
+
+	for i, c := range cases {
+		res := node.handleDatasetsRequest(&Message{
+			Phase:   MpRequest,
+			Type:    MtDatasets,
+			Payload: c.p,
+		})
+		if res == nil {
+			t.Errorf("case %d expected a response", i)
+			continue
+		}
+		got, err := DecodeDatasetsRes(res.Payload)
+		if err != nil {
+			t.Errorf("case %d error decoding response: %s", i, err.Error())
+			continue
+		}
+		if len(got.Datasets) != c.count {
+			t.Errorf("case %d count mismatch. expected: %d, got: %d", i, c.count, len(got.Datasets))
+		}
+		if got.Total != c.total {
+			t.Errorf("case %d total mismatch. expected: %d, got: %d", i, c.total, got.Total)
+		}
+		for _, ref := range got.Datasets {
+			if ref.Name == "secret" {
+				t.Errorf("case %d expected private dataset not to be listed", i)
+			}
+		}
+	}
+}
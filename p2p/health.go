@@ -0,0 +1,162 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	pstore "gx/ipfs/QmPgDWmTmuzvP7QE5zwo1TmjbJme9pmZHNujB2453jkCTr/go-libp2p-peerstore"
+)
+
+const (
+	// DefaultHealthCheckInterval is the default time between connectivity checks
+	DefaultHealthCheckInterval = time.Second * 30
+	// DefaultMinConnectedPeers is the default number of connected peers below
+	// which a node is considered disconnected & will re-bootstrap
+	DefaultMinConnectedPeers = 1
+)
+
+// Health describes the connectivity of a node to the p2p network
+type Health struct {
+	// Healthy is false when the node has fewer than MinConnectedPeers peers
+	Healthy bool `json:"healthy"`
+	// ConnectedPeers is the number of peers connected at the last check
+	ConnectedPeers int `json:"connectedPeers"`
+	// MinConnectedPeers is the threshold for a healthy connection
+	MinConnectedPeers int `json:"minConnectedPeers"`
+	// BootstrapConnected is the number of bootstrap peers connected at the
+	// last check
+	BootstrapConnected int `json:"bootstrapConnected"`
+	// LastCheck is the time of the last connectivity check
+	LastCheck time.Time `json:"lastCheck"`
+	// LastReconnect is the time of the last reconnection attempt
+	LastReconnect time.Time `json:"lastReconnect,omitempty"`
+	// Reconnects counts reconnection attempts
+	Reconnects int `json:"reconnects"`
+}
+
+// healthMonitor periodically checks connectivity, calling reconnect when
+// the number of connected peers drops below a threshold
+type healthMonitor struct {
+	log      Logger
+	interval time.Duration
+
+	// countPeers & countBootstrap report connected peers, and connected
+	// bootstrap peers
+	countPeers     func() int
+	countBootstrap func() int
+	// reconnect re-bootstraps the node
+	reconnect func()
+
+	lk     sync.Mutex
+	health Health
+}
+
+// check records current connectivity, reconnecting & logging any change
+// in health
+func (m *healthMonitor) check(now time.Time) {
+	peers := m.countPeers()
+	bootstrap := m.countBootstrap()
+
+	m.lk.Lock()
+	wasHealthy := m.health.Healthy
+	min := m.health.MinConnectedPeers
+	m.health.ConnectedPeers = peers
+	m.health.BootstrapConnected = bootstrap
+	m.health.Healthy = peers >= min
+	m.health.LastCheck = now
+	healthy := m.health.Healthy
+	if !healthy {
+		m.health.Reconnects++
+		m.health.LastReconnect = now
+	}
+	m.lk.Unlock()
+
+	if wasHealthy && !healthy {
+		m.log.Infof("p2p connectivity lost: %d connected peers, %d required. reconnecting", peers, min)
+	} else if !wasHealthy && healthy {
+		m.log.Infof("p2p connectivity restored: %d connected peers", peers)
+	}
+
+	if !healthy {
+		m.reconnect()
+	}
+}
+
+// status gives a copy of the last health check
+func (m *healthMonitor) status() Health {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return m.health
+}
+
+// run checks health every interval until done is closed
+func (m *healthMonitor) run(done <-chan struct{}) {
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			m.check(now)
+		case <-done:
+			return
+		}
+	}
+}
+
+// StartHealthMonitor begins checking this node's connectivity every interval,
+// re-bootstrapping to qri & IPFS networks whenever fewer than minPeers peers
+// are connected. interval values of zero or less disable monitoring
+func (n *QriNode) StartHealthMonitor(interval time.Duration, minPeers int) {
+	if !n.Online || interval <= 0 || n.health != nil {
+		return
+	}
+
+	n.health = &healthMonitor{
+		log:            n.log,
+		interval:       interval,
+		countPeers:     func() int { return len(n.ConnectedPeers()) },
+		countBootstrap: n.connectedBootstrapPeers,
+		reconnect:      n.reconnect,
+		health: Health{
+			Healthy:           true,
+			MinConnectedPeers: minPeers,
+		},
+	}
+	go n.health.run(n.ctx.Done())
+}
+
+// Health gives the result of the last connectivity check, returning nil if
+// the node isn't monitoring it's connectivity
+func (n *QriNode) Health() *Health {
+	if n.health == nil {
+		return nil
+	}
+	h := n.health.status()
+	return &h
+}
+
+// connectedBootstrapPeers counts the qri bootstrap peers this node is
+// connected to
+func (n *QriNode) connectedBootstrapPeers() int {
+	addrs, err := ParseMultiaddrs(n.BootstrapAddrs)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, p := range toPeerInfos(addrs) {
+		if len(n.Host.Network().ConnsToPeer(p.ID)) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// reconnect re-bootstraps this node to qri & IPFS networks. reconnect blocks
+// until bootstrapping is done, so a slow bootstrap delays the next health
+// check instead of piling up behind it
+func (n *QriNode) reconnect() {
+	// bootstrap never blocks sending to a channel with room for every address
+	bsPeers := make(chan pstore.PeerInfo, len(n.BootstrapAddrs))
+	n.Bootstrap(n.BootstrapAddrs, bsPeers)
+	n.BootstrapIPFS()
+}
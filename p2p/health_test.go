@@ -0,0 +1,58 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthMonitorCheck(t *testing.T) {
+	peers, reconnects := 0, 0
+	m := &healthMonitor{
+		log:            log,
+		countPeers:     func() int { return peers },
+		countBootstrap: func() int { return 0 },
+		reconnect:      func() { reconnects++ },
+		health:         Health{Healthy: true, MinConnectedPeers: 2},
+	}
+
+	cases := []struct {
+		peers      int
+		healthy    bool
+		reconnects int
+	}{
+		{3, true, 0},
+		{1, false, 1},
+		{0, false, 2},
+		{2, true, 2},
+	}
+
+	for i, c := range cases {
+		peers = c.peers
+		now := time.Now()
+		m.check(now)
+
+		got := m.status()
+		if got.Healthy != c.healthy {
+			t.Errorf("case %d healthy mismatch. expected: %t, got: %t", i, c.healthy, got.Healthy)
+		}
+		if got.ConnectedPeers != c.peers {
+			t.Errorf("case %d connected peers mismatch. expected: %d, got: %d", i, c.peers, got.ConnectedPeers)
+		}
+		if reconnects != c.reconnects || got.Reconnects != c.reconnects {
+			t.Errorf("case %d reconnects mismatch. expected: %d, got: %d (recorded %d)", i, c.reconnects, reconnects, got.Reconnects)
+		}
+		if !got.LastCheck.Equal(now) {
+			t.Errorf("case %d last check wasn't recorded", i)
+		}
+	}
+}
+
+func TestBootstrapIPFSInFlight(t *testing.T) {
+	// a node without a repo would panic looking for an IPFS node, so returning
+	// shows a bootstrap already in flight is skipped
+	n := &QriNode{bootstrappingIPFS: 1}
+	n.BootstrapIPFS()
+	if n.bootstrappingIPFS != 1 {
+		t.Errorf("expected skipped bootstrap to leave the running bootstrap's flag set")
+	}
+}
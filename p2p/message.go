@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"time"
 
@@ -31,6 +32,8 @@ const (
 	MtSearch
 	// MtPing is a ping/pong message
 	MtPing
+	// MtDataset is a single dataset message
+	MtDataset
 )
 
 func (mt MsgType) String() string {
@@ -42,6 +45,7 @@ func (mt MsgType) String() string {
 		MtNamespaces: "NAMESPACES",
 		MtSearch:     "SEARCH",
 		MtPing:       "PING",
+		MtDataset:    "DATASET",
 	}[mt]
 }
 
@@ -108,25 +112,39 @@ func (n *QriNode) MessageStreamHandler(s net.Stream) {
 
 // SendMessage to a given multiaddr
 func (n *QriNode) SendMessage(pi peer.ID, msg *Message) (res *Message, err error) {
-	// TODO - do we need a timeout here?
-	// ctx, cancel := context.WithTimeout(n.ctx, time.Second*60)
-	// defer cancel()
+	return n.SendMessageContext(n.ctx, pi, msg)
+}
 
-	s, err := n.Host.NewStream(n.ctx, pi, QriProtocolID)
+// SendMessageContext opens a stream & sends a message to a peer id, giving up
+// when ctx is done
+func (n *QriNode) SendMessageContext(ctx context.Context, pi peer.ID, msg *Message) (res *Message, err error) {
+	s, err := n.Host.NewStream(ctx, pi, QriProtocolID)
 	if err != nil {
 		return nil, fmt.Errorf("error opening stream: %s", err.Error())
 	}
 	defer s.Close()
 
+	// closing the stream unblocks sending & receiving once ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-done:
+		}
+	}()
+
 	wrappedStream := WrapStream(s)
 
 	msg.Phase = MpRequest
-	err = sendMessage(msg, wrappedStream)
-	if err != nil {
-		return
+	if err = sendMessage(msg, wrappedStream); err == nil {
+		res, err = receiveMessage(wrappedStream)
 	}
-
-	return receiveMessage(wrappedStream)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("message cancelled: %s", ctx.Err().Error())
+	}
+	return
 }
 
 // BroadcastMessage sends a message to all connected peers
@@ -238,6 +256,8 @@ func (n *QriNode) handleStream(ws *WrappedStream) {
 				res = n.handlePeersRequest(r)
 			case MtPing:
 				res = n.handlePingRequest(r)
+			case MtDataset:
+				res = n.handleDatasetRequest(r)
 			}
 		}
 
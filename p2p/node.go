@@ -3,12 +3,14 @@ package p2p
 import (
 	"context"
 	"fmt"
+	"time"
 	// "sort"
 
 	"github.com/qri-io/cafs/ipfs"
 	"github.com/qri-io/qri/repo"
 
 	yamux "gx/ipfs/QmNWCEvi7bPRcvqAV8AKLGVNoQdArWi7NJayka2SM4XtRe/go-smux-yamux"
+	net "gx/ipfs/QmNa31VPzC561NWwRsJLE7nGYZYuuD2QfpK2b1q9BK54J1/go-libp2p-net"
 	pstore "gx/ipfs/QmPgDWmTmuzvP7QE5zwo1TmjbJme9pmZHNujB2453jkCTr/go-libp2p-peerstore"
 	core "gx/ipfs/QmViBzgruNUoLNBnXcx8YWbDNwV8MNGEGKkLo6JGetygdw/go-ipfs/core"
 	msmux "gx/ipfs/QmVniQJkdzLZaZwzwMdd3dJTvWiJ1DQEkreVy6hs6h7Vk5/go-smux-multistream"
@@ -52,6 +54,13 @@ type QriNode struct {
 
 	// BootstrapAddrs is a list of multiaddresses to bootrap *qri* from (not IPFS)
 	BootstrapAddrs []string
+
+	// connectivity monitoring settings & state
+	healthCheckInterval time.Duration
+	minConnectedPeers   int
+	health              *healthMonitor
+	// bootstrappingIPFS is 1 while an IPFS bootstrap is running
+	bootstrappingIPFS int32
 }
 
 // NewQriNode creates a new node, providing no arguments will use
@@ -78,6 +87,9 @@ func NewQriNode(r repo.Repo, options ...func(o *NodeCfg)) (node *QriNode, err er
 		Repo:           r,
 		ctx:            context.Background(),
 		BootstrapAddrs: cfg.QriBootstrapAddrs,
+
+		healthCheckInterval: cfg.HealthCheckInterval,
+		minConnectedPeers:   cfg.MinConnectedPeers,
 	}
 
 	if cfg.Online {
@@ -107,13 +119,14 @@ func NewQriNode(r repo.Repo, options ...func(o *NodeCfg)) (node *QriNode, err er
 		// add multistream handler for qri protocol to the host
 		// for more info on multistreams check github.com/multformats/go-multistream
 		node.Host.SetStreamHandler(QriProtocolID, node.MessageStreamHandler)
+		node.Host.Network().Notify(&net.NotifyBundle{DisconnectedF: node.peerDisconnected})
 	}
 
 	return node, nil
 }
 
-// StartOnlineServices bootstraps the node to qri & IPFS networks
-// and begins NAT discovery
+// StartOnlineServices bootstraps the node to qri & IPFS networks,
+// begins NAT discovery and starts monitoring connectivity
 func (n *QriNode) StartOnlineServices(bootstrapped func(string)) error {
 	if !n.Online {
 		return nil
@@ -129,7 +142,11 @@ func (n *QriNode) StartOnlineServices(bootstrapped func(string)) error {
 	// TODO - this is an "original node" problem probably solved by being able
 	// to start a node with *no* qri peers specified.
 	defer bootstrapped("")
-	return n.StartDiscovery(bsPeers)
+	if err := n.StartDiscovery(bsPeers); err != nil {
+		return err
+	}
+	n.StartHealthMonitor(n.healthCheckInterval, n.minConnectedPeers)
+	return nil
 }
 
 // EncapsulatedAddresses returns a slice of full multaddrs for this node
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	// "time"
 
+	"github.com/qri-io/qri/repo"
+
+	net "gx/ipfs/QmNa31VPzC561NWwRsJLE7nGYZYuuD2QfpK2b1q9BK54J1/go-libp2p-net"
 	pstore "gx/ipfs/QmPgDWmTmuzvP7QE5zwo1TmjbJme9pmZHNujB2453jkCTr/go-libp2p-peerstore"
 	peer "gx/ipfs/QmXYjuNuxVzXKJCfWasQk1RqkhVLDM9jtUKhqc2WPQmFSB/go-libp2p-peer"
 )
@@ -24,6 +27,12 @@ func (n *QriNode) AddQriPeer(pinfo pstore.PeerInfo) error {
 		return err
 	}
 
+	// flag qri support so disconnects from this peer are logged
+	if err := n.Host.Peerstore().Put(pinfo.ID, qriSupportKey, true); err != nil {
+		n.log.Infof("error setting qri support flag: %s", err.Error())
+	}
+	n.logPeerEvent(repo.ETPeerConnected, pinfo.ID)
+
 	// some time later ask for a list of their peers, you know, "for a friend"
 	go func() {
 		// time.Sleep(time.Second * 2)
@@ -116,3 +125,22 @@ func (n *QriNode) ConnectedPeers() []string {
 
 	return peers
 }
+
+// peerDisconnected logs qri peers dropping their last connection to this node
+func (n *QriNode) peerDisconnected(_ net.Network, c net.Conn) {
+	pid := c.RemotePeer()
+	if support, err := n.Host.Peerstore().Get(pid, qriSupportKey); err != nil || support != true {
+		return
+	}
+	if len(n.Host.Network().ConnsToPeer(pid)) > 0 {
+		return
+	}
+	n.logPeerEvent(repo.ETPeerDisconnected, pid)
+}
+
+// logPeerEvent records a peer event in the repo's event log
+func (n *QriNode) logPeerEvent(t repo.EventType, pid peer.ID) {
+	if err := repo.LogEvent(n.Repo, &repo.Event{Type: t, PeerID: pid.Pretty()}); err != nil {
+		n.log.Infof("error logging peer event: %s", err.Error())
+	}
+}
@@ -0,0 +1,157 @@
+// Package ref parses the string forms used to refer to datasets. A dataset
+// reference is one of:
+//
+//	name              a dataset name in the local namespace, eg: movies
+//	name@N            the Nth version of a named dataset, counting the first
+//	                  version as 1, eg: movies@2
+//	peer/name[@N]     a dataset name in a peer's namespace, eg: QmPeer/movies
+//	path              a content-addressed path, eg: /ipfs/QmHash/dataset.json
+//	hash              a dataset hash or hash prefix, eg: QmHash or QmZ9Lq
+package ref
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset/dsfs"
+)
+
+// HashLength is the length of a complete base58-encoded sha256 multihash
+const HashLength = 46
+
+// MinHashPrefixLength is the shortest hash prefix that's treated as a hash,
+// counting the leading "Qm" all dataset hashes start with
+const MinHashPrefixLength = 6
+
+// base58Alphabet are the characters of base58-encoded hashes
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Ref is a parsed dataset reference. A Ref sets either Path, or Name along
+// with an optional Peer & Version
+type Ref struct {
+	// Peer is the peer a name belongs to, empty for local names
+	Peer string
+	// Name is the name of a dataset
+	Name string
+	// Version selects a version of a named dataset, counting the first
+	// version as 1. zero refers to the latest version
+	Version int
+	// Path is the content-addressed path to a dataset. complete paths include
+	// the dataset package filename, hash prefixes are left as-is to be
+	// resolved by a repo
+	Path string
+}
+
+// ParseRef parses a string into a dataset reference. references with a
+// leading slash and more than one segment are always paths, otherwise
+// surrounding slashes are ignored, so segments trimmed from a URL path can be
+// parsed as-is
+func ParseRef(str string) (Ref, error) {
+	str = strings.TrimSpace(str)
+	trimmed := strings.Trim(str, "/")
+	if trimmed == "" {
+		return Ref{}, fmt.Errorf("empty dataset reference")
+	}
+
+	segments := strings.Split(trimmed, "/")
+	if isPath(str, segments) {
+		return Ref{Path: canonicalPath(segments)}, nil
+	}
+
+	ref := Ref{}
+	switch len(segments) {
+	case 1:
+		ref.Name = segments[0]
+	case 2:
+		ref.Peer, ref.Name = segments[0], segments[1]
+		if ref.Peer == "" {
+			return Ref{}, fmt.Errorf("invalid dataset reference '%s': peer is required", str)
+		}
+	default:
+		return Ref{}, fmt.Errorf("invalid dataset reference '%s'", str)
+	}
+
+	if i := strings.LastIndex(ref.Name, "@"); i != -1 {
+		v, err := strconv.Atoi(ref.Name[i+1:])
+		if err != nil || v < 1 {
+			return Ref{}, fmt.Errorf("invalid dataset reference '%s': version must be a number greater than zero", str)
+		}
+		ref.Name, ref.Version = ref.Name[:i], v
+	}
+	if ref.Name == "" {
+		return Ref{}, fmt.Errorf("invalid dataset reference '%s': name is required", str)
+	}
+	return ref, nil
+}
+
+// IsPath returns true if the reference is a content-addressed path
+func (r Ref) IsPath() bool {
+	return r.Path != ""
+}
+
+// String gives the canonical string form of a reference
+func (r Ref) String() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	str := r.Name
+	if r.Peer != "" {
+		str = r.Peer + "/" + str
+	}
+	if r.Version > 0 {
+		str = fmt.Sprintf("%s@%d", str, r.Version)
+	}
+	return str
+}
+
+// RootPath gives the root of a dataset package by dropping the package
+// filename from path, eg: /ipfs/QmHash/dataset.json -> /ipfs/QmHash
+func RootPath(path string) string {
+	return strings.TrimSuffix(path, "/"+dsfs.PackageFileDataset.String())
+}
+
+// isPath checks if the segments of a reference point to a path. references
+// ending in a hash or package filename are paths, as are absolute references
+// with more than one segment. peer IDs are hashes too, so only the last
+// segment is checked
+func isPath(str string, segments []string) bool {
+	if strings.HasPrefix(str, "/") && len(segments) > 1 {
+		return true
+	}
+	last := segments[len(segments)-1]
+	return IsHash(last) || last == dsfs.PackageFileDataset.String()
+}
+
+// canonicalPath joins path segments, adding a store prefix to bare hashes and
+// the package filename to paths that end in a complete hash
+func canonicalPath(segments []string) string {
+	last := segments[len(segments)-1]
+	if len(segments) == 1 {
+		if len(last) < HashLength {
+			// hash prefix
+			return "/" + last
+		}
+		segments = []string{"ipfs", last}
+	}
+
+	path := "/" + strings.Join(segments, "/")
+	if IsHash(last) && len(last) == HashLength {
+		path += "/" + dsfs.PackageFileDataset.String()
+	}
+	return path
+}
+
+// IsHash checks if a path segment is a base58-encoded multihash, or a prefix
+// of one at least MinHashPrefixLength characters long
+func IsHash(segment string) bool {
+	if !strings.HasPrefix(segment, "Qm") || len(segment) < MinHashPrefixLength || len(segment) > HashLength {
+		return false
+	}
+	for _, c := range segment {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
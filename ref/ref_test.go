@@ -0,0 +1,93 @@
+package ref
+
+import (
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	hash := "QmZ9Lq8Fgt4jKbBUHRoxLk7mDBxAzQW3vfyT3Ze4AJdnaC"
+	cases := []struct {
+		str string
+		ref Ref
+		err string
+	}{
+		{"", Ref{}, "empty dataset reference"},
+		{"/", Ref{}, "empty dataset reference"},
+		{"movies", Ref{Name: "movies"}, ""},
+		{"/movies", Ref{Name: "movies"}, ""},
+		{"movies@2", Ref{Name: "movies", Version: 2}, ""},
+		{"movies@0", Ref{}, "invalid dataset reference 'movies@0': version must be a number greater than zero"},
+		{"movies@latest", Ref{}, "invalid dataset reference 'movies@latest': version must be a number greater than zero"},
+		{"@2", Ref{}, "invalid dataset reference '@2': name is required"},
+		{"b5/movies", Ref{Peer: "b5", Name: "movies"}, ""},
+		{hash + "/movies@3", Ref{Peer: hash, Name: "movies", Version: 3}, ""},
+		{"a/b/c", Ref{}, "invalid dataset reference 'a/b/c'"},
+		{hash, Ref{Path: "/ipfs/" + hash + "/dataset.json"}, ""},
+		{"QmZ9Lq", Ref{Path: "/QmZ9Lq"}, ""},
+		// names that only start like a hash aren't paths
+		{"Qm", Ref{Name: "Qm"}, ""},
+		{"QmZ9L", Ref{Name: "QmZ9L"}, ""},
+		{"Qm_movies", Ref{Name: "Qm_movies"}, ""},
+		{"QmOvies", Ref{Name: "QmOvies"}, ""},
+		{hash + "a", Ref{Name: hash + "a"}, ""},
+		{"/ipfs/QmZ9Lq", Ref{Path: "/ipfs/QmZ9Lq"}, ""},
+		{"/ipfs/" + hash, Ref{Path: "/ipfs/" + hash + "/dataset.json"}, ""},
+		{"ipfs/" + hash + "/dataset.json", Ref{Path: "/ipfs/" + hash + "/dataset.json"}, ""},
+		{"/map/missing", Ref{Path: "/map/missing"}, ""},
+	}
+
+	for i, c := range cases {
+		got, err := ParseRef(c.str)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if got != c.ref {
+			t.Errorf("case %d ref mismatch. expected: %#v, got: %#v", i, c.ref, got)
+		}
+	}
+}
+
+func TestRefString(t *testing.T) {
+	cases := []struct {
+		ref Ref
+		str string
+	}{
+		{Ref{Name: "movies"}, "movies"},
+		{Ref{Name: "movies", Version: 2}, "movies@2"},
+		{Ref{Peer: "b5", Name: "movies", Version: 2}, "b5/movies@2"},
+		{Ref{Path: "/ipfs/QmZ9Lq"}, "/ipfs/QmZ9Lq"},
+	}
+
+	for i, c := range cases {
+		if got := c.ref.String(); got != c.str {
+			t.Errorf("case %d string mismatch. expected: '%s', got: '%s'", i, c.str, got)
+			continue
+		}
+		// canonical strings should parse back to the same ref
+		parsed, err := ParseRef(c.str)
+		if err != nil {
+			t.Errorf("case %d error parsing string: %s", i, err.Error())
+			continue
+		}
+		if parsed != c.ref {
+			t.Errorf("case %d round trip mismatch. expected: %#v, got: %#v", i, c.ref, parsed)
+		}
+	}
+}
+
+func TestRootPath(t *testing.T) {
+	cases := []struct {
+		path, root string
+	}{
+		{"/ipfs/QmZ9Lq/dataset.json", "/ipfs/QmZ9Lq"},
+		{"/ipfs/QmZ9Lq", "/ipfs/QmZ9Lq"},
+		{"", ""},
+	}
+
+	for i, c := range cases {
+		if got := RootPath(c.path); got != c.root {
+			t.Errorf("case %d root mismatch. expected: '%s', got: '%s'", i, c.root, got)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// AliasGroups tracks sets of names that are aliases of one another, mapping
+// each name in a group to a shared group label. Names that aren't aliased
+// don't appear in the map. AliasGroups is a helper for Namestore
+// implementations, and does no checking of names against the namestore itself.
+type AliasGroups map[string]string
+
+// Link adds alias to the group primary belongs to, creating the group
+// if it doesn't exist
+func (g AliasGroups) Link(primary, alias string) {
+	label, ok := g[primary]
+	if !ok {
+		label = primary
+		g[primary] = label
+	}
+	g[alias] = label
+}
+
+// Group returns all names in the alias group containing name in lexographical
+// order. name is always included in the result, a name with no aliases
+// is a group of one
+func (g AliasGroups) Group(name string) []string {
+	label, ok := g[name]
+	if !ok {
+		return []string{name}
+	}
+
+	names := []string{}
+	for n, l := range g {
+		if l == label {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Remove drops name from it's alias group. groups that are left with a
+// single name are removed entirely
+func (g AliasGroups) Remove(name string) {
+	label, ok := g[name]
+	if !ok {
+		return
+	}
+	delete(g, name)
+
+	rest := []string{}
+	for n, l := range g {
+		if l == label {
+			rest = append(rest, n)
+		}
+	}
+	sort.Strings(rest)
+
+	if len(rest) == 1 {
+		delete(g, rest[0])
+		return
+	}
+	// keep group labels pointing at a member name so a future name that happens
+	// to match a stale label can't join this group by accident
+	if label == name {
+		for _, n := range rest {
+			g[n] = rest[0]
+		}
+	}
+}
+
+// UpdateName moves an existing name to path. Names in an alias group are
+// moved along with the rest of their group
+func UpdateName(ns Namestore, name string, path datastore.Key) error {
+	aliases, err := ns.Aliases(name)
+	if err != nil {
+		return err
+	}
+	if len(aliases) == 1 {
+		if err := ns.DeleteName(name); err != nil {
+			return err
+		}
+	}
+	return ns.PutName(name, path)
+}
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"github.com/ipfs/go-datastore"
+)
+
+// CacheSources is an optional interface for repos that can record which
+// peer datasets in the cache came from, so datasets a peer no longer lists
+// can be dropped from the cache. Check for support with a type assertion on
+// a Repo
+type CacheSources interface {
+	// SetCachedPaths records the paths cached from a peer, replacing any
+	// paths previously recorded for the peer. no paths removes the peer
+	SetCachedPaths(peerID string, paths []datastore.Key) error
+	// CachedPaths gives the paths recorded for each peer, keyed by peer id
+	CachedPaths() (map[string][]datastore.Key, error)
+}
@@ -90,7 +90,7 @@ func AcceptChangeRequest(r Repo, path datastore.Key) (err error) {
 		return err
 	}
 
-	if err := r.PutName(name, cr.Path); err != nil {
+	if err := UpdateName(r, name, cr.Path); err != nil {
 		return err
 	}
 
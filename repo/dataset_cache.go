@@ -0,0 +1,198 @@
+package repo
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+)
+
+// DefaultDatasetCacheSize is the default number of datasets a repo keeps in
+// memory
+const DefaultDatasetCacheSize = 500
+
+// DatasetCacher is an optional interface for repos that keep loaded datasets
+// in memory. Check for support with a type assertion on a Repo
+type DatasetCacher interface {
+	// DatasetCache gives the repo's cache of loaded datasets
+	DatasetCache() *DatasetCache
+}
+
+// DatasetCache is a size-bounded, least-recently-used cache of loaded datasets
+// & values derived from them, like computed stats, keyed by content-addressed
+// path. Datasets are content-addressed, so a path will always point to the
+// same dataset, which means cached values never need to be invalidated, only
+// evicted
+type DatasetCache struct {
+	size  int
+	lock  sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+// cacheEntry is a single item in a DatasetCache
+type cacheEntry struct {
+	key   string
+	value interface{}
+}
+
+// NewDatasetCache allocates a DatasetCache that holds at most size values.
+// A size of zero or less disables caching
+func NewDatasetCache(size int) *DatasetCache {
+	return &DatasetCache{
+		size:  size,
+		order: list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// SetSize sets the maximum number of values the cache holds, evicting the
+// least-recently-used values past the new size
+func (c *DatasetCache) SetSize(size int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.size = size
+	c.evict()
+}
+
+// LoadDataset returns the dataset at path, loading from store & caching the
+// result if the dataset isn't already in the cache
+func (c *DatasetCache) LoadDataset(store cafs.Filestore, path datastore.Key) (*dataset.Dataset, error) {
+	if v, ok := c.Get(path.String()); ok {
+		if ds, ok := v.(*dataset.Dataset); ok {
+			return copyDataset(ds), nil
+		}
+	}
+
+	ds, err := dsfs.LoadDataset(store, path)
+	if err != nil {
+		return nil, err
+	}
+	c.Put(path.String(), ds)
+	return copyDataset(ds), nil
+}
+
+// Len gives the number of values currently in the cache
+func (c *DatasetCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
+
+// Get gives a value cached under key. cached values are shared, callers must
+// copy values before modifying them
+func (c *DatasetCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).value, true
+}
+
+// Put caches a value under key. datasets are cached under their path, other
+// values need keys that can't be mistaken for a path. cached values must
+// never be modified
+func (c *DatasetCache) Put(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+	c.evict()
+}
+
+// evict drops the least-recently-used values until the cache fits it's size.
+// callers must hold the lock
+func (c *DatasetCache) evict() {
+	for c.order.Len() > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// copyDataset copies the parts of a dataset callers modify: the dataset, it's
+// components, schema fields & lists, so changes to a returned dataset can't
+// alter the cached one. values below that, like transform resources & field
+// constraints, are shared & must be treated as read-only
+func copyDataset(ds *dataset.Dataset) *dataset.Dataset {
+	cp := *ds
+	if ds.Abstract != nil {
+		cp.Abstract = copyDataset(ds.Abstract)
+	}
+	if ds.Structure != nil {
+		cp.Structure = copyStructure(ds.Structure)
+	}
+	if ds.Commit != nil {
+		commit := *ds.Commit
+		cp.Commit = &commit
+	}
+	if ds.License != nil {
+		license := *ds.License
+		cp.License = &license
+	}
+	if ds.Transform != nil {
+		cp.Transform = copyTransform(ds.Transform)
+	}
+	if ds.AbstractTransform != nil {
+		cp.AbstractTransform = copyTransform(ds.AbstractTransform)
+	}
+	if ds.Citations != nil {
+		cp.Citations = make([]*dataset.Citation, len(ds.Citations))
+		for i, c := range ds.Citations {
+			if c != nil {
+				citation := *c
+				cp.Citations[i] = &citation
+			}
+		}
+	}
+	if ds.Keywords != nil {
+		cp.Keywords = append([]string{}, ds.Keywords...)
+	}
+	if ds.Language != nil {
+		cp.Language = append([]string{}, ds.Language...)
+	}
+	return &cp
+}
+
+// copyStructure copies a structure & it's schema fields
+func copyStructure(st *dataset.Structure) *dataset.Structure {
+	cp := *st
+	if st.Schema != nil {
+		schema := *st.Schema
+		if st.Schema.Fields != nil {
+			schema.Fields = make([]*dataset.Field, len(st.Schema.Fields))
+			for i, f := range st.Schema.Fields {
+				if f != nil {
+					field := *f
+					schema.Fields[i] = &field
+				}
+			}
+		}
+		if st.Schema.PrimaryKey != nil {
+			schema.PrimaryKey = append(dataset.FieldKey{}, st.Schema.PrimaryKey...)
+		}
+		cp.Schema = &schema
+	}
+	return &cp
+}
+
+// copyTransform copies a transform & it's structure
+func copyTransform(t *dataset.Transform) *dataset.Transform {
+	cp := *t
+	if t.Structure != nil {
+		cp.Structure = copyStructure(t.Structure)
+	}
+	return &cp
+}
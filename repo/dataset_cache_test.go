@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+)
+
+// countingStore wraps a filestore, counting calls to Get
+type countingStore struct {
+	cafs.Filestore
+	gets int
+}
+
+func (s *countingStore) Get(path datastore.Key) (cafs.File, error) {
+	s.gets++
+	return s.Filestore.Get(path)
+}
+
+// saveCacheTestDataset saves a dataset with title to store
+func saveCacheTestDataset(t testing.TB, store cafs.Filestore, title string) datastore.Key {
+	ds := &dataset.Dataset{
+		Title:    title,
+		Keywords: []string{"a"},
+		Structure: &dataset.Structure{
+			Format: dataset.CSVDataFormat,
+			Schema: &dataset.Schema{Fields: []*dataset.Field{{Name: "a"}}},
+		},
+	}
+	path, err := dsfs.SaveDataset(store, ds, true)
+	if err != nil {
+		t.Fatalf("error saving dataset: %s", err.Error())
+	}
+	return path
+}
+
+func TestDatasetCache(t *testing.T) {
+	store := &countingStore{Filestore: memfs.NewMapstore()}
+	movies := saveCacheTestDataset(t, store, "movies")
+	cities := saveCacheTestDataset(t, store, "cities")
+	store.gets = 0
+
+	cache := NewDatasetCache(1)
+	ds, err := cache.LoadDataset(store, movies)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	gets := store.gets
+	if gets == 0 {
+		t.Errorf("expected first load to read from the store")
+	}
+
+	// modifying a returned dataset shouldn't alter the cache
+	title := ds.Title
+	ds.Title = "changed"
+	ds.Structure.Schema.Fields[0].Name = "changed"
+	ds.Structure.Format = dataset.JSONDataFormat
+	ds.Keywords[0] = "changed"
+
+	ds, err = cache.LoadDataset(store, movies)
+	if err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	if store.gets != gets {
+		t.Errorf("expected second load of the same path not to hit the store. store gets: %d, expected %d", store.gets, gets)
+	}
+	if ds.Title != title {
+		t.Errorf("cached dataset was modified. expected title: '%s', got: '%s'", title, ds.Title)
+	}
+	if ds.Structure.Schema.Fields[0].Name != "a" {
+		t.Errorf("cached dataset schema was modified. expected field name: 'a', got: '%s'", ds.Structure.Schema.Fields[0].Name)
+	}
+	if ds.Structure.Format != dataset.CSVDataFormat {
+		t.Errorf("cached dataset structure was modified. expected format: %s, got: %s", dataset.CSVDataFormat, ds.Structure.Format)
+	}
+	if len(ds.Keywords) != 1 || ds.Keywords[0] != "a" {
+		t.Errorf("cached dataset keywords were modified. expected: [a], got: %v", ds.Keywords)
+	}
+
+	// loading another dataset should evict movies
+	if _, err := cache.LoadDataset(store, cities); err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	if cache.Len() != 1 {
+		t.Errorf("cache length mismatch. expected: %d, got: %d", 1, cache.Len())
+	}
+	gets = store.gets
+	if _, err := cache.LoadDataset(store, movies); err != nil {
+		t.Errorf("error loading dataset: %s", err.Error())
+		return
+	}
+	if store.gets == gets {
+		t.Errorf("expected evicted dataset to be re-read from the store")
+	}
+
+	// shrinking the cache evicts datasets past the new size
+	cache.SetSize(0)
+	if cache.Len() != 0 {
+		t.Errorf("expected shrunk cache to be empty, got length: %d", cache.Len())
+	}
+
+	// a zero-size cache shouldn't cache anything
+	cache = NewDatasetCache(0)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.LoadDataset(store, movies); err != nil {
+			t.Errorf("error loading dataset: %s", err.Error())
+			return
+		}
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected zero-size cache to be empty, got length: %d", cache.Len())
+	}
+
+	if _, err := cache.LoadDataset(store, datastore.NewKey("/bad/path")); err == nil {
+		t.Errorf("expected loading a missing path to error")
+	}
+}
+
+func BenchmarkDatasetLoad(b *testing.B) {
+	store := memfs.NewMapstore()
+	path := saveCacheTestDataset(b, store, "movies")
+
+	b.Run("store", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := dsfs.LoadDataset(store, path); err != nil {
+				b.Errorf("error loading dataset: %s", err.Error())
+				return
+			}
+		}
+	})
+
+	b.Run("cache", func(b *testing.B) {
+		cache := NewDatasetCache(DefaultDatasetCacheSize)
+		for i := 0; i < b.N; i++ {
+			if _, err := cache.LoadDataset(store, path); err != nil {
+				b.Errorf("error loading dataset: %s", err.Error())
+				return
+			}
+		}
+	})
+}
+
+func TestDatasetCacheValues(t *testing.T) {
+	cache := NewDatasetCache(2)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+
+	// getting a refreshes it, so b is the least-recently-used value
+	if v, ok := cache.Get("a"); !ok || v != 1 {
+		t.Errorf("expected cached value for a. got: %v, %t", v, ok)
+	}
+	cache.Put("c", 3)
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected least-recently-used value to be evicted")
+	}
+	for key, expect := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := cache.Get(key); !ok || v != expect {
+			t.Errorf("expected cached value %d for %s. got: %v, %t", expect, key, v, ok)
+		}
+	}
+}
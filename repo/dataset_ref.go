@@ -21,6 +21,9 @@ type DatasetRef struct {
 	Name string `json:"name,omitempty"`
 	// Content-addressed path for this dataset
 	Path datastore.Key `json:"path"`
+	// Score is the relevance of this dataset to a search query. only set on
+	// search results
+	Score float64 `json:"score,omitempty"`
 }
 
 // CompareDatasetRef compares two Dataset References, returning an error
@@ -41,3 +44,63 @@ func CompareDatasetRef(a, b *DatasetRef) error {
 	}
 	return nil
 }
+
+// CompareDatasetRefStructural compares two Dataset References like
+// CompareDatasetRef, also comparing the stable parts of referenced datasets:
+// title, data path & structure. fields that change each time a dataset is
+// created, like timestamps & computed lengths, are ignored
+func CompareDatasetRefStructural(a, b *DatasetRef) error {
+	if err := CompareDatasetRef(a, b); err != nil || a == nil {
+		return err
+	}
+
+	ads, bds := a.Dataset, b.Dataset
+	if ads == nil && bds != nil || ads != nil && bds == nil {
+		return fmt.Errorf("dataset nil mismatch: %t != %t", ads == nil, bds == nil)
+	}
+	if ads == nil && bds == nil {
+		return nil
+	}
+
+	if ads.Title != bds.Title {
+		return fmt.Errorf("title mismatch. %s != %s", ads.Title, bds.Title)
+	}
+	if ads.Data != bds.Data {
+		return fmt.Errorf("data mismatch. %s != %s", ads.Data, bds.Data)
+	}
+	return compareStructures(ads.Structure, bds.Structure)
+}
+
+// compareStructures compares the format & schema fields of two structures
+func compareStructures(a, b *dataset.Structure) error {
+	if a == nil && b != nil || a != nil && b == nil {
+		return fmt.Errorf("structure nil mismatch: %t != %t", a == nil, b == nil)
+	}
+	if a == nil && b == nil {
+		return nil
+	}
+
+	if a.Format != b.Format {
+		return fmt.Errorf("format mismatch. %s != %s", a.Format, b.Format)
+	}
+	if a.Schema == nil && b.Schema != nil || a.Schema != nil && b.Schema == nil {
+		return fmt.Errorf("schema nil mismatch: %t != %t", a.Schema == nil, b.Schema == nil)
+	}
+	if a.Schema == nil && b.Schema == nil {
+		return nil
+	}
+
+	if len(a.Schema.Fields) != len(b.Schema.Fields) {
+		return fmt.Errorf("field count mismatch. %d != %d", len(a.Schema.Fields), len(b.Schema.Fields))
+	}
+	for i, af := range a.Schema.Fields {
+		bf := b.Schema.Fields[i]
+		if af.Name != bf.Name {
+			return fmt.Errorf("field %d name mismatch. %s != %s", i, af.Name, bf.Name)
+		}
+		if af.Type != bf.Type {
+			return fmt.Errorf("field %d type mismatch. %s != %s", i, af.Type, bf.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/datatypes"
+)
+
+func TestCompareDatasetRefStructural(t *testing.T) {
+	newRef := func(title string, ts time.Time, length int, fieldType datatypes.Type) *DatasetRef {
+		return &DatasetRef{
+			Name: "movies",
+			Path: datastore.NewKey("/map/a"),
+			Dataset: &dataset.Dataset{
+				Title:     title,
+				Data:      "/map/data",
+				Timestamp: ts,
+				Length:    length,
+				Structure: &dataset.Structure{
+					Format: dataset.CSVDataFormat,
+					Schema: &dataset.Schema{Fields: []*dataset.Field{{Name: "title", Type: fieldType}}},
+				},
+			},
+		}
+	}
+	now := time.Now()
+	a := newRef("movies", now, 10, datatypes.String)
+
+	cases := []struct {
+		a, b *DatasetRef
+		err  string
+	}{
+		{nil, nil, ""},
+		{a, a, ""},
+		{a, newRef("movies", now.Add(time.Hour), 20, datatypes.String), ""},
+		{a, &DatasetRef{Name: "movies", Path: datastore.NewKey("/map/a")}, "dataset nil mismatch: false != true"},
+		{a, newRef("films", now, 10, datatypes.String), "title mismatch. movies != films"},
+		{a, newRef("movies", now, 10, datatypes.Integer), "field 0 type mismatch. string != integer"},
+		{a, &DatasetRef{Name: "films", Path: datastore.NewKey("/map/a")}, "name mismatch. movies != films"},
+	}
+
+	for i, c := range cases {
+		err := CompareDatasetRefStructural(c.a, c.b)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+}
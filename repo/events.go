@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// EventType names a kind of event
+type EventType string
+
+const (
+	// ETDatasetDeleted is logged when a dataset is removed from the namespace
+	ETDatasetDeleted EventType = "dataset_deleted"
+	// ETPeerConnected is logged when a qri peer connects
+	ETPeerConnected EventType = "peer_connected"
+	// ETPeerDisconnected is logged when a qri peer disconnects
+	ETPeerDisconnected EventType = "peer_disconnected"
+)
+
+// MaxEvents is the number of events an event log keeps, older events are
+// dropped as new events are logged
+const MaxEvents = 1000
+
+// Event is an entry in a repo's event log
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// Name & Path identify the dataset of a dataset event
+	Name string        `json:"name,omitempty"`
+	Path datastore.Key `json:"path,omitempty"`
+	// PeerID identifies the peer of a peer event
+	PeerID string `json:"peerID,omitempty"`
+}
+
+// Events is an optional interface for repos that keep a log of events.
+// Check for support with a type assertion on a Repo
+type Events interface {
+	// LogEvent adds an event to the log, dropping the oldest events beyond
+	// MaxEvents
+	LogEvent(e *Event) error
+	// ListEvents gives all logged events, newest first
+	ListEvents() ([]*Event, error)
+}
+
+// LogEvent records an event if r keeps an event log, setting the event time
+// if it isn't already set
+func LogEvent(r Repo, e *Event) error {
+	el, ok := r.(Events)
+	if !ok {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().In(time.UTC)
+	}
+	return el.LogEvent(e)
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestLogEvent(t *testing.T) {
+	r, err := NewMemRepo(&profile.Profile{}, memfs.NewMapstore(), nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	for i := 0; i < MaxEvents+1; i++ {
+		et := ETPeerConnected
+		if i == MaxEvents {
+			et = ETPeerDisconnected
+		}
+		if err := LogEvent(r, &Event{Type: et, PeerID: "peer"}); err != nil {
+			t.Errorf("error logging event: %s", err.Error())
+			return
+		}
+	}
+
+	events, err := r.(Events).ListEvents()
+	if err != nil {
+		t.Errorf("error listing events: %s", err.Error())
+		return
+	}
+	if len(events) != MaxEvents {
+		t.Errorf("event count mismatch. expected: %d, got: %d", MaxEvents, len(events))
+		return
+	}
+	if events[0].Type != ETPeerDisconnected {
+		t.Errorf("expected newest event first. got: %s", events[0].Type)
+	}
+	if events[0].Time.IsZero() {
+		t.Errorf("expected LogEvent to set event time")
+	}
+}
@@ -0,0 +1,50 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// CacheSources is a file-based implementation of the repo.CacheSources
+// interface
+type CacheSources struct {
+	basepath
+}
+
+// SetCachedPaths records the paths cached from a peer
+func (c CacheSources) SetCachedPaths(peerID string, paths []datastore.Key) error {
+	sources, err := c.CachedPaths()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		if _, ok := sources[peerID]; !ok {
+			return nil
+		}
+		delete(sources, peerID)
+	} else {
+		sources[peerID] = paths
+	}
+	return c.saveFile(sources, FileCacheSources)
+}
+
+// CachedPaths gives the paths recorded for each peer
+func (c CacheSources) CachedPaths() (map[string][]datastore.Key, error) {
+	sources := map[string][]datastore.Key{}
+	data, err := ioutil.ReadFile(c.filepath(FileCacheSources))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sources, nil
+		}
+		return sources, fmt.Errorf("error loading cache sources: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return sources, fmt.Errorf("error unmarshaling cache sources: %s", err.Error())
+	}
+	return sources, nil
+}
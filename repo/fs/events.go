@@ -0,0 +1,63 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Events is a file-based implementation of the repo.Events interface
+type Events struct {
+	basepath
+	// lk serializes reading & rewriting the events file, events are logged
+	// concurrently by peer handlers
+	lk *sync.Mutex
+}
+
+// NewEvents creates an Events log stored in base
+func NewEvents(base string) Events {
+	return Events{basepath: basepath(base), lk: &sync.Mutex{}}
+}
+
+// LogEvent adds an event to the log
+func (e Events) LogEvent(event *repo.Event) error {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	events, err := e.events()
+	if err != nil {
+		return err
+	}
+	events = append([]*repo.Event{event}, events...)
+	if len(events) > repo.MaxEvents {
+		events = events[:repo.MaxEvents]
+	}
+	return e.saveFile(events, FileEvents)
+}
+
+// ListEvents gives all events, newest first
+func (e Events) ListEvents() ([]*repo.Event, error) {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	return e.events()
+}
+
+func (e Events) events() ([]*repo.Event, error) {
+	events := []*repo.Event{}
+	data, err := ioutil.ReadFile(e.filepath(FileEvents))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return events, nil
+		}
+		return events, fmt.Errorf("error loading events: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &events); err != nil {
+		return events, fmt.Errorf("error unmarshaling events: %s", err.Error())
+	}
+	return events, nil
+}
@@ -58,6 +58,20 @@ const (
 	FileSearchIndex
 	// FileChangeRequests is a file of change requests
 	FileChangeRequests
+	// FileAliases holds groups of namestore names that alias one another
+	FileAliases
+	// FileSnapshots holds snapshots of the namestore
+	FileSnapshots
+	// FileVisibilities holds the visibility of named datasets
+	FileVisibilities
+	// FileEvents is a log of repo events, newest first
+	FileEvents
+	// FileCacheSources records which peer cached datasets came from
+	FileCacheSources
+	// FileRefreshes records when datasets were last refreshed
+	FileRefreshes
+	// FileRowCounts records the number of rows in dataset data
+	FileRowCounts
 )
 
 var paths = map[File]string{
@@ -74,6 +88,13 @@ var paths = map[File]string{
 	FileAnalytics:      "/analytics.json",
 	FileSearchIndex:    "/index.bleve",
 	FileChangeRequests: "/change_requests.json",
+	FileAliases:        "/aliases.json",
+	FileSnapshots:      "/snapshots.json",
+	FileVisibilities:   "/visibilities.json",
+	FileEvents:         "/events.json",
+	FileCacheSources:   "/cache_sources.json",
+	FileRefreshes:      "/refreshes.json",
+	FileRowCounts:      "/row_counts.json",
 }
 
 // Filepath gives the relative filepath to a repofile
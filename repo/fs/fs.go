@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/analytics"
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/dataset/dsgraph"
 	"github.com/qri-io/doggos"
 	"github.com/qri-io/qri/repo"
@@ -19,17 +22,28 @@ import (
 type Repo struct {
 	store cafs.Filestore
 	basepath
-	graph map[string]*dsgraph.Node
+	// graphMu protects graph, which is built on first use & kept up to date
+	// as names change
+	graphMu sync.Mutex
+	graph   map[string]*dsgraph.Node
 
 	Datasets
 	Namestore
 	QueryLog
 	ChangeRequests
-
-	analytics Analytics
-	peers     PeerStore
-	cache     Datasets
-	index     search.Index
+	Snapshots
+	Visibilities
+	Events
+	CacheSources
+	Refreshes
+	RowCounts
+
+	analytics  Analytics
+	peers      PeerStore
+	cache      Datasets
+	index      search.Index
+	datasets   *repo.DatasetCache
+	visibility *repo.VisibilityIndex
 }
 
 // NewRepo creates a new file-based repository
@@ -50,32 +64,50 @@ func NewRepo(store cafs.Filestore, base, id string) (repo.Repo, error) {
 		Namestore:      Namestore{basepath: bp, store: store},
 		QueryLog:       NewQueryLog(base, FileQueryLogs, store),
 		ChangeRequests: NewChangeRequests(base, FileChangeRequests),
-
-		analytics: NewAnalytics(base),
-		peers:     PeerStore{bp},
-		cache:     NewDatasets(base, FileCache, nil),
+		Snapshots:      Snapshots{basepath: bp},
+		Visibilities:   Visibilities{basepath: bp},
+		Events:         NewEvents(base),
+		CacheSources:   CacheSources{basepath: bp},
+		Refreshes:      Refreshes{basepath: bp},
+		RowCounts:      RowCounts{basepath: bp},
+
+		analytics:  NewAnalytics(base),
+		peers:      PeerStore{bp},
+		cache:      NewDatasets(base, FileCache, nil),
+		datasets:   repo.NewDatasetCache(repo.DefaultDatasetCacheSize),
+		visibility: repo.NewVisibilityIndex(),
 	}
 
 	if index, err := search.LoadIndex(bp.filepath(FileSearchIndex)); err == nil {
 		r.index = index
-		r.Namestore.index = index
 	}
 
-	// TODO - this is racey.
-	// go func() {
-	// 	r.graph, _ = repo.Graph(r)
-	// }()
-
 	return r, nil
 }
 
 // Store returns the underlying cafs.Filestore driving this repo
-func (r Repo) Store() cafs.Filestore {
+func (r *Repo) Store() cafs.Filestore {
 	return r.store
 }
 
-// Graph returns the graph of dataset objects for this repo
+// DatasetCache gives the repo's cache of loaded datasets
+func (r *Repo) DatasetCache() *repo.DatasetCache {
+	return r.datasets
+}
+
+// VisibilityIndex gives the repo's default visibility & index of path
+// visibilities
+func (r *Repo) VisibilityIndex() *repo.VisibilityIndex {
+	return r.visibility
+}
+
+// Graph returns the graph of dataset objects for this repo. the graph is
+// built on first call & cached. the returned map is a copy, safe to use
+// while names change
 func (r *Repo) Graph() (map[string]*dsgraph.Node, error) {
+	r.graphMu.Lock()
+	defer r.graphMu.Unlock()
+
 	if r.graph == nil {
 		nodes, err := repo.Graph(r)
 		if err != nil {
@@ -83,7 +115,60 @@ func (r *Repo) Graph() (map[string]*dsgraph.Node, error) {
 		}
 		r.graph = nodes
 	}
-	return r.graph, nil
+
+	nodes := make(map[string]*dsgraph.Node, len(r.graph))
+	for path, node := range r.graph {
+		nodes[path] = node
+	}
+	return nodes, nil
+}
+
+// PutName adds a name for a dataset, updating the repo graph
+func (r *Repo) PutName(name string, path datastore.Key) error {
+	prev, err := r.Namestore.GetPath(name)
+	if err != nil && err != repo.ErrNotFound {
+		return err
+	}
+	moved := err == nil && !prev.Equal(path)
+
+	if err := r.Namestore.PutName(name, path); err != nil {
+		return err
+	}
+	r.addToGraph(path, prev, moved)
+	return nil
+}
+
+// DeleteName removes a name, dropping the cached repo graph
+func (r *Repo) DeleteName(name string) error {
+	if err := r.Namestore.DeleteName(name); err != nil {
+		return err
+	}
+	r.invalidateGraph()
+	return nil
+}
+
+// addToGraph adds the history of a newly named path to the cached graph. if
+// a name moved to a path that doesn't include it's previous path in it's
+// history, the previous path may no longer be named, so the graph is
+// dropped to be rebuilt on next use
+func (r *Repo) addToGraph(path, prev datastore.Key, moved bool) {
+	r.graphMu.Lock()
+	defer r.graphMu.Unlock()
+	if r.graph == nil {
+		return
+	}
+
+	stopped, err := repo.AddToGraph(r, r.graph, path)
+	if err != nil || moved && !stopped.Equal(prev) {
+		r.graph = nil
+	}
+}
+
+// invalidateGraph drops the cached graph
+func (r *Repo) invalidateGraph() {
+	r.graphMu.Lock()
+	r.graph = nil
+	r.graphMu.Unlock()
 }
 
 // Profile gives this repo's peer profile
@@ -187,6 +272,30 @@ func (r *Repo) UpdateSearchIndex(store cafs.Filestore) error {
 	return search.IndexRepo(r, r.index)
 }
 
+// IndexDataset adds or updates a single dataset in this repo's search index.
+// Use UpdateSearchIndex to rebuild the index from scratch
+func (r *Repo) IndexDataset(ref *repo.DatasetRef) error {
+	if r.index == nil {
+		return nil
+	}
+	ds := ref.Dataset
+	if ds == nil {
+		var err error
+		if ds, err = dsfs.LoadDataset(r.store, ref.Path); err != nil {
+			return err
+		}
+	}
+	return search.IndexDataset(r.index, ref.Path, ds)
+}
+
+// RemoveFromIndex drops the dataset at path from this repo's search index
+func (r *Repo) RemoveFromIndex(path datastore.Key) error {
+	if r.index == nil {
+		return nil
+	}
+	return search.RemoveDataset(r.index, path)
+}
+
 // Peers returns this repo's Peers implementation
 func (r *Repo) Peers() repo.Peers {
 	return r.peers
@@ -5,7 +5,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/test"
 )
 
@@ -23,3 +27,104 @@ func TestRepo(t *testing.T) {
 		t.Errorf("error cleaning up after test", err.Error())
 	}
 }
+
+func TestRepoGraph(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_repo_graph_test")
+	defer os.RemoveAll(path)
+	store := memfs.NewMapstore()
+	r, err := NewRepo(store, path, "test_repo_id")
+	if err != nil {
+		t.Errorf("error creating repo: %s", err.Error())
+		return
+	}
+
+	save := func(title string, prev datastore.Key) datastore.Key {
+		datakey, err := store.Put(memfs.NewMemfileBytes("data.csv", []byte(title+"\n")), false)
+		if err != nil {
+			t.Fatalf("error putting data: %s", err.Error())
+		}
+		ds := &dataset.Dataset{
+			Title:    title,
+			Data:     datakey.String(),
+			Previous: prev,
+			Structure: &dataset.Structure{
+				Format: dataset.CSVDataFormat,
+				Schema: &dataset.Schema{Fields: []*dataset.Field{{Name: "a"}}},
+			},
+		}
+		dspath, err := dsfs.SaveDataset(store, ds, true)
+		if err != nil {
+			t.Fatalf("error saving dataset: %s", err.Error())
+		}
+		return dspath
+	}
+	one := save("one", datastore.NewKey(""))
+	two := save("two", datastore.NewKey(""))
+	three := save("three", one)
+
+	steps := []struct {
+		description string
+		change      func() error
+		has, hasnt  []datastore.Key
+	}{
+		{"add first name", func() error { return r.PutName("one", one) }, []datastore.Key{one}, []datastore.Key{two, three}},
+		{"add second name", func() error { return r.PutName("two", two) }, []datastore.Key{one, two}, []datastore.Key{three}},
+		{"delete second name", func() error { return r.DeleteName("two") }, []datastore.Key{one}, []datastore.Key{two, three}},
+		{"move name to a new version", func() error { return repo.UpdateName(r, "one", three) }, []datastore.Key{one, three}, []datastore.Key{two}},
+		{"move name to an unrelated version", func() error { return repo.UpdateName(r, "one", two) }, []datastore.Key{two}, []datastore.Key{one, three}},
+	}
+
+	for i, s := range steps {
+		if err := s.change(); err != nil {
+			t.Errorf("step %d (%s) error: %s", i, s.description, err.Error())
+			return
+		}
+		nodes, err := r.Graph()
+		if err != nil {
+			t.Errorf("step %d (%s) error getting graph: %s", i, s.description, err.Error())
+			return
+		}
+		for _, p := range s.has {
+			if nodes[p.String()] == nil {
+				t.Errorf("step %d (%s) expected graph to include %s", i, s.description, p)
+			}
+		}
+		for _, p := range s.hasnt {
+			if nodes[p.String()] != nil {
+				t.Errorf("step %d (%s) expected graph not to include %s", i, s.description, p)
+			}
+		}
+	}
+}
+
+func TestEventsConcurrentLog(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_repo_events_test")
+	defer os.RemoveAll(path)
+	r, err := NewRepo(memfs.NewMapstore(), path, "test_repo_id")
+	if err != nil {
+		t.Errorf("error creating repo: %s", err.Error())
+		return
+	}
+
+	const n = 20
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- repo.LogEvent(r, &repo.Event{Type: repo.ETPeerConnected, PeerID: "peer"})
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("error logging event: %s", err.Error())
+		}
+	}
+
+	events, err := r.(repo.Events).ListEvents()
+	if err != nil {
+		t.Errorf("error listing events: %s", err.Error())
+		return
+	}
+	if len(events) != n {
+		t.Errorf("event count mismatch. expected: %d, got: %d", n, len(events))
+	}
+}
@@ -0,0 +1,40 @@
+package fsrepo
+
+import (
+	"context"
+	"fmt"
+
+	ipfs "github.com/qri-io/cafs/ipfs"
+
+	corerepo "gx/ipfs/QmViBzgruNUoLNBnXcx8YWbDNwV8MNGEGKkLo6JGetygdw/go-ipfs/core/corerepo"
+)
+
+// CollectGarbage removes blocks that aren't pinned from the repo's IPFS
+// node, returning the number of blocks & bytes removed. bytes removed is
+// measured as the change in blockstore size
+func (r *Repo) CollectGarbage() (int, int64, error) {
+	fs, ok := r.store.(*ipfs.Filestore)
+	if !ok {
+		return 0, 0, fmt.Errorf("garbage collection requires an ipfs store")
+	}
+	before, err := blocksSize(fs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading store size: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blocks := 0
+	for res := range corerepo.GarbageCollectAsync(fs.Node(), ctx) {
+		if res.Error != nil {
+			return blocks, 0, fmt.Errorf("error collecting garbage: %s", res.Error.Error())
+		}
+		blocks++
+	}
+
+	after, err := blocksSize(fs)
+	if err != nil {
+		return blocks, 0, fmt.Errorf("error reading store size: %s", err.Error())
+	}
+	return blocks, before - after, nil
+}
@@ -9,26 +9,22 @@ import (
 
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
-	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/repo"
-	"github.com/qri-io/qri/repo/search"
 )
 
 // Namestore is a file-based implementation of the repo.Namestore
 // interface. It stores names in a json file
 type Namestore struct {
 	basepath
-	// optional search index to add/remove from
-	index search.Index
 	// filestore for checking dataset integrity
 	store cafs.Filestore
 }
 
-// PutName adds a name to the store
+// PutName adds a name to the store. If name is part of an alias group
+// all names in the group are moved to path, other existing names return
+// repo.ErrNameTaken
 func (n Namestore) PutName(name string, path datastore.Key) (err error) {
-	var ds *dataset.Dataset
-
 	if name == "" {
 		return repo.ErrNameRequired
 	}
@@ -37,34 +33,38 @@ func (n Namestore) PutName(name string, path datastore.Key) (err error) {
 	if err != nil {
 		return err
 	}
+	aliases, err := n.aliases()
+	if err != nil {
+		return err
+	}
 
+	group := aliases.Group(name)
+	if len(group) == 1 {
+		for _, ref := range names {
+			if ref.Name == name {
+				return repo.ErrNameTaken
+			}
+		}
+	}
+	found := false
 	for _, ref := range names {
-		if ref.Name == name {
-			return repo.ErrNameTaken
+		for _, alias := range group {
+			if ref.Name == alias {
+				ref.Path = path
+				found = found || alias == name
+			}
 		}
 	}
 
-	r := &repo.DatasetRef{
-		Name: name,
-		Path: path,
+	if !found {
+		names = append(names, &repo.DatasetRef{
+			Name: name,
+			Path: path,
+		})
 	}
-	names = append(names, r)
 
 	if n.store != nil {
-		ds, err = dsfs.LoadDataset(n.store, path)
-		if err != nil {
-			return err
-		}
-	}
-
-	if n.index != nil {
-		batch := n.index.NewBatch()
-		err = batch.Index(path.String(), ds)
-		if err != nil {
-			return err
-		}
-		err = n.index.Batch(batch)
-		if err != nil {
+		if _, err = dsfs.LoadDataset(n.store, path); err != nil {
 			return err
 		}
 	}
@@ -109,25 +109,79 @@ func (n Namestore) DeleteName(name string) error {
 
 	for i, ref := range names {
 		if ref.Name == name {
-			if ref.Path.String() != "" && n.index != nil {
-				if err := n.index.Delete(ref.Path.String()); err != nil {
-					return err
-				}
-			}
 			names = append(names[:i], names[i+1:]...)
 			break
 		}
 	}
 
+	aliases, err := n.aliases()
+	if err != nil {
+		return err
+	}
+	if _, ok := aliases[name]; ok {
+		aliases.Remove(name)
+		if err := n.saveFile(aliases, FileAliases); err != nil {
+			return err
+		}
+	}
+
 	return n.save(names)
 }
 
+// AddAlias links alias to the dataset primary refers to
+func (n Namestore) AddAlias(primary, alias string) error {
+	if primary == "" || alias == "" {
+		return repo.ErrNameRequired
+	}
+	path, err := n.GetPath(primary)
+	if err != nil {
+		return err
+	}
+	if _, err := n.GetPath(alias); err != repo.ErrNotFound {
+		return repo.ErrNameTaken
+	}
+
+	aliases, err := n.aliases()
+	if err != nil {
+		return err
+	}
+	aliases.Link(primary, alias)
+	if err := n.saveFile(aliases, FileAliases); err != nil {
+		return err
+	}
+
+	if err := n.PutName(alias, path); err != nil {
+		// don't leave alias linked to a group it isn't named in
+		aliases.Remove(alias)
+		if serr := n.saveFile(aliases, FileAliases); serr != nil {
+			return fmt.Errorf("%s. error unlinking alias: %s", err.Error(), serr.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// Aliases gives all names in the alias group name belongs to
+func (n Namestore) Aliases(name string) ([]string, error) {
+	if _, err := n.GetPath(name); err != nil {
+		return nil, err
+	}
+	aliases, err := n.aliases()
+	if err != nil {
+		return nil, err
+	}
+	return aliases.Group(name), nil
+}
+
 // Namespace gives a set of dataset references from the store
 func (n Namestore) Namespace(limit, offset int) ([]*repo.DatasetRef, error) {
 	names, err := n.names()
 	if err != nil {
 		return nil, err
 	}
+	if offset >= len(names) {
+		return []*repo.DatasetRef{}, nil
+	}
 	res := make([]*repo.DatasetRef, limit)
 	for i, ref := range names {
 		if i < offset {
@@ -180,6 +234,22 @@ func (n *Namestore) names() ([]*repo.DatasetRef, error) {
 	return ns, nil
 }
 
+func (n *Namestore) aliases() (repo.AliasGroups, error) {
+	aliases := repo.AliasGroups{}
+	data, err := ioutil.ReadFile(n.filepath(FileAliases))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return aliases, nil
+		}
+		return aliases, fmt.Errorf("error loading aliases: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return aliases, fmt.Errorf("error unmarshaling aliases: %s", err.Error())
+	}
+	return aliases, nil
+}
+
 func (n *Namestore) save(ns []*repo.DatasetRef) error {
 	sort.Slice(ns, func(i, j int) bool { return ns[i].Name < ns[j].Name })
 	return n.saveFile(ns, FileNamestore)
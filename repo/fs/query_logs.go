@@ -7,6 +7,7 @@ import (
 	"os"
 	"sort"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/qri/repo"
 )
@@ -30,7 +31,7 @@ func (ql QueryLog) LogQuery(item *repo.QueryLogItem) error {
 		return err
 	}
 	log = append(log, item)
-	sort.Slice(log, func(i, j int) bool { return log[i].Time.Before(log[j].Time) })
+	sort.Slice(log, func(i, j int) bool { return log[i].Time.After(log[j].Time) })
 	return ql.saveFile(log, ql.file)
 }
 
@@ -52,7 +53,8 @@ func (ql QueryLog) QueryLogItem(q *repo.QueryLogItem) (*repo.QueryLogItem, error
 	return nil, repo.ErrNotFound
 }
 
-// ListQueryLogs fetches a set of QueryLogItems from the store
+// ListQueryLogs fetches a set of QueryLogItems from the store, most recent
+// first
 func (ql QueryLog) ListQueryLogs(limit, offset int) ([]*repo.QueryLogItem, error) {
 	logs, err := ql.logs()
 	if err != nil {
@@ -70,6 +72,58 @@ func (ql QueryLog) ListQueryLogs(limit, offset int) ([]*repo.QueryLogItem, error
 	return logs[offset:stop], nil
 }
 
+// QueryLogsForDataset fetches the QueryLogItems whose Name or DatasetPath
+// matches name from the store
+func (ql QueryLog) QueryLogsForDataset(name string, limit, offset int) ([]*repo.QueryLogItem, error) {
+	if name == "" {
+		return nil, repo.ErrNameRequired
+	}
+
+	logs, err := ql.logs()
+	if err != nil {
+		return nil, err
+	}
+
+	path := datastore.NewKey(name)
+	items := []*repo.QueryLogItem{}
+	for _, item := range logs {
+		if item.Name == name || item.DatasetPath.Equal(path) {
+			if offset > 0 {
+				offset--
+				continue
+			}
+			if len(items) == limit {
+				break
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// DeleteQueryLogs removes QueryLogItems whose Name matches name from the store
+func (ql QueryLog) DeleteQueryLogs(name string) error {
+	if name == "" {
+		return repo.ErrNameRequired
+	}
+
+	logs, err := ql.logs()
+	if err != nil {
+		return err
+	}
+
+	kept := make([]*repo.QueryLogItem, 0, len(logs))
+	for _, item := range logs {
+		if item.Name != name {
+			kept = append(kept, item)
+		}
+	}
+	if len(kept) == len(logs) {
+		return nil
+	}
+	return ql.saveFile(kept, ql.file)
+}
+
 func (ql *QueryLog) logs() ([]*repo.QueryLogItem, error) {
 	ds := []*repo.QueryLogItem{}
 	data, err := ioutil.ReadFile(ql.filepath(ql.file))
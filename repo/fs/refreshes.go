@@ -0,0 +1,56 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+// Refreshes is a file-based implementation of the repo.Refreshes interface
+type Refreshes struct {
+	basepath
+}
+
+// SetRefreshed records the time the dataset at path was refreshed
+func (r Refreshes) SetRefreshed(path datastore.Key, t time.Time) error {
+	rs, err := r.refreshes()
+	if err != nil {
+		return err
+	}
+	rs[path.String()] = t
+	return r.saveFile(rs, FileRefreshes)
+}
+
+// Refreshed gives the time the dataset at path was last refreshed
+func (r Refreshes) Refreshed(path datastore.Key) (time.Time, error) {
+	rs, err := r.refreshes()
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := rs[path.String()]
+	if !ok {
+		return time.Time{}, repo.ErrNotFound
+	}
+	return t, nil
+}
+
+func (r Refreshes) refreshes() (map[string]time.Time, error) {
+	rs := map[string]time.Time{}
+	data, err := ioutil.ReadFile(r.filepath(FileRefreshes))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rs, nil
+		}
+		return rs, fmt.Errorf("error loading refreshes: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return rs, fmt.Errorf("error unmarshaling refreshes: %s", err.Error())
+	}
+	return rs, nil
+}
@@ -0,0 +1,55 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+// RowCounts is a file-based implementation of the repo.RowCounts interface
+type RowCounts struct {
+	basepath
+}
+
+// SetRowCount records the number of rows in the data at path
+func (r RowCounts) SetRowCount(path datastore.Key, rows int) error {
+	rc, err := r.rowCounts()
+	if err != nil {
+		return err
+	}
+	rc[path.String()] = rows
+	return r.saveFile(rc, FileRowCounts)
+}
+
+// RowCount gives the number of rows in the data at path
+func (r RowCounts) RowCount(path datastore.Key) (int, error) {
+	rc, err := r.rowCounts()
+	if err != nil {
+		return 0, err
+	}
+	rows, ok := rc[path.String()]
+	if !ok {
+		return 0, repo.ErrNotFound
+	}
+	return rows, nil
+}
+
+func (r RowCounts) rowCounts() (map[string]int, error) {
+	rc := map[string]int{}
+	data, err := ioutil.ReadFile(r.filepath(FileRowCounts))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rc, nil
+		}
+		return rc, fmt.Errorf("error loading row counts: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &rc); err != nil {
+		return rc, fmt.Errorf("error unmarshaling row counts: %s", err.Error())
+	}
+	return rc, nil
+}
@@ -0,0 +1,73 @@
+package fsrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ipfs "github.com/qri-io/cafs/ipfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// Size gives the bytes this repo occupies on disk: the size of repo
+// metadata files plus the size of stored data. IPFS filestores are sized by
+// their blocks, other stores by the files in the repo's graph
+func (r *Repo) Size() (int64, error) {
+	size, err := dirSize(string(r.basepath))
+	if err != nil {
+		return 0, fmt.Errorf("error reading repo files: %s", err.Error())
+	}
+
+	var stored int64
+	if fs, ok := r.store.(*ipfs.Filestore); ok {
+		stored, err = blocksSize(fs)
+	} else {
+		stored, err = repo.GraphSize(r)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading store size: %s", err.Error())
+	}
+
+	return size + stored, nil
+}
+
+// dirSize sums the sizes of all files within a directory
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// blocksSize sums the sizes of all blocks in an IPFS node's blockstore
+func blocksSize(fs *ipfs.Filestore) (int64, error) {
+	node := fs.Node()
+	if node == nil || node.Blockstore == nil {
+		return 0, fmt.Errorf("ipfs node has no blockstore")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys, err := node.Blockstore.AllKeysChan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for key := range keys {
+		blk, err := node.Blockstore.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(blk.RawData()))
+	}
+	return size, nil
+}
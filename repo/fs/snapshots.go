@@ -0,0 +1,73 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Snapshots is a file-based implementation of the repo.Snapshots interface
+type Snapshots struct {
+	basepath
+}
+
+// PutSnapshot adds a snapshot to the store
+func (s Snapshots) PutSnapshot(snap *repo.Snapshot) error {
+	snaps, err := s.snapshots()
+	if err != nil {
+		return err
+	}
+	return s.saveFile(append([]*repo.Snapshot{snap}, snaps...), FileSnapshots)
+}
+
+// GetSnapshot gets a snapshot by ID
+func (s Snapshots) GetSnapshot(id string) (*repo.Snapshot, error) {
+	snaps, err := s.snapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snaps {
+		if snap.ID == id {
+			return snap, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+// ListSnapshots gives all snapshots, newest first
+func (s Snapshots) ListSnapshots() ([]*repo.Snapshot, error) {
+	return s.snapshots()
+}
+
+// DeleteSnapshot removes a snapshot from the store
+func (s Snapshots) DeleteSnapshot(id string) error {
+	snaps, err := s.snapshots()
+	if err != nil {
+		return err
+	}
+	for i, snap := range snaps {
+		if snap.ID == id {
+			return s.saveFile(append(snaps[:i], snaps[i+1:]...), FileSnapshots)
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func (s Snapshots) snapshots() ([]*repo.Snapshot, error) {
+	snaps := []*repo.Snapshot{}
+	data, err := ioutil.ReadFile(s.filepath(FileSnapshots))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snaps, nil
+		}
+		return snaps, fmt.Errorf("error loading snapshots: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &snaps); err != nil {
+		return snaps, fmt.Errorf("error unmarshaling snapshots: %s", err.Error())
+	}
+	return snaps, nil
+}
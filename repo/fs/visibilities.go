@@ -0,0 +1,67 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Visibilities is a file-based implementation of the repo.Visibilities interface
+type Visibilities struct {
+	basepath
+}
+
+// SetVisibility records the visibility of a dataset name
+func (v Visibilities) SetVisibility(name string, vis repo.Visibility) error {
+	vs, err := v.visibilities()
+	if err != nil {
+		return err
+	}
+	vs[name] = vis
+	return v.saveFile(vs, FileVisibilities)
+}
+
+// GetVisibility gets the visibility set for a name
+func (v Visibilities) GetVisibility(name string) (repo.Visibility, error) {
+	vs, err := v.visibilities()
+	if err != nil {
+		return "", err
+	}
+	vis, ok := vs[name]
+	if !ok {
+		return "", repo.ErrNotFound
+	}
+	return vis, nil
+}
+
+// DeleteVisibility removes any visibility recorded for name
+func (v Visibilities) DeleteVisibility(name string) error {
+	vs, err := v.visibilities()
+	if err != nil {
+		return err
+	}
+	if _, ok := vs[name]; !ok {
+		return nil
+	}
+	delete(vs, name)
+	return v.saveFile(vs, FileVisibilities)
+}
+
+func (v Visibilities) visibilities() (map[string]repo.Visibility, error) {
+	vs := map[string]repo.Visibility{}
+	data, err := ioutil.ReadFile(v.filepath(FileVisibilities))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vs, nil
+		}
+		return vs, fmt.Errorf("error loading visibilities: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &vs); err != nil {
+		return vs, fmt.Errorf("error unmarshaling visibilities: %s", err.Error())
+	}
+	return vs, nil
+}
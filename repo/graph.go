@@ -67,6 +67,37 @@ func Graph(r Repo) (map[string]*dsgraph.Node, error) {
 	return nodes.Nodes, err
 }
 
+// AddToGraph adds the dataset at path & it's history to nodes, a graph made
+// by Graph, without rebuilding the graph. history is added until a version
+// already in the graph is reached, the path of that version is returned.
+// stopped is empty if the entire history was added
+func AddToGraph(r Repo, nodes map[string]*dsgraph.Node, path datastore.Key) (stopped datastore.Key, err error) {
+	nl := NodeList{Nodes: nodes}
+	prev := nl.node(dsgraph.NtNamespace, "root")
+	for path.String() != "" && path.String() != "/" {
+		if n := nodes[path.String()]; n != nil && len(n.Links) > 0 {
+			return path, nil
+		}
+
+		ref := &DatasetRef{Path: path}
+		if ref.Dataset, err = dsfs.LoadDatasetRefs(r.Store(), path); err != nil {
+			return datastore.NewKey(""), err
+		}
+		ds := nl.nodesFromDatasetRef(r, ref)
+		prev.AddLinks(dsgraph.Link{From: prev, To: ds})
+		prev = ds
+
+		path = ref.Dataset.Previous
+		// match the paths WalkRepoDatasets visits
+		if r.Store().PathPrefix() == "ipfs" && path.String() != "" && path.String() != "/" {
+			if !strings.HasSuffix(path.String(), "/dataset.json") {
+				path = datastore.NewKey(path.String() + "/dataset.json")
+			}
+		}
+	}
+	return datastore.NewKey(""), nil
+}
+
 // QueriesMap returns a mapped subset of a list of nodes in the form:
 // 		QueryHash : DatasetHash
 func QueriesMap(nodes map[string]*dsgraph.Node) (qs map[string]datastore.Key) {
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"github.com/ipfs/go-datastore"
+)
+
+// MemCacheSources is an in-memory implementation of the CacheSources
+// interface
+type MemCacheSources map[string][]datastore.Key
+
+// SetCachedPaths records the paths cached from a peer
+func (m MemCacheSources) SetCachedPaths(peerID string, paths []datastore.Key) error {
+	if len(paths) == 0 {
+		delete(m, peerID)
+		return nil
+	}
+	m[peerID] = paths
+	return nil
+}
+
+// CachedPaths gives the paths recorded for each peer
+func (m MemCacheSources) CachedPaths() (map[string][]datastore.Key, error) {
+	sources := make(map[string][]datastore.Key, len(m))
+	for id, paths := range m {
+		sources[id] = paths
+	}
+	return sources, nil
+}
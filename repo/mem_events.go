@@ -0,0 +1,30 @@
+package repo
+
+import "sync"
+
+// MemEvents is an in-memory implementation of the Events interface
+type MemEvents struct {
+	// lk protects events, events are logged concurrently by peer handlers
+	lk     sync.Mutex
+	events []*Event
+}
+
+// LogEvent adds an event to the log
+func (m *MemEvents) LogEvent(e *Event) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	events := append([]*Event{e}, m.events...)
+	if len(events) > MaxEvents {
+		events = events[:MaxEvents]
+	}
+	m.events = events
+	return nil
+}
+
+// ListEvents gives all events, newest first
+func (m *MemEvents) ListEvents() ([]*Event, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return append([]*Event{}, m.events...), nil
+}
@@ -2,16 +2,18 @@ package repo
 
 import (
 	"sort"
+
+	"github.com/ipfs/go-datastore"
 )
 
 // MemQueryLog is an in-memory implementation of the
-// QueryLog interface
+// QueryLog interface. items are kept most-recent-first
 type MemQueryLog []*QueryLogItem
 
 // LogQuery adds a query entry to the store
 func (ql *MemQueryLog) LogQuery(item *QueryLogItem) error {
 	logs := append(*ql, item)
-	sort.Slice(logs, func(i, j int) bool { return logs[i].Time.Before(logs[j].Time) })
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Time.After(logs[j].Time) })
 	*ql = logs
 	return nil
 }
@@ -29,7 +31,7 @@ func (ql *MemQueryLog) QueryLogItem(q *QueryLogItem) (*QueryLogItem, error) {
 	return nil, ErrNotFound
 }
 
-// ListQueryLogs grabs a set of QueryLogItems from the store
+// ListQueryLogs grabs a set of QueryLogItems from the store, most recent first
 func (ql MemQueryLog) ListQueryLogs(limit, offset int) ([]*QueryLogItem, error) {
 	if offset > len(ql) {
 		offset = len(ql)
@@ -41,3 +43,42 @@ func (ql MemQueryLog) ListQueryLogs(limit, offset int) ([]*QueryLogItem, error)
 
 	return ql[offset:stop], nil
 }
+
+// QueryLogsForDataset lists logged queries whose Name or DatasetPath matches name
+func (ql MemQueryLog) QueryLogsForDataset(name string, limit, offset int) ([]*QueryLogItem, error) {
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+
+	path := datastore.NewKey(name)
+	items := []*QueryLogItem{}
+	for _, item := range ql {
+		if item.Name == name || item.DatasetPath.Equal(path) {
+			if offset > 0 {
+				offset--
+				continue
+			}
+			if len(items) == limit {
+				break
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// DeleteQueryLogs removes logged queries whose Name matches name
+func (ql *MemQueryLog) DeleteQueryLogs(name string) error {
+	if name == "" {
+		return ErrNameRequired
+	}
+
+	logs := MemQueryLog{}
+	for _, item := range *ql {
+		if item.Name != name {
+			logs = append(logs, item)
+		}
+	}
+	*ql = logs
+	return nil
+}
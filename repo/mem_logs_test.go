@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+func TestMemQueryLogList(t *testing.T) {
+	ql := &MemQueryLog{}
+	now := time.Now()
+	// logged out of order
+	items := []*QueryLogItem{
+		{Query: "b", Time: now.Add(time.Second)},
+		{Query: "a", Time: now},
+		{Query: "d", Time: now.Add(3 * time.Second)},
+		{Query: "c", Time: now.Add(2 * time.Second)},
+	}
+	for _, item := range items {
+		if err := ql.LogQuery(item); err != nil {
+			t.Errorf("error logging query: %s", err.Error())
+			return
+		}
+	}
+
+	cases := []struct {
+		limit, offset int
+		queries       []string
+	}{
+		{10, 0, []string{"d", "c", "b", "a"}},
+		{2, 0, []string{"d", "c"}},
+		{2, 2, []string{"b", "a"}},
+		{2, 3, []string{"a"}},
+		{2, 10, []string{}},
+	}
+
+	for i, c := range cases {
+		got, err := ql.ListQueryLogs(c.limit, c.offset)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got) != len(c.queries) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.queries), len(got))
+			continue
+		}
+		for j, item := range got {
+			if item.Query != c.queries[j] {
+				t.Errorf("case %d item %d query mismatch. expected: %s, got: %s", i, j, c.queries[j], item.Query)
+			}
+		}
+	}
+
+}
+
+func TestMemQueryLogsForDataset(t *testing.T) {
+	ql := &MemQueryLog{}
+	now := time.Now()
+	items := []*QueryLogItem{
+		{Query: "select * from movies", Name: "movies_q", DatasetPath: datastore.NewKey("/map/a"), Time: now},
+		{Query: "select title from movies", DatasetPath: datastore.NewKey("/map/b"), Time: now.Add(time.Second)},
+		{Query: "select * from counter", Name: "counter_q", DatasetPath: datastore.NewKey("/map/c"), Time: now.Add(2 * time.Second)},
+	}
+	for _, item := range items {
+		if err := ql.LogQuery(item); err != nil {
+			t.Errorf("error logging query: %s", err.Error())
+			return
+		}
+	}
+
+	cases := []struct {
+		name    string
+		queries []string
+		err     string
+	}{
+		{"", nil, "repo: name is required"},
+		{"not_a_dataset", []string{}, ""},
+		{"movies_q", []string{"select * from movies"}, ""},
+		{"/map/b", []string{"select title from movies"}, ""},
+	}
+
+	for i, c := range cases {
+		got, err := ql.QueryLogsForDataset(c.name, 10, 0)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != len(c.queries) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.queries), len(got))
+			continue
+		}
+		for j, item := range got {
+			if item.Query != c.queries[j] {
+				t.Errorf("case %d item %d query mismatch. expected: %s, got: %s", i, j, c.queries[j], item.Query)
+			}
+		}
+	}
+}
+
+func TestMemDeleteQueryLogs(t *testing.T) {
+	ql := &MemQueryLog{}
+	now := time.Now()
+	items := []*QueryLogItem{
+		{Query: "select * from movies", Name: "movies_q", Time: now},
+		{Query: "select title from movies", Name: "movies_q", Time: now.Add(time.Second)},
+		{Query: "select * from counter", Name: "counter_q", Time: now.Add(2 * time.Second)},
+	}
+	for _, item := range items {
+		if err := ql.LogQuery(item); err != nil {
+			t.Errorf("error logging query: %s", err.Error())
+			return
+		}
+	}
+
+	if err := ql.DeleteQueryLogs(""); err == nil || err.Error() != "repo: name is required" {
+		t.Errorf("expected a name required error, got: %s", err)
+	}
+	if err := ql.DeleteQueryLogs("movies_q"); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+
+	got, err := ql.ListQueryLogs(10, 0)
+	if err != nil {
+		t.Errorf("error listing query logs: %s", err.Error())
+		return
+	}
+	if len(got) != 1 || got[0].Name != "counter_q" {
+		t.Errorf("expected only counter_q queries to remain, got: %d items", len(got))
+	}
+}
@@ -7,29 +7,51 @@ import (
 )
 
 // MemNamestore is an in-memory implementation of the Namestore interface
-type MemNamestore []*DatasetRef
+type MemNamestore struct {
+	refs    []*DatasetRef
+	aliases AliasGroups
+}
 
-// PutName adds a name to the namestore
+// PutName adds a name to the namestore. If name is part of an alias group
+// all names in the group are moved to path, other existing names return
+// ErrNameTaken
 func (r *MemNamestore) PutName(name string, path datastore.Key) error {
-	for _, ref := range *r {
-		if ref.Name == name {
-			ref.Path = path
-			return nil
+	if name == "" {
+		return ErrNameRequired
+	}
+
+	group := r.aliases.Group(name)
+	if len(group) == 1 {
+		if _, err := r.GetPath(name); err == nil {
+			return ErrNameTaken
 		}
 	}
-	*r = append(*r, &DatasetRef{
+	found := false
+	for _, ref := range r.refs {
+		for _, n := range group {
+			if ref.Name == n {
+				ref.Path = path
+				found = found || n == name
+			}
+		}
+	}
+	if found {
+		return nil
+	}
+
+	r.refs = append(r.refs, &DatasetRef{
 		Name: name,
 		Path: path,
 	})
-	sl := *r
+	sl := r.refs
 	sort.Slice(sl, func(i, j int) bool { return sl[i].Name < sl[j].Name })
-	*r = sl
+	r.refs = sl
 	return nil
 }
 
 // GetPath returns the path associated with a given name
 func (r MemNamestore) GetPath(name string) (datastore.Key, error) {
-	for _, ref := range r {
+	for _, ref := range r.refs {
 		if ref.Name == name {
 			return ref.Path, nil
 		}
@@ -39,7 +61,7 @@ func (r MemNamestore) GetPath(name string) (datastore.Key, error) {
 
 // GetName returns the name for a given path in the store
 func (r MemNamestore) GetName(path datastore.Key) (string, error) {
-	for _, ref := range r {
+	for _, ref := range r.refs {
 		if ref.Path.Equal(path) {
 			return ref.Name, nil
 		}
@@ -48,20 +70,52 @@ func (r MemNamestore) GetName(path datastore.Key) (string, error) {
 }
 
 // DeleteName removes a name from the store
-func (r MemNamestore) DeleteName(name string) error {
-	for i, ref := range r {
+func (r *MemNamestore) DeleteName(name string) error {
+	for i, ref := range r.refs {
 		if ref.Name == name {
-			r = append(r[:i], r[i+1:]...)
+			r.refs = append(r.refs[:i], r.refs[i+1:]...)
+			r.aliases.Remove(name)
 			return nil
 		}
 	}
 	return ErrNotFound
 }
 
+// AddAlias links alias to the dataset primary refers to
+func (r *MemNamestore) AddAlias(primary, alias string) error {
+	if primary == "" || alias == "" {
+		return ErrNameRequired
+	}
+	path, err := r.GetPath(primary)
+	if err != nil {
+		return err
+	}
+	if _, err := r.GetPath(alias); err != ErrNotFound {
+		return ErrNameTaken
+	}
+
+	if r.aliases == nil {
+		r.aliases = AliasGroups{}
+	}
+	r.aliases.Link(primary, alias)
+	return r.PutName(alias, path)
+}
+
+// Aliases gives all names in the alias group name belongs to
+func (r MemNamestore) Aliases(name string) ([]string, error) {
+	if _, err := r.GetPath(name); err != nil {
+		return nil, err
+	}
+	return r.aliases.Group(name), nil
+}
+
 // Namespace grabs a set of names from the Store's namespace
 func (r MemNamestore) Namespace(limit, offset int) ([]*DatasetRef, error) {
+	if offset >= len(r.refs) {
+		return []*DatasetRef{}, nil
+	}
 	res := make([]*DatasetRef, limit)
-	for i, ref := range r {
+	for i, ref := range r.refs {
 		if i < offset {
 			continue
 		}
@@ -73,10 +127,10 @@ func (r MemNamestore) Namespace(limit, offset int) ([]*DatasetRef, error) {
 			Path: ref.Path,
 		}
 	}
-	return res[:len(r)-offset], nil
+	return res[:len(r.refs)-offset], nil
 }
 
 // NameCount returns the total number of names in the store
 func (r MemNamestore) NameCount() (int, error) {
-	return len(r), nil
+	return len(r.refs), nil
 }
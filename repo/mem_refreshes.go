@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// MemRefreshes is an in-memory implementation of the Refreshes interface
+type MemRefreshes map[string]time.Time
+
+// SetRefreshed records the time the dataset at path was refreshed
+func (m MemRefreshes) SetRefreshed(path datastore.Key, t time.Time) error {
+	m[path.String()] = t
+	return nil
+}
+
+// Refreshed gives the time the dataset at path was last refreshed
+func (m MemRefreshes) Refreshed(path datastore.Key) (time.Time, error) {
+	t, ok := m[path.String()]
+	if !ok {
+		return time.Time{}, ErrNotFound
+	}
+	return t, nil
+}
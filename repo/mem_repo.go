@@ -1,8 +1,11 @@
 package repo
 
 import (
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/analytics"
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/dataset/dsgraph"
 	"github.com/qri-io/qri/repo/profile"
 )
@@ -15,10 +18,18 @@ type MemRepo struct {
 	*MemNamestore
 	*MemQueryLog
 	MemChangeRequests
-	profile   *profile.Profile
-	peers     Peers
-	cache     MemDatasets
-	analytics analytics.Analytics
+	*MemSnapshots
+	MemVisibilities
+	*MemEvents
+	MemCacheSources
+	MemRefreshes
+	MemRowCounts
+	profile    *profile.Profile
+	peers      Peers
+	cache      MemDatasets
+	analytics  analytics.Analytics
+	datasets   *DatasetCache
+	visibility *VisibilityIndex
 }
 
 // NewMemRepo creates a new in-memory repository
@@ -29,10 +40,18 @@ func NewMemRepo(p *profile.Profile, store cafs.Filestore, ps Peers, a analytics.
 		MemNamestore:      &MemNamestore{},
 		MemQueryLog:       &MemQueryLog{},
 		MemChangeRequests: MemChangeRequests{},
+		MemSnapshots:      &MemSnapshots{},
+		MemVisibilities:   MemVisibilities{},
+		MemEvents:         &MemEvents{},
+		MemCacheSources:   MemCacheSources{},
+		MemRefreshes:      MemRefreshes{},
+		MemRowCounts:      MemRowCounts{},
 		profile:           p,
 		peers:             ps,
 		analytics:         a,
 		cache:             MemDatasets{},
+		datasets:          NewDatasetCache(DefaultDatasetCacheSize),
+		visibility:        NewVisibilityIndex(),
 	}, nil
 }
 
@@ -41,6 +60,27 @@ func (r *MemRepo) Store() cafs.Filestore {
 	return r.store
 }
 
+// GetDataset gets a dataset put in the repo, loading datasets that haven't
+// been put from the store, as the fs repo does
+func (r *MemRepo) GetDataset(path datastore.Key) (*dataset.Dataset, error) {
+	ds, err := r.MemDatasets.GetDataset(path)
+	if err == nil || r.store == nil {
+		return ds, err
+	}
+	return dsfs.LoadDataset(r.store, path)
+}
+
+// DatasetCache gives the repo's cache of loaded datasets
+func (r *MemRepo) DatasetCache() *DatasetCache {
+	return r.datasets
+}
+
+// VisibilityIndex gives the repo's default visibility & index of path
+// visibilities
+func (r *MemRepo) VisibilityIndex() *VisibilityIndex {
+	return r.visibility
+}
+
 // Graph gives the graph of objects in this repo
 func (r *MemRepo) Graph() (map[string]*dsgraph.Node, error) {
 	return Graph(r)
@@ -71,3 +111,8 @@ func (r *MemRepo) Cache() Datasets {
 func (r *MemRepo) Analytics() analytics.Analytics {
 	return r.analytics
 }
+
+// Size sums the byte lengths of files in this repo's store
+func (r *MemRepo) Size() (int64, error) {
+	return GraphSize(r)
+}
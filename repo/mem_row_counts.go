@@ -0,0 +1,21 @@
+package repo
+
+import "github.com/ipfs/go-datastore"
+
+// MemRowCounts is an in-memory implementation of the RowCounts interface
+type MemRowCounts map[string]int
+
+// SetRowCount records the number of rows in the data at path
+func (m MemRowCounts) SetRowCount(path datastore.Key, rows int) error {
+	m[path.String()] = rows
+	return nil
+}
+
+// RowCount gives the number of rows in the data at path
+func (m MemRowCounts) RowCount(path datastore.Key) (int, error) {
+	rows, ok := m[path.String()]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return rows, nil
+}
@@ -0,0 +1,36 @@
+package repo
+
+// MemSnapshots is an in-memory implementation of the Snapshots interface
+type MemSnapshots []*Snapshot
+
+// PutSnapshot adds a snapshot to the store
+func (m *MemSnapshots) PutSnapshot(s *Snapshot) error {
+	*m = append(MemSnapshots{s}, *m...)
+	return nil
+}
+
+// GetSnapshot gets a snapshot by ID
+func (m MemSnapshots) GetSnapshot(id string) (*Snapshot, error) {
+	for _, s := range m {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListSnapshots gives all snapshots, newest first
+func (m MemSnapshots) ListSnapshots() ([]*Snapshot, error) {
+	return append([]*Snapshot{}, m...), nil
+}
+
+// DeleteSnapshot removes a snapshot from the store
+func (m *MemSnapshots) DeleteSnapshot(id string) error {
+	for i, s := range *m {
+		if s.ID == id {
+			*m = append((*m)[:i], (*m)[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,25 @@
+package repo
+
+// MemVisibilities is an in-memory implementation of the Visibilities interface
+type MemVisibilities map[string]Visibility
+
+// SetVisibility records the visibility of a dataset name
+func (m MemVisibilities) SetVisibility(name string, v Visibility) error {
+	m[name] = v
+	return nil
+}
+
+// GetVisibility gets the visibility set for a name
+func (m MemVisibilities) GetVisibility(name string) (Visibility, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// DeleteVisibility removes any visibility recorded for name
+func (m MemVisibilities) DeleteVisibility(name string) error {
+	delete(m, name)
+	return nil
+}
@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/qri-io/qri/ref"
+)
+
+// MinHashPrefixLength is the shortest hash prefix that will be resolved to a
+// full path, counting the leading "Qm" all dataset hashes start with
+const MinHashPrefixLength = ref.MinHashPrefixLength
+
+// ErrAmbiguousPrefix is returned when a hash prefix matches more than one dataset
+var ErrAmbiguousPrefix = fmt.Errorf("repo: ambiguous prefix")
+
+// IsHashPrefix checks if path is a shortened dataset hash, like "QmZ9Lq"
+// or "/ipfs/QmZ9Lq", that can be passed to ResolveHashPrefix
+func IsHashPrefix(path string) bool {
+	hash := pathHash(path)
+	return ref.IsHash(hash) && len(hash) < ref.HashLength
+}
+
+// ResolveHashPrefix finds the full path of the single dataset the repo knows
+// about whose hash starts with prefix. Only datasets that are named or in the
+// repo's dataset store are considered
+func ResolveHashPrefix(r Repo, prefix string) (datastore.Key, error) {
+	if !IsHashPrefix(prefix) {
+		return datastore.NewKey(""), fmt.Errorf("invalid hash prefix '%s'. prefixes must start with Qm and be at least %d characters", prefix, MinHashPrefixLength)
+	}
+	hash := pathHash(prefix)
+
+	paths, err := knownPaths(r)
+	if err != nil {
+		return datastore.NewKey(""), err
+	}
+
+	matches := map[string]datastore.Key{}
+	for _, p := range paths {
+		h := pathHash(p.String())
+		if strings.HasPrefix(h, hash) {
+			// a dataset can be referenced both with & without a package filename,
+			// store the shortest path
+			if prev, ok := matches[h]; !ok || len(p.String()) < len(prev.String()) {
+				matches[h] = p
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return datastore.NewKey(""), ErrNotFound
+	case 1:
+		for _, p := range matches {
+			return p, nil
+		}
+	}
+	return datastore.NewKey(""), ErrAmbiguousPrefix
+}
+
+// knownPaths lists the paths of all named & stored datasets
+func knownPaths(r Repo) ([]datastore.Key, error) {
+	count, err := r.NameCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := r.Namespace(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+	paths := make([]datastore.Key, 0, len(refs))
+	for _, ref := range refs {
+		if ref != nil {
+			paths = append(paths, ref.Path)
+		}
+	}
+
+	res, err := r.Query(query.Query{KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("error querying datasets: %s", err.Error())
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, fmt.Errorf("error querying datasets: %s", err.Error())
+	}
+	for _, e := range entries {
+		paths = append(paths, datastore.NewKey(e.Key))
+	}
+	return paths, nil
+}
+
+// pathHash gives the hash segment of a path, dropping any store prefix &
+// package filename. eg: /ipfs/QmZ9Lq/dataset.json -> QmZ9Lq
+func pathHash(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, s := range segments {
+		if strings.HasPrefix(s, "Qm") {
+			return s
+		}
+	}
+	return segments[0]
+}
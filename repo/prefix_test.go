@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestIsHashPrefix(t *testing.T) {
+	cases := []struct {
+		path   string
+		expect bool
+	}{
+		{"", false},
+		{"Qm", false},
+		{"QmZ9L", false},
+		{"QmZ9Lq", true},
+		{"QmZ9L0", false},
+		{"Qm_data", false},
+		{"/ipfs/QmZ9Lq", true},
+		{"/map/QmZ9Lq/dataset.json", true},
+		{"/badpath", false},
+		{"/ipfs/QmZ9LqKfpNp8sTGxL4G9EF1Ue6dFmEMW4Ad5JUtp2Px9V6/dataset.json", false},
+	}
+
+	for i, c := range cases {
+		if got := IsHashPrefix(c.path); got != c.expect {
+			t.Errorf("case %d '%s' mismatch. expected: %t, got: %t", i, c.path, c.expect, got)
+		}
+	}
+}
+
+func TestResolveHashPrefix(t *testing.T) {
+	r, err := NewMemRepo(&profile.Profile{}, memfs.NewMapstore(), nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	named := datastore.NewKey("/ipfs/QmZ9LqKfpNp8sTGxL4G9EF1Ue6dFmEMW4Ad5JUtp2Px9V6/dataset.json")
+	stored := datastore.NewKey("/ipfs/QmWD5xrwVbK8DbkVwxFtUr7zPjMXj4t2zNvVyGPoNwm5qw")
+	similarA := datastore.NewKey("/ipfs/QmAbCdEfNp8sTGxL4G9EF1Ue6dFmEMW4Ad5JUtp2Px9V6a")
+	similarB := datastore.NewKey("/ipfs/QmAbCdEfVbK8DbkVwxFtUr7zPjMXj4t2zNvVyGPoNwm5qb")
+
+	r.PutName("named", named)
+	r.PutDataset(stored, &dataset.Dataset{})
+	r.PutName("similar_a", similarA)
+	r.PutDataset(similarB, &dataset.Dataset{})
+
+	cases := []struct {
+		prefix string
+		expect datastore.Key
+		err    string
+	}{
+		{"Qm", datastore.NewKey(""), "invalid hash prefix 'Qm'. prefixes must start with Qm and be at least 6 characters"},
+		{"QmZ9Lq", named, ""},
+		{"/ipfs/QmWD5x", stored, ""},
+		{"QmAbCdEfNp", similarA, ""},
+		{"QmAbCdEf", datastore.NewKey(""), "repo: ambiguous prefix"},
+		{"QmNotAMatch", datastore.NewKey(""), "repo: not found"},
+	}
+
+	for i, c := range cases {
+		got, err := ResolveHashPrefix(r, c.prefix)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if !got.Equal(c.expect) {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// Refreshes is an optional interface for repos that can record when
+// datasets were last checked for new data. a refresh that finds unchanged
+// data doesn't add a version, so the dataset's timestamp doesn't record it.
+// Check for support with a type assertion on a Repo
+type Refreshes interface {
+	// SetRefreshed records the time the dataset at path was refreshed
+	SetRefreshed(path datastore.Key, t time.Time) error
+	// Refreshed gives the time the dataset at path was last refreshed,
+	// returning ErrNotFound if it never has been
+	Refreshed(path datastore.Key) (time.Time, error)
+}
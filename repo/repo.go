@@ -72,11 +72,18 @@ type Repo interface {
 	// All repositories provide their own analytics information.
 	// Our analytics implementation is under super-active development.
 	Analytics() analytics.Analytics
+	// Size gives the number of bytes this repository occupies, including
+	// stored data & repo metadata
+	Size() (int64, error)
 }
 
 // Namestore is an in-progress solution for aliasing
 // datasets locally, it's an interface for storing & retrieving
 // datasets by local names
+// Names can be linked as aliases with AddAlias. Aliases all resolve to the
+// same path, and placing any name in an alias group with PutName moves
+// the entire group to the new path. PutName returns ErrNameTaken for any
+// other name that's already in use, use UpdateName to move a name
 type Namestore interface {
 	PutName(name string, path datastore.Key) error
 	GetPath(name string) (datastore.Key, error)
@@ -84,6 +91,11 @@ type Namestore interface {
 	DeleteName(name string) error
 	Namespace(limit, offset int) ([]*DatasetRef, error)
 	NameCount() (int, error)
+	// AddAlias links alias to the dataset primary refers to, returning
+	// ErrNotFound if primary doesn't exist & ErrNameTaken if alias does
+	AddAlias(primary, alias string) error
+	// Aliases gives all names in the alias group name belongs to, including name
+	Aliases(name string) ([]string, error)
 }
 
 // Datasets is the minimum interface to act as a store of datasets.
@@ -113,7 +125,9 @@ type QueryLogItem struct {
 	Name        string
 	Key         datastore.Key
 	DatasetPath datastore.Key
-	Time        time.Time
+	// Resources maps table names in the query to the path of each input dataset
+	Resources map[string]datastore.Key
+	Time      time.Time
 }
 
 // QueryLog keeps logs
@@ -121,12 +135,22 @@ type QueryLog interface {
 	LogQuery(*QueryLogItem) error
 	ListQueryLogs(limit, offset int) ([]*QueryLogItem, error)
 	QueryLogItem(q *QueryLogItem) (*QueryLogItem, error)
+	// QueryLogsForDataset lists logged queries that saved results under a
+	// dataset name, or that produced the dataset at a path
+	QueryLogsForDataset(name string, limit, offset int) ([]*QueryLogItem, error)
+	// DeleteQueryLogs removes logged queries that saved results under a
+	// dataset name
+	DeleteQueryLogs(name string) error
 }
 
 // SearchParams encapsulates parameters provided to Searchable.Search
 type SearchParams struct {
 	Q             string
 	Limit, Offset int
+	// Fields limits results to datasets with all of the named structure fields
+	Fields []string
+	// MinScore drops results with a relevance score lower than MinScore
+	MinScore float64
 }
 
 // Searchable is an opt-in interface for supporting repository search
@@ -134,6 +158,22 @@ type Searchable interface {
 	Search(p SearchParams) ([]*DatasetRef, error)
 }
 
+// SearchIndexer is an opt-in interface for repos that can update their
+// search index one dataset at a time
+type SearchIndexer interface {
+	IndexDataset(ref *DatasetRef) error
+	RemoveFromIndex(path datastore.Key) error
+}
+
+// GarbageCollector is an opt-in interface for repos that can remove data
+// that isn't pinned from their store. Check for support with a type
+// assertion on a Repo
+type GarbageCollector interface {
+	// CollectGarbage removes unpinned blocks, returning the number of blocks
+	// & bytes removed
+	CollectGarbage() (blocks int, bytes int64, err error)
+}
+
 // DatasetsQuery is a convenience function to read all query results & parse into a
 // map[string]*dataset.Dataset.
 func DatasetsQuery(dss Datasets, q query.Query) (map[string]*dataset.Dataset, error) {
@@ -0,0 +1,15 @@
+package repo
+
+import "github.com/ipfs/go-datastore"
+
+// RowCounts is an optional interface for repos that can record the number
+// of rows in dataset data, so data doesn't need to be read again to count
+// it. data is content-addressed, so a recorded count never changes.
+// Check for support with a type assertion on a Repo
+type RowCounts interface {
+	// SetRowCount records the number of rows in the data at path
+	SetRowCount(path datastore.Key, rows int) error
+	// RowCount gives the number of rows in the data at path, returning
+	// ErrNotFound if no count has been recorded
+	RowCount(path datastore.Key) (int, error)
+}
@@ -5,11 +5,14 @@ import (
 	"log"
 	"time"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/bleve"
+	"github.com/qri-io/bleve/analysis/analyzer/keyword"
 	"github.com/qri-io/bleve/analysis/lang/en"
 	//_ "github.com/qri-io/bleve/config"
 	"github.com/qri-io/bleve/mapping"
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/repo"
 )
@@ -24,6 +27,8 @@ type IndexableMetadata struct {
 	Kind          string `json:"kind"`
 	ExternalScore int    `json:"externalScore"`
 	internalScore int
+	// Fields are the names of the dataset's structure fields
+	Fields []string `json:"fields"`
 }
 
 // NewIndexableMetadataStruct sets the default variable used to identify document type to 'table'
@@ -40,6 +45,7 @@ func (imd *IndexableMetadata) MapValues() map[string]interface{} {
 		"kind":          imd.Kind,
 		"externalScore": imd.ExternalScore,
 		"internalScore": imd.internalScore,
+		"fields":        imd.Fields,
 	}
 }
 
@@ -94,6 +100,10 @@ func buildIndexMapping() (mapping.IndexMapping, error) {
 	datasetMapping.AddFieldMappingsAt("title", englishTextFieldMapping)
 	datasetMapping.AddFieldMappingsAt("description", englishTextFieldMapping)
 	datasetMapping.AddFieldMappingsAt("category", englishTextFieldMapping)
+	// field names are matched exactly, so aren't analyzed as english text
+	fieldNameMapping := bleve.NewTextFieldMapping()
+	fieldNameMapping.Analyzer = keyword.Name
+	datasetMapping.AddFieldMappingsAt("fields", fieldNameMapping)
 
 	indexMapping := bleve.NewIndexMapping()
 	indexMapping.AddDocumentMapping("table", datasetMapping)
@@ -112,6 +122,37 @@ func IndexRepo(r repo.Repo, i bleve.Index) error {
 	return indexDatasetRefs(r.Store(), i, refs)
 }
 
+// IndexDataset adds a single dataset to an index, replacing any existing
+// entry for the same path
+func IndexDataset(i bleve.Index, path datastore.Key, ds *dataset.Dataset) error {
+	md, err := indexableMetadata(ds)
+	if err != nil {
+		return err
+	}
+	return i.Index(path.String(), md.MapValues())
+}
+
+// RemoveDataset removes the dataset at path from an index
+func RemoveDataset(i bleve.Index, path datastore.Key) error {
+	return i.Delete(path.String())
+}
+
+// indexableMetadata strips a dataset down to the fields that are indexed
+func indexableMetadata(ds *dataset.Dataset) (*IndexableMetadata, error) {
+	data, err := json.Marshal(ds)
+	if err != nil {
+		return nil, err
+	}
+	md := NewIndexableMetadataStruct()
+	if err := json.Unmarshal(data, md); err != nil {
+		return nil, err
+	}
+	if ds.Structure != nil && ds.Structure.Schema != nil {
+		md.Fields = ds.Structure.Schema.FieldNames()
+	}
+	return md, nil
+}
+
 func indexDatasetRefs(store cafs.Filestore, i bleve.Index, refs []*repo.DatasetRef) error {
 	log.Printf("Indexing...")
 	count := 0
@@ -125,14 +166,12 @@ func indexDatasetRefs(store cafs.Filestore, i bleve.Index, refs []*repo.DatasetR
 			continue
 		}
 		//remove extra fields
-		data, err := json.Marshal(ds)
+		leanMetadata, err := indexableMetadata(ds)
 		if err != nil {
 			log.Printf("error marshalling dataset: %s", err.Error())
 			//continue
 			return err
 		}
-		leanMetadata := NewIndexableMetadataStruct()
-		json.Unmarshal(data, leanMetadata)
 
 		batch.Index(ref.Path.String(), leanMetadata.MapValues())
 		batchCount++
@@ -11,9 +11,23 @@ import (
 	"github.com/qri-io/qri/repo"
 )
 
-// Search searches this repo's bleve index
+// Search searches this repo's bleve index. results must match every field
+// name in p.Fields. results are ordered by descending score, results scoring
+// less than p.MinScore are dropped
 func Search(i Index, p repo.SearchParams) ([]*repo.DatasetRef, error) {
-	query := bleve.NewQueryStringQuery(p.Q)
+	var query bleve.Query = bleve.NewQueryStringQuery(p.Q)
+	if len(p.Fields) > 0 {
+		queries := make([]bleve.Query, 0, len(p.Fields)+1)
+		if p.Q != "" {
+			queries = append(queries, query)
+		}
+		for _, name := range p.Fields {
+			tq := bleve.NewTermQuery(name)
+			tq.SetField("fields")
+			queries = append(queries, tq)
+		}
+		query = bleve.NewConjunctionQuery(queries...)
+	}
 	search := bleve.NewSearchRequest(query)
 	//TODO: find better place to set default, and/or expose option
 	search.Size = p.Limit
@@ -23,9 +37,12 @@ func Search(i Index, p repo.SearchParams) ([]*repo.DatasetRef, error) {
 		return nil, err
 	}
 
-	res := make([]*repo.DatasetRef, results.Hits.Len())
-	for i, hit := range results.Hits {
-		res[i] = &repo.DatasetRef{Path: datastore.NewKey(hit.ID)}
+	res := make([]*repo.DatasetRef, 0, results.Hits.Len())
+	for _, hit := range results.Hits {
+		if hit.Score < p.MinScore {
+			continue
+		}
+		res = append(res, &repo.DatasetRef{Path: datastore.NewKey(hit.ID), Score: hit.Score})
 	}
 
 	// fmt.Println(searchResults)
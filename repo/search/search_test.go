@@ -0,0 +1,180 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/bleve"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestIndexableMetadataFields(t *testing.T) {
+	ds := &dataset.Dataset{
+		Title: "jobs",
+		Structure: &dataset.Structure{
+			Schema: &dataset.Schema{
+				Fields: []*dataset.Field{{Name: "rank"}, {Name: "soc_code"}},
+			},
+		},
+	}
+	md, err := indexableMetadata(ds)
+	if err != nil {
+		t.Errorf("error getting indexable metadata: %s", err.Error())
+		return
+	}
+	if len(md.Fields) != 2 || md.Fields[0] != "rank" || md.Fields[1] != "soc_code" {
+		t.Errorf("fields mismatch. expected: [rank soc_code], got: %v", md.Fields)
+	}
+
+	md, err = indexableMetadata(&dataset.Dataset{Title: "no structure"})
+	if err != nil {
+		t.Errorf("error getting indexable metadata: %s", err.Error())
+		return
+	}
+	if len(md.Fields) != 0 {
+		t.Errorf("expected a dataset without a structure to have no fields, got: %v", md.Fields)
+	}
+}
+
+func TestSearchFields(t *testing.T) {
+	m, err := buildIndexMapping()
+	if err != nil {
+		t.Errorf("error building index mapping: %s", err.Error())
+		return
+	}
+	i, err := bleve.NewMemOnly(m)
+	if err != nil {
+		t.Errorf("error creating index: %s", err.Error())
+		return
+	}
+	defer i.Close()
+
+	datasets := map[string][]string{
+		"/map/jobs":     {"rank", "probability_of_automation", "soc_code", "job_title"},
+		"/map/soc":      {"soc_code", "title"},
+		"/map/movies":   {"title", "duration"},
+		"/map/no_codes": {"code", "soc"},
+	}
+	for path, names := range datasets {
+		fields := make([]*dataset.Field, len(names))
+		for j, name := range names {
+			fields[j] = &dataset.Field{Name: name}
+		}
+		ds := &dataset.Dataset{
+			Title:     path,
+			Structure: &dataset.Structure{Schema: &dataset.Schema{Fields: fields}},
+		}
+		if err := IndexDataset(i, datastore.NewKey(path), ds); err != nil {
+			t.Errorf("error indexing %s: %s", path, err.Error())
+			return
+		}
+	}
+
+	cases := []struct {
+		fields []string
+		expect []string
+	}{
+		{[]string{"soc_code"}, []string{"/map/jobs", "/map/soc"}},
+		{[]string{"soc_code", "job_title"}, []string{"/map/jobs"}},
+		{[]string{"duration"}, []string{"/map/movies"}},
+		{[]string{"not_a_field"}, []string{}},
+	}
+
+	for j, c := range cases {
+		got, err := Search(i, repo.SearchParams{Fields: c.fields, Limit: 10})
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", j, err.Error())
+			continue
+		}
+		paths := map[string]bool{}
+		for _, ref := range got {
+			paths[ref.Path.String()] = true
+		}
+		if len(paths) != len(c.expect) {
+			t.Errorf("case %d result count mismatch. expected: %d, got: %d", j, len(c.expect), len(paths))
+			continue
+		}
+		for _, path := range c.expect {
+			if !paths[path] {
+				t.Errorf("case %d expected results to include %s", j, path)
+			}
+		}
+	}
+}
+
+func TestSearchScores(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	m, err := buildIndexMapping()
+	if err != nil {
+		t.Errorf("error building index mapping: %s", err.Error())
+		return
+	}
+	i, err := bleve.NewMemOnly(m)
+	if err != nil {
+		t.Errorf("error creating index: %s", err.Error())
+		return
+	}
+	defer i.Close()
+	if err := IndexRepo(mr, i); err != nil {
+		t.Errorf("error indexing test repo: %s", err.Error())
+		return
+	}
+	moviesPath, err := mr.GetPath("movies")
+	if err != nil {
+		t.Errorf("error getting movies path: %s", err.Error())
+		return
+	}
+
+	got, err := Search(i, repo.SearchParams{Q: "example movie", Limit: 10})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+		return
+	}
+	if len(got) != 3 {
+		t.Errorf("expected 3 results, got: %d", len(got))
+		return
+	}
+	if !got[0].Path.Equal(moviesPath) {
+		t.Errorf("expected movies to be the best match, got: %s", got[0].Path)
+	}
+	for j, ref := range got {
+		if ref.Score <= 0 {
+			t.Errorf("result %d expected a positive score, got: %f", j, ref.Score)
+		}
+		if j > 0 && ref.Score > got[j-1].Score {
+			t.Errorf("result %d expected results ordered by descending score", j)
+		}
+	}
+
+	cases := []struct {
+		minScore float64
+		expect   int
+	}{
+		{0, 3},
+		{got[2].Score, 3},
+		{got[0].Score, 1},
+		{got[0].Score + 1, 0},
+	}
+	for j, c := range cases {
+		res, err := Search(i, repo.SearchParams{Q: "example movie", Limit: 10, MinScore: c.minScore})
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", j, err.Error())
+			continue
+		}
+		if len(res) != c.expect {
+			t.Errorf("case %d result count mismatch. expected: %d, got: %d", j, c.expect, len(res))
+			continue
+		}
+		for _, ref := range res {
+			if ref.Score < c.minScore {
+				t.Errorf("case %d expected scores of at least %f, got: %f", j, c.minScore, ref.Score)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsgraph"
+)
+
+// GraphSize sums the byte lengths of every file in a repo's graph of
+// datasets. Stores that can't list their contents, like an in-memory
+// store, can be sized this way. files missing from the store are skipped
+func GraphSize(r Repo) (int64, error) {
+	nodes, err := r.Graph()
+	if err != nil {
+		return 0, err
+	}
+
+	store := r.Store()
+	var size int64
+	for path, node := range nodes {
+		if node.Type == dsgraph.NtNamespace {
+			continue
+		}
+		key := datastore.NewKey(path)
+		if has, err := store.Has(key); err != nil {
+			return 0, err
+		} else if !has {
+			continue
+		}
+		f, err := store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		n, err := io.Copy(ioutil.Discard, f)
+		f.Close()
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	return size, nil
+}
@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// Snapshot is a copy of a repo's namespace at a point in time, used to roll
+// back destructive operations. Snapshots only record names & the paths they
+// point to. Dataset content is content-addressed, and stays in the store
+type Snapshot struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Reason describes the operation the snapshot was taken before
+	Reason string        `json:"reason,omitempty"`
+	Refs   []*DatasetRef `json:"refs"`
+	// Aliases lists groups of names that alias one another
+	Aliases [][]string `json:"aliases,omitempty"`
+	// Unpin lists content the operation released. it's kept pinned so the
+	// snapshot can be restored, and unpinned when the snapshot is dropped
+	Unpin []datastore.Key `json:"unpin,omitempty"`
+}
+
+// Snapshots is an optional interface for repos that can keep namespace
+// snapshots. Check for support with a type assertion on a Repo
+type Snapshots interface {
+	// PutSnapshot stores a snapshot
+	PutSnapshot(s *Snapshot) error
+	// GetSnapshot gets a snapshot by ID, returning ErrNotFound if no
+	// snapshot exists
+	GetSnapshot(id string) (*Snapshot, error)
+	// ListSnapshots gives all stored snapshots, newest first
+	ListSnapshots() ([]*Snapshot, error)
+	// DeleteSnapshot removes a snapshot
+	DeleteSnapshot(id string) error
+}
+
+// TakeSnapshot records the current state of a namespace
+func TakeSnapshot(ns Namestore, reason string) (*Snapshot, error) {
+	count, err := ns.NameCount()
+	if err != nil {
+		return nil, fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	refs, err := ns.Namespace(count, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+
+	now := time.Now().In(time.UTC)
+	s := &Snapshot{
+		ID:        strconv.FormatInt(now.UnixNano(), 10),
+		Timestamp: now,
+		Reason:    reason,
+		Refs:      make([]*DatasetRef, 0, len(refs)),
+	}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		s.Refs = append(s.Refs, &DatasetRef{Name: ref.Name, Path: ref.Path})
+
+		group, err := ns.Aliases(ref.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error getting aliases for '%s': %s", ref.Name, err.Error())
+		}
+		// groups are sorted, only record each group once
+		if len(group) > 1 && group[0] == ref.Name {
+			s.Aliases = append(s.Aliases, group)
+		}
+	}
+	return s, nil
+}
+
+// RestoreSnapshot replaces the contents of a namespace with a snapshot. If
+// the snapshot can't be restored the namespace is rolled back to it's state
+// before the restore
+func RestoreSnapshot(ns Namestore, s *Snapshot) error {
+	prev, err := TakeSnapshot(ns, "")
+	if err != nil {
+		return err
+	}
+	if err := replaceNamespace(ns, s); err != nil {
+		if rerr := replaceNamespace(ns, prev); rerr != nil {
+			return fmt.Errorf("%s. error rolling back namespace: %s", err.Error(), rerr.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// replaceNamespace removes all names from ns, adding the names in s
+func replaceNamespace(ns Namestore, s *Snapshot) error {
+	count, err := ns.NameCount()
+	if err != nil {
+		return fmt.Errorf("error getting name count: %s", err.Error())
+	}
+	current, err := ns.Namespace(count, 0)
+	if err != nil {
+		return fmt.Errorf("error reading namespace: %s", err.Error())
+	}
+	for _, ref := range current {
+		if ref == nil {
+			continue
+		}
+		if err := ns.DeleteName(ref.Name); err != nil {
+			return fmt.Errorf("error removing name '%s': %s", ref.Name, err.Error())
+		}
+	}
+
+	aliased := map[string]bool{}
+	for _, group := range s.Aliases {
+		for _, name := range group[1:] {
+			aliased[name] = true
+		}
+	}
+	for _, ref := range s.Refs {
+		if aliased[ref.Name] {
+			continue
+		}
+		if err := ns.PutName(ref.Name, ref.Path); err != nil {
+			return fmt.Errorf("error restoring name '%s': %s", ref.Name, err.Error())
+		}
+	}
+	for _, group := range s.Aliases {
+		for _, name := range group[1:] {
+			if err := ns.AddAlias(group[0], name); err != nil {
+				return fmt.Errorf("error restoring alias '%s': %s", name, err.Error())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestSnapshots(t *testing.T) {
+	r, err := NewMemRepo(&profile.Profile{}, memfs.NewMapstore(), nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	a := datastore.NewKey("/path/to/a")
+	b := datastore.NewKey("/path/to/b")
+	r.PutName("a", a)
+	r.PutName("b", b)
+	if err := r.AddAlias("a", "a_alias"); err != nil {
+		t.Errorf("error adding alias: %s", err.Error())
+		return
+	}
+
+	snap, err := TakeSnapshot(r, "test")
+	if err != nil {
+		t.Errorf("error taking snapshot: %s", err.Error())
+		return
+	}
+	if len(snap.Refs) != 3 {
+		t.Errorf("expected snapshot to have 3 refs, got: %d", len(snap.Refs))
+	}
+	if len(snap.Aliases) != 1 {
+		t.Errorf("expected snapshot to have 1 alias group, got: %d", len(snap.Aliases))
+	}
+
+	ss, ok := r.(Snapshots)
+	if !ok {
+		t.Errorf("expected MemRepo to implement Snapshots")
+		return
+	}
+	if err := ss.PutSnapshot(snap); err != nil {
+		t.Errorf("error putting snapshot: %s", err.Error())
+		return
+	}
+
+	// mangle the namespace
+	r.DeleteName("a")
+	r.DeleteName("b")
+	r.PutName("c", datastore.NewKey("/path/to/c"))
+
+	got, err := ss.GetSnapshot(snap.ID)
+	if err != nil {
+		t.Errorf("error getting snapshot: %s", err.Error())
+		return
+	}
+	if err := RestoreSnapshot(r, got); err != nil {
+		t.Errorf("error restoring snapshot: %s", err.Error())
+		return
+	}
+
+	for name, path := range map[string]datastore.Key{"a": a, "a_alias": a, "b": b} {
+		p, err := r.GetPath(name)
+		if err != nil {
+			t.Errorf("error getting restored name '%s': %s", name, err.Error())
+			continue
+		}
+		if !p.Equal(path) {
+			t.Errorf("restored name '%s' path mismatch. expected: %s, got: %s", name, path, p)
+		}
+	}
+	if _, err := r.GetPath("c"); err != ErrNotFound {
+		t.Errorf("expected name added after snapshot to be removed, got error: %s", err)
+	}
+	group, err := r.Aliases("a")
+	if err != nil {
+		t.Errorf("error getting aliases: %s", err.Error())
+		return
+	}
+	if len(group) != 2 {
+		t.Errorf("expected restored alias group to have 2 names, got: %v", group)
+	}
+
+	if err := ss.DeleteSnapshot(snap.ID); err != nil {
+		t.Errorf("error deleting snapshot: %s", err.Error())
+	}
+	if _, err := ss.GetSnapshot(snap.ID); err != ErrNotFound {
+		t.Errorf("expected deleted snapshot to be missing, got error: %s", err)
+	}
+}
+
+// failNamestore wraps a Namestore, failing PutName for one name
+type failNamestore struct {
+	Namestore
+	fail string
+}
+
+func (ns failNamestore) PutName(name string, path datastore.Key) error {
+	if name == ns.fail {
+		return ErrNameRequired
+	}
+	return ns.Namestore.PutName(name, path)
+}
+
+func TestRestoreSnapshotRollback(t *testing.T) {
+	r, err := NewMemRepo(&profile.Profile{}, memfs.NewMapstore(), nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	a := datastore.NewKey("/path/to/a")
+	b := datastore.NewKey("/path/to/b")
+	r.PutName("a", a)
+	snap, err := TakeSnapshot(r, "test")
+	if err != nil {
+		t.Errorf("error taking snapshot: %s", err.Error())
+		return
+	}
+	r.DeleteName("a")
+	r.PutName("b", b)
+
+	// restoring "a" fails, so the namespace should be left holding "b"
+	if err := RestoreSnapshot(failNamestore{Namestore: r, fail: "a"}, snap); err == nil {
+		t.Errorf("expected restore to fail")
+		return
+	}
+	if p, err := r.GetPath("b"); err != nil || !p.Equal(b) {
+		t.Errorf("expected failed restore to keep 'b' at %s. got: %s, %v", b, p, err)
+	}
+	if _, err := r.GetPath("a"); err != ErrNotFound {
+		t.Errorf("expected failed restore not to add 'a', got error: %v", err)
+	}
+}
@@ -16,6 +16,7 @@ func RunRepoTests(t *testing.T, r repo.Repo) {
 	tests := []RepoTestFunc{
 		runTestProfile,
 		runTestNamespace,
+		runTestQueryLogs,
 		// runTestQueryResults,
 		// runTestResourceMeta,
 		// runTestResourceQueries,
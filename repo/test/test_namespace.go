@@ -13,6 +13,7 @@ func runTestNamespace(r repo.Repo) error {
 		testBlankName,
 		testNames,
 		testNamespace,
+		testAliases,
 	} {
 		if err := test(r); err != nil {
 			return fmt.Errorf("RunTestNamespace: %s", err.Error())
@@ -40,6 +41,9 @@ func testNames(r repo.Repo) error {
 	if err := r.PutName(name, path); err != nil {
 		return fmt.Errorf("repo.PutName: %s", err.Error())
 	}
+	if err := r.PutName(name, path); err != repo.ErrNameTaken {
+		return fmt.Errorf("putting an existing name should return repo.ErrNameTaken, got: %v", err)
+	}
 
 	resname, err := r.GetName(path)
 	if err != nil {
@@ -132,3 +136,75 @@ func testNamespace(r repo.Repo) error {
 	}
 	return nil
 }
+
+func testAliases(r repo.Repo) error {
+	a, err := r.Store().Put(memfs.NewMemfileBytes("test", []byte(`{ "title": "test_alias_a" }`)), true)
+	if err != nil {
+		return fmt.Errorf("error putting test file in datastore: %s", err.Error())
+	}
+	b, err := r.Store().Put(memfs.NewMemfileBytes("test", []byte(`{ "title": "test_alias_b" }`)), true)
+	if err != nil {
+		return fmt.Errorf("error putting test file in datastore: %s", err.Error())
+	}
+
+	if err := r.PutName("test_alias", a); err != nil {
+		return fmt.Errorf("repo.PutName: %s", err.Error())
+	}
+	if err := r.AddAlias("test_alias_missing", "test_alias_b"); err != repo.ErrNotFound {
+		return fmt.Errorf("aliasing a missing name should return repo.ErrNotFound, got: %v", err)
+	}
+	if err := r.AddAlias("test_alias", "test_alias"); err != repo.ErrNameTaken {
+		return fmt.Errorf("aliasing to an existing name should return repo.ErrNameTaken, got: %v", err)
+	}
+	if err := r.AddAlias("test_alias", "test_alias_b"); err != nil {
+		return fmt.Errorf("repo.AddAlias: %s", err.Error())
+	}
+	if err := r.AddAlias("test_alias_b", "test_alias_c"); err != nil {
+		return fmt.Errorf("repo.AddAlias: %s", err.Error())
+	}
+
+	aliases, err := r.Aliases("test_alias_c")
+	if err != nil {
+		return fmt.Errorf("repo.Aliases: %s", err.Error())
+	}
+	if len(aliases) != 3 {
+		return fmt.Errorf("repo.Aliases length mismatch. expected: 3, got: %d", len(aliases))
+	}
+
+	// putting any name in the group should move all aliases
+	if err := r.PutName("test_alias_b", b); err != nil {
+		return fmt.Errorf("repo.PutName: %s", err.Error())
+	}
+	for _, name := range aliases {
+		path, err := r.GetPath(name)
+		if err != nil {
+			return fmt.Errorf("repo.GetPath: %s, name: %s", err.Error(), name)
+		}
+		if !path.Equal(b) {
+			return fmt.Errorf("expected alias %s to move to path %s, got: %s", name, b, path)
+		}
+	}
+
+	// deleting a name should leave the rest of the group intact
+	if err := r.DeleteName("test_alias"); err != nil {
+		return fmt.Errorf("repo.DeleteName: %s", err.Error())
+	}
+	if aliases, err = r.Aliases("test_alias_b"); err != nil {
+		return fmt.Errorf("repo.Aliases: %s", err.Error())
+	}
+	if len(aliases) != 2 {
+		return fmt.Errorf("repo.Aliases length mismatch after delete. expected: 2, got: %d", len(aliases))
+	}
+
+	for _, name := range aliases {
+		if err := r.DeleteName(name); err != nil {
+			return fmt.Errorf("repo.DeleteName: %s", err.Error())
+		}
+	}
+	for _, path := range []datastore.Key{a, b} {
+		if err := r.Store().Delete(path); err != nil {
+			return fmt.Errorf("error removing path from repo store: %s", err.Error())
+		}
+	}
+	return nil
+}
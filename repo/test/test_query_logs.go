@@ -0,0 +1,66 @@
+package test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+)
+
+func runTestQueryLogs(r repo.Repo) error {
+	for _, test := range []RepoTestFunc{
+		testQueryLogsForDataset,
+	} {
+		if err := test(r); err != nil {
+			return fmt.Errorf("RunTestQueryLogs: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func testQueryLogsForDataset(r repo.Repo) error {
+	now := time.Now()
+	items := []*repo.QueryLogItem{
+		{Query: "select * from a", Name: "query_logs_test", Key: datastore.NewKey("/query_logs_test/a"), DatasetPath: datastore.NewKey("/query_logs_test/a"), Time: now},
+		{Query: "select * from b", Key: datastore.NewKey("/query_logs_test/b"), DatasetPath: datastore.NewKey("/query_logs_test/b"), Time: now.Add(time.Second)},
+		{Query: "select * from c", Name: "query_logs_test", Key: datastore.NewKey("/query_logs_test/c"), DatasetPath: datastore.NewKey("/query_logs_test/c"), Time: now.Add(2 * time.Second)},
+		{Query: "select * from d", Name: "query_logs_test_other", Key: datastore.NewKey("/query_logs_test/d"), DatasetPath: datastore.NewKey("/query_logs_test/d"), Time: now.Add(3 * time.Second)},
+	}
+	for _, item := range items {
+		if err := r.LogQuery(item); err != nil {
+			return fmt.Errorf("repo.LogQuery: %s", err.Error())
+		}
+	}
+
+	cases := []struct {
+		name          string
+		limit, offset int
+		queries       []string
+		err           error
+	}{
+		{"", 10, 0, nil, repo.ErrNameRequired},
+		{"query_logs_test_none", 10, 0, []string{}, nil},
+		{"query_logs_test", 10, 0, []string{"select * from c", "select * from a"}, nil},
+		{"query_logs_test", 1, 0, []string{"select * from c"}, nil},
+		{"query_logs_test", 10, 1, []string{"select * from a"}, nil},
+		{"/query_logs_test/b", 10, 0, []string{"select * from b"}, nil},
+	}
+
+	for i, c := range cases {
+		got, err := r.QueryLogsForDataset(c.name, c.limit, c.offset)
+		if err != c.err {
+			return fmt.Errorf("repo.QueryLogsForDataset case %d error mismatch. expected: %v, got: %v", i, c.err, err)
+		}
+		if len(got) != len(c.queries) {
+			return fmt.Errorf("repo.QueryLogsForDataset case %d length mismatch. expected: %d, got: %d", i, len(c.queries), len(got))
+		}
+		for j, item := range got {
+			if item.Query != c.queries[j] {
+				return fmt.Errorf("repo.QueryLogsForDataset case %d item %d query mismatch. expected: %s, got: %s", i, j, c.queries[j], item.Query)
+			}
+		}
+	}
+	return nil
+}
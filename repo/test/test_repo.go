@@ -82,20 +82,26 @@ a
 's;f a'
 sdlfj asdf`))
 
-// BadDataFormatFile has weird line lengths
-var BadDataFormatFile = memfs.NewMemfileBytes("abc.csv", []byte(`
+// BadDataFormatData has weird line lengths
+var BadDataFormatData = []byte(`
 "colA","colB","colC","colD"
 1,2,3,4
-1,2,3`))
+1,2,3`)
 
-// BadStructureFile has double-named columns
-var BadStructureFile = memfs.NewMemfileBytes("badStructure.csv", []byte(`
+// BadDataFormatFile is a file of BadDataFormatData
+var BadDataFormatFile = memfs.NewMemfileBytes("abc.csv", BadDataFormatData)
+
+// BadStructureData has double-named columns
+var BadStructureData = []byte(`
 colA, colB, colB, colC
 1,2,3,4
-1,2,3,4`))
+1,2,3,4`)
+
+// BadStructureFile is a file of BadStructureData
+var BadStructureFile = memfs.NewMemfileBytes("badStructure.csv", BadStructureData)
 
-// JobsByAutomationFile is real, valid data
-var JobsByAutomationFile = memfs.NewMemfileBytes("jobs_ranked_by_automation_probability.csv", []byte(`rank,probability_of_automation,soc_code,job_title
+// JobsByAutomationData is real, valid data
+var JobsByAutomationData = []byte(`rank,probability_of_automation,soc_code,job_title
 702,"0.99","41-9041","Telemarketers"
 701,"0.99","23-2093","Title Examiners, Abstractors, and Searchers"
 700,"0.99","51-6051","Sewers, Hand"
@@ -126,7 +132,10 @@ var JobsByAutomationFile = memfs.NewMemfileBytes("jobs_ranked_by_automation_prob
 675,"0.98","13-1031","Claims Adjusters, Examiners, and Investigators"
 674,"0.98","53-3031","Driver/Sales Workers"
 673,"0.98","27-4013","Radio Operators"
-`))
+`)
+
+// JobsByAutomationFile is a file of JobsByAutomationData
+var JobsByAutomationFile = memfs.NewMemfileBytes("jobs_ranked_by_automation_probability.csv", JobsByAutomationData)
 
 // JobsByAutomationFile2 is a copy of JobsByAutomationFile
 // TODO - refactor to just give the raw data and a convenience method to create files
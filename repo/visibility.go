@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/ref"
+)
+
+// Visibility controls who can read a dataset
+type Visibility string
+
+const (
+	// VisibilityPublic datasets can be listed & read by anyone, including peers
+	VisibilityPublic Visibility = "public"
+	// VisibilityPrivate datasets can only be listed & read by the repo owner
+	VisibilityPrivate Visibility = "private"
+)
+
+// ParseVisibility checks a string is a valid visibility
+func ParseVisibility(s string) (Visibility, error) {
+	switch v := Visibility(s); v {
+	case VisibilityPublic, VisibilityPrivate:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid visibility '%s'. must be one of: %s, %s", s, VisibilityPublic, VisibilityPrivate)
+}
+
+// DefaultVisibility gives the visibility of datasets in r that haven't been
+// given one. repos that don't keep a VisibilityIndex are public by default
+func DefaultVisibility(r Repo) Visibility {
+	if vi, ok := r.(VisibilityIndexer); ok {
+		return vi.VisibilityIndex().Default()
+	}
+	return VisibilityPublic
+}
+
+// SetDefaultVisibility sets the visibility of datasets in r that haven't been
+// explicitly given one. nodes that share open data will want public, nodes
+// that keep personal datasets will want private
+func SetDefaultVisibility(r Repo, v Visibility) error {
+	vi, ok := r.(VisibilityIndexer)
+	if !ok {
+		if v == VisibilityPublic {
+			return nil
+		}
+		return fmt.Errorf("this repo doesn't support a default visibility")
+	}
+	vi.VisibilityIndex().SetDefault(v)
+	return nil
+}
+
+// Visibilities is an optional interface for repos that can record the
+// visibility of named datasets. Check for support with a type assertion on a
+// Repo. Repos that don't support visibilities treat all datasets as having
+// the default visibility
+type Visibilities interface {
+	// SetVisibility records the visibility of a dataset name
+	SetVisibility(name string, v Visibility) error
+	// GetVisibility gets the visibility set for a name, returning ErrNotFound
+	// if the name hasn't been given a visibility
+	GetVisibility(name string) (Visibility, error)
+	// DeleteVisibility removes any visibility recorded for name
+	DeleteVisibility(name string) error
+}
+
+// NameVisibility gives the visibility of a dataset name. names in an alias
+// group refer to the same dataset, so a dataset is private if any of it's
+// names are private
+func NameVisibility(r Repo, name string) (Visibility, error) {
+	def := DefaultVisibility(r)
+	vs, ok := r.(Visibilities)
+	if !ok {
+		return def, nil
+	}
+	names, err := r.Aliases(name)
+	if err != nil {
+		return "", err
+	}
+
+	v := def
+	for _, n := range names {
+		nv, err := vs.GetVisibility(n)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if nv == VisibilityPrivate {
+			return VisibilityPrivate, nil
+		}
+		v = nv
+	}
+	return v, nil
+}
+
+// PathVisibility gives the visibility of the dataset at path. previous
+// versions of a dataset share the visibility of it's names, so a path is
+// private if the history of any private name includes it. paths no name's
+// history includes have the default visibility
+func PathVisibility(r Repo, path datastore.Key) (Visibility, error) {
+	count, err := r.NameCount()
+	if err != nil {
+		return "", err
+	}
+	refs, err := r.Namespace(count, 0)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]namedVisibility, 0, len(refs))
+	for _, named := range refs {
+		if named == nil {
+			continue
+		}
+		v, err := NameVisibility(r, named.Name)
+		if err != nil {
+			return "", err
+		}
+		names = append(names, namedVisibility{name: named.Name, head: named.Path, v: v})
+	}
+
+	vi, ok := r.(VisibilityIndexer)
+	if !ok {
+		if v, ok := indexPaths(r, names)[ref.RootPath(path.String())]; ok {
+			return v, nil
+		}
+		return VisibilityPublic, nil
+	}
+	if v, ok := vi.VisibilityIndex().pathVisibility(r, indexKey(names), names, path); ok {
+		return v, nil
+	}
+	return vi.VisibilityIndex().Default(), nil
+}
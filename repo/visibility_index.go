@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/ref"
+)
+
+// VisibilityIndexer is an optional interface for repos that keep a
+// VisibilityIndex. Check for support with a type assertion on a Repo. Repos
+// that don't have a public default visibility, and walk named histories on
+// every path visibility check
+type VisibilityIndexer interface {
+	// VisibilityIndex gives the repo's visibility index
+	VisibilityIndex() *VisibilityIndex
+}
+
+// VisibilityIndex holds a repo's default visibility & the visibility of
+// each version in the repo's named histories, so checking the visibility of
+// a path doesn't walk every history. The index is rebuilt whenever the
+// names, heads or visibilities it was built from change
+type VisibilityIndex struct {
+	lock sync.Mutex
+	def  Visibility
+	// key identifies the names, heads & visibilities paths was built from
+	key   string
+	paths map[string]Visibility
+}
+
+// NewVisibilityIndex allocates a VisibilityIndex with a public default
+// visibility
+func NewVisibilityIndex() *VisibilityIndex {
+	return &VisibilityIndex{def: VisibilityPublic}
+}
+
+// Default gives the visibility of datasets that haven't been given one
+func (vi *VisibilityIndex) Default() Visibility {
+	vi.lock.Lock()
+	defer vi.lock.Unlock()
+	return vi.def
+}
+
+// SetDefault sets the visibility of datasets that haven't been explicitly
+// given one
+func (vi *VisibilityIndex) SetDefault(v Visibility) {
+	vi.lock.Lock()
+	defer vi.lock.Unlock()
+	vi.def = v
+}
+
+// pathVisibility gives the indexed visibility of path, reporting if any
+// named history includes it. the index is rebuilt first if key doesn't
+// match the one it was built from
+func (vi *VisibilityIndex) pathVisibility(r Repo, key string, names []namedVisibility, path datastore.Key) (Visibility, bool) {
+	vi.lock.Lock()
+	defer vi.lock.Unlock()
+	if vi.paths == nil || vi.key != key {
+		vi.paths = indexPaths(r, names)
+		vi.key = key
+	}
+	v, ok := vi.paths[ref.RootPath(path.String())]
+	return v, ok
+}
+
+// namedVisibility is the head & visibility of a dataset name
+type namedVisibility struct {
+	name string
+	head datastore.Key
+	v    Visibility
+}
+
+// indexKey identifies a set of names, heads & visibilities
+func indexKey(names []namedVisibility) string {
+	b := strings.Builder{}
+	for _, n := range names {
+		b.WriteString(n.name + " " + n.head.String() + " " + string(n.v) + "\n")
+	}
+	return b.String()
+}
+
+// indexPaths maps the root path of each version in the history of names to
+// it's visibility. versions in the history of any private name are private.
+// versions that can't be loaded end a history
+func indexPaths(r Repo, names []namedVisibility) map[string]Visibility {
+	paths := map[string]Visibility{}
+	for _, n := range names {
+		seen := map[string]bool{}
+		for p := n.head.String(); p != "" && p != "/" && !seen[p]; {
+			seen[p] = true
+			root := ref.RootPath(p)
+			// histories already indexed as private, or with this visibility,
+			// have had their previous versions indexed too
+			if prev, ok := paths[root]; ok && (prev == VisibilityPrivate || prev == n.v) {
+				break
+			}
+			paths[root] = n.v
+
+			ds, err := dsfs.LoadDataset(r.Store(), datastore.NewKey(p))
+			if err != nil {
+				break
+			}
+			p = ds.Previous.String()
+			if rf, err := ref.ParseRef(p); err == nil && rf.IsPath() {
+				p = rf.Path
+			}
+		}
+	}
+	return paths
+}
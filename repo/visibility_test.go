@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs/memfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestVisibility(t *testing.T) {
+	r, err := NewMemRepo(&profile.Profile{}, memfs.NewMapstore(), nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+	vs := r.(Visibilities)
+
+	r.PutName("a", datastore.NewKey("/path/to/a"))
+	r.PutName("b", datastore.NewKey("/path/to/b"))
+	r.PutName("c", datastore.NewKey("/path/to/c"))
+	if err := r.AddAlias("b", "b_alias"); err != nil {
+		t.Errorf("error adding alias: %s", err.Error())
+		return
+	}
+	vs.SetVisibility("a", VisibilityPublic)
+	vs.SetVisibility("b_alias", VisibilityPrivate)
+
+	cases := []struct {
+		def    Visibility
+		name   string
+		path   string
+		expect Visibility
+	}{
+		{VisibilityPublic, "a", "/path/to/a", VisibilityPublic},
+		{VisibilityPrivate, "a", "/path/to/a", VisibilityPublic},
+		{VisibilityPublic, "b", "/path/to/b", VisibilityPrivate},
+		{VisibilityPublic, "c", "/path/to/c", VisibilityPublic},
+		{VisibilityPrivate, "c", "/path/to/c", VisibilityPrivate},
+		{VisibilityPrivate, "", "/path/to/unnamed", VisibilityPrivate},
+	}
+
+	for i, c := range cases {
+		if err := SetDefaultVisibility(r, c.def); err != nil {
+			t.Errorf("case %d error setting default visibility: %s", i, err.Error())
+			continue
+		}
+		if c.name != "" {
+			got, err := NameVisibility(r, c.name)
+			if err != nil {
+				t.Errorf("case %d unexpected error: %s", i, err.Error())
+				continue
+			}
+			if got != c.expect {
+				t.Errorf("case %d name visibility mismatch. expected: %s, got: %s", i, c.expect, got)
+			}
+		}
+		got, err := PathVisibility(r, datastore.NewKey(c.path))
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d path visibility mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestParseVisibility(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect Visibility
+		err    string
+	}{
+		{"public", VisibilityPublic, ""},
+		{"private", VisibilityPrivate, ""},
+		{"", "", "invalid visibility ''. must be one of: public, private"},
+		{"secret", "", "invalid visibility 'secret'. must be one of: public, private"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseVisibility(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d visibility mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestPathVisibilityHistory(t *testing.T) {
+	store := memfs.NewMapstore()
+	r, err := NewMemRepo(&profile.Profile{}, store, nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	v1, err := dsfs.SaveDataset(store, &dataset.Dataset{Title: "first"}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	v2, err := dsfs.SaveDataset(store, &dataset.Dataset{Title: "second", Previous: v1}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	other, err := dsfs.SaveDataset(store, &dataset.Dataset{Title: "other"}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	r.PutName("secret", v2)
+	r.PutName("open", other)
+	r.(Visibilities).SetVisibility("secret", VisibilityPrivate)
+
+	cases := []struct {
+		path   datastore.Key
+		expect Visibility
+	}{
+		{v2, VisibilityPrivate},
+		{v1, VisibilityPrivate},
+		{other, VisibilityPublic},
+		{datastore.NewKey("/path/to/unnamed"), VisibilityPublic},
+	}
+
+	for i, c := range cases {
+		got, err := PathVisibility(r, c.path)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d path visibility mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestVisibilityIndex(t *testing.T) {
+	store := memfs.NewMapstore()
+	a, err := NewMemRepo(&profile.Profile{}, store, nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+	b, err := NewMemRepo(&profile.Profile{}, store, nil, nil)
+	if err != nil {
+		t.Errorf("error creating test repo: %s", err.Error())
+		return
+	}
+
+	// default visibilities belong to a repo
+	if err := SetDefaultVisibility(a, VisibilityPrivate); err != nil {
+		t.Errorf("error setting default visibility: %s", err.Error())
+		return
+	}
+	if DefaultVisibility(a) != VisibilityPrivate || DefaultVisibility(b) != VisibilityPublic {
+		t.Errorf("expected default visibilities to be set per repo, got: %s, %s", DefaultVisibility(a), DefaultVisibility(b))
+	}
+
+	v1, err := dsfs.SaveDataset(store, &dataset.Dataset{Title: "first"}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	v2, err := dsfs.SaveDataset(store, &dataset.Dataset{Title: "second", Previous: v1}, true)
+	if err != nil {
+		t.Errorf("error saving dataset: %s", err.Error())
+		return
+	}
+	b.PutName("data", v2)
+
+	// the index is rebuilt as names & visibilities change
+	steps := []struct {
+		change func() error
+		expect Visibility
+	}{
+		{func() error { return nil }, VisibilityPublic},
+		{func() error { return b.(Visibilities).SetVisibility("data", VisibilityPrivate) }, VisibilityPrivate},
+		{func() error { return b.(Visibilities).DeleteVisibility("data") }, VisibilityPublic},
+		{func() error { return b.DeleteName("data") }, VisibilityPublic},
+		{func() error { return SetDefaultVisibility(b, VisibilityPrivate) }, VisibilityPrivate},
+	}
+	for i, s := range steps {
+		if err := s.change(); err != nil {
+			t.Errorf("step %d error: %s", i, err.Error())
+			continue
+		}
+		got, err := PathVisibility(b, v1)
+		if err != nil {
+			t.Errorf("step %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if got != s.expect {
+			t.Errorf("step %d path visibility mismatch. expected: %s, got: %s", i, s.expect, got)
+		}
+	}
+}